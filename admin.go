@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"chitchat4.0/pkg/authentication"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/migration"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/validation"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// admin 管理命令使用的仓库和服务，直接连接数据库和 redis，不启动服务器
+type admin struct {
+	repository repository.Repository
+
+	userService    service.UserService
+	groupService   service.GroupService
+	rbacService    service.RBACService
+	tokenService   service.AccessTokenService
+	sessionService service.SessionService
+}
+
+// newAdmin 根据配置连接数据库和 redis，数据库有未执行的迁移时返回错误
+func newAdmin() (*admin, error) {
+	conf, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := database.New(&conf.DB)
+	if err != nil {
+		return nil, errors.Wrap(err, "db 初始化失败")
+	}
+	migrator, err := migration.New(db, logger)
+	if err != nil {
+		closeDB(db)
+		return nil, err
+	}
+	if err := checkSchema(migrator); err != nil {
+		closeDB(db)
+		return nil, err
+	}
+	rdb, err := database.NewRedisClient(&conf.Redis)
+	if err != nil {
+		closeDB(db)
+		return nil, errors.Wrap(err, "创建 Reids 客户端失败")
+	}
+	// 注册请求参数的校验规则，管理命令和接口使用相同的规则
+	if err := validation.Init(); err != nil {
+		closeDB(db)
+		return nil, errors.Wrap(err, "注册校验规则失败")
+	}
+
+	repository := repository.NewRepository(db, rdb)
+	return &admin{
+		repository:     repository,
+		userService:    service.NewUserService(repository.User()),
+		groupService:   service.NewGroupService(repository.Group(), repository.User(), repository.RBAC()),
+		rbacService:    service.NewRBACService(repository.RBAC()),
+		tokenService:   service.NewAccessTokenService(repository.AccessToken(), repository.User()),
+		sessionService: service.NewSessionService(repository.Session(), authentication.NewJWTService(conf.Server.JWTSecret).ExpireDuration()),
+	}, nil
+}
+
+// checkSchema 数据库的版本必须和程序一致，管理命令不会自动执行迁移
+func checkSchema(migrator *migration.Migrator) error {
+	if err := migrator.Check(); err != nil {
+		return err
+	}
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("数据库有 %d 个未执行的迁移，请先执行 chitchat migrate up", len(pending))
+	}
+	return nil
+}
+
+// Close 关闭数据库和 redis 的连接
+func (a *admin) Close() error {
+	return a.repository.Close()
+}
+
+func (a *admin) user(name string) (*model.User, error) {
+	user, err := a.repository.User().GetUserByName(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "查询 user %s 失败", name)
+	}
+	return user, nil
+}
+
+func (a *admin) group(name string) (*model.Group, error) {
+	group, err := a.repository.Group().GetGroupByName(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "查询 group %s 失败", name)
+	}
+	return group, nil
+}
+
+func (a *admin) role(name string) (*model.Role, error) {
+	role, err := a.repository.RBAC().GetRoleByName(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "查询 role %s 失败", name)
+	}
+	return role, nil
+}
+
+// withAdmin 连接数据库和 redis 后执行管理命令，执行完成后关闭连接
+func withAdmin(run func(cmd *cobra.Command, a *admin, args []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		a, err := newAdmin()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		return run(cmd, a, args)
+	}
+}
+
+// readPassword 没有使用 --password 指定密码时，从标准输入读取一行作为密码，避免密码出现在命令历史中
+func readPassword(cmd *cobra.Command, password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("密码为空，使用 --password 指定或从标准输入读取")
+	}
+	return password, nil
+}
+
+// checkStruct 使用接口的校验规则校验 obj，返回所有字段的错误
+func checkStruct(obj interface{}) error {
+	if err := validation.Struct(obj); err != nil {
+		return errors.New(strings.Join(validation.Messages(err), "; "))
+	}
+	return nil
+}
+
+func idString(id uint) string {
+	return strconv.Itoa(int(id))
+}
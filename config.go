@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "管理配置文件",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "校验 --config 指定的配置文件，环境变量覆盖后的配置也会校验",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := loadConfig(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s 有效\n", appConfig)
+			return nil
+		},
+	})
+	return cmd
+}
@@ -23,30 +23,21 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/auth/token": {
-            "post": {
-                "description": "user login | 用户登录",
-                "consumes": [
-                    "application/json"
+        "/api/v1/alerts": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
                 ],
+                "description": "List alert rules of current user | 获取当前 user 的热搜提醒规则",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
-                ],
-                "summary": "Login | 登录",
-                "parameters": [
-                    {
-                        "description": "auth user info",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.AuthUser"
-                        }
-                    }
+                    "alert"
                 ],
+                "summary": "List alert rules | 提醒规则列表",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -59,7 +50,10 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.JWTToken"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.AlertRule"
+                                            }
                                         }
                                     }
                                 }
@@ -68,28 +62,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "User logout | User退出",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "Logout | 退出",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/common.Response"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/v1/auth/user": {
             "post": {
-                "description": "Create user and storage",
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create alert rule. A hot search matches when it belongs to one of tagIds, its title contains one of keywords, and it ranks within minRank; each hot search alerts once per rule | 创建热搜提醒规则：热搜属于 tagIds 中的 tag、标题包含任一关键词并且排名在前 minRank 名时提醒，同一条热搜对每个规则只提醒一次",
                 "consumes": [
                     "application/json"
                 ],
@@ -97,17 +76,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "alert"
                 ],
-                "summary": "Register user | 注册用户",
+                "summary": "Create alert rule | 创建提醒规则",
                 "parameters": [
                     {
-                        "description": "user info",
-                        "name": "user",
+                        "description": "alert rule",
+                        "name": "rule",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.CreatedUser"
+                            "$ref": "#/definitions/model.CreatedAlertRule"
                         }
                     }
                 ],
@@ -123,7 +102,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "$ref": "#/definitions/model.AlertRule"
                                         }
                                     }
                                 }
@@ -133,21 +112,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/groups": {
+        "/api/v1/alerts/{id}": {
             "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "List group | 查询所有group列表",
+                "description": "Get alert rule of current user | 获取当前 user 的提醒规则",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "group"
+                    "alert"
+                ],
+                "summary": "Get alert rule | 获取提醒规则",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "alert rule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "List group | group 列表",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -160,10 +148,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.Group"
-                                            }
+                                            "$ref": "#/definitions/model.AlertRule"
                                         }
                                     }
                                 }
@@ -172,13 +157,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
+            "put": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Create group and stroage | 创建 group 和 stroage 存储",
+                "description": "Replace alert rule of current user | 修改当前 user 的提醒规则",
                 "consumes": [
                     "application/json"
                 ],
@@ -186,17 +171,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "group"
+                    "alert"
                 ],
-                "summary": "Create group | 创建 group",
+                "summary": "Update alert rule | 修改提醒规则",
                 "parameters": [
                     {
-                        "description": "group info",
-                        "name": "group",
+                        "type": "integer",
+                        "description": "alert rule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "alert rule",
+                        "name": "rule",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.CreatedGroup"
+                            "$ref": "#/definitions/model.CreatedAlertRule"
                         }
                     }
                 ],
@@ -212,7 +204,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.Group"
+                                            "$ref": "#/definitions/model.AlertRule"
                                         }
                                     }
                                 }
@@ -220,27 +212,25 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/groups/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Get group | 通过id查询group",
+                "description": "Delete alert rule of current user, sent notifications are kept | 删除当前 user 的提醒规则，已经发送的站内信保留",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "group"
+                    "alert"
                 ],
-                "summary": "Get group | 获取 group",
+                "summary": "Delete alert rule | 删除提醒规则",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "group id",
+                        "description": "alert rule id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -250,30 +240,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/model.Group"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/common.Response"
                         }
                     }
                 }
-            },
-            "put": {
-                "security": [
-                    {
-                        "JWT": []
-                    }
-                ],
-                "description": "Update group and storage | 修改group和保存",
+            }
+        },
+        "/api/v1/auth/mfa": {
+            "post": {
+                "description": "Complete mfa challenge with totp code or recovery code | 使用一次性密码或恢复码完成二次验证\n被要求开启二次验证但未绑定的用户，先调用 /api/v1/auth/mfa/enroll，验证成功后返回恢复码",
                 "consumes": [
                     "application/json"
                 ],
@@ -281,25 +256,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "group"
+                    "auth"
                 ],
-                "summary": "Update group | 修改 group",
+                "summary": "MFA login | 二次验证登录",
                 "parameters": [
                     {
-                        "description": "group info",
-                        "name": "group",
+                        "description": "challenge and code",
+                        "name": "mfa",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.UpdatedGroup"
+                            "$ref": "#/definitions/model.MFALogin"
                         }
-                    },
-                    {
-                        "type": "integer",
-                        "description": "group id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -314,7 +282,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.Group"
+                                            "$ref": "#/definitions/model.JWTToken"
                                         }
                                     }
                                 }
@@ -322,110 +290,108 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "JWT": []
-                    }
+            }
+        },
+        "/api/v1/auth/mfa/enroll": {
+            "post": {
+                "description": "Enroll totp for user required to enable mfa | 被要求开启二次验证的用户使用挑战 token 获取密钥",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Delete group | 删除指定的group",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "group"
+                    "auth"
                 ],
-                "summary": "Delete group | 删除group",
+                "summary": "MFA enroll on login | 登录时绑定二次验证",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "group id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "challenge",
+                        "name": "mfa",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MFALogin"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.MFAEnrollment"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
             }
         },
-        "/api/v1/groups/{id}/roles/{rid}": {
+        "/api/v1/auth/token": {
             "post": {
-                "security": [
-                    {
-                        "JWT": []
-                    }
+                "description": "user login | 用户登录\n开启二次验证的用户返回 model.MFAChallenge，需要在 /api/v1/auth/mfa 完成验证\nsetCookie 为 true 时同时设置 csrfToken cookie，使用 cookie 登录时除了 GET 之外的请求需要在 X-CSRF-Token 请求头中提交相同的值",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Add role to group | 给 group 添加 role",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "group"
+                    "auth"
                 ],
-                "summary": "Add role | 添加role",
+                "summary": "Login | 登录",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "group id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "role id",
-                        "name": "rid",
-                        "in": "path",
-                        "required": true
+                        "description": "auth user info",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AuthUser"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.JWTToken"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
             },
             "delete": {
-                "security": [
-                    {
-                        "JWT": []
-                    }
-                ],
-                "description": "delete role from group | 删除group中的role",
+                "description": "User logout and revoke current session | User退出，同时撤销当前会话",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "group"
-                ],
-                "summary": "Delete role | 删除 role",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "group id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "role id",
-                        "name": "rid",
-                        "in": "path",
-                        "required": true
-                    }
+                    "auth"
                 ],
+                "summary": "Logout | 退出",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -436,135 +402,67 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/groups/{id}/users": {
-            "get": {
-                "security": [
-                    {
-                        "JWT": []
-                    }
+        "/api/v1/auth/user": {
+            "post": {
+                "description": "Create user and storage",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Get users to group| 根据 group 获取user集合",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "group"
+                    "auth"
                 ],
-                "summary": "Group Get users | 获取user集合",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "group id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/common.Response"
-                        }
-                    }
-                }
-            },
-            "post": {
-                "security": [
-                    {
-                        "JWT": []
-                    }
-                ],
-                "description": "Add user to group | 把user添加到group中",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "group"
-                ],
-                "summary": "Group Add user | 添加user",
+                "summary": "Register user | 注册用户",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "group id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "description": "user info",
                         "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.User"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/common.Response"
+                            "$ref": "#/definitions/model.CreatedUser"
                         }
                     }
-                }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "JWT": []
-                    }
-                ],
-                "description": "Delete user from group | 删除group中的user",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "group"
-                ],
-                "summary": "Delete user | 删除user",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "group id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "uid",
-                        "in": "query",
-                        "required": true
-                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.User"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
             }
         },
-        "/api/v1/operations": {
+        "/api/v1/bookmarks": {
             "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "List operations | 操作列表",
+                "description": "List hot searches bookmarked by current user, including those no longer on the list | 获取当前 user 收藏的热搜，包括已下榜的",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rbac"
+                    "feed"
                 ],
-                "summary": "List operations | 操作列表",
+                "summary": "List bookmarks | 收藏列表",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -579,7 +477,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/model.Operation"
+                                                "$ref": "#/definitions/model.Bookmark"
                                             }
                                         }
                                     }
@@ -590,21 +488,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/resources": {
+        "/api/v1/comments/reports": {
             "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "List resources | 资源列表",
+                "description": "List pending reports with the reported comments, only cluster admins | 获取待处理的举报和被举报的评论，只有管理员可以查看",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rbac"
+                    "comment"
                 ],
-                "summary": "List resources | 资源列表",
+                "summary": "List comment reports | 举报列表",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -619,7 +517,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/model.Resource"
+                                                "$ref": "#/definitions/model.CommentReport"
                                             }
                                         }
                                     }
@@ -630,49 +528,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/roles": {
-            "get": {
+        "/api/v1/comments/{id}": {
+            "delete": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "List rbac role | rbac 角色列表",
+                "description": "Soft delete comment, only the author and cluster admins | 软删除评论，只有作者和管理员可以删除",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "rbac"
+                    "comment"
+                ],
+                "summary": "Delete comment | 删除评论",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "comment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "List rbac role | rbac 角色列表",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.Role"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/common.Response"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/comments/{id}/reports": {
             "post": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Create rbac role | 创建 rbac 的角色",
+                "description": "Report comment to cluster admins, once per user | 向管理员举报评论，每个 user 只能举报一次",
                 "consumes": [
                     "application/json"
                 ],
@@ -680,17 +577,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rbac"
+                    "comment"
                 ],
-                "summary": "Create rbac role | 创建 rbac 的角色",
+                "summary": "Report comment | 举报评论",
                 "parameters": [
                     {
-                        "description": "rbac role info",
-                        "name": "role",
+                        "type": "integer",
+                        "description": "comment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "report reason",
+                        "name": "report",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Role"
+                            "$ref": "#/definitions/model.CreatedCommentReport"
                         }
                     }
                 ],
@@ -702,27 +606,25 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/roles/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Get role | 获取一个 rbac 的角色",
+                "description": "Dismiss reports of comment and keep it, only cluster admins | 忽略评论的举报并保留评论，只有管理员可以处理",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rbac"
+                    "comment"
                 ],
-                "summary": "Get role | 获取一个 rbac 的角色",
+                "summary": "Dismiss comment reports | 忽略举报",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "role id",
+                        "description": "comment id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -732,127 +634,114 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/model.Role"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/common.Response"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/export": {
+            "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Update rbac role | rbac 修改角色",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Export all roles, groups and users with their group memberships and roles as one document, without passwords, only cluster admins | 导出全部的 role、group 和 user（包括 user 所在的 group 和 role），不包括密码，只有管理员可以导出",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "rbac"
+                    "bulk"
                 ],
-                "summary": "Update rbac role | rbac 修改角色",
+                "summary": "Export RBAC configuration | 导出 RBAC 配置",
                 "parameters": [
                     {
-                        "description": "rbac role info",
-                        "name": "role",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.Role"
-                        }
-                    },
-                    {
-                        "type": "integer",
-                        "description": "role id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "json or yaml, default json",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/model.Role"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/model.BulkDocument"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/export/{resource}": {
+            "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Delete role | 删除角色",
+                "description": "Export roles, groups or users as a list, without passwords, only cluster admins. CSV has a header row, list columns are separated by ; and rules are written as resource:operation | 导出 role、group 或 user 的列表，不包括密码，只有管理员可以导出。CSV 第一行是表头，列表类型的列使用 ; 分隔，规则写作 resource:operation",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/yaml",
+                    "text/csv"
                 ],
                 "tags": [
-                    "rbac"
+                    "bulk"
                 ],
-                "summary": "Delete role | 删除角色",
+                "summary": "Export resource | 导出一种资源",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "role id",
-                        "name": "id",
+                        "type": "string",
+                        "description": "roles, groups or users",
+                        "name": "resource",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv, json or yaml, default json",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BulkUser"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/v1/users": {
+        "/api/v1/feed": {
             "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "获取用户列表并存储",
+                "description": "Hot searches ranked by followed tags, keyword matches and recency, cached for a minute. All hot searches are ranked by rank and recency when nothing is followed | 按关注的 tag、匹配的关键词和上榜时间排序的热搜，缓存一分钟；没有任何关注时按排名和上榜时间推荐全部热搜",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "feed"
+                ],
+                "summary": "Feed | 个性化热搜",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "size, default 50, max 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List user | 用户列表",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -865,10 +754,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.User"
-                                            }
+                                            "$ref": "#/definitions/model.Feed"
                                         }
                                     }
                                 }
@@ -876,35 +762,23 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/api/v1/follows": {
+            "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "创建用户并存储",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List tags and keywords followed by current user | 获取当前 user 关注的 tag 和关键词",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
-                ],
-                "summary": "Create user | 创建用户",
-                "parameters": [
-                    {
-                        "description": "user 信息",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.CreatedUser"
-                        }
-                    }
+                    "feed"
                 ],
+                "summary": "List follows | 关注列表",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -917,7 +791,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "$ref": "#/definitions/model.Follows"
                                         }
                                     }
                                 }
@@ -927,28 +801,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/users/{id}": {
-            "get": {
+        "/api/v1/follows/keywords": {
+            "post": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "获取用户并保存",
+                "description": "Follow keyword, hot searches with the keyword in title are added to feed. Keywords are case insensitive | 关注关键词，标题包含关键词的热搜会出现在个性化热搜中，不区分大小写",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "feed"
                 ],
-                "summary": "Get user | 获取单个用户",
+                "summary": "Follow keyword | 关注关键词",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "keyword",
+                        "name": "keyword",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedKeywordFollow"
+                        }
                     }
                 ],
                 "responses": {
@@ -963,7 +842,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "$ref": "#/definitions/model.KeywordFollow"
                                         }
                                     }
                                 }
@@ -971,42 +850,123 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/follows/keywords/{id}": {
+            "delete": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "修改用户信息并保存",
-                "consumes": [
+                "description": "Unfollow keyword | 取消关注关键词",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "feed"
+                ],
+                "summary": "Unfollow keyword | 取消关注关键词",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "keyword follow id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/follows/tags/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Follow tag, hot searches of followed tags rank higher in feed | 关注 tag，关注的 tag 的热搜在个性化热搜中排名更高",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "feed"
                 ],
-                "summary": "Update user | 修改用户信息",
+                "summary": "Follow tag | 关注 tag",
                 "parameters": [
                     {
-                        "description": "user info",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.UpdatedUser"
+                            "$ref": "#/definitions/common.Response"
                         }
-                    },
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Unfollow tag | 取消关注 tag",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "feed"
+                ],
+                "summary": "Unfollow tag | 取消关注 tag",
+                "parameters": [
                     {
                         "type": "integer",
-                        "description": "user id",
+                        "description": "tag id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/groups": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List group | 查询所有group列表",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "List group | group 列表",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1019,7 +979,10 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Group"
+                                            }
                                         }
                                     }
                                 }
@@ -1028,58 +991,75 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Delete user and stroage | 删除 user 和存储",
+                "description": "Create group and stroage, root and system:* are reserved for system groups | 创建 group 和 stroage 存储，root 和 system: 开头的名称保留给系统 group",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "group"
                 ],
-                "summary": "Delete user | 删除 user",
+                "summary": "Create group | 创建 group",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "group info",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedGroup"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Group"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
             }
         },
-        "/api/v1/users/{id}/groups": {
+        "/api/v1/groups/{id}": {
             "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Get groups | 获取 user 的全部group",
+                "description": "Get group | 通过id查询group",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "group"
                 ],
-                "summary": "Get groups | 获取 user 的groups",
+                "summary": "Get group | 获取 group",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "user id",
+                        "description": "group id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1089,48 +1069,81 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Group"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/users/{id}/roles/{rid}": {
-            "post": {
+            },
+            "put": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Add role to user | 给user添加角色",
+                "description": "Update group and storage, only the creator and cluster admins | 修改group和保存，只有创建者和管理员可以修改",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "group"
                 ],
-                "summary": "Add role | 添加角色",
+                "summary": "Update group | 修改 group",
                 "parameters": [
+                    {
+                        "description": "group info",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdatedGroup"
+                        }
+                    },
                     {
                         "type": "integer",
-                        "description": "user id",
+                        "description": "group id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "role id",
-                        "name": "rid",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "resource version, 412 if not match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Group"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
@@ -1141,28 +1154,27 @@ const docTemplate = `{
                         "JWT": []
                     }
                 ],
-                "description": "delete role from user | 删除user的role",
+                "description": "Delete group, only the creator and cluster admins | 删除指定的group，只有创建者和管理员可以删除",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "group"
                 ],
-                "summary": "Delete role | 删除角色",
+                "summary": "Delete group | 删除group",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "user id",
+                        "description": "group id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "role id",
-                        "name": "rid",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "resource version, 412 if not match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -1173,54 +1185,4536 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/index": {
-            "get": {
-                "description": "返回后端主页 html 源代码",
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Patch group with JSON Merge Patch or JSON Patch, only name and describe can be patched, only the creator and cluster admins | 使用 JSON Merge Patch 或 JSON Patch 修改 group，只能修改 name、describe，只有创建者和管理员可以修改",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json",
+                    "application/json"
+                ],
                 "produces": [
-                    "text/html"
+                    "application/json"
                 ],
                 "tags": [
-                    "home"
+                    "group"
                 ],
-                "summary": "Home",
-                "responses": {}
+                "summary": "Patch group | 修改 group 的部分信息",
+                "parameters": [
+                    {
+                        "description": "merge patch or json patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "resource version, 412 if not match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Group"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/groups/{id}/roles/{rid}": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Add role to group, only cluster admins | 给 group 添加 role，只有管理员可以添加",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Add role | 添加role",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "rid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "delete role from group, only cluster admins | 删除group中的role，只有管理员可以删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Delete role | 删除 role",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "rid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/groups/{id}/users": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get users to group| 根据 group 获取user集合",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Group Get users | 获取user集合",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Add user to group, only the creator or cluster admins, members of system groups can only be changed by cluster admins | 把user添加到group中，只有创建者和管理员可以添加，系统 group 的成员只有管理员可以修改",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Group Add user | 添加user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "user info",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Delete user from group, only the creator or cluster admins, members of system groups can only be changed by cluster admins | 删除group中的user，只有创建者和管理员可以删除，系统 group 的成员只有管理员可以修改",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Delete user | 删除user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "uid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/hotsearches": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List hot searches of all tags | 获取全部 tag 的热搜",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hotsearch"
+                ],
+                "summary": "List hot searches | 热搜列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.HotSearch"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/hotsearches/ws": {
+            "get": {
+                "description": "Upgrade to WebSocket and receive rank changes of subscribed tags. Send {\"action\":\"subscribe\",\"tags\":[1]} or {\"action\":\"unsubscribe\",\"tags\":[1]} to change subscriptions; slow clients are disconnected and should reload the list. Public like the hot search list and feeds, no login required; cross origin connections not in the CORS allow list are rejected | 升级为 WebSocket，接收订阅 tag 的排名变化；发送 subscribe、unsubscribe 消息修改订阅；处理太慢的客户端会被断开，需要重新获取榜单。和热搜列表、订阅源一样公开，不需要登录；不在跨域白名单中的来源会被拒绝",
+                "tags": [
+                    "hotsearch"
+                ],
+                "summary": "Subscribe hot searches | 订阅热搜榜",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tag ids to subscribe, separated by comma",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/hotsearch.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/hotsearches/{id}/bookmark": {
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Bookmark hot search | 收藏热搜",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "feed"
+                ],
+                "summary": "Bookmark hot search | 收藏热搜",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "hot search id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Remove hot search from bookmarks | 取消收藏热搜",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "feed"
+                ],
+                "summary": "Unbookmark hot search | 取消收藏热搜",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "hot search id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/import": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create or update roles, groups and users by name in one transaction, only cluster admins. Every row is validated first; nothing is imported when any row is invalid or dryRun is true. Groups and roles of a user are replaced when present and kept when omitted; passwords are only required for new users | 在一个事务中按名称创建或修改 role、group 和 user，只有管理员可以导入。先校验每一行，有任何一行出错或 dryRun 为 true 时不导入。user 的 group 和 role 存在时替换，省略时不修改；只有新建的 user 需要密码",
+                "consumes": [
+                    "application/json",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bulk"
+                ],
+                "summary": "Import RBAC configuration | 导入 RBAC 配置",
+                "parameters": [
+                    {
+                        "description": "RBAC configuration",
+                        "name": "document",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.BulkDocument"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "json or yaml, default from Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only validate",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ImportResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ImportResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/import/{resource}": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create or update roles, groups or users by name in one transaction, only cluster admins. The body is a list in JSON or YAML, or CSV with a header row. Nothing is imported when any row is invalid or dryRun is true | 在一个事务中按名称创建或修改 role、group 或 user，只有管理员可以导入。请求体是 JSON 或 YAML 的列表，或者第一行是表头的 CSV；有任何一行出错或 dryRun 为 true 时不导入",
+                "consumes": [
+                    "application/json",
+                    "application/yaml",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bulk"
+                ],
+                "summary": "Import resource | 导入一种资源",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "roles, groups or users",
+                        "name": "resource",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "list of resource",
+                        "name": "users",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BulkUser"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv, json or yaml, default from Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only validate",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ImportResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ImportResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List recent in-app notifications of current user | 获取当前 user 最近的站内信",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alert"
+                ],
+                "summary": "List notifications | 站内信",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "only unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size, default 50, max 200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Notification"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notifications/read": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Mark all notifications of current user as read | 把当前 user 的全部站内信标记为已读",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alert"
+                ],
+                "summary": "Mark all notifications read | 全部标记已读",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notifications/{id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Mark notification as read | 把站内信标记为已读",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alert"
+                ],
+                "summary": "Mark notification read | 标记站内信已读",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "notification id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/operations": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List operations | 操作列表",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "List operations | 操作列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Operation"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rbac/plan": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Read the rbac file configured in rbac.file and return the changes needed to reconcile the database without applying them, only cluster admins. Objects with the same name that are not managed by the file are reported as conflicts | 读取 rbac.file 配置的文件，返回同步到数据库需要的修改但不同步，只有管理员可以查看。不是配置文件管理的同名对象视为冲突",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "Plan RBAC file | 查看 RBAC 配置文件的差异",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.RBACPlan"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.RBACPlan"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rbac/reload": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Read the rbac file again and create, update or delete the resources, roles, groups and bindings it manages in one transaction, only cluster admins. Nothing is changed when there are conflicts or dryRun is true | 重新读取配置文件，在一个事务中创建、修改或删除它管理的资源、role、group 和绑定，只有管理员可以重新加载。有冲突或 dryRun 为 true 时不修改",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "Reload RBAC file | 重新加载 RBAC 配置文件",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "only show the changes",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.RBACPlan"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.RBACPlan"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/resources": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List resources | 资源列表",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "List resources | 资源列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Resource"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/roles": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List rbac role | rbac 角色列表",
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "List rbac role | rbac 角色列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Role"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create rbac role, only cluster admins | 创建 rbac 的角色，只有管理员可以创建",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "Create rbac role | 创建 rbac 的角色",
+                "parameters": [
+                    {
+                        "description": "rbac role info",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Role"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/roles/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get role | 获取一个 rbac 的角色",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "Get role | 获取一个 rbac 的角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Role"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Update rbac role, only cluster admins | rbac 修改角色，只有管理员可以修改",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "Update rbac role | rbac 修改角色",
+                "parameters": [
+                    {
+                        "description": "rbac role info",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Role"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "resource version, 412 if not match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Role"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Delete role, only cluster admins | 删除角色，只有管理员可以删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "Delete role | 删除角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "resource version, 412 if not match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Patch role with JSON Merge Patch or JSON Patch, only name, scope, namespace and rules can be patched, only cluster admins | 使用 JSON Merge Patch 或 JSON Patch 修改 role，只能修改 name、scope、namespace、rules，只有管理员可以修改",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rbac"
+                ],
+                "summary": "Patch rbac role | 修改角色的部分信息",
+                "parameters": [
+                    {
+                        "description": "merge patch or json patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "resource version, 412 if not match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Role"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rooms": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List rooms the user can enter, with unread counts | 获取 user 能进入的聊天室和未读消息数",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room"
+                ],
+                "summary": "List rooms | 聊天室列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Room"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create a room bound to a group, or an ad-hoc room with members | 创建绑定 group 的聊天室，或者指定成员的临时聊天室",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room"
+                ],
+                "summary": "Create room | 创建聊天室",
+                "parameters": [
+                    {
+                        "description": "room info",
+                        "name": "room",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedRoom"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Room"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rooms/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get room with unread count | 获取聊天室和未读消息数",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room"
+                ],
+                "summary": "Get room | 获取聊天室",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "room id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Room"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Delete room and its messages, only the creator | 删除聊天室和消息，只有创建者可以删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room"
+                ],
+                "summary": "Delete room | 删除聊天室",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "room id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rooms/{id}/members/{uid}": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Invite user to an ad-hoc room | 邀请 user 加入临时聊天室",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room"
+                ],
+                "summary": "Add member | 添加成员",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "room id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Remove user from an ad-hoc room, or leave it | 把 user 移出临时聊天室，或者自己退出",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room"
+                ],
+                "summary": "Delete member | 移出成员",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "room id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rooms/{id}/messages": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List messages from newest to oldest, pass nextBefore of the previous page as before | 按从新到旧的顺序分页获取历史消息，下一页的 before 使用上一页返回的 nextBefore",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room"
+                ],
+                "summary": "List messages | 历史消息",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "room id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only messages with id less than before",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, default 50, max 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.MessagePage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Send message to room, link previews are pushed later as message.updated | 发送消息，链接预览获取后以 message.updated 事件推送",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room"
+                ],
+                "summary": "Send message | 发送消息",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "room id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "message",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedMessage"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Message"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rooms/{id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Mark messages up to messageId as read, all messages when messageId is empty | 把 messageId 及之前的消息标记为已读，messageId 为空时全部标记已读",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room"
+                ],
+                "summary": "Mark read | 标记已读",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "room id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "last read message id",
+                        "name": "messageId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rooms/{id}/ws": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Upgrade to WebSocket and receive message and message.updated events of room | 升级为 WebSocket，接收聊天室的 message、message.updated 事件",
+                "tags": [
+                    "room"
+                ],
+                "summary": "Connect room | 连接聊天室",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "room id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/chat.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tags/{id}/feed.atom": {
+            "get": {
+                "description": "Atom 1.0 feed of hot searches of tag ordered by rank. Supports conditional GET with If-None-Match and If-Modified-Since | 按排名输出 tag 热搜榜的 Atom 1.0 订阅源，支持 If-None-Match 和 If-Modified-Since 条件请求",
+                "produces": [
+                    "application/atom+xml"
+                ],
+                "tags": [
+                    "hotsearch"
+                ],
+                "summary": "Atom feed of tag | tag 热搜榜的 Atom",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Atom 1.0",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    }
+                }
+            }
+        },
+        "/api/v1/tags/{id}/feed.json": {
+            "get": {
+                "description": "JSON Feed 1.1 of hot searches of tag ordered by rank. Supports conditional GET with If-None-Match and If-Modified-Since | 按排名输出 tag 热搜榜的 JSON Feed 1.1 订阅源，支持 If-None-Match 和 If-Modified-Since 条件请求",
+                "produces": [
+                    "application/feed+json"
+                ],
+                "tags": [
+                    "hotsearch"
+                ],
+                "summary": "JSON Feed of tag | tag 热搜榜的 JSON Feed",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "JSON Feed 1.1",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    }
+                }
+            }
+        },
+        "/api/v1/tags/{id}/feed.rss": {
+            "get": {
+                "description": "RSS 2.0 feed of hot searches of tag ordered by rank. Supports conditional GET with If-None-Match and If-Modified-Since | 按排名输出 tag 热搜榜的 RSS 2.0 订阅源，支持 If-None-Match 和 If-Modified-Since 条件请求",
+                "produces": [
+                    "application/rss+xml"
+                ],
+                "tags": [
+                    "hotsearch"
+                ],
+                "summary": "RSS feed of tag | tag 热搜榜的 RSS",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "RSS 2.0",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    }
+                }
+            }
+        },
+        "/api/v1/tags/{id}/hotsearches": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List hot searches of tag ordered by rank | 按排名获取 tag 的热搜榜",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hotsearch"
+                ],
+                "summary": "List hot searches of tag | tag 的热搜榜",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.HotSearch"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Replace hot searches of tag with a new snapshot from the collector, rank changes are pushed to subscribers. Only users with role hotsearch-collector and cluster admins | 采集器提交 tag 新的热搜快照，替换原来的榜单并把排名变化推送给订阅者，只有拥有 hotsearch-collector 角色的 user 和管理员可以提交",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hotsearch"
+                ],
+                "summary": "Store hot search snapshot | 保存热搜快照",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "hot searches ordered by rank",
+                        "name": "snapshot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.HotSearchSnapshot"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.HotSearchUpdate"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "获取用户列表并存储",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "List user | 用户列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.User"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "创建用户并存储",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Create user | 创建用户",
+                "parameters": [
+                    {
+                        "description": "user 信息",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "获取用户并保存",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user | 获取单个用户",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "修改用户信息并保存",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user | 修改用户信息",
+                "parameters": [
+                    {
+                        "description": "user info",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdatedUser"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "resource version, 412 if not match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Delete user and stroage | 删除 user 和存储",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete user | 删除 user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "resource version, 412 if not match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Patch user with JSON Merge Patch or JSON Patch, only name, email and avatar can be patched | 使用 JSON Merge Patch 或 JSON Patch 修改 user，只能修改 name、email、avatar",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Patch user | 修改用户的部分信息",
+                "parameters": [
+                    {
+                        "description": "merge patch or json patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "resource version, 412 if not match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/groups": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get groups | 获取 user 的全部group",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get groups | 获取 user 的groups",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/mfa": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Generate totp secret and provisioning uri | 生成 TOTP 密钥和二维码 URI，验证通过后才会开启",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Enroll mfa | 绑定二次验证",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.MFAEnrollment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Disable mfa with totp code or recovery code | 使用一次性密码或恢复码关闭二次验证",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Disable mfa | 关闭二次验证",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "totp code or recovery code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MFACode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/mfa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Regenerate recovery codes, old codes are invalidated | 重新生成恢复码，旧的恢复码全部失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Regenerate recovery codes | 重新生成恢复码",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "totp code or recovery code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MFACode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.MFARecoveryCodes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/mfa/required": {
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Admins in root group require user to enable mfa | root 组的管理员要求 user 开启二次验证",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Require mfa | 要求开启二次验证",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "mfa requirement",
+                        "name": "requirement",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MFARequirement"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/mfa/verify": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Verify totp code, enable mfa and generate recovery codes | 验证一次性密码，开启二次验证并返回恢复码",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Verify mfa | 验证并开启二次验证",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "totp code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MFACode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.MFARecoveryCodes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/roles/{rid}": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Add role to user, only cluster admins | 给user添加角色，只有管理员可以添加",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Add role | 添加角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "rid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "delete role from user, only cluster admins | 删除user的role，只有管理员可以删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete role | 删除角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "rid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List login sessions of user | 获取 user 登录的设备、IP 等会话信息",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "List sessions | 会话列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Session"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Revoke all sessions of user | 撤销 user 的全部会话（在所有设备上退出）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Revoke all sessions | 撤销全部会话",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/sessions/{sid}": {
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Revoke a single session, its token becomes invalid immediately | 撤销单个会话（远程退出），对应的 token 立即失效",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Revoke session | 撤销会话",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "session id",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List access tokens of user | 获取 user 的访问 token 列表",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "List access tokens | 访问 token 列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.AccessToken"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create scoped access token for yourself, or as a cluster admin for another user, bound roles must be owned by both users, token is returned only once | 给自己创建，或者管理员给其他 user 创建绑定角色的访问 token，绑定的角色必须是两个 user 都拥有的，token 明文只返回一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Create access token | 创建访问 token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "token info",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedAccessToken"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.IssuedAccessToken"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/tokens/{tid}": {
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Delete access token of user | 删除 user 的访问 token",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Delete access token | 删除访问 token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "token id",
+                        "name": "tid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/watch/{resource}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Stream change events of resource with SSE, or WebSocket when the request is an upgrade. Resume after resourceVersion (or Last-Event-ID), 410 if it is too old. Only visible events are sent: user events to the user, group events to the creator and members, role events to users owning the role, and all events to cluster admins | 使用 SSE 推送资源的变更事件，请求为 WebSocket 升级时使用 WebSocket；从 resourceVersion（或 Last-Event-ID）之后继续，序号太旧时返回 410。只推送 user 能看到的事件：user 事件只推送给本人，group 事件推送给创建者和成员，role 事件推送给拥有该角色的 user，管理员可以收到全部事件",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "watch"
+                ],
+                "summary": "Watch resource | 监听资源变更",
+                "parameters": [
+                    {
+                        "enum": [
+                            "users",
+                            "groups",
+                            "roles",
+                            "tags",
+                            "hotsearches"
+                        ],
+                        "type": "string",
+                        "description": "resource",
+                        "name": "resource",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "resume after this resource version",
+                        "name": "resourceVersion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "resume after this event id (SSE)",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List webhooks without secrets, only cluster admins | 获取 webhook 列表，不包含签名密钥，只有管理员可以查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "List webhooks | webhook 列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Webhook"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Create webhook, only cluster admins. Events are *, \u003cresource\u003e.* or \u003cresource\u003e.\u003cadded|modified|deleted\u003e of users, groups, roles, tags and hotsearches. Requests are signed with X-Chitchat-Signature: sha256=HMAC-SHA256(secret, timestamp + \".\" + body); the secret is generated when empty and only returned here | 创建 webhook，只有管理员可以创建。事件格式为 *、\u003cresource\u003e.* 或 \u003cresource\u003e.\u003cadded|modified|deleted\u003e，资源可以是 users、groups、roles、tags 和 hotsearches。请求使用 X-Chitchat-Signature 签名：sha256=HMAC-SHA256(secret, timestamp + \".\" + body)；密钥为空时生成，只在这里返回",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Create webhook | 创建 webhook",
+                "parameters": [
+                    {
+                        "description": "webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get webhook without secret, only cluster admins | 获取 webhook，不包含签名密钥，只有管理员可以查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Get webhook | 获取 webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Replace webhook, only cluster admins. The secret is kept when empty | 修改 webhook，只有管理员可以修改；签名密钥为空时不修改",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Update webhook | 修改 webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Delete webhook and its deliveries, pending deliveries are dropped, only cluster admins | 删除 webhook 和它的投递记录，未投递的事件不再投递，只有管理员可以删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Delete webhook | 删除 webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List recent deliveries of webhook from newest to oldest, only cluster admins | 按从新到旧的顺序获取 webhook 最近的投递记录，只有管理员可以查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "List deliveries | 投递记录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size, default 50, max 200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.WebhookDelivery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/deliveries/{deliveryId}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get delivery of webhook with payload and last response, only cluster admins | 获取 webhook 的投递记录，包括请求体和最后一次的响应，只有管理员可以查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Get delivery | 获取投递记录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "delivery id",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.WebhookDelivery"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Send the payload of delivery again as a new delivery, only cluster admins | 用投递记录的请求体重新投递，生成一条新的投递记录，只有管理员可以操作",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Redeliver | 重新投递",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "delivery id",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.WebhookDelivery"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/{targetType}/{id}/comments": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "List comment threads of a hot search or topic (tag) from newest to oldest, pass nextBefore of the previous page as before. Deleted comments with replies are kept as placeholders | 按从新到旧的顺序分页获取热搜或话题（tag）的评论楼层，下一页的 before 使用上一页返回的 nextBefore；已删除但有回复的评论只保留位置",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment"
+                ],
+                "summary": "List comments | 评论列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "hotsearches or tags",
+                        "name": "targetType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "target id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only threads with id less than before",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, default 20, max 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.CommentPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Comment on a hot search or topic (tag), or reply to a comment with parentId. Rate limited per user | 评论热搜或话题（tag），指定 parentId 时回复评论；按 user 限制频率",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment"
+                ],
+                "summary": "Create comment | 发表评论",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "hotsearches or tags",
+                        "name": "targetType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "target id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "comment",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedComment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Comment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/{targetType}/{id}/reactions/{emoji}": {
+            "put": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "React to a hot search, topic (tag) or comment with an emoji, once per user and emoji. Rate limited per user | 用 emoji 回应热搜、话题（tag）或评论，每个 user 对同一个 emoji 只能回应一次；按 user 限制频率",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment"
+                ],
+                "summary": "React | 回应",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "hotsearches, tags or comments",
+                        "name": "targetType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "target id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "url encoded emoji",
+                        "name": "emoji",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Remove the emoji reaction of current user | 取消当前 user 的 emoji 回应",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment"
+                ],
+                "summary": "Unreact | 取消回应",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "hotsearches, tags or comments",
+                        "name": "targetType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "target id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "url encoded emoji",
+                        "name": "emoji",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/index": {
+            "get": {
+                "description": "返回后端主页 html 源代码",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "home"
+                ],
+                "summary": "Home",
+                "responses": {}
+            }
+        }
+    },
+    "definitions": {
+        "apierrors.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "apierrors.Reason": {
+            "type": "string",
+            "enum": [
+                "NotFound",
+                "Conflict",
+                "Validation",
+                "BadRequest",
+                "Forbidden",
+                "Unauthenticated",
+                "PreconditionFailed",
+                "Expired",
+                "UnsupportedMediaType",
+                "TooManyRequests",
+                "NotImplemented",
+                "InternalError"
+            ],
+            "x-enum-comments": {
+                "ReasonBadRequest": "错误的请求",
+                "ReasonConflict": "资源冲突（如唯一约束）",
+                "ReasonExpired": "请求的 resourceVersion 已过期",
+                "ReasonForbidden": "没有权限",
+                "ReasonInternal": "服务器内部错误",
+                "ReasonNotFound": "资源不存在",
+                "ReasonNotImplemented": "当前配置不支持的功能",
+                "ReasonPreconditionFailed": "版本号不匹配",
+                "ReasonTooManyRequests": "请求太频繁",
+                "ReasonUnauthenticated": "未登录或登录失败",
+                "ReasonUnsupportedMediaType": "不支持的请求体类型",
+                "ReasonValidation": "参数校验失败"
+            },
+            "x-enum-varnames": [
+                "ReasonNotFound",
+                "ReasonConflict",
+                "ReasonValidation",
+                "ReasonBadRequest",
+                "ReasonForbidden",
+                "ReasonUnauthenticated",
+                "ReasonPreconditionFailed",
+                "ReasonExpired",
+                "ReasonUnsupportedMediaType",
+                "ReasonTooManyRequests",
+                "ReasonNotImplemented",
+                "ReasonInternal"
+            ]
+        },
+        "chat.Event": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "$ref": "#/definitions/model.Message"
+                },
+                "roomId": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {},
+                "details": {
+                    "description": "字段级别的错误详情",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apierrors.FieldError"
+                    }
+                },
+                "msg": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "应用错误码，成功时为空",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apierrors.Reason"
+                        }
+                    ]
+                }
+            }
+        },
+        "hotsearch.Message": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.HotSearchUpdate"
+                },
+                "msg": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AccessToken": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "为空表示永不过期",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "description": "最后使用时间",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "token 的前几位，便于识别",
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Role"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AlertRule": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "keywords": {
+                    "description": "小写",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "minRank": {
+                    "description": "排名在前 MinRank 名时才提醒，0 不限制",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tagIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "webhookUrl": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AuthInfo": {
+            "type": "object",
+            "properties": {
+                "authId": {
+                    "type": "string"
+                },
+                "authType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AuthUser": {
+            "type": "object",
+            "properties": {
+                "authCode": {
+                    "type": "string"
+                },
+                "authType": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "setCookie": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.Bookmark": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "hotSearch": {
+                    "$ref": "#/definitions/model.HotSearch"
+                },
+                "hotSearchId": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.BulkDocument": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BulkGroup"
+                    }
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BulkRole"
+                    }
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BulkUser"
+                    }
+                }
+            }
+        },
+        "model.BulkGroup": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "describe": {
+                    "type": "string",
+                    "maxLength": 1024
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "roles": {
+                    "description": "role 名称",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.BulkRole": {
+            "type": "object",
+            "required": [
+                "name",
+                "scope"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "namespace": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Rule"
+                    }
+                },
+                "scope": {
+                    "$ref": "#/definitions/model.Scope"
+                }
+            }
+        },
+        "model.BulkUser": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "avatar": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "groups": {
+                    "description": "group 名称",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "password": {
+                    "type": "string"
+                },
+                "roles": {
+                    "description": "role 名称",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "serviceAccount": {
+                    "description": "只能在创建时指定",
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.Comment": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "description": "已删除但还有回复，只保留位置",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "parentId": {
+                    "description": "回复的评论，为空时是顶层评论",
+                    "type": "integer"
+                },
+                "reactions": {
+                    "description": "emoji -\u003e 回应数",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "replies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Comment"
+                    }
+                },
+                "rootId": {
+                    "description": "所在楼层的顶层评论，为空时是顶层评论",
+                    "type": "integer"
+                },
+                "targetId": {
+                    "type": "integer"
+                },
+                "targetType": {
+                    "description": "hotsearches 或 tags",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/model.User"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CommentPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Comment"
+                    }
+                },
+                "nextBefore": {
+                    "description": "下一页的 before，为 0 时没有更多评论",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CommentReport": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "$ref": "#/definitions/model.Comment"
+                },
+                "commentId": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CreatedAccessToken": {
+            "type": "object",
+            "required": [
+                "name",
+                "roleIds"
+            ],
+            "properties": {
+                "expiresAt": {
+                    "description": "为空表示永不过期",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "roleIds": {
+                    "description": "必须是 user 角色的子集",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "model.CreatedAlertRule": {
+            "type": "object",
+            "required": [
+                "channels",
+                "keywords",
+                "name"
+            ],
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "maxItems": 3,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "enabled": {
+                    "description": "为空时启用",
+                    "type": "boolean"
+                },
+                "keywords": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "minRank": {
+                    "type": "integer",
+                    "maximum": 200,
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "tagIds": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "webhookUrl": {
+                    "type": "string",
+                    "maxLength": 2048
+                }
+            }
+        },
+        "model.CreatedComment": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "parentId": {
+                    "description": "回复的评论",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CreatedCommentReport": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 512
+                }
+            }
+        },
+        "model.CreatedGroup": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "creatorId": {
+                    "description": "创建者ID",
+                    "type": "integer"
+                },
+                "describe": {
+                    "description": "描述",
+                    "type": "string",
+                    "maxLength": 1024
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "model.CreatedHotSearch": {
+            "type": "object",
+            "required": [
+                "link",
+                "title"
+            ],
+            "properties": {
+                "extra": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "link": {
+                    "type": "string",
+                    "maxLength": 512
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 512
+                }
+            }
+        },
+        "model.CreatedKeywordFollow": {
+            "type": "object",
+            "required": [
+                "keyword"
+            ],
+            "properties": {
+                "keyword": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "model.CreatedMessage": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "maxLength": 4000
+                }
+            }
+        },
+        "model.CreatedRoom": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "describe": {
+                    "type": "string",
+                    "maxLength": 1024
+                },
+                "groupId": {
+                    "description": "绑定的 group",
+                    "type": "integer"
+                },
+                "members": {
+                    "description": "临时聊天室的成员，创建者自动加入",
+                    "type": "array",
+                    "maxItems": 500,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "model.CreatedUser": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "avatar": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "password": {
+                    "description": "服务账号不需要密码",
+                    "type": "string"
+                },
+                "serviceAccount": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.CreatedWebhook": {
+            "type": "object",
+            "required": [
+                "events",
+                "name",
+                "url"
+            ],
+            "properties": {
+                "enabled": {
+                    "description": "为空时启用",
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "secret": {
+                    "description": "创建时为空则生成，修改时为空则不变",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048
+                }
+            }
+        },
+        "model.Event": {
+            "type": "object",
+            "properties": {
+                "object": {
+                    "description": "变更后的对象，删除事件只包含 id"
+                },
+                "objectId": {
+                    "description": "发生变更的对象 id",
+                    "type": "integer"
+                },
+                "resource": {
+                    "description": "资源名称，如 users、groups",
+                    "type": "string"
+                },
+                "resourceVersion": {
+                    "description": "事件序号，单调递增，用于断线后从该序号之后继续 watch",
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.EventType"
+                }
+            }
+        },
+        "model.EventType": {
+            "type": "string",
+            "enum": [
+                "ADDED",
+                "MODIFIED",
+                "DELETED"
+            ],
+            "x-enum-comments": {
+                "EventAdded": "创建",
+                "EventDeleted": "删除",
+                "EventModified": "修改"
+            },
+            "x-enum-varnames": [
+                "EventAdded",
+                "EventModified",
+                "EventDeleted"
+            ]
+        },
+        "model.Feed": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "description": "计算时间，缓存期间不变",
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FeedItem"
+                    }
+                }
+            }
+        },
+        "model.FeedItem": {
+            "type": "object",
+            "properties": {
+                "bookmarked": {
+                    "type": "boolean"
+                },
+                "commentCount": {
+                    "description": "评论数",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "extra": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "rank": {
+                    "description": "在 tag 热搜榜中的排名，从 1 开始",
+                    "type": "integer"
+                },
+                "reactions": {
+                    "description": "emoji -\u003e 回应数",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                },
+                "tag": {
+                    "$ref": "#/definitions/model.Tag"
+                },
+                "tagId": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Follows": {
+            "type": "object",
+            "properties": {
+                "keywords": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.KeywordFollow"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Tag"
+                    }
+                }
+            }
+        },
+        "model.Group": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "creatorId": {
+                    "description": "创作者Id",
+                    "type": "integer"
+                },
+                "describe": {
+                    "description": "描述",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "种类",
+                    "type": "string"
+                },
+                "managedBy": {
+                    "description": "管理者，由声明式 RBAC 配置文件创建时为 rbac-file",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "resourceVersion": {
+                    "description": "版本号，每次修改加 1，用作 ETag",
+                    "type": "integer"
+                },
+                "roles": {
+                    "description": "角色组集合",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Role"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "updaterId": {
+                    "description": "更新 Id",
+                    "type": "integer"
+                },
+                "users": {
+                    "description": "用户集合",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.User"
+                    }
+                }
+            }
+        },
+        "model.HotSearch": {
+            "type": "object",
+            "properties": {
+                "commentCount": {
+                    "description": "评论数",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "extra": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "rank": {
+                    "description": "在 tag 热搜榜中的排名，从 1 开始",
+                    "type": "integer"
+                },
+                "reactions": {
+                    "description": "emoji -\u003e 回应数",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "tag": {
+                    "$ref": "#/definitions/model.Tag"
+                },
+                "tagId": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
             }
-        }
-    },
-    "definitions": {
-        "common.Response": {
+        },
+        "model.HotSearchSnapshot": {
             "type": "object",
+            "required": [
+                "items"
+            ],
             "properties": {
-                "code": {
+                "items": {
+                    "type": "array",
+                    "maxItems": 200,
+                    "items": {
+                        "$ref": "#/definitions/model.CreatedHotSearch"
+                    }
+                }
+            }
+        },
+        "model.HotSearchUpdate": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RankChange"
+                    }
+                },
+                "tagId": {
                     "type": "integer"
                 },
-                "data": {},
-                "msg": {
+                "total": {
+                    "description": "更新后榜单的热搜数量",
+                    "type": "integer"
+                },
+                "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "model.AuthInfo": {
+        "model.ImportResult": {
             "type": "object",
             "properties": {
-                "authId": {
+                "applied": {
+                    "description": "是否已导入",
+                    "type": "boolean"
+                },
+                "created": {
+                    "description": "创建的数量",
+                    "type": "integer"
+                },
+                "dryRun": {
+                    "description": "只校验，不导入",
+                    "type": "boolean"
+                },
+                "failed": {
+                    "description": "出错的行数",
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImportRow"
+                    }
+                },
+                "updated": {
+                    "description": "修改的数量",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ImportRow": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "create 或 update，有错误时为空",
                     "type": "string"
                 },
-                "authType": {
+                "errors": {
+                    "description": "校验错误",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "description": "名称",
+                    "type": "string"
+                },
+                "resource": {
+                    "description": "roles、groups 或 users",
                     "type": "string"
                 },
+                "row": {
+                    "description": "在该资源中的序号，从 1 开始，CSV 不包括表头",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.IssuedAccessToken": {
+            "type": "object",
+            "properties": {
                 "createdAt": {
                     "type": "string"
                 },
+                "expiresAt": {
+                    "description": "为空表示永不过期",
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "lastUsedAt": {
+                    "description": "最后使用时间",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "token 的前几位，便于识别",
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Role"
+                    }
+                },
+                "token": {
+                    "type": "string"
+                },
                 "updatedAt": {
                     "type": "string"
                 },
-                "url": {
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.JWTToken": {
+            "type": "object",
+            "properties": {
+                "csrfToken": {
+                    "description": "设置 cookie 时返回，使用 cookie 登录的修改请求需要放在 X-CSRF-Token 请求头中",
+                    "type": "string"
+                },
+                "describe": {
+                    "type": "string"
+                },
+                "recoveryCodes": {
+                    "description": "登录时完成二次验证绑定才会返回",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.KeywordFollow": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "keyword": {
+                    "description": "小写",
                     "type": "string"
                 },
                 "userId": {
@@ -1228,19 +5722,56 @@ const docTemplate = `{
                 }
             }
         },
-        "model.AuthUser": {
+        "model.LinkPreview": {
             "type": "object",
             "properties": {
-                "authCode": {
+                "description": {
                     "type": "string"
                 },
-                "authType": {
+                "id": {
+                    "type": "integer"
+                },
+                "image": {
                     "type": "string"
                 },
-                "name": {
+                "messageId": {
+                    "type": "integer"
+                },
+                "title": {
                     "type": "string"
                 },
-                "password": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MFACode": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MFAEnrollment": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string"
+                },
+                "uri": {
+                    "description": "otpauth URI，前端渲染为二维码",
+                    "type": "string"
+                }
+            }
+        },
+        "model.MFALogin": {
+            "type": "object",
+            "properties": {
+                "challenge": {
+                    "type": "string"
+                },
+                "code": {
                     "type": "string"
                 },
                 "setCookie": {
@@ -1248,115 +5779,225 @@ const docTemplate = `{
                 }
             }
         },
-        "model.CreatedGroup": {
+        "model.MFARecoveryCodes": {
             "type": "object",
             "properties": {
-                "creatorId": {
-                    "description": "创建者ID",
-                    "type": "integer"
-                },
-                "describe": {
-                    "description": "描述",
+                "codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.MFARequirement": {
+            "type": "object",
+            "properties": {
+                "required": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.Message": {
+            "type": "object",
+            "properties": {
+                "content": {
                     "type": "string"
                 },
-                "name": {
+                "createdAt": {
                     "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "linkPreviews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.LinkPreview"
+                    }
+                },
+                "roomId": {
+                    "type": "integer"
+                },
+                "sender": {
+                    "$ref": "#/definitions/model.User"
+                },
+                "senderId": {
+                    "type": "integer"
                 }
             }
         },
-        "model.CreatedUser": {
+        "model.MessagePage": {
             "type": "object",
             "properties": {
-                "avatar": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Message"
+                    }
+                },
+                "nextBefore": {
+                    "description": "下一页的 before 参数",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Notification": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
                     "type": "string"
                 },
-                "email": {
+                "hotSearchId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "keyword": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "read": {
+                    "type": "boolean"
+                },
+                "ruleId": {
+                    "type": "integer"
+                },
+                "ruleName": {
+                    "type": "string"
+                },
+                "tagId": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Operation": {
+            "type": "string",
+            "enum": [
+                "*",
+                "edit",
+                "view"
+            ],
+            "x-enum-comments": {
+                "AllOperation": "所有操作",
+                "EditOperation": "编辑操作",
+                "ViewOperation": "查看操作"
+            },
+            "x-enum-varnames": [
+                "AllOperation",
+                "EditOperation",
+                "ViewOperation"
+            ]
+        },
+        "model.RBACBinding": {
+            "type": "object",
+            "properties": {
+                "group": {
                     "type": "string"
                 },
-                "name": {
+                "role": {
                     "type": "string"
                 },
-                "password": {
+                "user": {
                     "type": "string"
                 }
             }
         },
-        "model.Group": {
+        "model.RBACChange": {
             "type": "object",
             "properties": {
-                "createdAt": {
+                "action": {
+                    "description": "create、update 或 delete",
                     "type": "string"
                 },
-                "creatorId": {
-                    "description": "创作者Id",
-                    "type": "integer"
-                },
-                "describe": {
-                    "description": "描述",
-                    "type": "string"
+                "binding": {
+                    "description": "绑定和成员的两端",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RBACBinding"
+                        }
+                    ]
                 },
-                "id": {
-                    "type": "integer"
+                "fields": {
+                    "description": "修改的字段",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "kind": {
-                    "description": "种类",
+                    "description": "resources、roles、groups、bindings 或 memberships",
                     "type": "string"
                 },
                 "name": {
+                    "description": "对象的名称",
                     "type": "string"
+                }
+            }
+        },
+        "model.RBACPlan": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "是否已同步",
+                    "type": "boolean"
                 },
-                "roles": {
-                    "description": "角色组集合",
+                "changes": {
+                    "description": "按执行顺序排列的修改",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.Role"
+                        "$ref": "#/definitions/model.RBACChange"
                     }
                 },
-                "updatedAt": {
-                    "type": "string"
-                },
-                "updaterId": {
-                    "description": "更新 Id",
-                    "type": "integer"
-                },
-                "users": {
-                    "description": "用户集合",
+                "conflicts": {
+                    "description": "配置文件要修改不是它管理的对象等冲突",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.User"
+                        "type": "string"
                     }
+                },
+                "dryRun": {
+                    "description": "只计算差异，不同步",
+                    "type": "boolean"
+                },
+                "file": {
+                    "description": "配置文件路径",
+                    "type": "string"
                 }
             }
         },
-        "model.JWTToken": {
+        "model.RankChange": {
             "type": "object",
             "properties": {
-                "describe": {
+                "extra": {
                     "type": "string"
                 },
-                "token": {
+                "link": {
+                    "type": "string"
+                },
+                "previousRank": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "model.Operation": {
-            "type": "string",
-            "enum": [
-                "*",
-                "edit",
-                "view"
-            ],
-            "x-enum-comments": {
-                "AllOperation": "所有操作",
-                "EditOperation": "编辑操作",
-                "ViewOperation": "查看操作"
-            },
-            "x-enum-varnames": [
-                "AllOperation",
-                "EditOperation",
-                "ViewOperation"
-            ]
-        },
         "model.Resource": {
             "type": "object",
             "properties": {
@@ -1366,6 +6007,10 @@ const docTemplate = `{
                 "kind": {
                     "type": "string"
                 },
+                "managedBy": {
+                    "description": "管理者，由声明式 RBAC 配置文件创建时为 rbac-file",
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -1376,16 +6021,30 @@ const docTemplate = `{
         },
         "model.Role": {
             "type": "object",
+            "required": [
+                "name",
+                "scope"
+            ],
             "properties": {
                 "id": {
                     "type": "integer"
                 },
-                "name": {
+                "managedBy": {
+                    "description": "管理者，由声明式 RBAC 配置文件创建时为 rbac-file",
                     "type": "string"
                 },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
                 "namespace": {
-                    "description": "表示命名空间",
-                    "type": "string"
+                    "description": "表示命名空间，namespace 范围时必填",
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "resourceVersion": {
+                    "description": "版本号，每次修改加 1，用作 ETag",
+                    "type": "integer"
                 },
                 "rules": {
                     "description": "Rules 表示规则集合，是切片类型",
@@ -1404,8 +6063,67 @@ const docTemplate = `{
                 }
             }
         },
+        "model.Room": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "creatorId": {
+                    "type": "integer"
+                },
+                "describe": {
+                    "type": "string"
+                },
+                "groupId": {
+                    "description": "每个 group 最多绑定一个聊天室",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RoomMember"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "unreadCount": {
+                    "description": "当前 user 的未读消息数",
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.RoomMember": {
+            "type": "object",
+            "properties": {
+                "joinedAt": {
+                    "type": "string"
+                },
+                "lastReadId": {
+                    "description": "最后已读消息的 id",
+                    "type": "integer"
+                },
+                "roomId": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
         "model.Rule": {
             "type": "object",
+            "required": [
+                "operation",
+                "resource"
+            ],
             "properties": {
                 "operation": {
                     "description": "操作",
@@ -1436,15 +6154,87 @@ const docTemplate = `{
                 "NamespaceScope"
             ]
         },
-        "model.UpdatedGroup": {
+        "model.Session": {
             "type": "object",
             "properties": {
-                "describe": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "是否是当前请求使用的会话",
+                    "type": "boolean"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "lastSeenAt": {
+                    "type": "string"
+                },
+                "userAgent": {
+                    "description": "设备（客户端的用户代理）",
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Tag": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "creator": {
+                    "$ref": "#/definitions/model.User"
+                },
+                "creatorId": {
+                    "type": "integer"
+                },
+                "icon_color": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
+                "resourceVersion": {
+                    "description": "版本号，每次修改加 1，用作 ETag",
+                    "type": "integer"
+                },
+                "sort": {
+                    "type": "integer"
+                },
+                "source_key": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.UpdatedGroup": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "describe": {
+                    "type": "string",
+                    "maxLength": 1024
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
                 "updaterId": {
                     "type": "integer"
                 }
@@ -1452,14 +6242,20 @@ const docTemplate = `{
         },
         "model.UpdatedUser": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "email": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 256
                 },
                 "name": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 100
                 },
                 "password": {
+                    "description": "为空时不修改密码",
                     "type": "string"
                 }
             }
@@ -1492,17 +6288,121 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "mfaEnabled": {
+                    "description": "是否已开启二次验证",
+                    "type": "boolean"
+                },
+                "mfaRequired": {
+                    "description": "是否被管理员要求开启二次验证",
+                    "type": "boolean"
+                },
                 "name": {
                     "type": "string"
                 },
+                "resourceVersion": {
+                    "description": "版本号，每次修改加 1，用作 ETag",
+                    "type": "integer"
+                },
                 "roles": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/model.Role"
                     }
                 },
+                "serviceAccount": {
+                    "description": "服务账号不能交互式登录，只能使用访问 token",
+                    "type": "boolean"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Webhook": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "description": "订阅的事件：*、users.* 或 users.added",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "只在创建和修改密钥时返回",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deliveredAt": {
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "最后一次投递的耗时，毫秒",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "payload": {
+                    "description": "请求体，重新投递时原样发送",
+                    "type": "string"
+                },
+                "redeliveryOf": {
+                    "description": "手动重新投递时原投递记录的 id",
+                    "type": "integer"
+                },
+                "responseBody": {
+                    "type": "string"
+                },
+                "responseCode": {
+                    "description": "最后一次投递的响应状态码",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
                 "updatedAt": {
                     "type": "string"
+                },
+                "webhookId": {
+                    "type": "integer"
                 }
             }
         }
@@ -1523,7 +6423,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{"http", "https"},
 	Title:            "ChitChat API",
-	Description:      "这是 chitchat 服务器 API 文档。\n查看应用版本：项目启动命令后追加-v=true\n指定应用配置路径：项目启动命令后追加-config=配置路径",
+	Description:      "这是 chitchat 服务器 API 文档。\n查看应用版本：项目启动命令后追加 --version\n指定应用配置路径：项目启动命令后追加 --config 配置路径\n数据库迁移：chitchat migrate up、down、status 或 to 版本\n管理命令：chitchat user、group、role、token、config，不需要启动服务器",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newGroupCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "group",
+		Short: "管理 group",
+	}
+	cmd.AddCommand(newGroupAddUserCommand())
+	return cmd
+}
+
+func newGroupAddUserCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add-user <group> <user>",
+		Short: "把 user 添加到 group 中",
+		Args:  cobra.ExactArgs(2),
+		RunE: withAdmin(func(cmd *cobra.Command, a *admin, args []string) error {
+			group, err := a.group(args[0])
+			if err != nil {
+				return err
+			}
+			user, err := a.user(args[1])
+			if err != nil {
+				return err
+			}
+			if err := a.groupService.AddUser(user, idString(group.ID)); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "group/%s user %s added\n", group.Name, user.Name)
+			return nil
+		}),
+	}
+}
@@ -1,33 +1,31 @@
 package main
 
 import (
-	"flag"
-	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"chitchat4.0/pkg/config"
 	"chitchat4.0/pkg/server"
 	"chitchat4.0/pkg/version"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
 )
 
-// 思路
-// 1.查看应用的版本信息
-// 2.灵活设置配置文件路径
-//
-// 方法：Flag 包实现了命令行参数的解析 flag.Type(flag名, 默认值, 帮助信息)*Type
-//
-// 声明全局变量，定义命令行 flag 参数
+// 所有子命令共用的全局变量
 var (
-	printVersion = flag.Bool("v", false, "打印版本")
-	appConfig    = flag.String("config", "config/app.yaml", "应用的配置路径")
+	appConfig string         // 应用的配置路径
+	logger    *logrus.Logger // 所有子命令共用的日志记录器
 )
 
 // @title           ChitChat API
 // @version         4.0
 // @description     这是 chitchat 服务器 API 文档。
-// @description     查看应用版本：项目启动命令后追加-v=true
-// @description     指定应用配置路径：项目启动命令后追加-config=配置路径
+// @description     查看应用版本：项目启动命令后追加 --version
+// @description     指定应用配置路径：项目启动命令后追加 --config 配置路径
+// @description     数据库迁移：chitchat migrate up、down、status 或 to 版本
+// @description     管理命令：chitchat user、group、role、token、config，不需要启动服务器
 
 // @contact.name 作者：黄鹏举
 // @contact.url https://huangpengju.github.io/
@@ -43,53 +41,99 @@ var (
 // @in header
 // @name Authorization
 func main() {
-	// 思路
-	// 1.解析命令行flag参数
-	// 2.打印版本
-	// 3.配置日志
-	// 4.加载配置
-	// 5.初始化服务
-	// 6.运行服务
-
-	// 1.
-	// flag.Parser() 实现命令行参数的解析
-	flag.Parse()
-	// 2.
-	if *printVersion {
-		fmt.Println("这里打印版本")
-		version.Print()
-		os.Exit(0)
-	}
-	// 3.
-	logger := logrus.StandardLogger()            // 定义一个 Logger 对象
+	logger = logrus.StandardLogger()             // 定义一个 Logger 对象
 	logger.SetFormatter(&logrus.JSONFormatter{}) // 设置标准 Logger 格式化程序(程序输出 JSON 格式的日志)
-	// 4.
-	conf, err := config.Parse(*appConfig)
+
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newRootCommand 返回根命令，没有子命令时和 serve 一样启动服务器
+func newRootCommand() *cobra.Command {
+	var printVersion bool
+	root := &cobra.Command{
+		Use:          "chitchat",
+		Short:        "chitchat 服务器和管理命令",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true, // 执行失败时只打印错误，不打印用法
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if printVersion {
+				version.Print()
+				return nil
+			}
+			return runServe()
+		},
+	}
+	root.PersistentFlags().StringVarP(&appConfig, "config", "c", "config/app.yaml", "应用的配置路径")
+	root.Flags().BoolVarP(&printVersion, "version", "v", false, "打印版本")
+
+	root.AddCommand(
+		newServeCommand(),
+		newMigrateCommand(),
+		newUserCommand(),
+		newGroupCommand(),
+		newRoleCommand(),
+		newTokenCommand(),
+		newConfigCommand(),
+	)
+	return root
+}
+
+func newServeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "启动服务器",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe()
+		},
+	}
+}
+
+// runServe 初始化并运行服务器，收到 SIGHUP 时热加载配置
+func runServe() error {
+	conf, err := loadConfig()
 	if err != nil {
-		logger.Fatalf("无法分析配置：%v", err)
+		return err
 	}
-	// 5.
 	s, err := server.New(conf, logger)
 	if err != nil {
-		logger.Fatalf("初始化服务器失败：%v", err)
+		return errors.Wrap(err, "初始化服务器失败")
 	}
 
-	// 6.
+	// 收到 SIGHUP 时重新读取配置，热加载可以热加载的部分
+	go reloadOnSignal(s)
+
 	if err := s.Run(); err != nil {
-		logger.Fatalf("服务器启动失败：%v", err)
+		return errors.Wrap(err, "服务器启动失败")
+	}
+	return nil
+}
+
+// loadConfig 读取 --config 指定的配置文件，环境变量覆盖配置文件中的值
+func loadConfig() (*config.Config, error) {
+	conf, err := config.Parse(appConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "无法分析配置")
+	}
+	return conf, nil
+}
+
+// reloadOnSignal 每次收到 SIGHUP 时重新读取配置文件和环境变量并热加载，配置无效时保留原来的配置
+func reloadOnSignal(s *server.Server) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		conf, err := config.Parse(appConfig)
+		if err != nil {
+			logger.Errorf("重新加载配置失败：%v", err)
+			continue
+		}
+		if err := s.Reload(conf); err != nil {
+			logger.Errorf("热加载配置失败：%v", err)
+			continue
+		}
+		logger.Infof("已重新加载配置：%s", appConfig)
 	}
-	// 加载配置
-	// setting.Init()
-	// 初始化数据库
-	// models.Init()
-	// 普通启动
-	// router := routers.InitRouter()
-	// s := &http.Server{
-	// 	Addr:           fmt.Sprintf("%v:%v", setting.HTTPHost, setting.HTTPPort),
-	// 	Handler:        router,
-	// 	ReadTimeout:    setting.ReadTimeout,
-	// 	WriteTimeout:   setting.WriteTimeout,
-	// 	MaxHeaderBytes: 1 << 20,
-	// }
-	// s.ListenAndServe()
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/migration"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// newMigrateCommand 返回 migrate 命令，只连接数据库，不启动服务器
+func newMigrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "数据库迁移",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "执行全部未执行的版本",
+			Args:  cobra.NoArgs,
+			RunE: withMigrator(func(cmd *cobra.Command, migrator *migration.Migrator, args []string) error {
+				if err := migrator.Up(); err != nil {
+					return err
+				}
+				return printVersion(cmd.OutOrStdout(), migrator)
+			}),
+		},
+		&cobra.Command{
+			Use:   "down",
+			Short: "回滚当前版本",
+			Args:  cobra.NoArgs,
+			RunE: withMigrator(func(cmd *cobra.Command, migrator *migration.Migrator, args []string) error {
+				if err := migrator.Down(); err != nil {
+					return err
+				}
+				return printVersion(cmd.OutOrStdout(), migrator)
+			}),
+		},
+		&cobra.Command{
+			Use:   "to <version>",
+			Short: "迁移到指定的版本，0 表示回滚全部版本",
+			Args:  cobra.ExactArgs(1),
+			RunE: withMigrator(func(cmd *cobra.Command, migrator *migration.Migrator, args []string) error {
+				version, err := strconv.ParseUint(args[0], 10, 32)
+				if err != nil {
+					return fmt.Errorf("无效的版本 %q", args[0])
+				}
+				if err := migrator.To(uint(version)); err != nil {
+					return err
+				}
+				return printVersion(cmd.OutOrStdout(), migrator)
+			}),
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "查看每个版本的状态",
+			Args:  cobra.NoArgs,
+			RunE: withMigrator(func(cmd *cobra.Command, migrator *migration.Migrator, args []string) error {
+				return printStatus(cmd.OutOrStdout(), migrator)
+			}),
+		},
+	)
+	return cmd
+}
+
+// withMigrator 连接数据库后执行迁移，执行完成后关闭数据库连接
+func withMigrator(run func(cmd *cobra.Command, migrator *migration.Migrator, args []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		conf, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		db, err := database.New(&conf.DB)
+		if err != nil {
+			return err
+		}
+		defer closeDB(db)
+		migrator, err := migration.New(db, logger)
+		if err != nil {
+			return err
+		}
+
+		return run(cmd, migrator, args)
+	}
+}
+
+// printVersion 打印数据库当前的版本
+func printVersion(out io.Writer, migrator *migration.Migrator) error {
+	version, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "数据库当前版本：%d\n", version)
+	return nil
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+func printStatus(out io.Writer, migrator *migration.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		status, appliedAt := "pending", ""
+		if s.Applied {
+			status, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Unknown {
+			status = "unknown"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+	}
+	return w.Flush()
+}
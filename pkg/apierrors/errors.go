@@ -0,0 +1,222 @@
+// Package apierrors 定义应用的错误类型，
+// repository 和 service 返回这些错误，common.ResponseFailed 根据错误类型设置 HTTP 状态码和应用错误码
+package apierrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Reason 应用错误码，返回给前端用于区分错误类型
+type Reason string
+
+const (
+	ReasonNotFound             Reason = "NotFound"             // 资源不存在
+	ReasonConflict             Reason = "Conflict"             // 资源冲突（如唯一约束）
+	ReasonValidation           Reason = "Validation"           // 参数校验失败
+	ReasonBadRequest           Reason = "BadRequest"           // 错误的请求
+	ReasonForbidden            Reason = "Forbidden"            // 没有权限
+	ReasonUnauthenticated      Reason = "Unauthenticated"      // 未登录或登录失败
+	ReasonPreconditionFailed   Reason = "PreconditionFailed"   // 版本号不匹配
+	ReasonExpired              Reason = "Expired"              // 请求的 resourceVersion 已过期
+	ReasonUnsupportedMediaType Reason = "UnsupportedMediaType" // 不支持的请求体类型
+	ReasonTooManyRequests      Reason = "TooManyRequests"      // 请求太频繁
+	ReasonNotImplemented       Reason = "NotImplemented"       // 当前配置不支持的功能
+	ReasonInternal             Reason = "InternalError"        // 服务器内部错误
+)
+
+// reasonStatus 应用错误码对应的 HTTP 状态码
+var reasonStatus = map[Reason]int{
+	ReasonNotFound:             http.StatusNotFound,
+	ReasonConflict:             http.StatusConflict,
+	ReasonValidation:           http.StatusBadRequest,
+	ReasonBadRequest:           http.StatusBadRequest,
+	ReasonForbidden:            http.StatusForbidden,
+	ReasonUnauthenticated:      http.StatusUnauthorized,
+	ReasonPreconditionFailed:   http.StatusPreconditionFailed,
+	ReasonExpired:              http.StatusGone,
+	ReasonUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	ReasonTooManyRequests:      http.StatusTooManyRequests,
+	ReasonNotImplemented:       http.StatusNotImplemented,
+	ReasonInternal:             http.StatusInternalServerError,
+}
+
+// FieldError 字段级别的错误详情
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error 应用错误
+type Error struct {
+	Reason  Reason
+	Message string
+	Details []FieldError
+	Err     error // 原始错误，不会返回给前端
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status 返回错误对应的 HTTP 状态码
+func (e *Error) Status() int {
+	if status, ok := reasonStatus[e.Reason]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// NewNotFound 资源不存在，name 为空时只描述资源
+func NewNotFound(resource string, name interface{}) *Error {
+	msg := fmt.Sprintf("%s not found", resource)
+	if name != nil && name != "" {
+		msg = fmt.Sprintf("%s %v not found", resource, name)
+	}
+	return &Error{Reason: ReasonNotFound, Message: msg}
+}
+
+// NewConflict 资源冲突
+func NewConflict(resource string, name interface{}, err error) *Error {
+	msg := fmt.Sprintf("%s already exists", resource)
+	if name != nil && name != "" {
+		msg = fmt.Sprintf("%s %v already exists", resource, name)
+	}
+	return &Error{Reason: ReasonConflict, Message: msg, Err: err}
+}
+
+// NewValidation 参数校验失败，details 描述每个字段的错误
+func NewValidation(msg string, details ...FieldError) *Error {
+	return &Error{Reason: ReasonValidation, Message: msg, Details: details}
+}
+
+// NewFieldInvalid 单个字段校验失败
+func NewFieldInvalid(field, msg string) *Error {
+	return NewValidation(fmt.Sprintf("%s: %s", field, msg), FieldError{Field: field, Message: msg})
+}
+
+// NewBadRequest 错误的请求
+func NewBadRequest(msg string) *Error {
+	return &Error{Reason: ReasonBadRequest, Message: msg}
+}
+
+// NewForbidden 没有权限
+func NewForbidden(msg string) *Error {
+	return &Error{Reason: ReasonForbidden, Message: msg}
+}
+
+// NewUnauthenticated 未登录或登录失败
+func NewUnauthenticated(msg string) *Error {
+	return &Error{Reason: ReasonUnauthenticated, Message: msg}
+}
+
+// NewPreconditionFailed 资源已被修改，版本号和 If-Match 不匹配
+func NewPreconditionFailed(resource string, name interface{}) *Error {
+	return &Error{Reason: ReasonPreconditionFailed, Message: fmt.Sprintf("%s %v has been modified, please apply your changes to the latest version", resource, name)}
+}
+
+// NewExpired 请求的 resourceVersion 已不在保留的历史事件中，需要重新获取列表后再 watch
+func NewExpired(resourceVersion uint64) *Error {
+	return &Error{Reason: ReasonExpired, Message: fmt.Sprintf("resourceVersion %d is too old or invalid", resourceVersion)}
+}
+
+// NewUnsupportedMediaType 不支持的请求体类型
+func NewUnsupportedMediaType(contentType string) *Error {
+	return &Error{Reason: ReasonUnsupportedMediaType, Message: fmt.Sprintf("unsupported media type %q", contentType)}
+}
+
+// NewTooManyRequests 请求太频繁
+func NewTooManyRequests(msg string) *Error {
+	return &Error{Reason: ReasonTooManyRequests, Message: msg}
+}
+
+// NewNotImplemented 当前配置不支持的功能，例如 redis 禁用时撤销会话
+func NewNotImplemented(msg string) *Error {
+	return &Error{Reason: ReasonNotImplemented, Message: msg}
+}
+
+// NewInternal 服务器内部错误，err 只记录日志
+func NewInternal(err error) *Error {
+	return &Error{Reason: ReasonInternal, Message: "internal server error", Err: err}
+}
+
+// ReasonForStatus 返回 HTTP 状态码对应的应用错误码，用于没有使用 apierrors 的错误
+func ReasonForStatus(status int) Reason {
+	switch status {
+	case http.StatusBadRequest:
+		return ReasonBadRequest
+	case http.StatusUnauthorized:
+		return ReasonUnauthenticated
+	case http.StatusForbidden:
+		return ReasonForbidden
+	case http.StatusNotFound:
+		return ReasonNotFound
+	case http.StatusConflict:
+		return ReasonConflict
+	case http.StatusPreconditionFailed:
+		return ReasonPreconditionFailed
+	case http.StatusGone:
+		return ReasonExpired
+	case http.StatusUnsupportedMediaType:
+		return ReasonUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return ReasonTooManyRequests
+	}
+	if status >= http.StatusInternalServerError {
+		return ReasonInternal
+	}
+	return ""
+}
+
+// FromError 返回 err 中的应用错误，没有时返回 nil
+func FromError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return nil
+}
+
+// ReasonForError 返回 err 的应用错误码，不是应用错误时返回空字符串
+func ReasonForError(err error) Reason {
+	if e := FromError(err); e != nil {
+		return e.Reason
+	}
+	return ""
+}
+
+func IsNotFound(err error) bool {
+	return ReasonForError(err) == ReasonNotFound
+}
+
+func IsConflict(err error) bool {
+	return ReasonForError(err) == ReasonConflict
+}
+
+func IsValidation(err error) bool {
+	return ReasonForError(err) == ReasonValidation
+}
+
+func IsPreconditionFailed(err error) bool {
+	return ReasonForError(err) == ReasonPreconditionFailed
+}
+
+func IsForbidden(err error) bool {
+	return ReasonForError(err) == ReasonForbidden
+}
+
+func IsUnauthenticated(err error) bool {
+	return ReasonForError(err) == ReasonUnauthenticated
+}
+
+func IsNotImplemented(err error) bool {
+	return ReasonForError(err) == ReasonNotImplemented
+}
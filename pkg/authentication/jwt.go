@@ -1,8 +1,13 @@
 package authentication
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"chitchat4.0/pkg/model"
@@ -11,33 +16,56 @@ import (
 
 const (
 	Issuer = "hpj.io"
+
+	MFAPurpose = "mfa" // 二次验证挑战 token 的用途
 )
 
 // CustomClaims 定制要求
 type CustomClaims struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Purpose string `json:"purpose,omitempty"` // 为空表示正式登录 token
 	jwt.RegisteredClaims
 }
 
 // JWTService 结构体
 type JWTService struct {
-	signKey        []byte
-	issuer         string
-	expireDuration time.Duration // 过期时间
+	signKey           []byte
+	issuer            string
+	expireDuration    time.Duration // 过期时间
+	mfaExpireDuration time.Duration // 二次验证挑战 token 的过期时间
 }
 
 // NewJWTService 创建一个 JWT 服务
 func NewJWTService(secret string) *JWTService {
 	return &JWTService{
-		signKey:        []byte(secret),
-		issuer:         Issuer,
-		expireDuration: 7 * 24 * time.Hour,
+		signKey:           []byte(secret),
+		issuer:            Issuer,
+		expireDuration:    7 * 24 * time.Hour,
+		mfaExpireDuration: 5 * time.Minute,
+	}
+}
+
+// CreateToken 创建 Token，token 的 ID 是登录会话的 ID
+func (s *JWTService) CreateToken(user *model.User, sessionID string) (string, error) {
+	return s.createToken(user, sessionID, "", s.expireDuration)
+}
+
+// ExpireDuration 返回 token 的有效期
+func (s *JWTService) ExpireDuration() time.Duration {
+	return s.expireDuration
+}
+
+// CreateMFAToken 创建二次验证的挑战 token，
+// 挑战 token 有效期很短，只能在 /auth/mfa 换取正式 token
+func (s *JWTService) CreateMFAToken(user *model.User) (string, error) {
+	if user == nil {
+		return "", fmt.Errorf("empty user")
 	}
+	return s.createToken(user, strconv.Itoa(int(user.ID)), MFAPurpose, s.mfaExpireDuration)
 }
 
-// CreateToken 创建 Token
-func (s *JWTService) CreateToken(user *model.User) (string, error) {
+func (s *JWTService) createToken(user *model.User, id, purpose string, expire time.Duration) (string, error) {
 	if user == nil {
 		return "", fmt.Errorf("empty user")
 	}
@@ -45,12 +73,13 @@ func (s *JWTService) CreateToken(user *model.User) (string, error) {
 	token := jwt.NewWithClaims(
 		jwt.SigningMethodHS256,
 		CustomClaims{
-			Name: user.Name,
-			ID:   user.ID,
+			Name:    user.Name,
+			ID:      user.ID,
+			Purpose: purpose,
 			RegisteredClaims: jwt.RegisteredClaims{
-				ExpiresAt: jwt.NewNumericDate(now.Add(s.expireDuration)), // 过期时间
+				ExpiresAt: jwt.NewNumericDate(now.Add(expire)), // 过期时间
 				NotBefore: jwt.NewNumericDate(now.Add(-1000 * time.Second)),
-				ID:        strconv.Itoa(int(user.ID)),
+				ID:        id,
 				Issuer:    s.issuer,
 			},
 		},
@@ -61,6 +90,28 @@ func (s *JWTService) CreateToken(user *model.User) (string, error) {
 
 // ParseToken 解析token
 func (s *JWTService) ParseToken(tokenString string) (*model.User, error) {
+	claims, err := s.ParseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return claims.User(), nil
+}
+
+// ParseClaims 解析 token，返回全部的 claims（包括会话 ID）
+func (s *JWTService) ParseClaims(tokenString string) (*CustomClaims, error) {
+	return s.parseToken(tokenString, "")
+}
+
+// ParseMFAToken 解析二次验证的挑战 token
+func (s *JWTService) ParseMFAToken(tokenString string) (*model.User, error) {
+	claims, err := s.parseToken(tokenString, MFAPurpose)
+	if err != nil {
+		return nil, err
+	}
+	return claims.User(), nil
+}
+
+func (s *JWTService) parseToken(tokenString, purpose string) (*CustomClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(t *jwt.Token) (interface{}, error) {
 		return s.signKey, nil
 	})
@@ -71,10 +122,49 @@ func (s *JWTService) ParseToken(tokenString string) (*model.User, error) {
 	if !ok || !token.Valid {
 		return nil, fmt.Errorf("invalid token")
 	}
+	// 挑战 token 不能当作正式 token 使用，反之亦然
+	if claims.Purpose != purpose {
+		return nil, fmt.Errorf("invalid token purpose")
+	}
 
-	user := &model.User{
-		ID:   claims.ID,
-		Name: claims.Name,
+	return claims, nil
+}
+
+// User 返回 claims 中的 user
+func (c *CustomClaims) User() *model.User {
+	return &model.User{
+		ID:   c.ID,
+		Name: c.Name,
 	}
-	return user, nil
+}
+
+// SessionID 返回 token 对应的登录会话 ID
+func (c *CustomClaims) SessionID() string {
+	return c.RegisteredClaims.ID
+}
+
+// CreateCSRFToken 创建登录会话的 CSRF token，格式为 随机数.签名，签名绑定会话 ID，
+// 用于 cookie 登录时的双重提交校验
+func (s *JWTService) CreateCSRFToken(sessionID string) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(nonce)
+	return encoded + "." + s.csrfSignature(sessionID, encoded), nil
+}
+
+// VerifyCSRFToken 检查 CSRF token 是否是 sessionID 对应的会话签发的
+func (s *JWTService) VerifyCSRFToken(sessionID, token string) bool {
+	nonce, signature, ok := strings.Cut(token, ".")
+	if !ok || nonce == "" || sessionID == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.csrfSignature(sessionID, nonce)))
+}
+
+func (s *JWTService) csrfSignature(sessionID, nonce string) string {
+	mac := hmac.New(sha256.New, s.signKey)
+	mac.Write([]byte("csrf:" + sessionID + ":" + nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
@@ -0,0 +1,38 @@
+package authentication
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSRFToken(t *testing.T) {
+	s := NewJWTService("chitchat-test-secret")
+	token, err := s.CreateCSRFToken("session-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.VerifyCSRFToken("session-a", token) {
+		t.Fatal("token is rejected by its own session")
+	}
+
+	nonce, signature, _ := strings.Cut(token, ".")
+	tests := map[string]struct {
+		sessionID, token string
+	}{
+		"other session":      {"session-b", token},
+		"empty session":      {"", token},
+		"empty token":        {"session-a", ""},
+		"missing signature":  {"session-a", nonce},
+		"missing nonce":      {"session-a", "." + signature},
+		"other nonce":        {"session-a", "x" + token},
+		"modified signature": {"session-a", nonce + ".x" + signature},
+		"other secret":       {"session-a", nonce + "." + NewJWTService("other-secret").csrfSignature("session-a", nonce)},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if s.VerifyCSRFToken(tt.sessionID, tt.token) {
+				t.Fatalf("VerifyCSRFToken(%q, %q) = true", tt.sessionID, tt.token)
+			}
+		})
+	}
+}
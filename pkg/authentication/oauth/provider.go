@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"chitchat4.0/pkg/config"
@@ -74,6 +75,7 @@ func (ui *UserInfo) User() *model.User {
 
 // 授权管理
 type OAuthManager struct {
+	mu   sync.RWMutex
 	conf map[string]config.OAuthConfig // 授权配置（授权类型、客户Id、客户秘密）
 }
 
@@ -84,14 +86,23 @@ func NewOAuthManager(conf map[string]config.OAuthConfig) *OAuthManager {
 	}
 }
 
+// Update 替换授权配置，用于热加载
+func (m *OAuthManager) Update(conf map[string]config.OAuthConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conf = conf
+}
+
 /**
  * @description: GetAuthProvider 获取授权提供者
  * @param {string} authType 授权类型
  * @return 授权提供者
  */
 func (m *OAuthManager) GetAuthProvider(authType string) (AuthProvider, error) {
-	var provider AuthProvider    // 接口类型
+	var provider AuthProvider // 接口类型
+	m.mu.RLock()
 	conf, ok := m.conf[authType] // 检查授权类型是否存在，存在返回结构体 OAuthConfig
+	m.mu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("auth type %s not found in config", authType) // 在配置中找不到验证类型
 	}
@@ -36,7 +36,7 @@ func Authorize(user *model.User, ri *request.RequestInfo) (bool, error) {
 	// }
 
 	var err error
-	if user.ID != 0 {
+	if user.ID != 0 && store != nil {
 		// store 是 repository
 		_, err = store.User().GetUserByID(user.ID)
 	}
@@ -76,6 +76,11 @@ func Authorize(user *model.User, ri *request.RequestInfo) (bool, error) {
  * @return {*}
  */
 func IsClusterAdmin(user *model.User) bool {
+	return HasRole(user, model.ClusterAdminRole)
+}
+
+// HasRole 判断 user 自己或所在的 group 有没有名为 name 的角色，使用访问 token 时只有 token 绑定的角色
+func HasRole(user *model.User, name string) bool {
 	if user == nil || user.Name == "" {
 		return false
 	}
@@ -87,11 +92,26 @@ func IsClusterAdmin(user *model.User) bool {
 	}
 
 	for _, role := range roles {
-		// cluster-admin 群管理员
-		if role.Name == "cluster-admin" {
+		if role.Name == name {
 			return true
 		}
-
 	}
 	return false
 }
+
+/**
+ * @description: 判断是不是 root 组中的管理员
+ * @return {*}
+ */
+func IsRootAdmin(user *model.User) bool {
+	if user == nil || user.Name == "" {
+		return false
+	}
+
+	for _, g := range user.Groups {
+		if g.Name == model.RootGroup {
+			return true
+		}
+	}
+	return IsClusterAdmin(user)
+}
@@ -0,0 +1,317 @@
+// Package bulk 编解码批量导入导出的 RBAC 配置，支持 CSV、JSON 和 YAML。
+// 完整的配置包括 role、group 和 user，只能使用 JSON 或 YAML；CSV 每个文件只包含一种资源，
+// 第一行是表头，列的顺序不限，列表类型的列使用 ; 分隔，role 的规则写作 resource:operation
+package bulk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// 批量导入导出的格式
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Formats 支持的格式
+var Formats = []string{FormatCSV, FormatJSON, FormatYAML}
+
+const listSeparator = ";" // CSV 中列表类型的列的分隔符
+
+// 每种资源的 CSV 列，导出时按这个顺序输出，password 只用于导入
+var columns = map[string][]string{
+	model.RoleResource:  {"name", "scope", "namespace", "rules"},
+	model.GroupResource: {"name", "describe", "roles"},
+	model.UserResource:  {"name", "email", "avatar", "password", "serviceAccount", "groups", "roles"},
+}
+
+// mediaTypes 格式对应的媒体类型
+var mediaTypes = map[string]string{
+	FormatCSV:  "text/csv",
+	FormatJSON: "application/json",
+	FormatYAML: "application/yaml",
+}
+
+// ContentType 返回格式对应的 Content-Type
+func ContentType(format string) string {
+	return mediaTypes[format] + "; charset=utf-8"
+}
+
+// FormatOf 根据 Content-Type 选择格式，不支持时返回 false
+func FormatOf(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, true
+	case "application/json":
+		return FormatJSON, true
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return FormatYAML, true
+	}
+	return "", false
+}
+
+// Decode 解码导入的数据。resource 为空时解码完整的配置，否则解码一种资源的列表
+func Decode(format, resource string, data []byte) (*model.BulkDocument, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // 表格软件导出的 CSV 可能带有 BOM
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, apierrors.NewBadRequest("empty import data")
+	}
+	doc := new(model.BulkDocument)
+	var target interface{} = doc
+	if resource != "" {
+		if _, ok := columns[resource]; !ok {
+			return nil, apierrors.NewBadRequest("unsupported resource " + strconv.Quote(resource))
+		}
+		target = section(doc, resource)
+	}
+
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(target); err != nil {
+			return nil, apierrors.NewBadRequest("invalid json: " + err.Error())
+		}
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(target); err != nil {
+			return nil, apierrors.NewBadRequest("invalid yaml: " + err.Error())
+		}
+	case FormatCSV:
+		if resource == "" {
+			return nil, apierrors.NewBadRequest("csv contains only one resource, import it with the resource")
+		}
+		if err := decodeCSV(resource, data, doc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, apierrors.NewBadRequest("unsupported format " + strconv.Quote(format))
+	}
+	return doc, nil
+}
+
+// Encode 编码导出的数据。resource 为空时编码完整的配置，否则编码一种资源的列表
+func Encode(format, resource string, doc *model.BulkDocument) ([]byte, error) {
+	var source interface{} = doc
+	if resource != "" {
+		if _, ok := columns[resource]; !ok {
+			return nil, apierrors.NewBadRequest("unsupported resource " + strconv.Quote(resource))
+		}
+		source = section(doc, resource)
+	}
+
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(source, "", "  ")
+	case FormatYAML:
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(source); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatCSV:
+		if resource == "" {
+			return nil, apierrors.NewBadRequest("csv contains only one resource, export it with the resource")
+		}
+		return encodeCSV(resource, doc)
+	}
+	return nil, apierrors.NewBadRequest("unsupported format " + strconv.Quote(format))
+}
+
+// section 返回配置中资源对应的列表的指针
+func section(doc *model.BulkDocument, resource string) interface{} {
+	switch resource {
+	case model.RoleResource:
+		return &doc.Roles
+	case model.GroupResource:
+		return &doc.Groups
+	default:
+		return &doc.Users
+	}
+}
+
+func decodeCSV(resource string, data []byte, doc *model.BulkDocument) error {
+	reader := csv.NewReader(bytes.NewReader(data))
+	records, err := reader.ReadAll()
+	if err != nil {
+		return apierrors.NewBadRequest("invalid csv: " + err.Error())
+	}
+	if len(records) == 0 {
+		return apierrors.NewBadRequest("empty import data")
+	}
+
+	// 表头中列名对应的下标
+	index := make(map[string]int)
+	allowed := columns[resource]
+	for i, name := range records[0] {
+		name = strings.TrimSpace(name)
+		if !contains(allowed, name) {
+			return apierrors.NewBadRequest(fmt.Sprintf("unknown column %q, %s columns are %s", name, resource, strings.Join(allowed, ",")))
+		}
+		if _, ok := index[name]; ok {
+			return apierrors.NewBadRequest(fmt.Sprintf("duplicate column %q", name))
+		}
+		index[name] = i
+	}
+	if _, ok := index["name"]; !ok {
+		return apierrors.NewBadRequest("missing column \"name\"")
+	}
+
+	for n, record := range records[1:] {
+		row := csvRow{index: index, record: record}
+		switch resource {
+		case model.RoleResource:
+			rules, err := parseRules(row.list("rules"))
+			if err != nil {
+				return apierrors.NewBadRequest(fmt.Sprintf("row %d: %v", n+1, err))
+			}
+			doc.Roles = append(doc.Roles, model.BulkRole{
+				Name:      row.get("name"),
+				Scope:     model.Scope(row.get("scope")),
+				Namespace: row.get("namespace"),
+				Rules:     rules,
+			})
+		case model.GroupResource:
+			doc.Groups = append(doc.Groups, model.BulkGroup{
+				Name:     row.get("name"),
+				Describe: row.get("describe"),
+				Roles:    row.list("roles"),
+			})
+		case model.UserResource:
+			serviceAccount := false
+			if value := row.get("serviceAccount"); value != "" {
+				if serviceAccount, err = strconv.ParseBool(value); err != nil {
+					return apierrors.NewBadRequest(fmt.Sprintf("row %d: invalid serviceAccount %q", n+1, value))
+				}
+			}
+			doc.Users = append(doc.Users, model.BulkUser{
+				Name:           row.get("name"),
+				Email:          row.get("email"),
+				Avatar:         row.get("avatar"),
+				Password:       row.password(),
+				ServiceAccount: serviceAccount,
+				Groups:         row.list("groups"),
+				Roles:          row.list("roles"),
+			})
+		}
+	}
+	return nil
+}
+
+// csvRow CSV 中的一行数据
+type csvRow struct {
+	index  map[string]int
+	record []string
+}
+
+// get 返回去掉首尾空格的列的值，没有这一列时返回空字符串
+func (r csvRow) get(column string) string {
+	return strings.TrimSpace(r.raw(column))
+}
+
+// raw 返回列的原始值，没有这一列时返回空字符串
+func (r csvRow) raw(column string) string {
+	i, ok := r.index[column]
+	if !ok {
+		return ""
+	}
+	return r.record[i]
+}
+
+// password 返回密码，密码不去掉首尾空格，只有空格时视为空
+func (r csvRow) password() string {
+	if r.get("password") == "" {
+		return ""
+	}
+	return r.raw("password")
+}
+
+// list 返回列表类型的列的值，没有这一列时返回 nil，表示不修改；有这一列但为空时返回空列表
+func (r csvRow) list(column string) []string {
+	if _, ok := r.index[column]; !ok {
+		return nil
+	}
+	items := make([]string, 0)
+	for _, item := range strings.Split(r.get(column), listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseRules 解析 resource:operation 格式的规则
+func parseRules(items []string) (model.Rules, error) {
+	rules := make(model.Rules, 0, len(items))
+	for _, item := range items {
+		i := strings.LastIndex(item, ":")
+		if i <= 0 || i == len(item)-1 {
+			return nil, fmt.Errorf("invalid rule %q, rule should be resource:operation", item)
+		}
+		rules = append(rules, model.Rule{Resource: item[:i], Operation: model.Operation(item[i+1:])})
+	}
+	return rules, nil
+}
+
+func encodeCSV(resource string, doc *model.BulkDocument) ([]byte, error) {
+	header := columns[resource]
+	records := [][]string{header}
+	switch resource {
+	case model.RoleResource:
+		for _, role := range doc.Roles {
+			rules := make([]string, 0, len(role.Rules))
+			for _, rule := range role.Rules {
+				rules = append(rules, rule.Resource+":"+string(rule.Operation))
+			}
+			records = append(records, []string{role.Name, string(role.Scope), role.Namespace, strings.Join(rules, listSeparator)})
+		}
+	case model.GroupResource:
+		for _, group := range doc.Groups {
+			records = append(records, []string{group.Name, group.Describe, strings.Join(group.Roles, listSeparator)})
+		}
+	case model.UserResource:
+		for _, user := range doc.Users {
+			records = append(records, []string{
+				user.Name, user.Email, user.Avatar, "", strconv.FormatBool(user.ServiceAccount),
+				strings.Join(user.Groups, listSeparator), strings.Join(user.Roles, listSeparator),
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
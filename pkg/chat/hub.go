@@ -0,0 +1,212 @@
+// Package chat 实现聊天消息的实时推送和链接预览：
+// 消息保存后通过 Redis pub/sub 分发到所有副本，每个副本再推送给连接到该聊天室的 WebSocket 客户端
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// Channel 聊天消息在 Redis 中的 pub/sub channel
+const Channel = "chitchat:chat:messages"
+
+// 推送给客户端的事件类型
+const (
+	EventMessage        = "message"         // 新消息
+	EventMessageUpdated = "message.updated" // 消息更新，例如获取到了链接预览
+)
+
+const (
+	writeWait      = 10 * time.Second  // 写消息的超时时间
+	pongWait       = 60 * time.Second  // 等待客户端 pong 的时间，超时断开
+	pingPeriod     = pongWait * 9 / 10 // 发送 ping（心跳）的间隔，必须小于 pongWait
+	maxMessageSize = 512               // 客户端只发送控制消息
+	sendBufferSize = 64                // 每个连接待发送事件的缓冲区大小，写满说明客户端太慢
+)
+
+// Event 推送给聊天室客户端的事件
+type Event struct {
+	Type    string         `json:"type"`
+	RoomID  uint           `json:"roomId"`
+	Message *model.Message `json:"message"`
+}
+
+// Hub 管理连接到聊天室的 WebSocket 客户端，按 room id 分发事件
+type Hub struct {
+	rdb *database.RedisDB
+
+	mu    sync.RWMutex
+	rooms map[uint]map[*client]struct{} // room id -> 连接到该聊天室的客户端
+}
+
+type client struct {
+	conn   *websocket.Conn
+	roomID uint
+	send   chan []byte
+	closed bool // 由 hub.mu 保护
+}
+
+// NewHub 创建聊天推送中心，rdb 未启用时只在当前进程内分发
+func NewHub(rdb *database.RedisDB) *Hub {
+	return &Hub{
+		rdb:   rdb,
+		rooms: make(map[uint]map[*client]struct{}),
+	}
+}
+
+// Publish 发布聊天室事件。
+// 启用 Redis 时发布到 Channel，由每个副本（包括当前进程）在 Run 中收到后推送；否则直接推送给当前进程的客户端
+func (h *Hub) Publish(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if h.rdb != nil && h.rdb.Enabled() {
+		err := h.rdb.Publish(Channel, data)
+		if err == nil {
+			return nil
+		}
+		logrus.Errorf("publish chat event of room %d to redis failed, only push to local clients: %v", event.RoomID, err)
+	}
+	h.broadcast(event.RoomID, data)
+	return nil
+}
+
+// Run 订阅 Redis 中各副本发布的事件并推送给当前进程的客户端，直到 ctx 结束。
+// Redis 未启用时直接返回
+func (h *Hub) Run(ctx context.Context) error {
+	if h.rdb == nil {
+		return nil
+	}
+	sub, err := h.rdb.Subscribe(ctx, Channel)
+	if errors.Is(err, database.RedisDisableError) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			event := new(Event)
+			if err := json.Unmarshal([]byte(msg.Payload), event); err != nil {
+				logrus.Warnf("invalid chat event from redis: %v", err)
+				continue
+			}
+			h.broadcast(event.RoomID, []byte(msg.Payload))
+		}
+	}
+}
+
+// Serve 把 WebSocket 连接加入聊天室，推送聊天室的事件直到连接断开。
+// 客户端通过 HTTP 接口发送消息，连接上收到的消息会被忽略
+func (h *Hub) Serve(conn *websocket.Conn, roomID uint) {
+	c := &client{
+		conn:   conn,
+		roomID: roomID,
+		send:   make(chan []byte, sendBufferSize),
+	}
+	h.mu.Lock()
+	if h.rooms[roomID] == nil {
+		h.rooms[roomID] = make(map[*client]struct{})
+	}
+	h.rooms[roomID][c] = struct{}{}
+	h.mu.Unlock()
+	defer h.remove(c)
+
+	go c.writePump()
+	c.readPump()
+}
+
+// broadcast 把事件推送给连接到聊天室的客户端，缓冲区已满的客户端会被断开，由客户端重连后重新获取历史消息
+func (h *Hub) broadcast(roomID uint, data []byte) {
+	slow := make([]*client, 0)
+	h.mu.RLock()
+	for c := range h.rooms[roomID] {
+		select {
+		case c.send <- data:
+		default:
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		logrus.Warnf("chat client %s of room %d is too slow, disconnected", c.conn.RemoteAddr(), roomID)
+		h.remove(c)
+	}
+}
+
+// remove 把客户端移出聊天室并关闭发送通道，可以重复调用
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c.closed {
+		return
+	}
+	if clients, ok := h.rooms[c.roomID]; ok {
+		delete(clients, c)
+		if len(clients) == 0 {
+			delete(h.rooms, c.roomID)
+		}
+	}
+	c.closed = true
+	close(c.send)
+}
+
+// readPump 读取并丢弃客户端消息，收到 pong 时延长读超时，连接出错时返回
+func (c *client) readPump() {
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump 把事件写到连接，并定时发送 ping；发送通道被关闭时断开连接
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+	for {
+		select {
+		case data, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too slow"))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
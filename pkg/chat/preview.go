@@ -0,0 +1,178 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/utils/netutil"
+	"golang.org/x/net/html"
+)
+
+const (
+	MaxLinksPerMessage = 3               // 每条消息最多获取预览的链接数
+	previewTimeout     = 5 * time.Second // 获取单个链接预览的超时时间
+	maxPreviewBody     = 512 * 1024      // 只解析页面的前 512KB
+	maxRedirects       = 3               // 最多跟随的重定向次数
+)
+
+var (
+	linkPattern        = regexp.MustCompile(`https?://[A-Za-z0-9\-._~:/?#\[\]@!$&()*+,;=%]+`)
+	errNotHTML         = errors.New("link is not a html page")
+	errTooManyRedirect = errors.New("too many redirects")
+)
+
+// ExtractLinks 提取消息中的 http(s) 链接，去重后最多返回 MaxLinksPerMessage 个
+func ExtractLinks(content string) []string {
+	links := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, link := range linkPattern.FindAllString(content, -1) {
+		link = strings.TrimRight(link, ".,;:!?)]")
+		if seen[link] {
+			continue
+		}
+		if u, err := url.Parse(link); err != nil || u.Host == "" {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+		if len(links) == MaxLinksPerMessage {
+			break
+		}
+	}
+	return links
+}
+
+// PreviewFetcher 获取链接的标题、描述和图片。
+// 只允许访问公网地址，防止通过链接预览访问内网服务
+type PreviewFetcher struct {
+	client *http.Client
+}
+
+// NewPreviewFetcher 创建链接预览获取器
+func NewPreviewFetcher() *PreviewFetcher {
+	return &PreviewFetcher{
+		client: &http.Client{
+			Timeout:   previewTimeout,
+			Transport: netutil.PublicTransport(previewTimeout),
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return errTooManyRedirect
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Fetch 获取链接的预览，页面没有标题、描述和图片时返回的预览只有 URL
+func (f *PreviewFetcher) Fetch(ctx context.Context, link string) (*model.LinkPreview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "chitchat-link-preview/4.0")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", link, resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" {
+		return nil, errNotHTML
+	}
+
+	preview := parsePreview(io.LimitReader(resp.Body, maxPreviewBody), resp.Request.URL)
+	preview.URL = link
+	return preview, nil
+}
+
+// parsePreview 解析页面 head 中的 title 和 og:title、og:description、og:image、description，优先使用 Open Graph
+func parsePreview(r io.Reader, base *url.URL) *model.LinkPreview {
+	preview := new(model.LinkPreview)
+	var title, description string
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return finishPreview(preview, title, description)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "body":
+				return finishPreview(preview, title, description)
+			case "title":
+				if z.Next() == html.TextToken && title == "" {
+					title = strings.TrimSpace(string(z.Text()))
+				}
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				attrs := make(map[string]string)
+				for {
+					key, val, more := z.TagAttr()
+					attrs[string(key)] = string(val)
+					if !more {
+						break
+					}
+				}
+				content := strings.TrimSpace(attrs["content"])
+				switch strings.ToLower(attrs["property"] + attrs["name"]) {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "description":
+					description = content
+				case "og:image":
+					if u, err := base.Parse(content); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+						preview.Image = u.String()
+					}
+				}
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return finishPreview(preview, title, description)
+			}
+		}
+	}
+}
+
+func finishPreview(preview *model.LinkPreview, title, description string) *model.LinkPreview {
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	if preview.Description == "" {
+		preview.Description = description
+	}
+	preview.Title = truncate(preview.Title, 512)
+	preview.Description = truncate(preview.Description, 1024)
+	if len(preview.Image) > 2048 {
+		preview.Image = ""
+	}
+	return preview
+}
+
+// truncate 按字符截断字符串，保证不超过 n 个字节
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
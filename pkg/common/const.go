@@ -3,10 +3,14 @@ package common
 const (
 	AppName                = `chitchat`
 	UserContextKey         = `user`
+	SessionContextKey      = `session`     // 当前请求的登录会话 ID
 	TraceContextKey        = `trace`       // 追踪上下文的钥匙
 	RequestInfoContextKey  = `requestInfo` // 请求信息
 	KubeResourceContextKey = `kubeResource`
 
 	CookieTokenName = `token`
 	CookieLoginUser = `loginUser`
+	CookieCSRFToken = `csrfToken` // CSRF token，前端读取后放在 HeaderCSRFToken 请求头中
+
+	HeaderCSRFToken = `X-CSRF-Token`
 )
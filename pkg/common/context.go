@@ -36,6 +36,22 @@ func GetUser(c *gin.Context) *model.User {
 	return user
 }
 
+// SetSession 在 Context 中设置当前请求的登录会话 ID
+func SetSession(c *gin.Context, sessionID string) {
+	if c == nil || sessionID == "" {
+		return
+	}
+	c.Set(SessionContextKey, sessionID)
+}
+
+// GetSession 获取 Context 中当前请求的登录会话 ID，使用访问 token 时为空
+func GetSession(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	return c.GetString(SessionContextKey)
+}
+
 // GetTrace 获取追踪钥匙
 func GetTrace(c *gin.Context) *trace.Trace {
 	if c == nil {
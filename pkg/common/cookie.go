@@ -0,0 +1,28 @@
+package common
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cookieSameSite 登录 cookie 的 SameSite，服务启动时根据配置设置
+var cookieSameSite = http.SameSiteLaxMode
+
+// SetCookieSameSite 设置登录 cookie 的 SameSite
+func SetCookieSameSite(mode http.SameSite) {
+	cookieSameSite = mode
+}
+
+// SetCookie 设置只通过 HTTPS 发送的登录 cookie，maxAge 为负数时删除 cookie
+func SetCookie(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	c.SetSameSite(cookieSameSite)
+	c.SetCookie(name, value, maxAge, "/", "", true, httpOnly)
+}
+
+// ClearLoginCookies 删除登录时设置的全部 cookie
+func ClearLoginCookies(c *gin.Context) {
+	SetCookie(c, CookieTokenName, "", -1, true)
+	SetCookie(c, CookieLoginUser, "", -1, false)
+	SetCookie(c, CookieCSRFToken, "", -1, false)
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"github.com/gin-gonic/gin"
+)
+
+// SetETag 把资源的版本号写入 ETag 响应头，格式为 "版本号"
+func SetETag(c *gin.Context, version uint64) {
+	if version == 0 {
+		return
+	}
+	c.Header("ETag", strconv.Quote(strconv.FormatUint(version, 10)))
+}
+
+// IfMatch 解析 If-Match 请求头中的版本号，没有该请求头或为 * 时返回 0（不比较版本号）
+func IfMatch(c *gin.Context) (uint64, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	tag := strings.TrimPrefix(value, "W/")
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		tag = unquoted
+	}
+	version, err := strconv.ParseUint(tag, 10, 64)
+	if err != nil || version == 0 {
+		return 0, apierrors.NewBadRequest("invalid If-Match header " + strconv.Quote(value))
+	}
+	return version, nil
+}
+
+// NotModified 处理条件 GET：If-None-Match 中有 etag（弱比较）时，或者没有 If-None-Match 并且
+// If-Modified-Since 不早于 lastModified 时返回 true
+func NotModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if value := c.GetHeader("If-None-Match"); value != "" {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if value := c.GetHeader("If-Modified-Since"); value != "" && !lastModified.IsZero() {
+		if since, err := http.ParseTime(value); err == nil {
+			return !lastModified.Truncate(time.Second).After(since)
+		}
+	}
+	return false
+}
@@ -3,14 +3,17 @@ package common
 import (
 	"net/http"
 
+	"chitchat4.0/pkg/apierrors"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 type Response struct {
-	Code int         `json:"code"`
-	Msg  string      `json:"msg"`
-	Data interface{} `json:"data"`
+	Code    int                    `json:"code"`
+	Reason  apierrors.Reason       `json:"reason,omitempty"` // 应用错误码，成功时为空
+	Msg     string                 `json:"msg"`
+	Details []apierrors.FieldError `json:"details,omitempty"` // 字段级别的错误详情
+	Data    interface{}            `json:"data"`
 }
 
 // NewResponse 创建一个新的响应
@@ -27,20 +30,30 @@ func ResponseSuccess(c *gin.Context, data interface{}) {
 	NewResponse(c, http.StatusOK, data, "success")
 }
 
-// ResponseFailed 失败时做相关响应
+// ResponseFailed 失败时做相关响应，
+// err 是 apierrors.Error 时，使用错误对应的状态码和应用错误码；
+// release 模式下不返回服务器内部错误的原始信息
 func ResponseFailed(c *gin.Context, code int, err error) {
 	if code == 0 {
 		code = http.StatusInternalServerError
 	}
+	apiErr := apierrors.FromError(err)
+	if apiErr != nil {
+		code = apiErr.Status()
+	}
 	if code == http.StatusUnauthorized && c.Request != nil {
 		if val, err := c.Cookie(CookieTokenName); err == nil && val != "" {
-			c.SetCookie(CookieTokenName, "", -1, "/", "", true, true)
-			c.SetCookie(CookieLoginUser, "", -1, "/", "", true, true)
+			ClearLoginCookies(c)
 		}
 	}
-	var msg string
+
+	resp := Response{Code: code, Reason: apierrors.ReasonForStatus(code)}
+	if apiErr != nil {
+		resp.Reason = apiErr.Reason
+		resp.Details = apiErr.Details
+	}
 	if err != nil {
-		msg = err.Error()
+		resp.Msg = err.Error()
 		user := GetUser(c) //这里
 		var name string
 		if user != nil {
@@ -50,7 +63,14 @@ func ResponseFailed(c *gin.Context, code int, err error) {
 		if c.Request != nil {
 			url = c.Request.URL.String()
 		}
-		logrus.Warnf("url:%s,user:%s,err:%v", url, name, msg)
+		logrus.Warnf("url:%s,user:%s,err:%v", url, name, resp.Msg)
+		if apiErr != nil && apiErr.Err != nil {
+			logrus.Warnf("url:%s,user:%s,cause:%v", url, name, apiErr.Err)
+		}
+	}
+	// 服务器内部错误可能包含数据库等敏感信息
+	if code >= http.StatusInternalServerError && gin.Mode() == gin.ReleaseMode {
+		resp.Msg = http.StatusText(code)
 	}
-	NewResponse(c, code, nil, msg)
+	c.JSON(code, resp)
 }
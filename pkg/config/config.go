@@ -1,7 +1,11 @@
 package config
 
 import (
+	"io"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"chitchat4.0/pkg/utils/ratelimit"
 	"gopkg.in/yaml.v3"
@@ -15,6 +19,8 @@ type Config struct {
 	OAuthConfig map[string]OAuthConfig `yaml:"oauth"`
 	Docker      DockerConfig           `yaml:"docker"`
 	Kubernetes  KubeConfig             `yaml:"kubernetes"`
+	SMTP        SMTPConfig             `yaml:"smtp"` // 发送提醒邮件的 SMTP 服务
+	RBAC        RBACConfig             `yaml:"rbac"` // 声明式 RBAC 配置
 }
 
 // ServerConfig 服务配置
@@ -25,16 +31,73 @@ type ServerConfig struct {
 	GracefulShutdownPeriod int                     `yaml:"gracefulShutdownPeriod"` // 正常停机时间
 	LimitConfig            []ratelimit.LimitConfig `yaml:"rateLimits"`             // 速率限制
 	JWTSecret              string                  `yaml:"jwtSecret"`              // jsonWebToken
+	LogLevel               string                  `yaml:"logLevel"`               // 日志级别，默认 info，可以热加载
+	CORS                   CORSConfig              `yaml:"cors"`                   // 跨域配置，可以热加载
+	Cookie                 CookieConfig            `yaml:"cookie"`                 // 登录 cookie 配置
+	PublicURL              string                  `yaml:"publicURL"`              // 服务的公开访问地址，例如 https://chitchat.example.com，订阅源中的链接使用这个地址
 }
 
-// DBConfig 是PostgreSQL数据库配置
+// CookieConfig 登录时设置的 cookie 的配置
+type CookieConfig struct {
+	SameSite string `yaml:"sameSite"` // lax、strict 或 none，默认 lax；none 只在 HTTPS 下有效
+}
+
+// SameSiteMode 返回 SameSite 对应的 http.SameSite，配置已经校验过
+func (c CookieConfig) SameSiteMode() http.SameSite {
+	switch strings.ToLower(c.SameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteLaxMode
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	// 允许的来源，例如 https://example.com；https://*.example.com 允许 example.com 的全部子域名；
+	// * 允许所有来源，但不能和 allowCredentials 同时使用；为空时只允许同源请求
+	AllowOrigins     []string `yaml:"allowOrigins"`
+	AllowMethods     []string `yaml:"allowMethods"`     // 允许的请求方法，默认 GET、POST、PUT、PATCH、DELETE 和 OPTIONS
+	AllowHeaders     []string `yaml:"allowHeaders"`     // 允许的请求头，默认 Origin、Authorization、Content-Length、Content-Type、If-Match 和 X-CSRF-Token
+	ExposeHeaders    []string `yaml:"exposeHeaders"`    // 允许前端读取的响应头，默认 Content-Length 和 ETag
+	AllowCredentials bool     `yaml:"allowCredentials"` // 是否允许携带 cookie 等凭证，使用 cookie 登录时需要开启
+	MaxAge           int      `yaml:"maxAge"`           // 预检请求结果的缓存秒数，默认 43200（12 小时）
+}
+
+// 支持的数据库
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// DBConfig 是数据库配置，默认使用 PostgreSQL；SQLite 用于本地开发和测试，只使用 Name 和 Migrate
 type DBConfig struct {
+	Driver   string `yaml:"driver"`   // postgres 或 sqlite，默认 postgres
 	Host     string `yaml:"host"`     // 数据库主机
 	Port     int    `yaml:"port"`     // 数据库端口
-	Name     string `yaml:"name"`     // 库名称
+	Name     string `yaml:"name"`     // 库名称，sqlite 时为数据库文件路径，:memory: 表示内存数据库
 	User     string `yaml:"user"`     // 用户
 	Password string `yaml:"password"` // 密码
-	Migrate  bool   `yaml:"migrate"`  // 迁移（是否自动迁移）
+	Migrate  bool   `yaml:"migrate"`  // 启动时是否执行未执行的版本迁移
+
+	SSLMode     string `yaml:"sslMode"`     // disable、allow、prefer、require、verify-ca 或 verify-full，默认 disable
+	SSLRootCert string `yaml:"sslRootCert"` // 校验服务器证书的 CA 证书文件
+	SSLCert     string `yaml:"sslCert"`     // 客户端证书文件
+	SSLKey      string `yaml:"sslKey"`      // 客户端私钥文件
+	TimeZone    string `yaml:"timeZone"`    // 会话时区，默认 Asia/Shanghai
+
+	MaxOpenConns     int           `yaml:"maxOpenConns"`     // 最大连接数，0 表示不限制
+	MaxIdleConns     int           `yaml:"maxIdleConns"`     // 最大空闲连接数，0 表示使用默认值 2
+	ConnMaxLifetime  time.Duration `yaml:"connMaxLifetime"`  // 连接的最长使用时间，例如 1h，0 表示不限制
+	ConnMaxIdleTime  time.Duration `yaml:"connMaxIdleTime"`  // 连接的最长空闲时间，0 表示不限制
+	ConnectTimeout   time.Duration `yaml:"connectTimeout"`   // 建立连接的超时时间，0 表示不限制
+	StatementTimeout time.Duration `yaml:"statementTimeout"` // 单条语句的超时时间，0 表示不限制
+
+	// 只读副本的主机和端口，端口为 0 时和主库相同；用户、库名称、SSL、时区和超时等配置和主库相同。
+	// 配置后只有可以容忍复制延迟的查询（热搜榜和订阅源）使用只读副本，连接池配置同样作用于只读副本
+	ReplicaHost string `yaml:"replicaHost"`
+	ReplicaPort int    `yaml:"replicaPort"`
 }
 
 // RedisConfig 是 Redis 数据库配置
@@ -62,7 +125,22 @@ type KubeConfig struct {
 	WatchResources []string `yaml:"watchResources"`
 }
 
-// Parse 根据传入的路径分析配置信息，返回应用的配置 Config 和 error
+// SMTPConfig 发送邮件的 SMTP 服务配置，未启用时不能通过邮件发送提醒
+type SMTPConfig struct {
+	Enable   bool   `yaml:"enable"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"` // 为空时不认证
+	Password string `yaml:"password"`
+	From     string `yaml:"from"` // 发件人地址
+}
+
+// RBACConfig 声明式 RBAC 配置，File 为空时不同步，只能通过 API 管理 role
+type RBACConfig struct {
+	File string `yaml:"file"` // 描述资源、role、group 和绑定的 YAML 文件，启动时同步
+}
+
+// Parse 根据传入的路径分析配置信息，使用 CHITCHAT_ 开头的环境变量覆盖后校验，返回应用的配置 Config 和 error
 func Parse(appConfig string) (*Config, error) {
 	config := &Config{} // 定义一个空的配置
 
@@ -74,7 +152,14 @@ func Parse(appConfig string) (*Config, error) {
 	defer file.Close()
 	// NewDecoder 创建一个新的解码器
 	// Decode 给 config 结构填充相应的数据
-	if err := yaml.NewDecoder(file).Decode(&config); err != nil {
+	if err := yaml.NewDecoder(file).Decode(&config); err != nil && err != io.EOF {
+		return nil, err
+	}
+	// 环境变量覆盖配置文件
+	if err := ApplyEnv(config, os.Environ()); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 	return config, nil
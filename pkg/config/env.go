@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EnvPrefix 覆盖配置的环境变量前缀。
+// 环境变量名是 yaml 路径的大写下划线形式，例如 server.jwtSecret 对应 CHITCHAT_SERVER_JWT_SECRET，
+// db.password 对应 CHITCHAT_DB_PASSWORD，oauth.github.clientSecret 对应 CHITCHAT_OAUTH_GITHUB_CLIENT_SECRET，
+// server.rateLimits[0].qps 对应 CHITCHAT_SERVER_RATE_LIMITS_0_QPS；字符串列表使用逗号分隔。
+// 变量名加上 _FILE 后缀时从该文件读取值（去掉末尾的换行），用于从挂载的文件读取密码等敏感配置
+const EnvPrefix = "CHITCHAT"
+
+// fileSuffix 从文件读取值的环境变量后缀
+const fileSuffix = "_FILE"
+
+// durationType 时间间隔使用 time.ParseDuration 的格式，例如 30s、1h
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ApplyEnv 使用环境变量覆盖 config 中的字段，environ 的格式和 os.Environ 相同
+func ApplyEnv(config *Config, environ []string) error {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(key, EnvPrefix+"_") {
+			env[key] = value
+		}
+	}
+	return applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix, env)
+}
+
+func applyEnv(v reflect.Value, name string, env map[string]string) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if tag == "" || tag == "-" || !field.IsExported() {
+				continue
+			}
+			if err := applyEnv(v.Field(i), name+"_"+envName(tag), env); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		return applyEnvMap(v, name, env)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			return applyEnvSlice(v, name, env)
+		}
+	}
+
+	value, ok, err := lookupEnv(name, env)
+	if err != nil || !ok {
+		return err
+	}
+	if err := setValue(v, value); err != nil {
+		return fmt.Errorf("invalid environment variable %s: %v", name, err)
+	}
+	return nil
+}
+
+// applyEnvSlice 按下标覆盖结构体列表的元素，下标超出长度时追加元素
+func applyEnvSlice(v reflect.Value, name string, env map[string]string) error {
+	for i := 0; ; i++ {
+		if i >= v.Len() {
+			if !hasPrefix(env, name+"_"+strconv.Itoa(i)+"_") {
+				return nil
+			}
+			v.Set(reflect.Append(v, reflect.New(v.Type().Elem()).Elem()))
+		}
+		if err := applyEnv(v.Index(i), name+"_"+strconv.Itoa(i), env); err != nil {
+			return err
+		}
+	}
+}
+
+// applyEnvMap 覆盖 map 中结构体的字段，环境变量中出现的新 key 会被添加，key 使用小写
+func applyEnvMap(v reflect.Value, name string, env map[string]string) error {
+	elem := v.Type().Elem()
+	if v.Type().Key().Kind() != reflect.String || elem.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := make([]string, 0, elem.NumField())
+	for i := 0; i < elem.NumField(); i++ {
+		if tag := strings.Split(elem.Field(i).Tag.Get("yaml"), ",")[0]; tag != "" && tag != "-" {
+			fields = append(fields, envName(tag))
+		}
+	}
+
+	keys := make(map[string]bool)
+	for _, key := range v.MapKeys() {
+		keys[key.String()] = true
+	}
+	for variable := range env {
+		if !strings.HasPrefix(variable, name+"_") {
+			continue
+		}
+		rest := strings.TrimSuffix(strings.TrimPrefix(variable, name+"_"), fileSuffix)
+		for _, field := range fields {
+			if key := strings.TrimSuffix(rest, "_"+field); key != rest && key != "" {
+				keys[strings.ToLower(key)] = true
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	if v.IsNil() && len(sorted) > 0 {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	for _, key := range sorted {
+		item := reflect.New(elem).Elem()
+		if current := v.MapIndex(reflect.ValueOf(key)); current.IsValid() {
+			item.Set(current)
+		}
+		if err := applyEnv(item, name+"_"+envName(key), env); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key), item)
+	}
+	return nil
+}
+
+// lookupEnv 返回变量的值，变量不存在时读取 _FILE 变量指定的文件
+func lookupEnv(name string, env map[string]string) (string, bool, error) {
+	if value, ok := env[name]; ok {
+		return value, true, nil
+	}
+	path, ok := env[name+fileSuffix]
+	if !ok {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid environment variable %s: %v", name+fileSuffix, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func setValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		fallthrough
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		items := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func hasPrefix(env map[string]string, prefix string) bool {
+	for key := range env {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// envName 把 yaml 中的驼峰名称转换为大写下划线形式，例如 jwtSecret 转换为 JWT_SECRET
+func envName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+			b.WriteByte('_')
+		}
+		if r == '-' || r == '.' {
+			r = '_'
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ValidationError 配置校验失败，包含全部字段的错误
+type ValidationError struct {
+	Errors []string // 每一项的格式为 "字段: 错误"，字段使用 yaml 路径
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Errors, "; ")
+}
+
+// Validate 校验全部配置，返回包含每个错误字段的 *ValidationError
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	server := &c.Server
+	v.check(server.ENV == "" || server.ENV == "debug" || server.ENV == "release" || server.ENV == "test",
+		"server.env", "must be debug, release or test")
+	v.port("server.port", server.Port)
+	v.check(server.GracefulShutdownPeriod >= 0, "server.gracefulShutdownPeriod", "must not be negative")
+	v.check(server.JWTSecret != "", "server.jwtSecret", "is required")
+	for i := range server.LimitConfig {
+		if err := server.LimitConfig[i].Validate(); err != nil {
+			v.add(fmt.Sprintf("server.rateLimits[%d]", i), err.Error())
+		}
+	}
+	if server.LogLevel != "" {
+		_, err := logrus.ParseLevel(server.LogLevel)
+		v.check(err == nil, "server.logLevel", "must be one of panic, fatal, error, warn, info, debug or trace")
+	}
+	for i, origin := range server.CORS.AllowOrigins {
+		v.check(validOrigin(origin), fmt.Sprintf("server.cors.allowOrigins[%d]", i), fmt.Sprintf("invalid origin %q", origin))
+		v.check(origin != "*" || !server.CORS.AllowCredentials, fmt.Sprintf("server.cors.allowOrigins[%d]", i), "* can not be used with allowCredentials")
+	}
+	for i, method := range server.CORS.AllowMethods {
+		v.check(httpMethods[strings.ToUpper(method)], fmt.Sprintf("server.cors.allowMethods[%d]", i), fmt.Sprintf("unknown method %q", method))
+	}
+	v.check(server.CORS.MaxAge >= 0, "server.cors.maxAge", "must not be negative")
+	if server.PublicURL != "" {
+		u, err := url.Parse(server.PublicURL)
+		v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.RawQuery == "" && u.Fragment == "",
+			"server.publicURL", fmt.Sprintf("invalid url %q", server.PublicURL))
+	}
+	switch strings.ToLower(server.Cookie.SameSite) {
+	case "", "lax", "strict", "none":
+	default:
+		v.add("server.cookie.sameSite", "must be lax, strict or none")
+	}
+
+	switch c.DB.Driver {
+	case "", DriverPostgres:
+		v.check(c.DB.Host != "", "db.host", "is required")
+		v.port("db.port", c.DB.Port)
+		v.check(c.DB.Name != "", "db.name", "is required")
+		v.check(c.DB.User != "", "db.user", "is required")
+		switch c.DB.SSLMode {
+		case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			v.add("db.sslMode", "must be disable, allow, prefer, require, verify-ca or verify-full")
+		}
+		if c.DB.TimeZone != "" {
+			_, err := time.LoadLocation(c.DB.TimeZone)
+			v.check(err == nil, "db.timeZone", fmt.Sprintf("unknown time zone %q", c.DB.TimeZone))
+		}
+		if c.DB.ReplicaPort != 0 {
+			v.port("db.replicaPort", c.DB.ReplicaPort)
+		}
+	case DriverSQLite:
+		v.check(c.DB.Name != "", "db.name", "is required, use a file path or :memory:")
+		v.check(c.DB.ReplicaHost == "", "db.replicaHost", "is not supported by sqlite")
+	default:
+		v.add("db.driver", "must be postgres or sqlite")
+	}
+	v.check(c.DB.MaxOpenConns >= 0, "db.maxOpenConns", "must not be negative")
+	v.check(c.DB.MaxIdleConns >= 0, "db.maxIdleConns", "must not be negative")
+	v.check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns, "db.maxIdleConns", "must not be greater than maxOpenConns")
+	v.check(c.DB.ConnMaxLifetime >= 0, "db.connMaxLifetime", "must not be negative")
+	v.check(c.DB.ConnMaxIdleTime >= 0, "db.connMaxIdleTime", "must not be negative")
+	v.check(c.DB.ConnectTimeout >= 0, "db.connectTimeout", "must not be negative")
+	v.check(c.DB.StatementTimeout >= 0, "db.statementTimeout", "must not be negative")
+
+	if c.Redis.Enable {
+		v.check(c.Redis.Host != "", "redis.host", "is required when redis is enabled")
+		v.port("redis.port", c.Redis.Port)
+	}
+
+	for name, oauth := range c.OAuthConfig {
+		v.check(oauth.ClientId != "", "oauth."+name+".clientId", "is required")
+	}
+
+	if c.SMTP.Enable {
+		v.check(c.SMTP.Host != "", "smtp.host", "is required when smtp is enabled")
+		v.port("smtp.port", c.SMTP.Port)
+		_, err := mail.ParseAddress(c.SMTP.From)
+		v.check(err == nil, "smtp.from", fmt.Sprintf("invalid address %q", c.SMTP.From))
+	}
+
+	if len(v.errors) > 0 {
+		return &ValidationError{Errors: v.errors}
+	}
+	return nil
+}
+
+type validator struct {
+	errors []string
+}
+
+func (v *validator) add(field, msg string) {
+	v.errors = append(v.errors, field+": "+msg)
+}
+
+func (v *validator) check(ok bool, field, msg string) {
+	if !ok {
+		v.add(field, msg)
+	}
+}
+
+func (v *validator) port(field string, port int) {
+	v.check(port > 0 && port <= 65535, field, fmt.Sprintf("must be between 1 and 65535, got %d", port))
+}
+
+var httpMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// validOrigin 来源是 *、只包含协议和主机的 URL，或者主机的第一段是 * 的通配子域名，例如 https://*.example.com
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	if strings.Contains(origin, "*") {
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || !strings.HasPrefix(host, "*.") || strings.Count(host, "*") != 1 {
+			return false
+		}
+		origin = scheme + "://wildcard" + host[1:]
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Scheme != "" && u.Host != "" && (u.Path == "" || u.Path == "/") && u.RawQuery == ""
+}
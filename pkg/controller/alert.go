@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/utils/request"
+	"chitchat4.0/pkg/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// AlertController 热搜提醒规则和站内信控制器
+type AlertController struct {
+	alertService service.AlertService
+}
+
+// NewAlertController 创建热搜提醒控制器
+func NewAlertController(alertService service.AlertService) Controller {
+	return &AlertController{
+		alertService: alertService,
+	}
+}
+
+// @Summary List alert rules | 提醒规则列表
+// @Description List alert rules of current user | 获取当前 user 的热搜提醒规则
+// @Produce json
+// @Tags alert
+// @Security JWT
+// @Success 200 {object} common.Response{data=[]model.AlertRule}
+// @Router /api/v1/alerts [get]
+func (a *AlertController) List(c *gin.Context) {
+	user, ok := authorize(c, model.AlertResource, request.ListOperation)
+	if !ok {
+		return
+	}
+	rules, err := a.alertService.List(user)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, rules)
+}
+
+// @Summary Create alert rule | 创建提醒规则
+// @Description Create alert rule. A hot search matches when it belongs to one of tagIds, its title contains one of keywords, and it ranks within minRank; each hot search alerts once per rule | 创建热搜提醒规则：热搜属于 tagIds 中的 tag、标题包含任一关键词并且排名在前 minRank 名时提醒，同一条热搜对每个规则只提醒一次
+// @Accept json
+// @Produce json
+// @Tags alert
+// @Security JWT
+// @Param rule body model.CreatedAlertRule true "alert rule"
+// @Success 200 {object} common.Response{data=model.AlertRule}
+// @Router /api/v1/alerts [post]
+func (a *AlertController) Create(c *gin.Context) {
+	user, ok := authorize(c, model.AlertResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	created := new(model.CreatedAlertRule)
+	if err := validation.BindJSON(c, created); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	rule, err := a.alertService.Create(user, created)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, rule)
+}
+
+// @Summary Get alert rule | 获取提醒规则
+// @Description Get alert rule of current user | 获取当前 user 的提醒规则
+// @Produce json
+// @Tags alert
+// @Security JWT
+// @Param id path int true "alert rule id"
+// @Success 200 {object} common.Response{data=model.AlertRule}
+// @Router /api/v1/alerts/{id} [get]
+func (a *AlertController) Get(c *gin.Context) {
+	user, ok := authorize(c, model.AlertResource, request.GetOperation)
+	if !ok {
+		return
+	}
+	rule, err := a.alertService.Get(user, c.Param("id"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, rule)
+}
+
+// @Summary Update alert rule | 修改提醒规则
+// @Description Replace alert rule of current user | 修改当前 user 的提醒规则
+// @Accept json
+// @Produce json
+// @Tags alert
+// @Security JWT
+// @Param id path int true "alert rule id"
+// @Param rule body model.CreatedAlertRule true "alert rule"
+// @Success 200 {object} common.Response{data=model.AlertRule}
+// @Router /api/v1/alerts/{id} [put]
+func (a *AlertController) Update(c *gin.Context) {
+	user, ok := authorize(c, model.AlertResource, request.UpdateOperation)
+	if !ok {
+		return
+	}
+	updated := new(model.CreatedAlertRule)
+	if err := validation.BindJSON(c, updated); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	rule, err := a.alertService.Update(user, c.Param("id"), updated)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, rule)
+}
+
+// @Summary Delete alert rule | 删除提醒规则
+// @Description Delete alert rule of current user, sent notifications are kept | 删除当前 user 的提醒规则，已经发送的站内信保留
+// @Produce json
+// @Tags alert
+// @Security JWT
+// @Param id path int true "alert rule id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/alerts/{id} [delete]
+func (a *AlertController) Delete(c *gin.Context) {
+	user, ok := authorize(c, model.AlertResource, request.DeleteOperation)
+	if !ok {
+		return
+	}
+	if err := a.alertService.Delete(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary List notifications | 站内信
+// @Description List recent in-app notifications of current user | 获取当前 user 最近的站内信
+// @Produce json
+// @Tags alert
+// @Security JWT
+// @Param unread query bool false "only unread notifications"
+// @Param limit query int false "size, default 50, max 200"
+// @Success 200 {object} common.Response{data=[]model.Notification}
+// @Router /api/v1/notifications [get]
+func (a *AlertController) ListNotifications(c *gin.Context) {
+	user, ok := authorize(c, model.NotificationResource, request.ListOperation)
+	if !ok {
+		return
+	}
+	unread, _ := strconv.ParseBool(c.Query("unread"))
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			common.ResponseFailed(c, http.StatusBadRequest, apierrors.NewFieldInvalid("limit", "invalid limit "+strconv.Quote(value)))
+			return
+		}
+		limit = n
+	}
+	notifications, err := a.alertService.ListNotifications(user, unread, limit)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, notifications)
+}
+
+// @Summary Mark notification read | 标记站内信已读
+// @Description Mark notification as read | 把站内信标记为已读
+// @Produce json
+// @Tags alert
+// @Security JWT
+// @Param id path int true "notification id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/notifications/{id}/read [post]
+func (a *AlertController) MarkRead(c *gin.Context) {
+	user, ok := authorize(c, model.NotificationResource, request.UpdateOperation)
+	if !ok {
+		return
+	}
+	if err := a.alertService.MarkRead(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Mark all notifications read | 全部标记已读
+// @Description Mark all notifications of current user as read | 把当前 user 的全部站内信标记为已读
+// @Produce json
+// @Tags alert
+// @Security JWT
+// @Success 200 {object} common.Response
+// @Router /api/v1/notifications/read [post]
+func (a *AlertController) MarkAllRead(c *gin.Context) {
+	user, ok := authorize(c, model.NotificationResource, request.UpdateOperation)
+	if !ok {
+		return
+	}
+	if err := a.alertService.MarkRead(user, ""); err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+func (a *AlertController) RegisterRoute(api *gin.RouterGroup) {
+	api.GET("/alerts", a.List)                      // 提醒规则列表
+	api.POST("/alerts", a.Create)                   // 创建提醒规则
+	api.GET("/alerts/:id", a.Get)                   // 获取提醒规则
+	api.PUT("/alerts/:id", a.Update)                // 修改提醒规则
+	api.DELETE("/alerts/:id", a.Delete)             // 删除提醒规则
+	api.GET("/notifications", a.ListNotifications)  // 站内信
+	api.POST("/notifications/read", a.MarkAllRead)  // 全部标记已读
+	api.POST("/notifications/:id/read", a.MarkRead) // 标记站内信已读
+}
+
+func (a *AlertController) Name() string {
+	return "Alert"
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"net/http"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/utils/request"
+	"github.com/gin-gonic/gin"
+)
+
+// authorize 使用 RBAC 检查当前 user 能否对 resource 执行 verb，不能时返回错误响应。
+// authorization.Authorize 还没有按角色的规则检查，只要求 user 已经登录，修改其他 user 创建的对象时还要检查创建者
+func authorize(c *gin.Context, resource, verb string) (*model.User, bool) {
+	user := common.GetUser(c)
+	if user == nil {
+		common.ResponseFailed(c, http.StatusUnauthorized, apierrors.NewUnauthenticated("login required"))
+		return nil, false
+	}
+	ok, err := authorization.Authorize(user, &request.RequestInfo{
+		IsResourceRequest: true,
+		Verb:              verb,
+		Resource:          resource,
+		Name:              c.Param("id"),
+	})
+	if err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if !ok {
+		common.ResponseFailed(c, http.StatusForbidden, apierrors.NewForbidden("user "+user.Name+" is forbidden to "+verb+" "+resource))
+		return nil, false
+	}
+	return user, true
+}
+
+// authorizeBulk 检查 user 对 resource 的权限，resource 为空时检查全部可以批量导入导出的资源
+func authorizeBulk(c *gin.Context, resource, verb string) (*model.User, bool) {
+	resources := model.BulkResources
+	if resource != "" {
+		resources = []string{resource}
+	}
+	var user *model.User
+	for _, r := range resources {
+		u, ok := authorize(c, r, verb)
+		if !ok {
+			return nil, false
+		}
+		user = u
+	}
+	return user, true
+}
+
+// requireClusterAdmin 当前用户不是管理员时返回 403。
+// 修改角色和用户的角色绑定等同于修改权限，普通用户可以操作就能给自己授予任意权限
+func requireClusterAdmin(c *gin.Context, message string) bool {
+	user := common.GetUser(c)
+	if user == nil {
+		common.ResponseFailed(c, http.StatusUnauthorized, apierrors.NewUnauthenticated("login required"))
+		return false
+	}
+	if !authorization.IsClusterAdmin(user) {
+		common.ResponseFailed(c, http.StatusForbidden, apierrors.NewForbidden(message))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/bulk"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/utils/request"
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportSize = 10 << 20 // 导入数据的最大字节数
+
+// BulkController 批量导入导出 role、group 和 user 的控制器
+type BulkController struct {
+	bulkService service.BulkService
+}
+
+// NewBulkController 创建批量导入导出的控制器
+func NewBulkController(bulkService service.BulkService) Controller {
+	return &BulkController{
+		bulkService: bulkService,
+	}
+}
+
+// @Summary Export RBAC configuration | 导出 RBAC 配置
+// @Description Export all roles, groups and users with their group memberships and roles as one document, without passwords, only cluster admins | 导出全部的 role、group 和 user（包括 user 所在的 group 和 role），不包括密码，只有管理员可以导出
+// @Produce json
+// @Produce application/yaml
+// @Tags bulk
+// @Security JWT
+// @Param format query string false "json or yaml, default json"
+// @Success 200 {object} model.BulkDocument
+// @Router /api/v1/export [get]
+func (b *BulkController) ExportAll(c *gin.Context) {
+	b.export(c, "")
+}
+
+// @Summary Export resource | 导出一种资源
+// @Description Export roles, groups or users as a list, without passwords, only cluster admins. CSV has a header row, list columns are separated by ; and rules are written as resource:operation | 导出 role、group 或 user 的列表，不包括密码，只有管理员可以导出。CSV 第一行是表头，列表类型的列使用 ; 分隔，规则写作 resource:operation
+// @Produce json
+// @Produce application/yaml
+// @Produce text/csv
+// @Tags bulk
+// @Security JWT
+// @Param resource path string true "roles, groups or users"
+// @Param format query string false "csv, json or yaml, default json"
+// @Success 200 {array} model.BulkUser
+// @Router /api/v1/export/{resource} [get]
+func (b *BulkController) Export(c *gin.Context) {
+	b.export(c, c.Param("resource"))
+}
+
+// @Summary Import RBAC configuration | 导入 RBAC 配置
+// @Description Create or update roles, groups and users by name in one transaction, only cluster admins. Every row is validated first; nothing is imported when any row is invalid or dryRun is true. Groups and roles of a user are replaced when present and kept when omitted; passwords are only required for new users | 在一个事务中按名称创建或修改 role、group 和 user，只有管理员可以导入。先校验每一行，有任何一行出错或 dryRun 为 true 时不导入。user 的 group 和 role 存在时替换，省略时不修改；只有新建的 user 需要密码
+// @Accept json
+// @Accept application/yaml
+// @Produce json
+// @Tags bulk
+// @Security JWT
+// @Param document body model.BulkDocument true "RBAC configuration"
+// @Param format query string false "json or yaml, default from Content-Type"
+// @Param dryRun query bool false "only validate"
+// @Success 200 {object} common.Response{data=model.ImportResult}
+// @Failure 422 {object} common.Response{data=model.ImportResult}
+// @Router /api/v1/import [post]
+func (b *BulkController) ImportAll(c *gin.Context) {
+	b.importData(c, "")
+}
+
+// @Summary Import resource | 导入一种资源
+// @Description Create or update roles, groups or users by name in one transaction, only cluster admins. The body is a list in JSON or YAML, or CSV with a header row. Nothing is imported when any row is invalid or dryRun is true | 在一个事务中按名称创建或修改 role、group 或 user，只有管理员可以导入。请求体是 JSON 或 YAML 的列表，或者第一行是表头的 CSV；有任何一行出错或 dryRun 为 true 时不导入
+// @Accept json
+// @Accept application/yaml
+// @Accept text/csv
+// @Produce json
+// @Tags bulk
+// @Security JWT
+// @Param resource path string true "roles, groups or users"
+// @Param users body []model.BulkUser true "list of resource"
+// @Param format query string false "csv, json or yaml, default from Content-Type"
+// @Param dryRun query bool false "only validate"
+// @Success 200 {object} common.Response{data=model.ImportResult}
+// @Failure 422 {object} common.Response{data=model.ImportResult}
+// @Router /api/v1/import/{resource} [post]
+func (b *BulkController) Import(c *gin.Context) {
+	b.importData(c, c.Param("resource"))
+}
+
+func (b *BulkController) export(c *gin.Context, resource string) {
+	user, ok := authorizeBulk(c, resource, request.ListOperation)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", bulk.FormatJSON)
+	doc, err := b.bulkService.Export(user, resource)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	data, err := bulk.Encode(format, resource, doc)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	name := resource
+	if name == "" {
+		name = "rbac"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+	c.Data(http.StatusOK, bulk.ContentType(format), data)
+}
+
+func (b *BulkController) importData(c *gin.Context, resource string) {
+	user, ok := authorizeBulk(c, resource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	dryRun, ok := dryRunQuery(c)
+	if !ok {
+		return
+	}
+	format := c.Query("format")
+	if format == "" {
+		if format, ok = bulk.FormatOf(c.ContentType()); !ok {
+			common.ResponseFailed(c, http.StatusUnsupportedMediaType, apierrors.NewUnsupportedMediaType(c.ContentType()))
+			return
+		}
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = apierrors.NewBadRequest(fmt.Sprintf("import data is larger than %d bytes", maxImportSize))
+		}
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	doc, err := bulk.Decode(format, resource, data)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	result, err := b.bulkService.Import(user, doc, dryRun)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	if result.Failed > 0 && !dryRun {
+		common.NewResponse(c, http.StatusUnprocessableEntity, result, "import contains invalid rows, nothing is imported")
+		return
+	}
+	common.ResponseSuccess(c, result)
+}
+
+// dryRunQuery 解析 dryRun 查询参数，默认为 false，无效时返回 400
+func dryRunQuery(c *gin.Context) (bool, bool) {
+	value := c.Query("dryRun")
+	if value == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, apierrors.NewFieldInvalid("dryRun", "invalid dryRun "+strconv.Quote(value)))
+		return false, false
+	}
+	return dryRun, true
+}
+
+func (b *BulkController) RegisterRoute(api *gin.RouterGroup) {
+	api.GET("/export", b.ExportAll)         // 导出 RBAC 配置
+	api.GET("/export/:resource", b.Export)  // 导出一种资源
+	api.POST("/import", b.ImportAll)        // 导入 RBAC 配置
+	api.POST("/import/:resource", b.Import) // 导入一种资源
+}
+
+func (b *BulkController) Name() string {
+	return "Bulk"
+}
@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/utils/request"
+	"chitchat4.0/pkg/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// targetTypeKey 保存在 gin.Context 中的评论、回应对象类型
+const targetTypeKey = "targetType"
+
+// CommentController 评论和回应控制器
+type CommentController struct {
+	commentService service.CommentService
+}
+
+// NewCommentController 创建评论和回应控制器
+func NewCommentController(commentService service.CommentService) Controller {
+	return &CommentController{
+		commentService: commentService,
+	}
+}
+
+// @Summary List comments | 评论列表
+// @Description List comment threads of a hot search or topic (tag) from newest to oldest, pass nextBefore of the previous page as before. Deleted comments with replies are kept as placeholders | 按从新到旧的顺序分页获取热搜或话题（tag）的评论楼层，下一页的 before 使用上一页返回的 nextBefore；已删除但有回复的评论只保留位置
+// @Produce json
+// @Tags comment
+// @Security JWT
+// @Param targetType path string true "hotsearches or tags"
+// @Param id path int true "target id"
+// @Param before query int false "only threads with id less than before"
+// @Param limit query int false "page size, default 20, max 50"
+// @Success 200 {object} common.Response{data=model.CommentPage}
+// @Router /api/v1/{targetType}/{id}/comments [get]
+func (h *CommentController) List(c *gin.Context) {
+	if _, ok := authorize(c, model.CommentResource, request.ListOperation); !ok {
+		return
+	}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			common.ResponseFailed(c, http.StatusBadRequest, apierrors.NewFieldInvalid("limit", "invalid limit "+strconv.Quote(value)))
+			return
+		}
+		limit = n
+	}
+	page, err := h.commentService.List(c.GetString(targetTypeKey), c.Param("id"), c.Query("before"), limit)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, page)
+}
+
+// @Summary Create comment | 发表评论
+// @Description Comment on a hot search or topic (tag), or reply to a comment with parentId. Rate limited per user | 评论热搜或话题（tag），指定 parentId 时回复评论；按 user 限制频率
+// @Accept json
+// @Produce json
+// @Tags comment
+// @Security JWT
+// @Param targetType path string true "hotsearches or tags"
+// @Param id path int true "target id"
+// @Param comment body model.CreatedComment true "comment"
+// @Success 200 {object} common.Response{data=model.Comment}
+// @Router /api/v1/{targetType}/{id}/comments [post]
+func (h *CommentController) Create(c *gin.Context) {
+	user, ok := authorize(c, model.CommentResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	created := new(model.CreatedComment)
+	if err := validation.BindJSON(c, created); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	comment, err := h.commentService.Create(user, c.GetString(targetTypeKey), c.Param("id"), created)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, comment)
+}
+
+// @Summary Delete comment | 删除评论
+// @Description Soft delete comment, only the author and cluster admins | 软删除评论，只有作者和管理员可以删除
+// @Produce json
+// @Tags comment
+// @Security JWT
+// @Param id path int true "comment id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/comments/{id} [delete]
+func (h *CommentController) Delete(c *gin.Context) {
+	user, ok := authorize(c, model.CommentResource, request.DeleteOperation)
+	if !ok {
+		return
+	}
+	if err := h.commentService.Delete(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Report comment | 举报评论
+// @Description Report comment to cluster admins, once per user | 向管理员举报评论，每个 user 只能举报一次
+// @Accept json
+// @Produce json
+// @Tags comment
+// @Security JWT
+// @Param id path int true "comment id"
+// @Param report body model.CreatedCommentReport true "report reason"
+// @Success 200 {object} common.Response
+// @Router /api/v1/comments/{id}/reports [post]
+func (h *CommentController) Report(c *gin.Context) {
+	user, ok := authorize(c, model.CommentResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	created := new(model.CreatedCommentReport)
+	if err := validation.BindJSON(c, created); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.commentService.Report(user, c.Param("id"), created); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary List comment reports | 举报列表
+// @Description List pending reports with the reported comments, only cluster admins | 获取待处理的举报和被举报的评论，只有管理员可以查看
+// @Produce json
+// @Tags comment
+// @Security JWT
+// @Success 200 {object} common.Response{data=[]model.CommentReport}
+// @Router /api/v1/comments/reports [get]
+func (h *CommentController) ListReports(c *gin.Context) {
+	user, ok := authorize(c, model.CommentResource, request.ListOperation)
+	if !ok {
+		return
+	}
+	reports, err := h.commentService.ListReports(user)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, reports)
+}
+
+// @Summary Dismiss comment reports | 忽略举报
+// @Description Dismiss reports of comment and keep it, only cluster admins | 忽略评论的举报并保留评论，只有管理员可以处理
+// @Produce json
+// @Tags comment
+// @Security JWT
+// @Param id path int true "comment id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/comments/{id}/reports [delete]
+func (h *CommentController) DismissReports(c *gin.Context) {
+	user, ok := authorize(c, model.CommentResource, request.UpdateOperation)
+	if !ok {
+		return
+	}
+	if err := h.commentService.DismissReports(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary React | 回应
+// @Description React to a hot search, topic (tag) or comment with an emoji, once per user and emoji. Rate limited per user | 用 emoji 回应热搜、话题（tag）或评论，每个 user 对同一个 emoji 只能回应一次；按 user 限制频率
+// @Produce json
+// @Tags comment
+// @Security JWT
+// @Param targetType path string true "hotsearches, tags or comments"
+// @Param id path int true "target id"
+// @Param emoji path string true "url encoded emoji"
+// @Success 200 {object} common.Response
+// @Router /api/v1/{targetType}/{id}/reactions/{emoji} [put]
+func (h *CommentController) React(c *gin.Context) {
+	user, ok := authorize(c, model.ReactionResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	if err := h.commentService.React(user, c.GetString(targetTypeKey), c.Param("id"), c.Param("emoji")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Unreact | 取消回应
+// @Description Remove the emoji reaction of current user | 取消当前 user 的 emoji 回应
+// @Produce json
+// @Tags comment
+// @Security JWT
+// @Param targetType path string true "hotsearches, tags or comments"
+// @Param id path int true "target id"
+// @Param emoji path string true "url encoded emoji"
+// @Success 200 {object} common.Response
+// @Router /api/v1/{targetType}/{id}/reactions/{emoji} [delete]
+func (h *CommentController) Unreact(c *gin.Context) {
+	user, ok := authorize(c, model.ReactionResource, request.DeleteOperation)
+	if !ok {
+		return
+	}
+	if err := h.commentService.Unreact(user, c.GetString(targetTypeKey), c.Param("id"), c.Param("emoji")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// withTargetType 记录路由对应的评论、回应对象类型
+func withTargetType(targetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(targetTypeKey, targetType)
+	}
+}
+
+func (h *CommentController) RegisterRoute(api *gin.RouterGroup) {
+	for _, target := range model.CommentTargets {
+		api.GET("/"+target+"/:id/comments", withTargetType(target), h.List)    // 评论列表
+		api.POST("/"+target+"/:id/comments", withTargetType(target), h.Create) // 发表评论
+	}
+	for _, target := range model.ReactionTargets {
+		api.PUT("/"+target+"/:id/reactions/:emoji", withTargetType(target), h.React)      // 回应
+		api.DELETE("/"+target+"/:id/reactions/:emoji", withTargetType(target), h.Unreact) // 取消回应
+	}
+	api.GET("/comments/reports", h.ListReports)           // 待处理的举报
+	api.DELETE("/comments/:id", h.Delete)                 // 删除评论
+	api.POST("/comments/:id/reports", h.Report)           // 举报评论
+	api.DELETE("/comments/:id/reports", h.DismissReports) // 忽略举报
+}
+
+func (h *CommentController) Name() string {
+	return "Comment"
+}
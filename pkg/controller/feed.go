@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/utils/request"
+	"chitchat4.0/pkg/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// FeedController 关注、收藏和个性化热搜控制器
+type FeedController struct {
+	feedService service.FeedService
+}
+
+// NewFeedController 创建关注、收藏和个性化热搜控制器
+func NewFeedController(feedService service.FeedService) Controller {
+	return &FeedController{
+		feedService: feedService,
+	}
+}
+
+// @Summary List follows | 关注列表
+// @Description List tags and keywords followed by current user | 获取当前 user 关注的 tag 和关键词
+// @Produce json
+// @Tags feed
+// @Security JWT
+// @Success 200 {object} common.Response{data=model.Follows}
+// @Router /api/v1/follows [get]
+func (f *FeedController) ListFollows(c *gin.Context) {
+	user, ok := authorize(c, model.FollowResource, request.ListOperation)
+	if !ok {
+		return
+	}
+	follows, err := f.feedService.ListFollows(user)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, follows)
+}
+
+// @Summary Follow tag | 关注 tag
+// @Description Follow tag, hot searches of followed tags rank higher in feed | 关注 tag，关注的 tag 的热搜在个性化热搜中排名更高
+// @Produce json
+// @Tags feed
+// @Security JWT
+// @Param id path int true "tag id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/follows/tags/{id} [put]
+func (f *FeedController) FollowTag(c *gin.Context) {
+	user, ok := authorize(c, model.FollowResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	if err := f.feedService.FollowTag(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Unfollow tag | 取消关注 tag
+// @Description Unfollow tag | 取消关注 tag
+// @Produce json
+// @Tags feed
+// @Security JWT
+// @Param id path int true "tag id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/follows/tags/{id} [delete]
+func (f *FeedController) UnfollowTag(c *gin.Context) {
+	user, ok := authorize(c, model.FollowResource, request.DeleteOperation)
+	if !ok {
+		return
+	}
+	if err := f.feedService.UnfollowTag(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Follow keyword | 关注关键词
+// @Description Follow keyword, hot searches with the keyword in title are added to feed. Keywords are case insensitive | 关注关键词，标题包含关键词的热搜会出现在个性化热搜中，不区分大小写
+// @Accept json
+// @Produce json
+// @Tags feed
+// @Security JWT
+// @Param keyword body model.CreatedKeywordFollow true "keyword"
+// @Success 200 {object} common.Response{data=model.KeywordFollow}
+// @Router /api/v1/follows/keywords [post]
+func (f *FeedController) FollowKeyword(c *gin.Context) {
+	user, ok := authorize(c, model.FollowResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	created := new(model.CreatedKeywordFollow)
+	if err := validation.BindJSON(c, created); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	keyword, err := f.feedService.FollowKeyword(user, created)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, keyword)
+}
+
+// @Summary Unfollow keyword | 取消关注关键词
+// @Description Unfollow keyword | 取消关注关键词
+// @Produce json
+// @Tags feed
+// @Security JWT
+// @Param id path int true "keyword follow id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/follows/keywords/{id} [delete]
+func (f *FeedController) UnfollowKeyword(c *gin.Context) {
+	user, ok := authorize(c, model.FollowResource, request.DeleteOperation)
+	if !ok {
+		return
+	}
+	if err := f.feedService.UnfollowKeyword(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary List bookmarks | 收藏列表
+// @Description List hot searches bookmarked by current user, including those no longer on the list | 获取当前 user 收藏的热搜，包括已下榜的
+// @Produce json
+// @Tags feed
+// @Security JWT
+// @Success 200 {object} common.Response{data=[]model.Bookmark}
+// @Router /api/v1/bookmarks [get]
+func (f *FeedController) ListBookmarks(c *gin.Context) {
+	user, ok := authorize(c, model.BookmarkResource, request.ListOperation)
+	if !ok {
+		return
+	}
+	bookmarks, err := f.feedService.ListBookmarks(user)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, bookmarks)
+}
+
+// @Summary Bookmark hot search | 收藏热搜
+// @Description Bookmark hot search | 收藏热搜
+// @Produce json
+// @Tags feed
+// @Security JWT
+// @Param id path int true "hot search id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/hotsearches/{id}/bookmark [put]
+func (f *FeedController) Bookmark(c *gin.Context) {
+	user, ok := authorize(c, model.BookmarkResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	if err := f.feedService.Bookmark(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Unbookmark hot search | 取消收藏热搜
+// @Description Remove hot search from bookmarks | 取消收藏热搜
+// @Produce json
+// @Tags feed
+// @Security JWT
+// @Param id path int true "hot search id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/hotsearches/{id}/bookmark [delete]
+func (f *FeedController) Unbookmark(c *gin.Context) {
+	user, ok := authorize(c, model.BookmarkResource, request.DeleteOperation)
+	if !ok {
+		return
+	}
+	if err := f.feedService.Unbookmark(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Feed | 个性化热搜
+// @Description Hot searches ranked by followed tags, keyword matches and recency, cached for a minute. All hot searches are ranked by rank and recency when nothing is followed | 按关注的 tag、匹配的关键词和上榜时间排序的热搜，缓存一分钟；没有任何关注时按排名和上榜时间推荐全部热搜
+// @Produce json
+// @Tags feed
+// @Security JWT
+// @Param limit query int false "size, default 50, max 100"
+// @Success 200 {object} common.Response{data=model.Feed}
+// @Router /api/v1/feed [get]
+func (f *FeedController) Feed(c *gin.Context) {
+	user, ok := authorize(c, model.FeedResource, request.GetOperation)
+	if !ok {
+		return
+	}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			common.ResponseFailed(c, http.StatusBadRequest, apierrors.NewFieldInvalid("limit", "invalid limit "+strconv.Quote(value)))
+			return
+		}
+		limit = n
+	}
+	feed, err := f.feedService.Feed(user, limit)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, feed)
+}
+
+func (f *FeedController) RegisterRoute(api *gin.RouterGroup) {
+	api.GET("/follows", f.ListFollows)                     // 关注的 tag 和关键词
+	api.PUT("/follows/tags/:id", f.FollowTag)              // 关注 tag
+	api.DELETE("/follows/tags/:id", f.UnfollowTag)         // 取消关注 tag
+	api.POST("/follows/keywords", f.FollowKeyword)         // 关注关键词
+	api.DELETE("/follows/keywords/:id", f.UnfollowKeyword) // 取消关注关键词
+	api.GET("/bookmarks", f.ListBookmarks)                 // 收藏的热搜
+	api.PUT("/hotsearches/:id/bookmark", f.Bookmark)       // 收藏热搜
+	api.DELETE("/hotsearches/:id/bookmark", f.Unbookmark)  // 取消收藏热搜
+	api.GET("/feed", f.Feed)                               // 个性化热搜
+}
+
+func (f *FeedController) Name() string {
+	return "Feed"
+}
@@ -14,10 +14,14 @@ import (
 	"fmt"
 	"net/http"
 
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authorization"
 	"chitchat4.0/pkg/common"
 	"chitchat4.0/pkg/model"
 	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/utils/request"
 	"chitchat4.0/pkg/utils/trace"
+	"chitchat4.0/pkg/validation"
 	"github.com/gin-gonic/gin"
 )
 
@@ -37,7 +41,7 @@ func NewGroupController(groupService service.GroupService) Controller {
 }
 
 // @Summary Create group | 创建 group
-// @Description Create group and stroage | 创建 group 和 stroage 存储
+// @Description Create group and stroage, root and system:* are reserved for system groups | 创建 group 和 stroage 存储，root 和 system: 开头的名称保留给系统 group
 // @Accept json
 // @produce json
 // @Tags group
@@ -49,9 +53,10 @@ func (g *GroupController) Create(c *gin.Context) {
 	user := common.GetUser(c)
 	if user == nil {
 		common.ResponseFailed(c, http.StatusBadRequest, fmt.Errorf("Create Group 获取User失败"))
+		return
 	}
 	createdGroup := new(model.CreatedGroup)
-	if err := c.BindJSON(createdGroup); err != nil {
+	if err := validation.BindJSON(c, createdGroup); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
@@ -65,6 +70,7 @@ func (g *GroupController) Create(c *gin.Context) {
 		common.ResponseFailed(c, http.StatusInternalServerError, err)
 		return
 	}
+	common.SetETag(c, group.ResourceVersion)
 	common.ResponseSuccess(c, group)
 }
 
@@ -82,6 +88,7 @@ func (g *GroupController) Get(c *gin.Context) {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
+	common.SetETag(c, group.ResourceVersion)
 	common.ResponseSuccess(c, group)
 }
 
@@ -104,26 +111,31 @@ func (g *GroupController) List(c *gin.Context) {
 }
 
 // @Summary Update group | 修改 group
-// @Description Update group and storage | 修改group和保存
+// @Description Update group and storage, only the creator and cluster admins | 修改group和保存，只有创建者和管理员可以修改
 // @Accept json
 // @Produce json
 // @Tags group
 // @Security JWT
 // @Param group body model.UpdatedGroup true "group info"
 // @Param id path int true "group id"
+// @Param If-Match header string false "resource version, 412 if not match"
 // @Success 200 {object} common.Response{data=model.Group}
 // @Router /api/v1/groups/{id} [put]
 func (g *GroupController) Update(c *gin.Context) {
-	user := common.GetUser(c)
-	if user == nil {
-		common.ResponseFailed(c, http.StatusBadRequest, fmt.Errorf("Update Group:Failed to get user"))
+	user, ok := g.authorizeGroup(c, request.UpdateOperation)
+	if !ok {
 		return
 	}
 
 	id := c.Param("id")
+	version, err := common.IfMatch(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
 
 	new := new(model.UpdatedGroup)
-	if err := c.BindJSON(new); err != nil {
+	if err := validation.BindJSON(c, new); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
@@ -131,30 +143,75 @@ func (g *GroupController) Update(c *gin.Context) {
 	common.TraceStep(c, "start update group", trace.Field{Key: "group", Value: new.Name})
 	defer common.TraceStep(c, "update group done", trace.Field{Key: "group", Value: new.Name})
 
-	group, err := g.groupService.Update(id, new.GetGroup(user.ID))
+	updated := new.GetGroup(user.ID)
+	updated.ResourceVersion = version
+	group, err := g.groupService.Update(id, updated)
 	if err != nil {
 		common.ResponseFailed(c, http.StatusInternalServerError, err)
 		return
 	}
+	common.SetETag(c, group.ResourceVersion)
+	common.ResponseSuccess(c, group)
+}
+
+// @Summary Patch group | 修改 group 的部分信息
+// @Description Patch group with JSON Merge Patch or JSON Patch, only name and describe can be patched, only the creator and cluster admins | 使用 JSON Merge Patch 或 JSON Patch 修改 group，只能修改 name、describe，只有创建者和管理员可以修改
+// @Accept application/merge-patch+json,application/json-patch+json,json
+// @Produce json
+// @Tags group
+// @Security JWT
+// @Param patch body object true "merge patch or json patch"
+// @Param id path int true "group id"
+// @Param If-Match header string false "resource version, 412 if not match"
+// @Success 200 {object} common.Response{data=model.Group}
+// @Router /api/v1/groups/{id} [patch]
+func (g *GroupController) Patch(c *gin.Context) {
+	if _, ok := g.authorizeGroup(c, request.PatchOperation); !ok {
+		return
+	}
+	version, err := common.IfMatch(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	data, err := c.GetRawData()
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+
+	common.TraceStep(c, "start patch group", trace.Field{Key: "id", Value: c.Param("id")})
+	defer common.TraceStep(c, "patch group done", trace.Field{Key: "id", Value: c.Param("id")})
+
+	group, err := g.groupService.Patch(c.Param("id"), version, c.ContentType(), data)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, validation.Translate(c, err))
+		return
+	}
+	common.SetETag(c, group.ResourceVersion)
 	common.ResponseSuccess(c, group)
 }
 
 // @Summary Delete group | 删除group
-// @Description Delete group | 删除指定的group
+// @Description Delete group, only the creator and cluster admins | 删除指定的group，只有创建者和管理员可以删除
 // @Produce json
 // @Tags group
 // @Security JWT
 // @Param id path int true "group id"
+// @Param If-Match header string false "resource version, 412 if not match"
 // @Success 200 {object} common.Response
 // @Router /api/v1/groups/{id} [delete]
 func (g *GroupController) Delete(c *gin.Context) {
-	user := common.GetUser(c)
-	if user == nil {
-		common.ResponseFailed(c, http.StatusBadRequest, fmt.Errorf("Delete group:failed to get user"))
+	if _, ok := g.authorizeGroup(c, request.DeleteOperation); !ok {
 		return
 	}
 
-	if err := g.groupService.Delete(c.Param("id")); err != nil {
+	version, err := common.IfMatch(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := g.groupService.Delete(c.Param("id"), version); err != nil {
 		common.ResponseFailed(c, http.StatusInternalServerError, err)
 		return
 	}
@@ -179,7 +236,7 @@ func (g *GroupController) GetUsers(c *gin.Context) {
 }
 
 // @Summary Group Add user | 添加user
-// @Description Add user to group | 把user添加到group中
+// @Description Add user to group, only the creator or cluster admins, members of system groups can only be changed by cluster admins | 把user添加到group中，只有创建者和管理员可以添加，系统 group 的成员只有管理员可以修改
 // @Produce json
 // @Tags group
 // @Security JWT
@@ -188,9 +245,12 @@ func (g *GroupController) GetUsers(c *gin.Context) {
 // @Success 200 {object} common.Response
 // @Router /api/v1/groups/{id}/users [post]
 func (g *GroupController) AddUser(c *gin.Context) {
+	if _, ok := g.authorizeGroup(c, request.UpdateOperation); !ok {
+		return
+	}
 	user := new(model.User)
 
-	if err := c.BindJSON(user); err != nil {
+	if err := validation.BindJSON(c, user); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
@@ -203,7 +263,7 @@ func (g *GroupController) AddUser(c *gin.Context) {
 }
 
 // @Summary Delete user | 删除user
-// @Description Delete user from group | 删除group中的user
+// @Description Delete user from group, only the creator or cluster admins, members of system groups can only be changed by cluster admins | 删除group中的user，只有创建者和管理员可以删除，系统 group 的成员只有管理员可以修改
 // @Produce json
 // @Tags group
 // @Security JWT
@@ -212,6 +272,9 @@ func (g *GroupController) AddUser(c *gin.Context) {
 // @Success 200 {object} common.Response
 // @Router /api/v1/groups/{id}/users [delete]
 func (g *GroupController) DelUser(c *gin.Context) {
+	if _, ok := g.authorizeGroup(c, request.UpdateOperation); !ok {
+		return
+	}
 	if err := g.groupService.DelUser(c.Param("id"), c.Query("uid")); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
@@ -219,8 +282,27 @@ func (g *GroupController) DelUser(c *gin.Context) {
 	common.ResponseSuccess(c, nil)
 }
 
+// authorizeGroup 只有 group 的创建者和管理员可以修改 group 和它的成员。
+// 系统 group 没有创建者，只有管理员可以修改，所以普通 user 不能把自己加入 root 组
+func (g *GroupController) authorizeGroup(c *gin.Context, verb string) (*model.User, bool) {
+	user, ok := authorize(c, model.GroupResource, verb)
+	if !ok {
+		return nil, false
+	}
+	group, err := g.groupService.Get(c.Param("id"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return nil, false
+	}
+	if group.CreatorId != user.ID && !authorization.IsClusterAdmin(user) {
+		common.ResponseFailed(c, http.StatusForbidden, apierrors.NewForbidden("only the creator can "+verb+" group "+group.Name))
+		return nil, false
+	}
+	return user, true
+}
+
 // @Summary Add role | 添加role
-// @Description Add role to group | 给 group 添加 role
+// @Description Add role to group, only cluster admins | 给 group 添加 role，只有管理员可以添加
 // @Produce json
 // @Tags group
 // @Security JWT
@@ -229,6 +311,9 @@ func (g *GroupController) DelUser(c *gin.Context) {
 // @Success 200 {object} common.Response
 // @Router /api/v1/groups/{id}/roles/{rid} [post]
 func (g *GroupController) AddRole(c *gin.Context) {
+	if !requireClusterAdmin(c, "only cluster admins can grant roles") {
+		return
+	}
 	if err := g.groupService.AddRole(c.Param("id"), c.Param("rid")); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
@@ -238,7 +323,7 @@ func (g *GroupController) AddRole(c *gin.Context) {
 }
 
 // @Summary Delete role | 删除 role
-// @Description delete role from group | 删除group中的role
+// @Description delete role from group, only cluster admins | 删除group中的role，只有管理员可以删除
 // @produce json
 // @Tags group
 // @Security JWT
@@ -247,6 +332,9 @@ func (g *GroupController) AddRole(c *gin.Context) {
 // @Success 200 {object} common.Response
 // @Router /api/v1/groups/{id}/roles/{rid} [delete]
 func (g *GroupController) DelRole(c *gin.Context) {
+	if !requireClusterAdmin(c, "only cluster admins can revoke roles") {
+		return
+	}
 	if err := g.groupService.DelRole(c.Param("id"), c.Param("rid")); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
@@ -264,6 +352,7 @@ func (g *GroupController) RegisterRoute(api *gin.RouterGroup) {
 	api.POST("/groups", g.Create)                   // 创建 group
 	api.GET("/groups/:id", g.Get)                   // 获取 group
 	api.PUT("/groups/:id", g.Update)                // 修改 group
+	api.PATCH("/groups/:id", g.Patch)               // 修改 group 的部分字段
 	api.DELETE("/groups/:id", g.Delete)             // 删除group
 	api.GET("/groups/:id/users", g.GetUsers)        // 获取 group 中的user集合
 	api.POST("/groups/:id/users", g.AddUser)        // 把user添加到group中
@@ -1,47 +1,225 @@
 package controller
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/hotsearch"
+	"chitchat4.0/pkg/model"
 	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/syndication"
+	"chitchat4.0/pkg/utils/trace"
+	"chitchat4.0/pkg/validation"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
 )
 
+const feedCacheControl = "public, max-age=60" // 订阅源允许客户端和代理缓存一分钟
+
 type HotSearchController struct {
 	hotSearchService service.HotSearchService
+	hub              *hotsearch.Hub
+	upgrader         *websocket.Upgrader
+	publicURL        string // 服务的公开访问地址，为空时使用请求的地址
 }
 
-func NewHotSearchController(hotSearchService service.HotSearchService) Controller {
+// NewHotSearchController 创建热搜控制器，hub 用于向 WebSocket 客户端推送热搜榜的更新，upgrader 检查 WebSocket 的来源，
+// publicURL 是订阅源中链接使用的地址
+func NewHotSearchController(hotSearchService service.HotSearchService, hub *hotsearch.Hub, upgrader *websocket.Upgrader, publicURL string) Controller {
 	return &HotSearchController{
 		hotSearchService: hotSearchService,
+		hub:              hub,
+		upgrader:         upgrader,
+		publicURL:        strings.TrimRight(publicURL, "/"),
 	}
 }
 
+// @Summary List hot searches | 热搜列表
+// @Description List hot searches of all tags | 获取全部 tag 的热搜
+// @Produce json
+// @Tags hotsearch
+// @Security JWT
+// @Success 200 {object} common.Response{data=[]model.HotSearch}
+// @Router /api/v1/hotsearches [get]
 func (h *HotSearchController) List(c *gin.Context) {
+	hotSearches, err := h.hotSearchService.List()
+	if err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, hotSearches)
+}
 
+// @Summary List hot searches of tag | tag 的热搜榜
+// @Description List hot searches of tag ordered by rank | 按排名获取 tag 的热搜榜
+// @Produce json
+// @Tags hotsearch
+// @Security JWT
+// @Param id path int true "tag id"
+// @Success 200 {object} common.Response{data=[]model.HotSearch}
+// @Router /api/v1/tags/{id}/hotsearches [get]
+func (h *HotSearchController) ListByTag(c *gin.Context) {
+	hotSearches, err := h.hotSearchService.ListByTag(c.Param("id"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, hotSearches)
 }
 
-func (h *HotSearchController) Create(c *gin.Context) {
+// @Summary Store hot search snapshot | 保存热搜快照
+// @Description Replace hot searches of tag with a new snapshot from the collector, rank changes are pushed to subscribers. Only users with role hotsearch-collector and cluster admins | 采集器提交 tag 新的热搜快照，替换原来的榜单并把排名变化推送给订阅者，只有拥有 hotsearch-collector 角色的 user 和管理员可以提交
+// @Accept json
+// @Produce json
+// @Tags hotsearch
+// @Security JWT
+// @Param id path int true "tag id"
+// @Param snapshot body model.HotSearchSnapshot true "hot searches ordered by rank"
+// @Success 200 {object} common.Response{data=model.HotSearchUpdate}
+// @Router /api/v1/tags/{id}/hotsearches [put]
+func (h *HotSearchController) Snapshot(c *gin.Context) {
+	user := common.GetUser(c)
+	// 服务账号不一定是采集器，需要管理员给它绑定 hotsearch-collector 角色
+	if !authorization.HasRole(user, model.HotSearchCollectorRole) && !authorization.IsClusterAdmin(user) {
+		common.ResponseFailed(c, http.StatusForbidden, apierrors.NewForbidden("only users with role "+model.HotSearchCollectorRole+" and cluster admins can store hot search snapshots"))
+		return
+	}
+	snapshot := new(model.HotSearchSnapshot)
+	if err := validation.BindJSON(c, snapshot); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+
+	common.TraceStep(c, "start store hot search snapshot", trace.Field{Key: "tag", Value: c.Param("id")})
+	defer common.TraceStep(c, "store hot search snapshot done", trace.Field{Key: "tag", Value: c.Param("id")})
+
+	update, err := h.hotSearchService.Snapshot(c.Param("id"), snapshot)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, update)
+}
 
+// @Summary Subscribe hot searches | 订阅热搜榜
+// @Description Upgrade to WebSocket and receive rank changes of subscribed tags. Send {"action":"subscribe","tags":[1]} or {"action":"unsubscribe","tags":[1]} to change subscriptions; slow clients are disconnected and should reload the list. Public like the hot search list and feeds, no login required; cross origin connections not in the CORS allow list are rejected | 升级为 WebSocket，接收订阅 tag 的排名变化；发送 subscribe、unsubscribe 消息修改订阅；处理太慢的客户端会被断开，需要重新获取榜单。和热搜列表、订阅源一样公开，不需要登录；不在跨域白名单中的来源会被拒绝
+// @Tags hotsearch
+// @Param tags query string false "tag ids to subscribe, separated by comma"
+// @Success 101 {object} hotsearch.Message
+// @Router /api/v1/hotsearches/ws [get]
+func (h *HotSearchController) Subscribe(c *gin.Context) {
+	// 推送的只有公开的热搜排名，不需要登录；连接不关联用户，也不会收到任何用户的数据
+	tags, err := parseTagIDs(c.Query("tags"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logrus.Warnf("upgrade hot search connection failed: %v", err)
+		return
+	}
+	h.hub.Serve(conn, tags)
 }
 
-func (h *HotSearchController) Get(c *gin.Context) {
+// @Summary RSS feed of tag | tag 热搜榜的 RSS
+// @Description RSS 2.0 feed of hot searches of tag ordered by rank. Supports conditional GET with If-None-Match and If-Modified-Since | 按排名输出 tag 热搜榜的 RSS 2.0 订阅源，支持 If-None-Match 和 If-Modified-Since 条件请求
+// @Produce application/rss+xml
+// @Tags hotsearch
+// @Param id path int true "tag id"
+// @Success 200 {string} string "RSS 2.0"
+// @Success 304 "not modified"
+// @Router /api/v1/tags/{id}/feed.rss [get]
+func (h *HotSearchController) RSS(c *gin.Context) {
+	h.feed(c, syndication.FormatRSS)
+}
 
+// @Summary Atom feed of tag | tag 热搜榜的 Atom
+// @Description Atom 1.0 feed of hot searches of tag ordered by rank. Supports conditional GET with If-None-Match and If-Modified-Since | 按排名输出 tag 热搜榜的 Atom 1.0 订阅源，支持 If-None-Match 和 If-Modified-Since 条件请求
+// @Produce application/atom+xml
+// @Tags hotsearch
+// @Param id path int true "tag id"
+// @Success 200 {string} string "Atom 1.0"
+// @Success 304 "not modified"
+// @Router /api/v1/tags/{id}/feed.atom [get]
+func (h *HotSearchController) Atom(c *gin.Context) {
+	h.feed(c, syndication.FormatAtom)
 }
 
-func (h *HotSearchController) Update(c *gin.Context) {
+// @Summary JSON Feed of tag | tag 热搜榜的 JSON Feed
+// @Description JSON Feed 1.1 of hot searches of tag ordered by rank. Supports conditional GET with If-None-Match and If-Modified-Since | 按排名输出 tag 热搜榜的 JSON Feed 1.1 订阅源，支持 If-None-Match 和 If-Modified-Since 条件请求
+// @Produce application/feed+json
+// @Tags hotsearch
+// @Param id path int true "tag id"
+// @Success 200 {string} string "JSON Feed 1.1"
+// @Success 304 "not modified"
+// @Router /api/v1/tags/{id}/feed.json [get]
+func (h *HotSearchController) JSONFeed(c *gin.Context) {
+	h.feed(c, syndication.FormatJSON)
+}
 
+// feed 输出订阅源，内容没有变化时返回 304
+func (h *HotSearchController) feed(c *gin.Context, format string) {
+	document, err := h.hotSearchService.TagFeed(c.Param("id"), format, h.baseURL(c))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	c.Header("ETag", document.ETag)
+	c.Header("Last-Modified", document.LastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", feedCacheControl)
+	if common.NotModified(c, document.ETag, document.LastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, document.ContentType, document.Body)
 }
 
-func (h *HotSearchController) Delete(c *gin.Context) {
+// baseURL 返回客户端访问服务使用的地址，配置了 publicURL 时使用配置的地址。
+// 否则使用请求的协议和主机，不使用客户端可以伪造的 X-Forwarded-Proto，在反向代理后面时需要配置 publicURL
+func (h *HotSearchController) baseURL(c *gin.Context) string {
+	if h.publicURL != "" {
+		return h.publicURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
 
+// parseTagIDs 解析逗号分隔的 tag id
+func parseTagIDs(value string) ([]uint, error) {
+	ids := make([]uint, 0)
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || id == 0 {
+			return nil, apierrors.NewFieldInvalid("tags", "invalid tag id "+strconv.Quote(s))
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
 }
 
 func (h *HotSearchController) RegisterRoute(api *gin.RouterGroup) {
-	api.GET("/hotsearchs", h.List)
-	api.POST("/hotsearchs", h.Create)
-	api.GET("/hotsearchs/:id", h.Get)
-	api.PUT("/hotsearchs/:id", h.Update)
-	api.DELETE("/hotsearchs/:id", h.Delete)
+	api.GET("/hotsearches", h.List)               // 热搜列表
+	api.GET("/hotsearches/ws", h.Subscribe)       // 订阅热搜榜的更新
+	api.GET("/tags/:id/hotsearches", h.ListByTag) // tag 的热搜榜
+	api.PUT("/tags/:id/hotsearches", h.Snapshot)  // 采集器提交热搜快照
+	api.GET("/tags/:id/feed.rss", h.RSS)          // tag 热搜榜的 RSS
+	api.GET("/tags/:id/feed.atom", h.Atom)        // tag 热搜榜的 Atom
+	api.GET("/tags/:id/feed.json", h.JSONFeed)    // tag 热搜榜的 JSON Feed
 }
+
 func (h *HotSearchController) Name() string {
 	return "HotSearch"
 }
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// MFAController 二次验证控制器
+type MFAController struct {
+	mfaService service.MFAService
+}
+
+// NewMFAController 创建二次验证控制器
+func NewMFAController(mfaService service.MFAService) Controller {
+	return &MFAController{
+		mfaService: mfaService,
+	}
+}
+
+// @Summary Enroll mfa | 绑定二次验证
+// @Description Generate totp secret and provisioning uri | 生成 TOTP 密钥和二维码 URI，验证通过后才会开启
+// @Produce json
+// @Tags mfa
+// @Security JWT
+// @Param id path int true "user id"
+// @Success 200 {object} common.Response{data=model.MFAEnrollment}
+// @Router /api/v1/users/{id}/mfa [post]
+func (m *MFAController) Enroll(c *gin.Context) {
+	if !isSelf(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can only enroll mfa for yourself"))
+		return
+	}
+	enrollment, err := m.mfaService.Enroll(c.Param("id"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, enrollment)
+}
+
+// @Summary Verify mfa | 验证并开启二次验证
+// @Description Verify totp code, enable mfa and generate recovery codes | 验证一次性密码，开启二次验证并返回恢复码
+// @Accept json
+// @Produce json
+// @Tags mfa
+// @Security JWT
+// @Param id path int true "user id"
+// @Param code body model.MFACode true "totp code"
+// @Success 200 {object} common.Response{data=model.MFARecoveryCodes}
+// @Router /api/v1/users/{id}/mfa/verify [post]
+func (m *MFAController) Verify(c *gin.Context) {
+	if !isSelf(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can only verify mfa for yourself"))
+		return
+	}
+	code := new(model.MFACode)
+	if err := validation.BindJSON(c, code); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	codes, err := m.mfaService.Activate(c.Param("id"), code.Code)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, codes)
+}
+
+// @Summary Regenerate recovery codes | 重新生成恢复码
+// @Description Regenerate recovery codes, old codes are invalidated | 重新生成恢复码，旧的恢复码全部失效
+// @Accept json
+// @Produce json
+// @Tags mfa
+// @Security JWT
+// @Param id path int true "user id"
+// @Param code body model.MFACode true "totp code or recovery code"
+// @Success 200 {object} common.Response{data=model.MFARecoveryCodes}
+// @Router /api/v1/users/{id}/mfa/recovery-codes [post]
+func (m *MFAController) RegenerateRecoveryCodes(c *gin.Context) {
+	if !isSelf(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can only regenerate your own recovery codes"))
+		return
+	}
+	code := new(model.MFACode)
+	if err := validation.BindJSON(c, code); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	codes, err := m.mfaService.RegenerateRecoveryCodes(c.Param("id"), code.Code)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, codes)
+}
+
+// @Summary Disable mfa | 关闭二次验证
+// @Description Disable mfa with totp code or recovery code | 使用一次性密码或恢复码关闭二次验证
+// @Accept json
+// @Produce json
+// @Tags mfa
+// @Security JWT
+// @Param id path int true "user id"
+// @Param code body model.MFACode true "totp code or recovery code"
+// @Success 200 {object} common.Response
+// @Router /api/v1/users/{id}/mfa [delete]
+func (m *MFAController) Disable(c *gin.Context) {
+	if !isSelf(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can only disable mfa for yourself"))
+		return
+	}
+	code := new(model.MFACode)
+	if err := validation.BindJSON(c, code); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := m.mfaService.Disable(c.Param("id"), code.Code); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Require mfa | 要求开启二次验证
+// @Description Admins in root group require user to enable mfa | root 组的管理员要求 user 开启二次验证
+// @Accept json
+// @Produce json
+// @Tags mfa
+// @Security JWT
+// @Param id path int true "user id"
+// @Param requirement body model.MFARequirement true "mfa requirement"
+// @Success 200 {object} common.Response
+// @Router /api/v1/users/{id}/mfa/required [put]
+func (m *MFAController) SetRequired(c *gin.Context) {
+	if !authorization.IsRootAdmin(common.GetUser(c)) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("only admins in root group can require mfa"))
+		return
+	}
+	requirement := new(model.MFARequirement)
+	if err := validation.BindJSON(c, requirement); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := m.mfaService.SetRequired(c.Param("id"), requirement.Required); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// isSelf 判断当前登录的 user 是不是路径中的 user
+func isSelf(c *gin.Context) bool {
+	user := common.GetUser(c)
+	return user != nil && strconv.Itoa(int(user.ID)) == c.Param("id")
+}
+
+func (m *MFAController) RegisterRoute(api *gin.RouterGroup) {
+	api.POST("/users/:id/mfa", m.Enroll)                                 // 绑定二次验证
+	api.DELETE("/users/:id/mfa", m.Disable)                              // 关闭二次验证
+	api.POST("/users/:id/mfa/verify", m.Verify)                          // 验证并开启二次验证
+	api.POST("/users/:id/mfa/recovery-codes", m.RegenerateRecoveryCodes) // 重新生成恢复码
+	api.PUT("/users/:id/mfa/required", m.SetRequired)                    // 要求开启二次验证
+}
+
+func (m *MFAController) Name() string {
+	return "MFA"
+}
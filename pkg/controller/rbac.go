@@ -6,6 +6,7 @@ import (
 	"chitchat4.0/pkg/common"
 	"chitchat4.0/pkg/model"
 	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/validation"
 	"github.com/gin-gonic/gin"
 )
 
@@ -43,7 +44,7 @@ func (rbac *RBACController) List(c *gin.Context) {
 }
 
 // @Summary Create rbac role | 创建 rbac 的角色
-// @Description Create rbac role | 创建 rbac 的角色
+// @Description Create rbac role, only cluster admins | 创建 rbac 的角色，只有管理员可以创建
 // @Accept json
 // @Produce json
 // @Tags rbac
@@ -52,8 +53,11 @@ func (rbac *RBACController) List(c *gin.Context) {
 // @Success 200 {object} common.Response
 // @Router /api/v1/roles [post]
 func (rbac *RBACController) Create(c *gin.Context) {
+	if !requireClusterAdmin(c, "only cluster admins can create roles") {
+		return
+	}
 	role := &model.Role{}
-	if err := c.BindJSON(role); err != nil {
+	if err := validation.BindJSON(c, role); err != nil {
 		common.ResponseFailed(c, http.StatusInternalServerError, err)
 		return
 	}
@@ -63,6 +67,7 @@ func (rbac *RBACController) Create(c *gin.Context) {
 		common.ResponseFailed(c, http.StatusInternalServerError, err)
 		return
 	}
+	common.SetETag(c, role.ResourceVersion)
 	common.ResponseSuccess(c, role)
 
 }
@@ -82,11 +87,12 @@ func (rbac *RBACController) Get(c *gin.Context) {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
+	common.SetETag(c, role.ResourceVersion)
 	common.ResponseSuccess(c, role)
 }
 
 // @Summary Update rbac role | rbac 修改角色
-// @Description Update rbac role | rbac 修改角色
+// @Description Update rbac role, only cluster admins | rbac 修改角色，只有管理员可以修改
 // @Accept json
 // @Produce json
 // @Tags rbac
@@ -94,32 +100,89 @@ func (rbac *RBACController) Get(c *gin.Context) {
 // @Param role body model.Role true "rbac role info"
 // @Success 200 {object} common.Response{data=model.Role}
 // @Param id path int true "role id"
+// @Param If-Match header string false "resource version, 412 if not match"
 // @Router /api/v1/roles/{id} [put]
 func (rbac *RBACController) Update(c *gin.Context) {
+	if !requireClusterAdmin(c, "only cluster admins can update roles") {
+		return
+	}
 	role := &model.Role{}
-	if err := c.BindJSON(role); err != nil {
+	if err := validation.BindJSON(c, role); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
+	// If-Match 优先于请求体中的 resourceVersion
+	version, err := common.IfMatch(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	if version != 0 {
+		role.ResourceVersion = version
+	}
 	id := c.Param("id")
-	role, err := rbac.rbacService.Update(id, role)
+	role, err = rbac.rbacService.Update(id, role)
 	if err != nil {
 		common.ResponseFailed(c, http.StatusInternalServerError, err)
 		return
 	}
+	common.SetETag(c, role.ResourceVersion)
+	common.ResponseSuccess(c, role)
+}
+
+// @Summary Patch rbac role | 修改角色的部分信息
+// @Description Patch role with JSON Merge Patch or JSON Patch, only name, scope, namespace and rules can be patched, only cluster admins | 使用 JSON Merge Patch 或 JSON Patch 修改 role，只能修改 name、scope、namespace、rules，只有管理员可以修改
+// @Accept application/merge-patch+json,application/json-patch+json,json
+// @Produce json
+// @Tags rbac
+// @Security JWT
+// @Param patch body object true "merge patch or json patch"
+// @Param id path int true "role id"
+// @Param If-Match header string false "resource version, 412 if not match"
+// @Success 200 {object} common.Response{data=model.Role}
+// @Router /api/v1/roles/{id} [patch]
+func (rbac *RBACController) Patch(c *gin.Context) {
+	if !requireClusterAdmin(c, "only cluster admins can update roles") {
+		return
+	}
+	version, err := common.IfMatch(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	data, err := c.GetRawData()
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	role, err := rbac.rbacService.Patch(c.Param("id"), version, c.ContentType(), data)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, validation.Translate(c, err))
+		return
+	}
+	common.SetETag(c, role.ResourceVersion)
 	common.ResponseSuccess(c, role)
 }
 
 // @Summary Delete role | 删除角色
-// @Description Delete role | 删除角色
+// @Description Delete role, only cluster admins | 删除角色，只有管理员可以删除
 // @Produce json
 // @Tags rbac
 // @Security JWT
 // @Param id path int true "role id"
+// @Param If-Match header string false "resource version, 412 if not match"
 // @Success 200 {object} common.Response
 // @Router /api/v1/roles/{id} [delete]
 func (rbac *RBACController) Delete(c *gin.Context) {
-	if err := rbac.rbacService.Delete(c.Param("id")); err != nil {
+	if !requireClusterAdmin(c, "only cluster admins can delete roles") {
+		return
+	}
+	version, err := common.IfMatch(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := rbac.rbacService.Delete(c.Param("id"), version); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
@@ -175,6 +238,7 @@ func (rbac *RBACController) RegisterRoute(api *gin.RouterGroup) {
 	api.POST("/roles", rbac.Create)             // rbac.Create 处理程序函数，开始创建角色
 	api.GET("/roles/:id", rbac.Get)             // rbac.Get 获取指定id的 roles
 	api.PUT("/roles/:id", rbac.Update)          // rbac.Update 更新role
+	api.PATCH("/roles/:id", rbac.Patch)         // rbac.Patch 修改role的部分字段
 	api.DELETE("/roles/:id", rbac.Delete)       // rbac.Delete 删除role
 	api.GET("/resources", rbac.ListResources)   // rbac.ListResources 资源列表
 	api.GET("/operations", rbac.ListOperations) // rbac.ListOperations 操作列表
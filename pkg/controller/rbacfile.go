@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/utils/request"
+	"github.com/gin-gonic/gin"
+)
+
+// RBACFileController 声明式 RBAC 配置文件的控制器
+type RBACFileController struct {
+	rbacFileService service.RBACFileService
+}
+
+// NewRBACFileController 创建声明式 RBAC 配置文件的控制器
+func NewRBACFileController(rbacFileService service.RBACFileService) Controller {
+	return &RBACFileController{
+		rbacFileService: rbacFileService,
+	}
+}
+
+// @Summary Plan RBAC file | 查看 RBAC 配置文件的差异
+// @Description Read the rbac file configured in rbac.file and return the changes needed to reconcile the database without applying them, only cluster admins. Objects with the same name that are not managed by the file are reported as conflicts | 读取 rbac.file 配置的文件，返回同步到数据库需要的修改但不同步，只有管理员可以查看。不是配置文件管理的同名对象视为冲突
+// @Produce json
+// @Tags rbac
+// @Security JWT
+// @Success 200 {object} common.Response{data=model.RBACPlan}
+// @Failure 409 {object} common.Response{data=model.RBACPlan}
+// @Router /api/v1/rbac/plan [get]
+func (r *RBACFileController) Plan(c *gin.Context) {
+	r.reconcile(c, true)
+}
+
+// @Summary Reload RBAC file | 重新加载 RBAC 配置文件
+// @Description Read the rbac file again and create, update or delete the resources, roles, groups and bindings it manages in one transaction, only cluster admins. Nothing is changed when there are conflicts or dryRun is true | 重新读取配置文件，在一个事务中创建、修改或删除它管理的资源、role、group 和绑定，只有管理员可以重新加载。有冲突或 dryRun 为 true 时不修改
+// @Produce json
+// @Tags rbac
+// @Security JWT
+// @Param dryRun query bool false "only show the changes"
+// @Success 200 {object} common.Response{data=model.RBACPlan}
+// @Failure 409 {object} common.Response{data=model.RBACPlan}
+// @Router /api/v1/rbac/reload [post]
+func (r *RBACFileController) Reload(c *gin.Context) {
+	dryRun, ok := dryRunQuery(c)
+	if !ok {
+		return
+	}
+	r.reconcile(c, dryRun)
+}
+
+func (r *RBACFileController) reconcile(c *gin.Context, dryRun bool) {
+	verb := request.UpdateOperation
+	if dryRun {
+		verb = request.GetOperation
+	}
+	user, ok := authorize(c, model.RoleResource, verb)
+	if !ok {
+		return
+	}
+	plan, err := r.rbacFileService.Reload(user, dryRun)
+	var apiErr *apierrors.Error
+	if err != nil && plan != nil && errors.As(err, &apiErr) && apiErr.Reason == apierrors.ReasonConflict {
+		common.NewResponse(c, http.StatusConflict, plan, err.Error())
+		return
+	}
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, plan)
+}
+
+func (r *RBACFileController) RegisterRoute(api *gin.RouterGroup) {
+	api.GET("/rbac/plan", r.Plan)      // 查看 RBAC 配置文件的差异
+	api.POST("/rbac/reload", r.Reload) // 重新加载 RBAC 配置文件
+}
+
+func (r *RBACFileController) Name() string {
+	return "RBACFile"
+}
@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/chat"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/utils/request"
+	"chitchat4.0/pkg/utils/trace"
+	"chitchat4.0/pkg/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// RoomController 聊天室和消息控制器
+type RoomController struct {
+	roomService service.RoomService
+	hub         *chat.Hub
+	upgrader    *websocket.Upgrader
+}
+
+// NewRoomController 创建聊天室控制器，hub 用于向 WebSocket 客户端推送聊天室的消息，upgrader 检查 WebSocket 的来源
+func NewRoomController(roomService service.RoomService, hub *chat.Hub, upgrader *websocket.Upgrader) Controller {
+	return &RoomController{
+		roomService: roomService,
+		hub:         hub,
+		upgrader:    upgrader,
+	}
+}
+
+// @Summary List rooms | 聊天室列表
+// @Description List rooms the user can enter, with unread counts | 获取 user 能进入的聊天室和未读消息数
+// @Produce json
+// @Tags room
+// @Security JWT
+// @Success 200 {object} common.Response{data=[]model.Room}
+// @Router /api/v1/rooms [get]
+func (r *RoomController) List(c *gin.Context) {
+	user, ok := authorize(c, model.RoomResource, request.ListOperation)
+	if !ok {
+		return
+	}
+	rooms, err := r.roomService.List(user)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, rooms)
+}
+
+// @Summary Create room | 创建聊天室
+// @Description Create a room bound to a group, or an ad-hoc room with members | 创建绑定 group 的聊天室，或者指定成员的临时聊天室
+// @Accept json
+// @Produce json
+// @Tags room
+// @Security JWT
+// @Param room body model.CreatedRoom true "room info"
+// @Success 200 {object} common.Response{data=model.Room}
+// @Router /api/v1/rooms [post]
+func (r *RoomController) Create(c *gin.Context) {
+	user, ok := authorize(c, model.RoomResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	created := new(model.CreatedRoom)
+	if err := validation.BindJSON(c, created); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+
+	common.TraceStep(c, "start create room", trace.Field{Key: "room", Value: created.Name})
+	defer common.TraceStep(c, "create room done", trace.Field{Key: "room", Value: created.Name})
+
+	room, err := r.roomService.Create(user, created)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, room)
+}
+
+// @Summary Get room | 获取聊天室
+// @Description Get room with unread count | 获取聊天室和未读消息数
+// @Produce json
+// @Tags room
+// @Security JWT
+// @Param id path int true "room id"
+// @Success 200 {object} common.Response{data=model.Room}
+// @Router /api/v1/rooms/{id} [get]
+func (r *RoomController) Get(c *gin.Context) {
+	user, ok := authorize(c, model.RoomResource, request.GetOperation)
+	if !ok {
+		return
+	}
+	room, err := r.roomService.Get(user, c.Param("id"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, room)
+}
+
+// @Summary Delete room | 删除聊天室
+// @Description Delete room and its messages, only the creator | 删除聊天室和消息，只有创建者可以删除
+// @Produce json
+// @Tags room
+// @Security JWT
+// @Param id path int true "room id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/rooms/{id} [delete]
+func (r *RoomController) Delete(c *gin.Context) {
+	user, ok := authorize(c, model.RoomResource, request.DeleteOperation)
+	if !ok {
+		return
+	}
+	if err := r.roomService.Delete(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Add member | 添加成员
+// @Description Invite user to an ad-hoc room | 邀请 user 加入临时聊天室
+// @Produce json
+// @Tags room
+// @Security JWT
+// @Param id path int true "room id"
+// @Param uid path int true "user id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/rooms/{id}/members/{uid} [post]
+func (r *RoomController) AddMember(c *gin.Context) {
+	user, ok := authorize(c, model.RoomResource, request.UpdateOperation)
+	if !ok {
+		return
+	}
+	if err := r.roomService.AddMember(user, c.Param("id"), c.Param("uid")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Delete member | 移出成员
+// @Description Remove user from an ad-hoc room, or leave it | 把 user 移出临时聊天室，或者自己退出
+// @Produce json
+// @Tags room
+// @Security JWT
+// @Param id path int true "room id"
+// @Param uid path int true "user id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/rooms/{id}/members/{uid} [delete]
+func (r *RoomController) DelMember(c *gin.Context) {
+	user, ok := authorize(c, model.RoomResource, request.UpdateOperation)
+	if !ok {
+		return
+	}
+	if err := r.roomService.DelMember(user, c.Param("id"), c.Param("uid")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary List messages | 历史消息
+// @Description List messages from newest to oldest, pass nextBefore of the previous page as before | 按从新到旧的顺序分页获取历史消息，下一页的 before 使用上一页返回的 nextBefore
+// @Produce json
+// @Tags room
+// @Security JWT
+// @Param id path int true "room id"
+// @Param before query int false "only messages with id less than before"
+// @Param limit query int false "page size, default 50, max 100"
+// @Success 200 {object} common.Response{data=model.MessagePage}
+// @Router /api/v1/rooms/{id}/messages [get]
+func (r *RoomController) ListMessages(c *gin.Context) {
+	user, ok := authorize(c, model.MessageResource, request.ListOperation)
+	if !ok {
+		return
+	}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			common.ResponseFailed(c, http.StatusBadRequest, apierrors.NewFieldInvalid("limit", "invalid limit "+strconv.Quote(value)))
+			return
+		}
+		limit = n
+	}
+	page, err := r.roomService.ListMessages(user, c.Param("id"), c.Query("before"), limit)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, page)
+}
+
+// @Summary Send message | 发送消息
+// @Description Send message to room, link previews are pushed later as message.updated | 发送消息，链接预览获取后以 message.updated 事件推送
+// @Accept json
+// @Produce json
+// @Tags room
+// @Security JWT
+// @Param id path int true "room id"
+// @Param message body model.CreatedMessage true "message"
+// @Success 200 {object} common.Response{data=model.Message}
+// @Router /api/v1/rooms/{id}/messages [post]
+func (r *RoomController) SendMessage(c *gin.Context) {
+	user, ok := authorize(c, model.MessageResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	created := new(model.CreatedMessage)
+	if err := validation.BindJSON(c, created); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	message, err := r.roomService.SendMessage(user, c.Param("id"), created)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, message)
+}
+
+// @Summary Mark read | 标记已读
+// @Description Mark messages up to messageId as read, all messages when messageId is empty | 把 messageId 及之前的消息标记为已读，messageId 为空时全部标记已读
+// @Produce json
+// @Tags room
+// @Security JWT
+// @Param id path int true "room id"
+// @Param messageId query int false "last read message id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/rooms/{id}/read [post]
+func (r *RoomController) MarkRead(c *gin.Context) {
+	user, ok := authorize(c, model.MessageResource, request.UpdateOperation)
+	if !ok {
+		return
+	}
+	if err := r.roomService.MarkRead(user, c.Param("id"), c.Query("messageId")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Connect room | 连接聊天室
+// @Description Upgrade to WebSocket and receive message and message.updated events of room | 升级为 WebSocket，接收聊天室的 message、message.updated 事件
+// @Tags room
+// @Security JWT
+// @Param id path int true "room id"
+// @Success 101 {object} chat.Event
+// @Router /api/v1/rooms/{id}/ws [get]
+func (r *RoomController) Connect(c *gin.Context) {
+	user, ok := authorize(c, model.MessageResource, request.WatchOperation)
+	if !ok {
+		return
+	}
+	room, err := r.roomService.Join(user, c.Param("id"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	conn, err := r.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logrus.Warnf("upgrade room connection failed: %v", err)
+		return
+	}
+	r.hub.Serve(conn, room.ID)
+}
+
+func (r *RoomController) RegisterRoute(api *gin.RouterGroup) {
+	api.GET("/rooms", r.List)                          // 聊天室列表
+	api.POST("/rooms", r.Create)                       // 创建聊天室
+	api.GET("/rooms/:id", r.Get)                       // 获取聊天室
+	api.DELETE("/rooms/:id", r.Delete)                 // 删除聊天室
+	api.POST("/rooms/:id/members/:uid", r.AddMember)   // 邀请 user 加入临时聊天室
+	api.DELETE("/rooms/:id/members/:uid", r.DelMember) // 移出临时聊天室的成员
+	api.GET("/rooms/:id/messages", r.ListMessages)     // 历史消息
+	api.POST("/rooms/:id/messages", r.SendMessage)     // 发送消息
+	api.POST("/rooms/:id/read", r.MarkRead)            // 标记已读
+	api.GET("/rooms/:id/ws", r.Connect)                // 实时接收聊天室的消息
+}
+
+func (r *RoomController) Name() string {
+	return "Room"
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionController 登录会话控制器
+type SessionController struct {
+	sessionService service.SessionService
+}
+
+// NewSessionController 创建登录会话控制器
+func NewSessionController(sessionService service.SessionService) Controller {
+	return &SessionController{
+		sessionService: sessionService,
+	}
+}
+
+// @Summary List sessions | 会话列表
+// @Description List login sessions of user | 获取 user 登录的设备、IP 等会话信息
+// @Produce json
+// @Tags session
+// @Security JWT
+// @Param id path int true "user id"
+// @Success 200 {object} common.Response{data=[]model.Session}
+// @Router /api/v1/users/{id}/sessions [get]
+func (s *SessionController) List(c *gin.Context) {
+	if !canManageSessions(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can not manage sessions of user %s", c.Param("id")))
+		return
+	}
+	sessions, err := s.sessionService.List(c.Param("id"), common.GetSession(c))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, sessions)
+}
+
+// @Summary Revoke session | 撤销会话
+// @Description Revoke a single session, its token becomes invalid immediately | 撤销单个会话（远程退出），对应的 token 立即失效
+// @Produce json
+// @Tags session
+// @Security JWT
+// @Param id path int true "user id"
+// @Param sid path string true "session id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/users/{id}/sessions/{sid} [delete]
+func (s *SessionController) Delete(c *gin.Context) {
+	if !canManageSessions(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can not manage sessions of user %s", c.Param("id")))
+		return
+	}
+	if err := s.sessionService.Delete(c.Param("id"), c.Param("sid")); err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary Revoke all sessions | 撤销全部会话
+// @Description Revoke all sessions of user | 撤销 user 的全部会话（在所有设备上退出）
+// @Produce json
+// @Tags session
+// @Security JWT
+// @Param id path int true "user id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/users/{id}/sessions [delete]
+func (s *SessionController) DeleteAll(c *gin.Context) {
+	if !canManageSessions(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can not manage sessions of user %s", c.Param("id")))
+		return
+	}
+	if err := s.sessionService.DeleteAll(c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// canManageSessions user 自己或管理员可以管理会话
+func canManageSessions(c *gin.Context) bool {
+	return isSelf(c) || authorization.IsClusterAdmin(common.GetUser(c))
+}
+
+func (s *SessionController) RegisterRoute(api *gin.RouterGroup) {
+	api.GET("/users/:id/sessions", s.List)           // 会话列表
+	api.DELETE("/users/:id/sessions", s.DeleteAll)   // 撤销全部会话
+	api.DELETE("/users/:id/sessions/:sid", s.Delete) // 撤销单个会话
+}
+
+func (s *SessionController) Name() string {
+	return "Session"
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/utils/trace"
+	"chitchat4.0/pkg/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessTokenController 个人访问 token 控制器
+type AccessTokenController struct {
+	tokenService service.AccessTokenService
+}
+
+// NewAccessTokenController 创建个人访问 token 控制器
+func NewAccessTokenController(tokenService service.AccessTokenService) Controller {
+	return &AccessTokenController{
+		tokenService: tokenService,
+	}
+}
+
+// @Summary List access tokens | 访问 token 列表
+// @Description List access tokens of user | 获取 user 的访问 token 列表
+// @Produce json
+// @Tags token
+// @Security JWT
+// @Param id path int true "user id"
+// @Success 200 {object} common.Response{data=[]model.AccessToken}
+// @Router /api/v1/users/{id}/tokens [get]
+func (t *AccessTokenController) List(c *gin.Context) {
+	if !canManageTokens(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can not manage access tokens of user %s", c.Param("id")))
+		return
+	}
+	tokens, err := t.tokenService.List(c.Param("id"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, tokens)
+}
+
+// @Summary Create access token | 创建访问 token
+// @Description Create scoped access token for yourself, or as a cluster admin for another user, bound roles must be owned by both users, token is returned only once | 给自己创建，或者管理员给其他 user 创建绑定角色的访问 token，绑定的角色必须是两个 user 都拥有的，token 明文只返回一次
+// @Accept json
+// @Produce json
+// @Tags token
+// @Security JWT
+// @Param id path int true "user id"
+// @Param token body model.CreatedAccessToken true "token info"
+// @Success 200 {object} common.Response{data=model.IssuedAccessToken}
+// @Router /api/v1/users/{id}/tokens [post]
+func (t *AccessTokenController) Create(c *gin.Context) {
+	if !canManageTokens(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can not manage access tokens of user %s", c.Param("id")))
+		return
+	}
+	created := new(model.CreatedAccessToken)
+	if err := validation.BindJSON(c, created); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+
+	common.TraceStep(c, "start create access token", trace.Field{Key: "token", Value: created.Name})
+	defer common.TraceStep(c, "create access token done", trace.Field{Key: "token", Value: created.Name})
+
+	token, err := t.tokenService.Create(common.GetUser(c), c.Param("id"), created)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, token)
+}
+
+// @Summary Delete access token | 删除访问 token
+// @Description Delete access token of user | 删除 user 的访问 token
+// @Produce json
+// @Tags token
+// @Security JWT
+// @Param id path int true "user id"
+// @Param tid path int true "token id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/users/{id}/tokens/{tid} [delete]
+func (t *AccessTokenController) Delete(c *gin.Context) {
+	if !canManageTokens(c) {
+		common.ResponseFailed(c, http.StatusForbidden, fmt.Errorf("can not manage access tokens of user %s", c.Param("id")))
+		return
+	}
+	if err := t.tokenService.Delete(c.Param("id"), c.Param("tid")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// canManageTokens user 自己或管理员（服务账号无法登录，由管理员管理）可以管理访问 token
+func canManageTokens(c *gin.Context) bool {
+	return isSelf(c) || authorization.IsClusterAdmin(common.GetUser(c))
+}
+
+func (t *AccessTokenController) RegisterRoute(api *gin.RouterGroup) {
+	api.GET("/users/:id/tokens", t.List)           // 访问 token 列表
+	api.POST("/users/:id/tokens", t.Create)        // 创建访问 token
+	api.DELETE("/users/:id/tokens/:tid", t.Delete) // 删除访问 token
+}
+
+func (t *AccessTokenController) Name() string {
+	return "AccessToken"
+}
@@ -9,6 +9,7 @@ import (
 	"chitchat4.0/pkg/model"
 	"chitchat4.0/pkg/service"
 	"chitchat4.0/pkg/utils/trace"
+	"chitchat4.0/pkg/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -40,7 +41,7 @@ func (u *UserController) Create(c *gin.Context) {
 	// 准备一个空的结构模型 createdUser
 	createdUser := new(model.CreatedUser)
 	// 把接收到的数据绑定到 createdUser
-	if err := c.BindJSON(createdUser); err != nil {
+	if err := validation.BindJSON(c, createdUser); err != nil {
 		// 数据绑定失败时，做出响应
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
@@ -62,8 +63,9 @@ func (u *UserController) Create(c *gin.Context) {
 	user, err := u.userService.Create(user)
 	if err != nil {
 		common.ResponseFailed(c, http.StatusInternalServerError, err)
-		// return  需要确认是否添加
+		return
 	}
+	common.SetETag(c, user.ResourceVersion)
 	common.ResponseSuccess(c, user)
 }
 
@@ -82,6 +84,7 @@ func (u *UserController) Get(c *gin.Context) {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
+	common.SetETag(c, user.ResourceVersion)
 	common.ResponseSuccess(c, user)
 }
 
@@ -111,6 +114,7 @@ func (u *UserController) List(c *gin.Context) {
 // @Security JWT
 // @Param user body model.UpdatedUser true "user info"
 // @Param id path  int true "user id"
+// @Param If-Match header string false "resource version, 412 if not match"
 // @Success 200 {object} common.Response{data=model.User}
 // @Router /api/v1/users/{id} [put]
 func (u *UserController) Update(c *gin.Context) {
@@ -130,21 +134,69 @@ func (u *UserController) Update(c *gin.Context) {
 	// 是自己修改自己，直接允许去修改
 	// 要修改的 user 信息
 	new := new(model.UpdatedUser)
-	if err := c.BindJSON(new); err != nil {
+	if err := validation.BindJSON(c, new); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
 	logrus.Infof("已获取修改的 user: %#v", new.Name)
+	version, err := common.IfMatch(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
 
 	common.TraceStep(c, "start update user", trace.Field{Key: "user", Value: new.Name})
 	defer common.TraceStep(c, "update user done", trace.Field{Key: "user", Value: new.Name})
 
 	// 修改user信息
-	user, err := u.userService.Update(c.Param("id"), new.GetUser())
+	updated := new.GetUser()
+	updated.ResourceVersion = version
+	user, err = u.userService.Update(c.Param("id"), updated)
 	if err != nil {
 		common.ResponseFailed(c, http.StatusInternalServerError, err)
 		return
 	}
+	common.SetETag(c, user.ResourceVersion)
+	common.ResponseSuccess(c, user)
+}
+
+// @Summary Patch user | 修改用户的部分信息
+// @Description Patch user with JSON Merge Patch or JSON Patch, only name, email and avatar can be patched | 使用 JSON Merge Patch 或 JSON Patch 修改 user，只能修改 name、email、avatar
+// @Accept application/merge-patch+json,application/json-patch+json,json
+// @Produce json
+// @Tags user
+// @Security JWT
+// @Param patch body object true "merge patch or json patch"
+// @Param id path int true "user id"
+// @Param If-Match header string false "resource version, 412 if not match"
+// @Success 200 {object} common.Response{data=model.User}
+// @Router /api/v1/users/{id} [patch]
+func (u *UserController) Patch(c *gin.Context) {
+	user := common.GetUser(c)
+	if user == nil || (strconv.Itoa(int(user.ID)) != c.Param("id") && !authorization.IsClusterAdmin(user)) {
+		common.ResponseFailed(c, http.StatusForbidden, nil)
+		return
+	}
+	version, err := common.IfMatch(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	data, err := c.GetRawData()
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+
+	common.TraceStep(c, "start patch user", trace.Field{Key: "id", Value: c.Param("id")})
+	defer common.TraceStep(c, "patch user done", trace.Field{Key: "id", Value: c.Param("id")})
+
+	user, err = u.userService.Patch(c.Param("id"), version, c.ContentType(), data)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, validation.Translate(c, err))
+		return
+	}
+	common.SetETag(c, user.ResourceVersion)
 	common.ResponseSuccess(c, user)
 }
 
@@ -154,16 +206,22 @@ func (u *UserController) Update(c *gin.Context) {
 // @Tags user
 // @Security JWT
 // @Param id path int true "user id"
+// @Param If-Match header string false "resource version, 412 if not match"
 // @Success 200 {object} common.Response
 // @Router /api/v1/users/{id} [delete]
 func (u *UserController) Delete(c *gin.Context) {
 	user := common.GetUser(c)
 	if user == nil || (strconv.Itoa(int(user.ID))) != c.Param("id") && !authorization.IsClusterAdmin(user) {
-		common.ResponseFailed(c, http.StatusBadRequest, nil)
+		common.ResponseFailed(c, http.StatusForbidden, nil)
 		return
 	}
 
-	if err := u.userService.Delete(c.Param("id")); err != nil {
+	version, err := common.IfMatch(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := u.userService.Delete(c.Param("id"), version); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
 	}
@@ -190,7 +248,7 @@ func (u *UserController) GetGroups(c *gin.Context) {
 }
 
 // @Summary Add role | 添加角色
-// @Description Add role to user | 给user添加角色
+// @Description Add role to user, only cluster admins | 给user添加角色，只有管理员可以添加
 // @Produce json
 // @Tags user
 // @Security JWT
@@ -199,6 +257,9 @@ func (u *UserController) GetGroups(c *gin.Context) {
 // @Success 200 {object} common.Response
 // @Router /api/v1/users/{id}/roles/{rid} [post]
 func (u *UserController) AddRole(c *gin.Context) {
+	if !requireClusterAdmin(c, "only cluster admins can grant roles") {
+		return
+	}
 	if err := u.userService.AddRole(c.Param("id"), c.Param("rid")); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
@@ -207,7 +268,7 @@ func (u *UserController) AddRole(c *gin.Context) {
 }
 
 // @Summary Delete role | 删除角色
-// @Description delete role from user | 删除user的role
+// @Description delete role from user, only cluster admins | 删除user的role，只有管理员可以删除
 // @Produce json
 // @Tags user
 // @Security JWT
@@ -216,6 +277,9 @@ func (u *UserController) AddRole(c *gin.Context) {
 // @Success 200 {object} common.Response
 // @Router /api/v1/users/{id}/roles/{rid} [delete]
 func (u *UserController) DelRole(c *gin.Context) {
+	if !requireClusterAdmin(c, "only cluster admins can revoke roles") {
+		return
+	}
 	if err := u.userService.DelRole(c.Param("id"), c.Param("rid")); err != nil {
 		common.ResponseFailed(c, http.StatusBadRequest, err)
 		return
@@ -229,6 +293,7 @@ func (u *UserController) RegisterRoute(api *gin.RouterGroup) {
 	api.POST("/users", u.Create)                   // 创建用户
 	api.GET("/users/:id", u.Get)                   // 查询某个用户
 	api.PUT("/users/:id", u.Update)                // 修改用户信息
+	api.PATCH("/users/:id", u.Patch)               // 修改用户的部分信息
 	api.DELETE("/users/:id", u.Delete)             // 删除 user
 	api.GET("/users/:id/groups", u.GetGroups)      // user 的全部 group
 	api.POST("/users/:id/roles/:rid", u.AddRole)   // 给user添加role
@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/utils/request"
+	"chitchat4.0/pkg/watch"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const heartbeatInterval = 30 * time.Second // SSE 心跳间隔，防止代理断开空闲连接
+
+// watchResources 可以 watch 的资源
+var watchResources = map[string]bool{
+	model.UserResource:      true,
+	model.GroupResource:     true,
+	model.RoleResource:      true,
+	model.TagResource:       true,
+	model.HotSearchResource: true,
+}
+
+// WatchController 资源变更事件控制器，通过 SSE 或 WebSocket 推送事件
+type WatchController struct {
+	events   *watch.Broadcaster
+	upgrader *websocket.Upgrader
+}
+
+// NewWatchController 创建 watch 控制器，events 是仓库发布资源变更事件的广播器，upgrader 检查 WebSocket 的来源
+func NewWatchController(events *watch.Broadcaster, upgrader *websocket.Upgrader) Controller {
+	return &WatchController{
+		events:   events,
+		upgrader: upgrader,
+	}
+}
+
+// @Summary Watch resource | 监听资源变更
+// @Description Stream change events of resource with SSE, or WebSocket when the request is an upgrade. Resume after resourceVersion (or Last-Event-ID), 410 if it is too old. Only visible events are sent: user events to the user, group events to the creator and members, role events to users owning the role, and all events to cluster admins | 使用 SSE 推送资源的变更事件，请求为 WebSocket 升级时使用 WebSocket；从 resourceVersion（或 Last-Event-ID）之后继续，序号太旧时返回 410。只推送 user 能看到的事件：user 事件只推送给本人，group 事件推送给创建者和成员，role 事件推送给拥有该角色的 user，管理员可以收到全部事件
+// @Produce text/event-stream
+// @Tags watch
+// @Security JWT
+// @Param resource path string true "resource" Enums(users, groups, roles, tags, hotsearches)
+// @Param resourceVersion query int false "resume after this resource version"
+// @Param Last-Event-ID header string false "resume after this event id (SSE)"
+// @Success 200 {object} model.Event
+// @Router /api/v1/watch/{resource} [get]
+func (w *WatchController) Watch(c *gin.Context) {
+	user := common.GetUser(c)
+	if user == nil {
+		common.ResponseFailed(c, http.StatusUnauthorized, apierrors.NewUnauthenticated("watch requires login"))
+		return
+	}
+	resource := c.Param("resource")
+	if !watchResources[resource] {
+		common.ResponseFailed(c, http.StatusNotFound, apierrors.NewNotFound("resource", resource))
+		return
+	}
+	since, err := resourceVersion(c)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+
+	watcher, err := w.events.Watch(resource, since)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusGone, err)
+		return
+	}
+	defer watcher.Stop()
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		w.serveWebSocket(c, user, watcher)
+		return
+	}
+	w.serveSSE(c, user, watcher)
+}
+
+// serveSSE 以 text/event-stream 格式推送事件，事件序号写入 id 字段，断线重连时浏览器会带上 Last-Event-ID
+func (w *WatchController) serveSSE(c *gin.Context, user *model.User, watcher *watch.Watcher) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			if !visible(user, event) {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				logrus.Errorf("marshal %s event failed: %v", event.Resource, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ResourceVersion, event.Type, data); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// serveWebSocket 升级为 WebSocket 连接，每个事件作为一条 JSON 消息发送
+func (w *WatchController) serveWebSocket(c *gin.Context, user *model.User, watcher *watch.Watcher) {
+	conn, err := w.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logrus.Warnf("upgrade watch connection failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// 读取客户端消息，客户端关闭连接时结束 watch
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "watch expired"))
+				return
+			}
+			if !visible(user, event) {
+				continue
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// visible 判断 user 能不能看到事件：管理员可以看到全部事件；user 事件只推送给本人，
+// group 事件只推送给创建者和成员，role 事件只推送给拥有该角色的 user，tag 和热搜对登录的 user 公开
+func visible(user *model.User, event model.Event) bool {
+	ok, err := authorization.Authorize(user, &request.RequestInfo{
+		IsResourceRequest: true,
+		Verb:              request.GetOperation,
+		Resource:          event.Resource,
+		Name:              strconv.Itoa(int(event.ObjectID)),
+	})
+	if err != nil {
+		logrus.Warnf("authorize %s event for user %s failed: %v", event.Resource, user.Name, err)
+	}
+	if !ok {
+		return false
+	}
+	if authorization.IsClusterAdmin(user) {
+		return true
+	}
+
+	switch event.Resource {
+	case model.UserResource:
+		return event.ObjectID == user.ID
+	case model.GroupResource:
+		if group, ok := event.Object.(*model.Group); ok && group.CreatorId == user.ID {
+			return true
+		}
+		for _, g := range user.Groups {
+			if g.ID == event.ObjectID {
+				return true
+			}
+		}
+		return false
+	case model.RoleResource:
+		for _, role := range user.Roles {
+			if role.ID == event.ObjectID {
+				return true
+			}
+		}
+		for _, g := range user.Groups {
+			for _, role := range g.Roles {
+				if role.ID == event.ObjectID {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// resourceVersion 获取继续 watch 的起始序号，query 参数优先，其次是 SSE 重连时的 Last-Event-ID 请求头
+func resourceVersion(c *gin.Context) (uint64, error) {
+	value := c.Query("resourceVersion")
+	if value == "" {
+		value = c.GetHeader("Last-Event-ID")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	version, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, apierrors.NewBadRequest("invalid resourceVersion " + strconv.Quote(value))
+	}
+	return version, nil
+}
+
+func (w *WatchController) RegisterRoute(api *gin.RouterGroup) {
+	api.GET("/watch/:resource", w.Watch) // 监听资源变更
+}
+
+func (w *WatchController) Name() string {
+	return "Watch"
+}
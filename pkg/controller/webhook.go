@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/service"
+	"chitchat4.0/pkg/utils/request"
+	"chitchat4.0/pkg/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookController webhook 控制器
+type WebhookController struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookController 创建 webhook 控制器
+func NewWebhookController(webhookService service.WebhookService) Controller {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+// @Summary List webhooks | webhook 列表
+// @Description List webhooks without secrets, only cluster admins | 获取 webhook 列表，不包含签名密钥，只有管理员可以查看
+// @Produce json
+// @Tags webhook
+// @Security JWT
+// @Success 200 {object} common.Response{data=[]model.Webhook}
+// @Router /api/v1/webhooks [get]
+func (w *WebhookController) List(c *gin.Context) {
+	user, ok := authorize(c, model.WebhookResource, request.ListOperation)
+	if !ok {
+		return
+	}
+	hooks, err := w.webhookService.List(user)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, hooks)
+}
+
+// @Summary Create webhook | 创建 webhook
+// @Description Create webhook, only cluster admins. Events are *, <resource>.* or <resource>.<added|modified|deleted> of users, groups, roles, tags and hotsearches. Requests are signed with X-Chitchat-Signature: sha256=HMAC-SHA256(secret, timestamp + "." + body); the secret is generated when empty and only returned here | 创建 webhook，只有管理员可以创建。事件格式为 *、<resource>.* 或 <resource>.<added|modified|deleted>，资源可以是 users、groups、roles、tags 和 hotsearches。请求使用 X-Chitchat-Signature 签名：sha256=HMAC-SHA256(secret, timestamp + "." + body)；密钥为空时生成，只在这里返回
+// @Accept json
+// @Produce json
+// @Tags webhook
+// @Security JWT
+// @Param webhook body model.CreatedWebhook true "webhook"
+// @Success 200 {object} common.Response{data=model.Webhook}
+// @Router /api/v1/webhooks [post]
+func (w *WebhookController) Create(c *gin.Context) {
+	user, ok := authorize(c, model.WebhookResource, request.CreateOperation)
+	if !ok {
+		return
+	}
+	created := new(model.CreatedWebhook)
+	if err := validation.BindJSON(c, created); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	hook, err := w.webhookService.Create(user, created)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, hook)
+}
+
+// @Summary Get webhook | 获取 webhook
+// @Description Get webhook without secret, only cluster admins | 获取 webhook，不包含签名密钥，只有管理员可以查看
+// @Produce json
+// @Tags webhook
+// @Security JWT
+// @Param id path int true "webhook id"
+// @Success 200 {object} common.Response{data=model.Webhook}
+// @Router /api/v1/webhooks/{id} [get]
+func (w *WebhookController) Get(c *gin.Context) {
+	user, ok := authorize(c, model.WebhookResource, request.GetOperation)
+	if !ok {
+		return
+	}
+	hook, err := w.webhookService.Get(user, c.Param("id"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, hook)
+}
+
+// @Summary Update webhook | 修改 webhook
+// @Description Replace webhook, only cluster admins. The secret is kept when empty | 修改 webhook，只有管理员可以修改；签名密钥为空时不修改
+// @Accept json
+// @Produce json
+// @Tags webhook
+// @Security JWT
+// @Param id path int true "webhook id"
+// @Param webhook body model.CreatedWebhook true "webhook"
+// @Success 200 {object} common.Response{data=model.Webhook}
+// @Router /api/v1/webhooks/{id} [put]
+func (w *WebhookController) Update(c *gin.Context) {
+	user, ok := authorize(c, model.WebhookResource, request.UpdateOperation)
+	if !ok {
+		return
+	}
+	updated := new(model.CreatedWebhook)
+	if err := validation.BindJSON(c, updated); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	hook, err := w.webhookService.Update(user, c.Param("id"), updated)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, hook)
+}
+
+// @Summary Delete webhook | 删除 webhook
+// @Description Delete webhook and its deliveries, pending deliveries are dropped, only cluster admins | 删除 webhook 和它的投递记录，未投递的事件不再投递，只有管理员可以删除
+// @Produce json
+// @Tags webhook
+// @Security JWT
+// @Param id path int true "webhook id"
+// @Success 200 {object} common.Response
+// @Router /api/v1/webhooks/{id} [delete]
+func (w *WebhookController) Delete(c *gin.Context) {
+	user, ok := authorize(c, model.WebhookResource, request.DeleteOperation)
+	if !ok {
+		return
+	}
+	if err := w.webhookService.Delete(user, c.Param("id")); err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, nil)
+}
+
+// @Summary List deliveries | 投递记录
+// @Description List recent deliveries of webhook from newest to oldest, only cluster admins | 按从新到旧的顺序获取 webhook 最近的投递记录，只有管理员可以查看
+// @Produce json
+// @Tags webhook
+// @Security JWT
+// @Param id path int true "webhook id"
+// @Param limit query int false "size, default 50, max 200"
+// @Success 200 {object} common.Response{data=[]model.WebhookDelivery}
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (w *WebhookController) ListDeliveries(c *gin.Context) {
+	user, ok := authorize(c, model.WebhookResource, request.GetOperation)
+	if !ok {
+		return
+	}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			common.ResponseFailed(c, http.StatusBadRequest, apierrors.NewFieldInvalid("limit", "invalid limit "+strconv.Quote(value)))
+			return
+		}
+		limit = n
+	}
+	deliveries, err := w.webhookService.ListDeliveries(user, c.Param("id"), limit)
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, deliveries)
+}
+
+// @Summary Get delivery | 获取投递记录
+// @Description Get delivery of webhook with payload and last response, only cluster admins | 获取 webhook 的投递记录，包括请求体和最后一次的响应，只有管理员可以查看
+// @Produce json
+// @Tags webhook
+// @Security JWT
+// @Param id path int true "webhook id"
+// @Param deliveryId path int true "delivery id"
+// @Success 200 {object} common.Response{data=model.WebhookDelivery}
+// @Router /api/v1/webhooks/{id}/deliveries/{deliveryId} [get]
+func (w *WebhookController) GetDelivery(c *gin.Context) {
+	user, ok := authorize(c, model.WebhookResource, request.GetOperation)
+	if !ok {
+		return
+	}
+	delivery, err := w.webhookService.GetDelivery(user, c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, delivery)
+}
+
+// @Summary Redeliver | 重新投递
+// @Description Send the payload of delivery again as a new delivery, only cluster admins | 用投递记录的请求体重新投递，生成一条新的投递记录，只有管理员可以操作
+// @Produce json
+// @Tags webhook
+// @Security JWT
+// @Param id path int true "webhook id"
+// @Param deliveryId path int true "delivery id"
+// @Success 200 {object} common.Response{data=model.WebhookDelivery}
+// @Router /api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (w *WebhookController) Redeliver(c *gin.Context) {
+	user, ok := authorize(c, model.WebhookResource, request.UpdateOperation)
+	if !ok {
+		return
+	}
+	delivery, err := w.webhookService.Redeliver(user, c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		common.ResponseFailed(c, http.StatusBadRequest, err)
+		return
+	}
+	common.ResponseSuccess(c, delivery)
+}
+
+func (w *WebhookController) RegisterRoute(api *gin.RouterGroup) {
+	api.GET("/webhooks", w.List)                                            // webhook 列表
+	api.POST("/webhooks", w.Create)                                         // 创建 webhook
+	api.GET("/webhooks/:id", w.Get)                                         // 获取 webhook
+	api.PUT("/webhooks/:id", w.Update)                                      // 修改 webhook
+	api.DELETE("/webhooks/:id", w.Delete)                                   // 删除 webhook
+	api.GET("/webhooks/:id/deliveries", w.ListDeliveries)                   // 投递记录
+	api.GET("/webhooks/:id/deliveries/:deliveryId", w.GetDelivery)          // 获取投递记录
+	api.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", w.Redeliver) // 重新投递
+}
+
+func (w *WebhookController) Name() string {
+	return "Webhook"
+}
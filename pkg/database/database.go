@@ -0,0 +1,24 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"chitchat4.0/pkg/config"
+	"gorm.io/gorm"
+)
+
+// ErrSQLiteUnavailable 编译时没有启用 cgo，不能使用 SQLite
+var ErrSQLiteUnavailable = errors.New("sqlite requires cgo, build with CGO_ENABLED=1")
+
+// New 根据配置的 driver 连接 PostgreSQL 或 SQLite，driver 为空时使用 PostgreSQL
+func New(conf *config.DBConfig) (*gorm.DB, error) {
+	switch conf.Driver {
+	case "", config.DriverPostgres:
+		return NewPostgres(conf)
+	case config.DriverSQLite:
+		return NewSQLite(conf)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", conf.Driver)
+	}
+}
@@ -1,19 +1,151 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 
 	"chitchat4.0/pkg/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+const (
+	defaultSSLMode  = "disable"
+	defaultTimeZone = "Asia/Shanghai"
+
+	replicaResolver = "replica" // 只读副本的 dbresolver 名称
 )
 
 // NewPostgres 接收一个 *config.DBConfig 类型的参数。
 // 参数是应用配置 Config 中的子配置 DB ，DB 具有 PostgreSQL 数据库 Host、port 等信息。
-// 作用初始化数据库连接
+// 作用初始化数据库连接，设置连接池；配置了只读副本时，只有通过 Replica 指定的查询使用只读副本。
+// 连接池的状态导出到 /metrics，db_name 为 source 或 replica
 // 返回一个PostgreSQL数据库的 *gorm.DB 结构体 和 error
 func NewPostgres(conf *config.DBConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable TimeZone=Asia/Shanghai",
-		conf.Host, conf.User, conf.Password, conf.Name, conf.Port)
-	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(DSN(conf)), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	source, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	setPool(source, conf)
+	if err := registerDBStats(source, "source"); err != nil {
+		return nil, err
+	}
+
+	if conf.ReplicaHost == "" {
+		return db, nil
+	}
+	replica, err := sql.Open("pgx", ReplicaDSN(conf))
+	if err != nil {
+		return nil, fmt.Errorf("open replica: %w", err)
+	}
+	if err := replica.Ping(); err != nil {
+		return nil, fmt.Errorf("connect replica: %w", err)
+	}
+	setPool(replica, conf)
+	if err := registerDBStats(replica, "replica"); err != nil {
+		return nil, err
+	}
+	// 只注册有名称的 resolver，没有指定的查询仍然使用主库，写入后立即读取不会读到复制延迟前的数据
+	err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{postgres.New(postgres.Config{Conn: replica})},
+	}, replicaResolver))
+	return db, err
+}
+
+// Replica 返回使用只读副本查询的 db，只能用于可以容忍复制延迟的读取，例如热搜榜和订阅源；
+// 没有配置只读副本时使用主库
+func Replica(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Use(replicaResolver))
+}
+
+// DSN 根据配置生成 PostgreSQL 的连接字符串，statement_timeout 作为会话参数在连接时设置
+func DSN(conf *config.DBConfig) string {
+	return dsn(conf, conf.Host, conf.Port)
+}
+
+// ReplicaDSN 生成只读副本的连接字符串，除了主机和端口之外和主库相同
+func ReplicaDSN(conf *config.DBConfig) string {
+	port := conf.ReplicaPort
+	if port == 0 {
+		port = conf.Port
+	}
+	return dsn(conf, conf.ReplicaHost, port)
+}
+
+func dsn(conf *config.DBConfig, host string, port int) string {
+	params := [][2]string{
+		{"host", host},
+		{"user", conf.User},
+		{"password", conf.Password},
+		{"dbname", conf.Name},
+		{"port", strconv.Itoa(port)},
+		{"sslmode", withDefault(conf.SSLMode, defaultSSLMode)},
+		{"sslrootcert", conf.SSLRootCert},
+		{"sslcert", conf.SSLCert},
+		{"sslkey", conf.SSLKey},
+		{"TimeZone", withDefault(conf.TimeZone, defaultTimeZone)},
+	}
+	if conf.ConnectTimeout > 0 {
+		// connect_timeout 的单位是秒，不足 1 秒时按 1 秒处理
+		seconds := math.Ceil(conf.ConnectTimeout.Seconds())
+		params = append(params, [2]string{"connect_timeout", strconv.Itoa(int(seconds))})
+	}
+	if conf.StatementTimeout > 0 {
+		params = append(params, [2]string{"statement_timeout", strconv.FormatInt(conf.StatementTimeout.Milliseconds(), 10)})
+	}
+
+	parts := make([]string, 0, len(params))
+	for _, param := range params {
+		if param[1] != "" {
+			parts = append(parts, param[0]+"="+quoteDSNValue(param[1]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSNValue 值为空或包含空格、引号和反斜杠时使用单引号包围并转义
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+func setPool(db *sql.DB, conf *config.DBConfig) {
+	db.SetMaxOpenConns(conf.MaxOpenConns)
+	if conf.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(conf.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(conf.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(conf.ConnMaxIdleTime)
+}
+
+// registerDBStats 把连接池的状态注册到 prometheus，已经注册过同名的连接池时忽略
+func registerDBStats(db *sql.DB, name string) error {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, name))
+	var registered prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &registered) {
+		return err
+	}
+	return nil
+}
+
+func withDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
 }
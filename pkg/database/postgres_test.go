@@ -0,0 +1,34 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"chitchat4.0/pkg/config"
+)
+
+func TestReplicaDSN(t *testing.T) {
+	conf := &config.DBConfig{
+		Host:             "primary",
+		Port:             5432,
+		Name:             "chitchat",
+		User:             "root",
+		Password:         "pass word",
+		StatementTimeout: 30 * time.Second,
+		ReplicaHost:      "replica",
+	}
+	want := "host=replica user=root password='pass word' dbname=chitchat port=5432 sslmode=disable TimeZone=Asia/Shanghai statement_timeout=30000"
+	if got := ReplicaDSN(conf); got != want {
+		t.Fatalf("ReplicaDSN = %q, want %q", got, want)
+	}
+
+	conf.ReplicaPort = 5433
+	got := ReplicaDSN(conf)
+	if !strings.Contains(got, "host=replica ") || !strings.Contains(got, " port=5433 ") {
+		t.Fatalf("ReplicaDSN with port = %q", got)
+	}
+	if primary := DSN(conf); !strings.HasPrefix(primary, "host=primary ") || !strings.Contains(primary, " port=5432 ") {
+		t.Fatalf("DSN = %q", primary)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"chitchat4.0/pkg/config"
 	"github.com/go-redis/redis/v8"
@@ -25,7 +26,7 @@ type RedisDB struct {
 // 返回一个Redis客户端的 *RedisDB 结构体 和 error
 func NewRedisClient(conf *config.RedisConfig) (*RedisDB, error) {
 	if !conf.Enable {
-		logrus.Info("redis 禁用")
+		logrus.Warn("redis 禁用，登录会话不会保存，也无法撤销，token 在过期前一直有效")
 		return &RedisDB{}, nil
 	}
 	rdb := redis.NewClient(&redis.Options{
@@ -42,6 +43,22 @@ func NewRedisClient(conf *config.RedisConfig) (*RedisDB, error) {
 	}, nil
 }
 
+// Set 设置 key 的值和过期时间
+func (rdb *RedisDB) Set(key string, val interface{}, expiration time.Duration) error {
+	if !rdb.enable {
+		return nil
+	}
+	return rdb.Client.Set(context.Background(), key, val, expiration).Err()
+}
+
+// Get 获取 key 的值，redis 禁用时返回 RedisDisableError，key 不存在时返回 redis.Nil
+func (rdb *RedisDB) Get(key string, obj interface{}) error {
+	if !rdb.enable {
+		return RedisDisableError
+	}
+	return rdb.Client.Get(context.Background(), key).Scan(obj)
+}
+
 // HSet
 // 参数 key：users:id，
 // 参数 field：id，
@@ -54,6 +71,31 @@ func (rdb *RedisDB) HSet(key, field string, val interface{}) error {
 	return rdb.Client.HSet(context.Background(), key, field, val).Err()
 }
 
+// hsetIfExists 只在 field 还存在时修改，并把 key 的过期时间延长到不早于 ARGV[3] 毫秒
+var hsetIfExists = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[3]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+// HSetIfExists 原子地修改已经存在的 field，field 已被删除时不会重新写入，返回是否修改。
+// key 的过期时间不会早于 expiration，redis 禁用时什么也不做
+func (rdb *RedisDB) HSetIfExists(key, field string, val interface{}, expiration time.Duration) (bool, error) {
+	if !rdb.enable {
+		return false, nil
+	}
+	updated, err := hsetIfExists.Run(context.Background(), rdb.Client, []string{key}, field, val, expiration.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
+
 // HGet
 func (rdb *RedisDB) HGet(key, field string, obj interface{}) error {
 	if !rdb.enable {
@@ -70,3 +112,64 @@ func (rdb *RedisDB) HDel(key string, fields ...string) error {
 	}
 	return rdb.Client.HDel(context.Background(), key, fields...).Err()
 }
+
+// HGetAll 获取 key 下的全部 field
+func (rdb *RedisDB) HGetAll(key string) (map[string]string, error) {
+	if !rdb.enable {
+		return nil, RedisDisableError
+	}
+	return rdb.Client.HGetAll(context.Background(), key).Result()
+}
+
+// Expire 设置 key 的过期时间
+func (rdb *RedisDB) Expire(key string, expiration time.Duration) error {
+	if !rdb.enable {
+		return nil
+	}
+	return rdb.Client.Expire(context.Background(), key, expiration).Err()
+}
+
+// Del 删除 key
+func (rdb *RedisDB) Del(keys ...string) error {
+	if !rdb.enable {
+		return nil
+	}
+	return rdb.Client.Del(context.Background(), keys...).Err()
+}
+
+// Enabled 判断 redis 是否启用
+func (rdb *RedisDB) Enabled() bool {
+	return rdb.enable
+}
+
+// Publish 发布消息到 channel
+func (rdb *RedisDB) Publish(channel string, message interface{}) error {
+	if !rdb.enable {
+		return RedisDisableError
+	}
+	return rdb.Client.Publish(context.Background(), channel, message).Err()
+}
+
+// Subscribe 订阅 channel，redis 禁用时返回 RedisDisableError
+func (rdb *RedisDB) Subscribe(ctx context.Context, channels ...string) (*redis.PubSub, error) {
+	if !rdb.enable {
+		return nil, RedisDisableError
+	}
+	return rdb.Client.Subscribe(ctx, channels...), nil
+}
+
+// Ping 检查 redis 的连接，redis 禁用时不检查
+func (rdb *RedisDB) Ping(ctx context.Context) error {
+	if !rdb.enable {
+		return nil
+	}
+	return rdb.Client.Ping(ctx).Err()
+}
+
+// Close 关闭 redis 客户端，redis 禁用时什么也不做
+func (rdb *RedisDB) Close() error {
+	if !rdb.enable {
+		return nil
+	}
+	return rdb.Client.Close()
+}
@@ -0,0 +1,40 @@
+//go:build cgo
+
+package database
+
+import (
+	"strings"
+
+	"chitchat4.0/pkg/config"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// NewSQLite 打开 conf.Name 指定的 SQLite 数据库文件，:memory: 表示内存数据库，用于本地开发和测试。
+// 启用外键约束，唯一约束冲突转换为 gorm.ErrDuplicatedKey。
+// SQLite 同一时间只允许一个写入，所以只使用一个连接，连接一直保持打开，内存数据库不会因为连接关闭而丢失。
+// 驱动 gorm.io/driver/sqlite 基于 mattn/go-sqlite3，只在 CGO_ENABLED=1 时编译，否则见 sqlite_nocgo.go。
+func NewSQLite(conf *config.DBConfig) (*gorm.DB, error) {
+	dsn := conf.Name
+	if strings.Contains(dsn, "?") {
+		dsn += "&"
+	} else {
+		dsn += "?"
+	}
+	dsn += "_foreign_keys=1&_busy_timeout=5000"
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	if err := registerDBStats(sqlDB, "source"); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
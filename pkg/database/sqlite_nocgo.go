@@ -0,0 +1,13 @@
+//go:build !cgo
+
+package database
+
+import (
+	"chitchat4.0/pkg/config"
+	"gorm.io/gorm"
+)
+
+// NewSQLite 没有 cgo 时不能使用 SQLite 驱动，返回 ErrSQLiteUnavailable，需要用 CGO_ENABLED=1 重新编译
+func NewSQLite(conf *config.DBConfig) (*gorm.DB, error) {
+	return nil, ErrSQLiteUnavailable
+}
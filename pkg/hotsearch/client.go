@@ -0,0 +1,162 @@
+package hotsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	writeWait        = 10 * time.Second  // 写消息的超时时间
+	pongWait         = 60 * time.Second  // 等待客户端 pong 的时间，超时断开
+	pingPeriod       = pongWait * 9 / 10 // 发送 ping（心跳）的间隔，必须小于 pongWait
+	maxMessageSize   = 4096              // 客户端消息的最大长度
+	sendBufferSize   = 32                // 每个客户端待发送消息的缓冲区大小，写满说明客户端太慢
+	maxTagsPerClient = 64                // 每个客户端最多订阅的 tag 数量
+)
+
+// 客户端发送的消息类型
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+)
+
+var (
+	errClientClosed = errors.New("client closed")
+	errTooManyTags  = errors.New("too many tags subscribed")
+)
+
+// Request 客户端发送的订阅消息，例如 {"action":"subscribe","tags":[1,2]}
+type Request struct {
+	Action string `json:"action"`
+	Tags   []uint `json:"tags"`
+}
+
+// Client 订阅热搜榜的 WebSocket 客户端
+type Client struct {
+	hub  *Hub
+	conn *websocket.Conn
+	send chan []byte
+
+	// 以下字段由 hub.mu 保护
+	tags      map[uint]struct{}
+	closed    bool
+	closeCode int    // 关闭连接时发送给客户端的关闭码，在关闭 send 之前设置
+	closeText string // 关闭原因
+}
+
+// Serve 处理 WebSocket 连接，先订阅 tagIDs，之后根据客户端的消息订阅或取消订阅，连接断开时返回
+func (h *Hub) Serve(conn *websocket.Conn, tagIDs []uint) {
+	c := &Client{
+		hub:  h,
+		conn: conn,
+		send: make(chan []byte, sendBufferSize),
+		tags: make(map[uint]struct{}),
+	}
+	defer h.remove(c)
+
+	go c.writePump()
+	c.reply(h.subscribe(c, tagIDs))
+	c.readPump()
+}
+
+// readPump 读取客户端消息，收到 pong 时延长读超时，连接出错时返回
+func (c *Client) readPump() {
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		req := new(Request)
+		if err := c.conn.ReadJSON(req); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				c.write(&Message{Type: MessageError, Msg: "invalid message"})
+				continue
+			}
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logrus.Debugf("hot search client %s closed: %v", c.conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		switch req.Action {
+		case ActionSubscribe:
+			c.reply(c.hub.subscribe(c, req.Tags))
+		case ActionUnsubscribe:
+			c.reply(c.hub.unsubscribe(c, req.Tags), nil)
+		default:
+			c.write(&Message{Type: MessageError, Msg: "unknown action " + req.Action})
+		}
+	}
+}
+
+// writePump 把 hub 推送的消息写到连接，并定时发送 ping；发送通道被关闭时发送关闭帧并断开连接
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+	for {
+		select {
+		case data, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// hub 关闭了通道：客户端太慢或者连接已结束，关闭码在关闭通道之前设置
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, c.closeText))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// reply 告诉客户端当前订阅的 tag
+func (c *Client) reply(tags []uint, err error) {
+	if err != nil && !errors.Is(err, errClientClosed) {
+		c.write(&Message{Type: MessageError, Tags: tags, Msg: err.Error()})
+		return
+	}
+	c.write(&Message{Type: MessageSubscribed, Tags: tags})
+}
+
+// write 把消息放入发送通道，通道已满或已关闭时丢弃
+func (c *Client) write(message *Message) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+	}
+}
+
+// tagIDs 返回订阅的 tag，需要持有 hub.mu
+func (c *Client) tagIDs() []uint {
+	ids := make([]uint, 0, len(c.tags))
+	for id := range c.tags {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
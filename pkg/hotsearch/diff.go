@@ -0,0 +1,51 @@
+package hotsearch
+
+import (
+	"time"
+
+	"chitchat4.0/pkg/model"
+)
+
+// Diff 比较 tag 前后两次快照，返回排名或内容发生变化的热搜，热搜用 Link 区分。
+// 新上榜的 PreviousRank 为 0，下榜的 Rank 为 0
+func Diff(tagID uint, previous, current []model.HotSearch) *model.HotSearchUpdate {
+	old := make(map[string]model.HotSearch, len(previous))
+	for _, hotSearch := range previous {
+		old[hotSearch.Link] = hotSearch
+	}
+
+	changes := make([]model.RankChange, 0)
+	for _, hotSearch := range current {
+		before, ok := old[hotSearch.Link]
+		delete(old, hotSearch.Link)
+		if ok && before.Rank == hotSearch.Rank && before.Title == hotSearch.Title && before.Extra == hotSearch.Extra {
+			continue
+		}
+		changes = append(changes, model.RankChange{
+			Title:        hotSearch.Title,
+			Link:         hotSearch.Link,
+			Extra:        hotSearch.Extra,
+			Rank:         hotSearch.Rank,
+			PreviousRank: before.Rank,
+		})
+	}
+	// 按原来的排名输出下榜的热搜
+	for _, hotSearch := range previous {
+		if _, ok := old[hotSearch.Link]; !ok {
+			continue
+		}
+		changes = append(changes, model.RankChange{
+			Title:        hotSearch.Title,
+			Link:         hotSearch.Link,
+			Extra:        hotSearch.Extra,
+			PreviousRank: hotSearch.Rank,
+		})
+	}
+
+	return &model.HotSearchUpdate{
+		TagID:     tagID,
+		Changes:   changes,
+		Total:     len(current),
+		UpdatedAt: time.Now(),
+	}
+}
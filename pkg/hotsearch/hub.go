@@ -0,0 +1,184 @@
+// Package hotsearch 实现热搜榜的实时推送：
+// 采集器保存新的快照后，把排名的增量更新通过 Redis pub/sub 分发到所有副本，
+// 每个副本再推送给订阅了该 tag 的 WebSocket 客户端
+package hotsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// Channel 热搜更新在 Redis 中的 pub/sub channel
+const Channel = "chitchat:hotsearch:updates"
+
+// 推送给客户端的消息类型
+const (
+	MessageUpdate     = "update"     // 热搜榜增量更新
+	MessageSubscribed = "subscribed" // 订阅的 tag 发生变化
+	MessageError      = "error"      // 客户端消息有误
+)
+
+// Message 推送给客户端的消息
+type Message struct {
+	Type string                 `json:"type"`
+	Data *model.HotSearchUpdate `json:"data,omitempty"`
+	Tags []uint                 `json:"tags,omitempty"`
+	Msg  string                 `json:"msg,omitempty"`
+}
+
+// Hub 管理订阅热搜榜的客户端，按 tag id 分发更新
+type Hub struct {
+	rdb *database.RedisDB
+
+	mu          sync.RWMutex
+	subscribers map[uint]map[*Client]struct{} // tag id -> 订阅该 tag 的客户端
+}
+
+// NewHub 创建热搜推送中心，rdb 未启用时只在当前进程内分发
+func NewHub(rdb *database.RedisDB) *Hub {
+	return &Hub{
+		rdb:         rdb,
+		subscribers: make(map[uint]map[*Client]struct{}),
+	}
+}
+
+// Publish 发布 tag 热搜榜的增量更新。
+// 启用 Redis 时发布到 Channel，由每个副本（包括当前进程）在 Run 中收到后推送；否则直接推送给当前进程的客户端
+func (h *Hub) Publish(update *model.HotSearchUpdate) error {
+	data, err := json.Marshal(&Message{Type: MessageUpdate, Data: update})
+	if err != nil {
+		return err
+	}
+	if h.rdb != nil && h.rdb.Enabled() {
+		err := h.rdb.Publish(Channel, data)
+		if err == nil {
+			return nil
+		}
+		logrus.Errorf("publish hot search update of tag %d to redis failed, only push to local clients: %v", update.TagID, err)
+	}
+	h.broadcast(update.TagID, data)
+	return nil
+}
+
+// Run 订阅 Redis 中其他副本发布的更新并推送给当前进程的客户端，直到 ctx 结束。
+// Redis 未启用时直接返回
+func (h *Hub) Run(ctx context.Context) error {
+	if h.rdb == nil {
+		return nil
+	}
+	sub, err := h.rdb.Subscribe(ctx, Channel)
+	if errors.Is(err, database.RedisDisableError) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			message := new(Message)
+			if err := json.Unmarshal([]byte(msg.Payload), message); err != nil || message.Data == nil {
+				logrus.Warnf("invalid hot search update from redis: %v", err)
+				continue
+			}
+			h.broadcast(message.Data.TagID, []byte(msg.Payload))
+		}
+	}
+}
+
+// broadcast 把消息推送给订阅了 tag 的客户端，缓冲区已满的客户端会被断开，由客户端重连后重新获取榜单
+func (h *Hub) broadcast(tagID uint, data []byte) {
+	slow := make([]*Client, 0)
+	h.mu.RLock()
+	for c := range h.subscribers[tagID] {
+		select {
+		case c.send <- data:
+		default:
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		logrus.Warnf("hot search client %s is too slow, disconnected", c.conn.RemoteAddr())
+		h.drop(c, websocket.ClosePolicyViolation, "too slow")
+	}
+}
+
+// subscribe 订阅 tag，返回客户端当前订阅的全部 tag
+func (h *Hub) subscribe(c *Client, tagIDs []uint) ([]uint, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c.closed {
+		return nil, errClientClosed
+	}
+	for _, id := range tagIDs {
+		if _, ok := c.tags[id]; ok {
+			continue
+		}
+		if len(c.tags) >= maxTagsPerClient {
+			return c.tagIDs(), errTooManyTags
+		}
+		c.tags[id] = struct{}{}
+		if h.subscribers[id] == nil {
+			h.subscribers[id] = make(map[*Client]struct{})
+		}
+		h.subscribers[id][c] = struct{}{}
+	}
+	return c.tagIDs(), nil
+}
+
+// unsubscribe 取消订阅 tag，返回客户端当前订阅的全部 tag
+func (h *Hub) unsubscribe(c *Client, tagIDs []uint) []uint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, id := range tagIDs {
+		h.unsubscribeLocked(c, id)
+	}
+	return c.tagIDs()
+}
+
+func (h *Hub) unsubscribeLocked(c *Client, tagID uint) {
+	delete(c.tags, tagID)
+	if clients, ok := h.subscribers[tagID]; ok {
+		delete(clients, c)
+		if len(clients) == 0 {
+			delete(h.subscribers, tagID)
+		}
+	}
+}
+
+// remove 取消客户端的全部订阅并关闭发送通道，客户端会收到正常的关闭帧，可以重复调用
+func (h *Hub) remove(c *Client) {
+	h.drop(c, websocket.CloseNormalClosure, "")
+}
+
+// drop 取消客户端的全部订阅并关闭发送通道，writePump 会以 code 和 text 关闭连接，可以重复调用
+func (h *Hub) drop(c *Client, code int, text string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c.closed {
+		return
+	}
+	for id := range c.tags {
+		h.unsubscribeLocked(c, id)
+	}
+	c.closed = true
+	c.closeCode, c.closeText = code, text
+	close(c.send)
+}
@@ -0,0 +1,109 @@
+package hotsearch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// serve 启动一个订阅 tagID 的热搜 WebSocket 服务并连接
+func serve(t *testing.T, h *Hub, tagID uint) *websocket.Conn {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		h.Serve(conn, []uint{tagID})
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// closeCode 读取消息直到连接关闭，返回服务端发送的关闭码
+func closeCode(t *testing.T, conn *websocket.Conn) int {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("read: %v, want close error", err)
+		}
+		return closeErr.Code
+	}
+}
+
+// subscribed 等待服务端完成订阅
+func subscribed(t *testing.T, h *Hub, tagID uint) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		h.mu.RLock()
+		n := len(h.subscribers[tagID])
+		h.mu.RUnlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("tag %d is not subscribed", tagID)
+}
+
+func TestServeNormalClose(t *testing.T) {
+	h := NewHub(nil)
+	conn := serve(t, h, 1)
+	subscribed(t, h, 1)
+
+	// 不是因为太慢而移除的客户端收到正常的关闭帧
+	h.mu.RLock()
+	clients := make([]*Client, 0)
+	for c := range h.subscribers[1] {
+		clients = append(clients, c)
+	}
+	h.mu.RUnlock()
+	for _, c := range clients {
+		h.remove(c)
+	}
+	if code := closeCode(t, conn); code != websocket.CloseNormalClosure {
+		t.Fatalf("close code = %d, want %d", code, websocket.CloseNormalClosure)
+	}
+}
+
+func TestServeSlowClient(t *testing.T) {
+	h := NewHub(nil)
+	conn := serve(t, h, 1)
+	subscribed(t, h, 1)
+
+	// 客户端不读取消息，发送缓冲区写满后被断开
+	data := make([]byte, 256<<10)
+	for i := 0; ; i++ {
+		h.mu.RLock()
+		n := len(h.subscribers[1])
+		h.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if i == 10000 {
+			t.Fatal("slow client is not dropped")
+		}
+		h.broadcast(1, data)
+	}
+	if code := closeCode(t, conn); code != websocket.ClosePolicyViolation {
+		t.Fatalf("close code = %d, want %d", code, websocket.ClosePolicyViolation)
+	}
+}
@@ -1,34 +1,67 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"chitchat4.0/pkg/apierrors"
 	"chitchat4.0/pkg/authentication"
 	"chitchat4.0/pkg/common"
 	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/service"
 	"github.com/gin-gonic/gin"
 )
 
-// AuthenticationMiddleware 验证Token的中间件，同时把 user 存储到 gin的Context中
-func AuthenticationMiddleware(jwtService *authentication.JWTService, userRepo repository.UserRepository) gin.HandlerFunc {
+// AuthenticationMiddleware 验证Token的中间件，同时把 user 存储到 gin的Context中，
+// token 可以是 JWT，也可以是个人访问 token
+func AuthenticationMiddleware(jwtService *authentication.JWTService, tokenService service.AccessTokenService, sessionService service.SessionService, userRepo repository.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		// header 获取 token
 		token, _ := getTokenFromAuthorizationHeader(c)
 
+		fromCookie := false // token 来自 cookie 时，修改请求需要校验 CSRF token
 		if token == "" {
 			// request 获取 token
 			token, _ = getTokenFromCookie(c)
+			fromCookie = token != ""
+		}
+
+		// 个人访问 token
+		if service.IsAccessToken(token) {
+			user, err := tokenService.Authenticate(token)
+			if err != nil {
+				common.ResponseFailed(c, http.StatusUnauthorized, err)
+				c.Abort()
+				return
+			}
+			// 个人访问 token 没有会话，不能通过 cookie 发送修改请求
+			if fromCookie && !checkCSRF(c, jwtService, "") {
+				return
+			}
+			common.SetUser(c, user)
+			c.Next()
+			return
 		}
 		// 解析 Token ,获取当前 user 信息
-		user, _ := jwtService.ParseToken(token)
+		claims, _ := jwtService.ParseClaims(token)
 
 		// 判断用户
-		if user != nil {
+		if claims != nil {
+			// 会话被撤销后 token 立即失效
+			if err := sessionService.Validate(claims.ID, claims.SessionID(), c.Request.UserAgent(), c.ClientIP()); err != nil {
+				common.ResponseFailed(c, http.StatusUnauthorized, err)
+				c.Abort()
+				return
+			}
+			if fromCookie && !checkCSRF(c, jwtService, claims.SessionID()) {
+				return
+			}
+
 			// 使用 user.id 查询数据库，确认用户
-			user, err := userRepo.GetUserByID(user.ID)
+			user, err := userRepo.GetUserByID(claims.ID)
 			if err != nil {
 				common.ResponseFailed(c, http.StatusInternalServerError, fmt.Errorf("failed to get user"))
 				c.Abort()
@@ -37,12 +70,31 @@ func AuthenticationMiddleware(jwtService *authentication.JWTService, userRepo re
 
 			// 在 gin 的 Context 中设置 user,后续可以使用 Context 中的 user
 			common.SetUser(c, user)
+			common.SetSession(c, claims.SessionID())
 		}
 		c.Next()
 
 	}
 }
 
+// checkCSRF 使用 cookie 登录时，除了 GET、HEAD 和 OPTIONS 之外的请求需要在 X-CSRF-Token 请求头中提交
+// 和 csrfToken cookie 相同、并且由当前会话签发的 CSRF token（双重提交），跨站的表单无法读取 cookie 中的 token。
+// 校验失败时返回 403 并返回 false
+func checkCSRF(c *gin.Context, jwtService *authentication.JWTService, sessionID string) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	header := c.GetHeader(common.HeaderCSRFToken)
+	cookie, _ := c.Cookie(common.CookieCSRFToken)
+	if header == "" || subtle.ConstantTimeCompare([]byte(header), []byte(cookie)) != 1 || !jwtService.VerifyCSRFToken(sessionID, header) {
+		common.ResponseFailed(c, http.StatusForbidden, apierrors.NewForbidden("missing or invalid csrf token, send the csrfToken cookie in the "+common.HeaderCSRFToken+" header or use the Authorization header"))
+		c.Abort()
+		return false
+	}
+	return true
+}
+
 // getTokenFromCookie 在Request中的获取Cookie，
 // c.Request.Cookie
 func getTokenFromCookie(c *gin.Context) (string, error) {
@@ -1,24 +1,177 @@
 package middleware
 
 import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"chitchat4.0/pkg/config"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
-func CORSMiddleware() gin.HandlerFunc {
-	// cors.New函数创建了一个CORS中间件实例
-	return cors.New(cors.Config{
-		// 当函数返回 true 时，表示允许该来源的跨源请求；返回 false 则表示不允许。如果你希望对允许访问的来源有更精细的控制，你可以在函数中添加适当的逻辑。例如，你可以检查 origin 是否匹配某个特定的模式，或者检查请求的其他头或参数。
-		AllowOriginFunc: func(origin string) bool {
+// 没有配置时使用的跨域默认值
+var (
+	defaultCORSMethods       = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders       = []string{"Origin", "Authorization", "Content-Length", "Content-Type", "If-Match", "X-CSRF-Token"}
+	defaultCORSExposeHeaders = []string{"Content-Length", "ETag"}
+)
+
+const defaultCORSMaxAge = 12 * time.Hour
+
+// CORS 可以热加载的跨域中间件，Update 后新的请求使用新的配置
+type CORS struct {
+	mu       sync.RWMutex
+	handler  gin.HandlerFunc
+	origins  *originMatcher
+	upgrader *websocket.Upgrader
+}
+
+// NewCORS 根据配置创建跨域中间件，配置已经通过 config.Validate 校验
+func NewCORS(conf config.CORSConfig) *CORS {
+	c := &CORS{}
+	c.upgrader = &websocket.Upgrader{CheckOrigin: c.CheckOrigin}
+	c.Update(conf)
+	return c
+}
+
+// Update 根据新的配置重新创建跨域处理函数
+func (c *CORS) Update(conf config.CORSConfig) {
+	handler := cors.New(corsConfig(conf))
+	origins := newOriginMatcher(conf.AllowOrigins)
+
+	c.mu.Lock()
+	c.handler = handler
+	c.origins = origins
+	c.mu.Unlock()
+}
+
+// Upgrader 返回所有 WebSocket 接口共用的 Upgrader，使用 CheckOrigin 检查来源
+func (c *CORS) Upgrader() *websocket.Upgrader {
+	return c.upgrader
+}
+
+// CheckOrigin 检查 WebSocket 握手的来源。
+// 浏览器建立 WebSocket 连接时会携带 cookie，而且不受同源策略限制，所以只允许同源和配置中明确列出的来源；
+// 配置为 "*" 时 HTTP 接口不携带凭证，WebSocket 无法做到，因此 "*" 不允许跨域的 WebSocket。
+// 没有 Origin 请求头的不是浏览器发起的连接，直接允许
+func (c *CORS) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	c.mu.RLock()
+	origins := c.origins
+	c.mu.RUnlock()
+	return origins.listed(origin)
+}
+
+// Middleware 返回跨域中间件。
+// 没有 Origin 请求头或者来源和请求的主机相同时不是跨域请求；来源不被允许时返回 403，预检请求返回 204
+func (c *CORS) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		c.mu.RLock()
+		handler := c.handler
+		c.mu.RUnlock()
+		handler(ctx)
+	}
+}
+
+// corsConfig 把应用的跨域配置转换为 gin-contrib/cors 的配置
+func corsConfig(conf config.CORSConfig) cors.Config {
+	result := cors.Config{
+		AllowMethods:     withDefault(conf.AllowMethods, defaultCORSMethods),
+		AllowHeaders:     withDefault(conf.AllowHeaders, defaultCORSHeaders),
+		ExposeHeaders:    withDefault(conf.ExposeHeaders, defaultCORSExposeHeaders),
+		AllowCredentials: conf.AllowCredentials,
+		MaxAge:           time.Duration(conf.MaxAge) * time.Second,
+		AllowWebSockets:  true, // 允许使用WebSocket协议
+	}
+	if conf.MaxAge == 0 {
+		result.MaxAge = defaultCORSMaxAge
+	}
+
+	matcher := newOriginMatcher(conf.AllowOrigins)
+	if matcher.any {
+		// 允许所有来源时返回 Access-Control-Allow-Origin: *，浏览器不会携带凭证
+		result.AllowAllOrigins = true
+		result.AllowCredentials = false
+		return result
+	}
+	result.AllowOriginFunc = matcher.match
+	return result
+}
+
+// originMatcher 匹配允许的来源，包括精确的来源和 https://*.example.com 形式的通配子域名
+type originMatcher struct {
+	any       bool
+	exact     map[string]bool
+	wildcards [][2]string // 通配符前后的部分，例如 https:// 和 .example.com
+}
+
+func newOriginMatcher(origins []string) *originMatcher {
+	m := &originMatcher{exact: make(map[string]bool, len(origins))}
+	for _, origin := range origins {
+		origin = strings.TrimSuffix(strings.ToLower(origin), "/")
+		switch {
+		case origin == "*":
+			m.any = true
+		case strings.Contains(origin, "://*."):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			m.wildcards = append(m.wildcards, [2]string{prefix, suffix})
+		default:
+			m.exact[origin] = true
+		}
+	}
+	return m
+}
+
+func (m *originMatcher) match(origin string) bool {
+	return m.any || m.listed(origin)
+}
+
+// listed 判断来源是否是配置中明确列出的来源或通配子域名，不包括 "*"
+func (m *originMatcher) listed(origin string) bool {
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+	for _, w := range m.wildcards {
+		if !strings.HasPrefix(origin, w[0]) || !strings.HasSuffix(origin, w[1]) || len(origin) <= len(w[0])+len(w[1]) {
+			continue
+		}
+		// 通配符只匹配子域名，不能包含端口和路径
+		if sub := origin[len(w[0]) : len(origin)-len(w[1])]; validSubdomain(sub) {
 			return true
-		}, // AllowOriginFunc是一个用于验证起源的自定义函数。它将origin原点作为参数，如果允许则返回true，否则返回false。如果设置了这个选项，AllowOrigins的内容将被忽略。
-		AllowMethods:     []string{"PUT", "PATCH", "GET", "DELETE", "POST", "OPTIONS"},          // 允许的请求方法
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Length", "Content-Type"}, // 允许的请求头
-		ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin"},             // 允许暴露的
-		AllowCredentials: true,                                                                  // AllowCredentials指示请求是否可以包含用户凭据，如cookie、HTTP身份验证或客户端SSL证书。
-		MaxAge:           12 * time.Hour,                                                        // 表示预检请求的结果可以缓存多长时间(以秒精度计算)
-		AllowWebSockets:  true,                                                                  // 允许使用WebSocket协议
-	})
+		}
+	}
+	return false
+}
+
+func validSubdomain(sub string) bool {
+	for _, label := range strings.Split(sub, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func withDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
 }
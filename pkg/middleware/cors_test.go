@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"chitchat4.0/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+func newCORSEngine(c *CORS) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(c.Middleware())
+	e.GET("/api/v1/users", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	return e
+}
+
+func preflight(e *gin.Engine, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "http://api.example.com/api/v1/users", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type, If-Match")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflight(t *testing.T) {
+	conf := config.CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+		AllowMethods:     []string{"GET", "PUT"},
+		AllowHeaders:     []string{"Content-Type", "If-Match"},
+		ExposeHeaders:    []string{"ETag"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	e := newCORSEngine(NewCORS(conf))
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"exact origin", "https://app.example.com", true},
+		{"exact origin is case insensitive", "https://APP.example.com", true},
+		{"other scheme", "http://app.example.com", false},
+		{"other port", "https://app.example.com:8443", false},
+		{"unknown origin", "https://evil.com", false},
+		{"suffix of exact origin", "https://app.example.com.evil.com", false},
+		{"wildcard subdomain", "https://web.example.org", true},
+		{"wildcard nested subdomain", "https://a.b.example.org", true},
+		{"wildcard does not match apex", "https://example.org", false},
+		{"wildcard does not match other domain", "https://evilexample.org", false},
+		{"wildcard does not match port", "https://web.example.org:8443", false},
+		{"wildcard does not match empty label", "https://.example.org", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := preflight(e, tt.origin)
+			if !tt.allowed {
+				if w.Code != http.StatusForbidden {
+					t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+				}
+				if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+					t.Fatalf("Access-Control-Allow-Origin = %q, want empty", got)
+				}
+				return
+			}
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+			}
+			want := map[string]string{
+				"Access-Control-Allow-Origin":      tt.origin,
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET,PUT",
+				"Access-Control-Allow-Headers":     "Content-Type,If-Match",
+				"Access-Control-Max-Age":           "600",
+			}
+			for key, value := range want {
+				if got := w.Header().Get(key); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+			if got := w.Header().Values("Vary"); len(got) == 0 || got[0] != "Origin" {
+				t.Errorf("Vary = %q, want Origin first", got)
+			}
+		})
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	e := newCORSEngine(NewCORS(config.CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowCredentials: true,
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/v1/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "Content-Length,Etag" {
+		t.Errorf("Access-Control-Expose-Headers = %q, want default", got)
+	}
+
+	// 没有 Origin 和同源的请求不是跨域请求
+	for _, origin := range []string{"", "http://api.example.com"} {
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/v1/users", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("origin %q: status = %d, Access-Control-Allow-Origin = %q", origin, w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+		}
+	}
+}
+
+func TestCORSDefaults(t *testing.T) {
+	// 没有配置来源时拒绝所有跨域请求
+	e := newCORSEngine(NewCORS(config.CORSConfig{}))
+	if w := preflight(e, "https://app.example.com"); w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	// * 允许所有来源，但不允许携带凭证
+	e = newCORSEngine(NewCORS(config.CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}))
+	w := preflight(e, "https://any.example.net")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want empty", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET,POST,PUT,PATCH,DELETE,OPTIONS" {
+		t.Errorf("Access-Control-Allow-Methods = %q, want default", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "43200" {
+		t.Errorf("Access-Control-Max-Age = %q, want 43200", got)
+	}
+}
+
+func TestCORSUpdate(t *testing.T) {
+	c := NewCORS(config.CORSConfig{AllowOrigins: []string{"https://old.example.com"}})
+	e := newCORSEngine(c)
+	if w := preflight(e, "https://new.example.com"); w.Code != http.StatusForbidden {
+		t.Fatalf("before update: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	c.Update(config.CORSConfig{AllowOrigins: []string{"https://new.example.com"}})
+	if w := preflight(e, "https://new.example.com"); w.Code != http.StatusNoContent {
+		t.Fatalf("after update: status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := preflight(e, "https://old.example.com"); w.Code != http.StatusForbidden {
+		t.Fatalf("after update: old origin status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestCORSCheckOrigin(t *testing.T) {
+	c := NewCORS(config.CORSConfig{AllowOrigins: []string{"https://app.example.com", "https://*.example.org"}})
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"no origin", "", true},
+		{"same host", "https://api.example.com", true},
+		{"exact origin", "https://app.example.com", true},
+		{"wildcard subdomain", "https://web.example.org", true},
+		{"unknown origin", "https://evil.com", false},
+		{"wildcard does not match apex", "https://example.org", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/v1/ws", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if got := c.Upgrader().CheckOrigin(req); got != tt.allowed {
+				t.Fatalf("CheckOrigin = %v, want %v", got, tt.allowed)
+			}
+		})
+	}
+
+	// "*" 不允许跨域的 WebSocket
+	c.Update(config.CORSConfig{AllowOrigins: []string{"*"}})
+	req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/v1/ws", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	if c.Upgrader().CheckOrigin(req) {
+		t.Fatal("CheckOrigin allowed a cross origin WebSocket with *")
+	}
+}
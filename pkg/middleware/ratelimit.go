@@ -10,6 +10,8 @@ package middleware
 
 import (
 	"net/http"
+	"reflect"
+	"sync"
 
 	"chitchat4.0/pkg/common"
 	"chitchat4.0/pkg/utils/ratelimit"
@@ -19,20 +21,63 @@ import (
 // RateLimitMiddleware 速度限制中间件
 // 函数RateLimitMiddleware接收一个配置数组configs，返回一个gin.HandlerFunc和错误
 func RateLimitMiddleware(configs []ratelimit.LimitConfig) (gin.HandlerFunc, error) {
+	limits, err := NewRateLimits(configs)
+	if err != nil {
+		return nil, err
+	}
+	return limits.Middleware(), nil
+}
+
+// RateLimits 可以热加载的速率限制，Update 后新的请求使用新的限制器
+type RateLimits struct {
+	mu       sync.RWMutex
+	limiters []*ratelimit.RateLimiter // 限制控制器切片（存放server类型和iP类型的限制）
+	configs  []ratelimit.LimitConfig  // 当前限制控制器的配置
+}
+
+// NewRateLimits 根据配置创建速率限制
+func NewRateLimits(configs []ratelimit.LimitConfig) (*RateLimits, error) {
+	r := &RateLimits{}
+	if err := r.Update(configs); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
 
-	var limiters []*ratelimit.RateLimiter // 定义限制控制器切片（存放server类型和iP类型的限制）
+// Update 替换全部限制控制器，任何一个配置无效时保留原来的限制；
+// 配置没有变化时保留原来的限制控制器和计数，替换后已有的计数会清空
+func (r *RateLimits) Update(configs []ratelimit.LimitConfig) error {
+	// 校验时会填充默认值，复制一份，不修改调用者的配置
+	configs = append([]ratelimit.LimitConfig(nil), configs...)
+	limiters := make([]*ratelimit.RateLimiter, 0, len(configs))
 
 	// configs= [{server 500 100 1} {ip 50 10 2048}]
 	for key := range configs {
 		// 生成限制控制器
 		limiter, err := ratelimit.NewRateLimiter(&configs[key])
 		if err != nil {
-			return nil, err
+			return err
 		}
 		limiters = append(limiters, limiter)
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.limiters != nil && reflect.DeepEqual(r.configs, configs) {
+		return nil
+	}
+	r.limiters = limiters
+	r.configs = configs
+	return nil
+}
+
+// Middleware 返回检查速率限制的中间件
+func (r *RateLimits) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		r.mu.RLock()
+		limiters := r.limiters
+		r.mu.RUnlock()
+
 		// 遍历限制控制器，接受请求
 		for _, limiter := range limiters {
 			if err := limiter.Accept(c); err != nil {
@@ -42,5 +87,5 @@ func RateLimitMiddleware(configs []ratelimit.LimitConfig) (gin.HandlerFunc, erro
 		}
 		// 执行下一个中间件
 		c.Next()
-	}, nil
+	}
 }
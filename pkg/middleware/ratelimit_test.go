@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"chitchat4.0/pkg/utils/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// TestRateLimitsUpdate 配置没有变化时 Update 不清空计数，修改配置后使用新的限制
+func TestRateLimitsUpdate(t *testing.T) {
+	configs := []ratelimit.LimitConfig{{LimitType: ratelimit.ServerLimitType, Burst: 2, QPS: 1}}
+	r, err := NewRateLimits(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(r.Middleware())
+	e.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	get := func() int {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := get(); code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, code)
+		}
+	}
+	if code := get(); code != http.StatusTooManyRequests {
+		t.Fatalf("over burst: status %d, want 429", code)
+	}
+
+	same := []ratelimit.LimitConfig{{LimitType: ratelimit.ServerLimitType, Burst: 2, QPS: 1}}
+	if err := r.Update(same); err != nil {
+		t.Fatal(err)
+	}
+	if code := get(); code != http.StatusTooManyRequests {
+		t.Fatalf("after update with the same config: status %d, want 429", code)
+	}
+
+	if err := r.Update([]ratelimit.LimitConfig{{LimitType: ratelimit.ServerLimitType, Burst: 5, QPS: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("after update with a new config: status %d, want 200", code)
+	}
+
+	if err := r.Update([]ratelimit.LimitConfig{{LimitType: ratelimit.ServerLimitType, Burst: 1, QPS: 2}}); err == nil {
+		t.Fatal("invalid config is accepted")
+	}
+}
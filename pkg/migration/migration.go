@@ -0,0 +1,338 @@
+// Package migration 管理数据库表结构的版本。
+// 迁移文件按数据库类型嵌入到程序中，文件名为 <版本>_<名称>.up.sql 和 <版本>_<名称>.down.sql，
+// 已经执行的版本记录在 schema_migrations 表中，每个版本和它的记录在同一个事务中执行
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+//go:embed sql
+var files embed.FS
+
+// 多个实例同时迁移时使用的 PostgreSQL advisory lock
+const lockKey = 4202311
+
+// ErrNewerSchema 数据库的版本比程序知道的最新版本更新，通常是数据库已经被更新的版本迁移过
+var ErrNewerSchema = errors.New("database schema is newer than this binary")
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 一个版本的状态，Unknown 表示数据库中记录了但程序中没有的版本
+type Status struct {
+	Version   uint
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Unknown   bool
+}
+
+// record schema_migrations 表中的一行
+type record struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:256;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (record) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator 执行数据库的版本迁移
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration // 按版本排序
+	logger     *logrus.Logger
+}
+
+// New 返回数据库对应类型的迁移，程序中没有这种数据库的迁移文件时返回错误。
+// 配置了只读副本时也只读写主库，避免副本的延迟
+func New(db *gorm.DB, logger *logrus.Logger) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := load(files, path.Join("sql", dialect))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db.Clauses(dbresolver.Write).Session(&gorm.Session{}),
+		dialect:    dialect,
+		migrations: migrations,
+		logger:     logger,
+	}, nil
+}
+
+// load 读取目录中的迁移文件，每个版本都需要 up 和 down 两个文件
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s: %w", path.Base(dir), err)
+	}
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[m.Version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", m.Version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Latest 返回程序知道的最新版本
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version 返回数据库当前的版本，也就是已经执行的最大版本，没有执行过迁移时为 0
+func (m *Migrator) Version() (uint, error) {
+	records, err := m.applied(m.db)
+	if err != nil {
+		return 0, err
+	}
+	return currentVersion(records), nil
+}
+
+// Check 数据库的版本比程序知道的最新版本更新时返回 ErrNewerSchema，旧版本的程序不能在新的表结构上运行
+func (m *Migrator) Check() error {
+	current, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if current > m.Latest() {
+		return fmt.Errorf("%w: database is at version %d, latest known version is %d", ErrNewerSchema, current, m.Latest())
+	}
+	return nil
+}
+
+// Pending 返回还没有执行的版本
+func (m *Migrator) Pending() ([]Migration, error) {
+	records, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]Migration, 0)
+	for _, migration := range m.migrations {
+		if _, ok := records[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Status 返回程序中每个版本的状态，以及数据库中记录了但程序中没有的版本
+func (m *Migrator) Status() ([]Status, error) {
+	records, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	known := make(map[uint]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if r, ok := records[migration.Version]; ok {
+			appliedAt := r.AppliedAt
+			status.Applied, status.AppliedAt = true, &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, r := range records {
+		if !known[version] {
+			appliedAt := r.AppliedAt
+			statuses = append(statuses, Status{Version: version, Name: r.Name, Applied: true, AppliedAt: &appliedAt, Unknown: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Up 执行全部还没有执行的版本
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down 回滚当前版本
+func (m *Migrator) Down() error {
+	records, err := m.applied(m.db)
+	if err != nil {
+		return err
+	}
+	current := currentVersion(records)
+	if current == 0 {
+		return errors.New("no migration to roll back")
+	}
+	var previous uint
+	for version := range records {
+		if version < current && version > previous {
+			previous = version
+		}
+	}
+	return m.To(previous)
+}
+
+// To 迁移到指定的版本：执行不超过 version 的全部未执行版本，并按从新到旧的顺序回滚比 version 新的版本。
+// version 为 0 时回滚全部版本
+func (m *Migrator) To(version uint) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	if err := m.Check(); err != nil {
+		return err
+	}
+	records, err := m.applied(m.db)
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := records[migration.Version]; ok && migration.Version > version {
+			if err := m.run(migration, false); err != nil {
+				return err
+			}
+		}
+	}
+	for _, migration := range m.migrations {
+		if _, ok := records[migration.Version]; !ok && migration.Version <= version {
+			if err := m.run(migration, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// run 在一个事务中执行一个版本的 up 或 down，并修改 schema_migrations。
+// 加锁后重新检查版本的状态，其他实例已经执行过时跳过
+func (m *Migrator) run(migration Migration, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if m.dialect == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+		}
+		records, err := m.applied(tx)
+		if err != nil {
+			return err
+		}
+		if _, applied := records[migration.Version]; applied == up {
+			return nil
+		}
+
+		if up {
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&record{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		}
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&record{}, migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	m.logger.Infof("migration %04d_%s %s", migration.Version, migration.Name, direction)
+	return nil
+}
+
+// applied 返回已经执行的版本，schema_migrations 表不存在时创建。
+// 多个实例同时创建失败时，只要表已经存在就继续
+func (m *Migrator) applied(db *gorm.DB) (map[uint]record, error) {
+	if !db.Migrator().HasTable(&record{}) {
+		if err := db.Migrator().CreateTable(&record{}); err != nil && !db.Migrator().HasTable(&record{}) {
+			return nil, err
+		}
+	}
+	records := make([]record, 0)
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint]record, len(records))
+	for _, r := range records {
+		result[r.Version] = r
+	}
+	return result, nil
+}
+
+func (m *Migrator) find(version uint) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func currentVersion(records map[uint]record) uint {
+	var current uint
+	for version := range records {
+		if version > current {
+			current = version
+		}
+	}
+	return current
+}
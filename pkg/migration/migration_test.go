@@ -0,0 +1,240 @@
+package migration
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"chitchat4.0/pkg/config"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// BaseModel 嵌入的结构体需要导出，AutoMigrate 才会创建其中的列
+type BaseModel struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+// 引入迁移之前的模型，只保留影响表结构的字段，用 AutoMigrate 创建升级前的数据库
+type baseUser struct {
+	ID        uint           `gorm:"autoIncrement;primaryKey"`
+	Name      string         `gorm:"size:100;not null;unique"`
+	Password  string         `gorm:"size:256"`
+	Email     string         `gorm:"size:256"`
+	Avatar    string         `gorm:"size:256"`
+	AuthInfos []baseAuthInfo `gorm:"foreignKey:UserId;references:ID"`
+	Groups    []baseGroup    `gorm:"many2many:user_groups;joinForeignKey:UserID;joinReferences:GroupID"`
+	Roles     []baseRole     `gorm:"many2many:user_roles;joinForeignKey:UserID;joinReferences:RoleID"`
+	BaseModel
+}
+
+func (baseUser) TableName() string { return "users" }
+
+type baseAuthInfo struct {
+	ID           uint   `gorm:"autoIncrement;primaryKey"`
+	UserId       uint   `gorm:"size:256"`
+	Url          string `gorm:"size:256"`
+	AuthType     string `gorm:"size:256"`
+	AuthId       string `gorm:"size:256"`
+	AccessToken  string `gorm:"size:256"`
+	RefreshToken string `gorm:"size:256"`
+	Expiry       time.Time
+	BaseModel
+}
+
+func (baseAuthInfo) TableName() string { return "auth_infos" }
+
+type baseGroup struct {
+	ID        uint   `gorm:"autoIncrement;primaryKey"`
+	Name      string `gorm:"size:100;not null;unique"`
+	Kind      string `gorm:"size:100"`
+	Describe  string `gorm:"size:1024;"`
+	CreatorID uint
+	UpdaterID uint
+	Users     []baseUser `gorm:"many2many:user_groups;joinForeignKey:GroupID;joinReferences:UserID"`
+	Roles     []baseRole `gorm:"many2many:group_roles;joinForeignKey:GroupID;joinReferences:RoleID"`
+	BaseModel
+}
+
+func (baseGroup) TableName() string { return "groups" }
+
+type baseRole struct {
+	ID        uint        `gorm:"autoIncrement;primaryKey"`
+	Name      string      `gorm:"size:100;not null;unique"`
+	Scope     string      `gorm:"size:100"`
+	Namespace string      `gorm:"size:100"`
+	Rules     model.Rules `gorm:"type:json"`
+}
+
+func (baseRole) TableName() string { return "roles" }
+
+type baseResource struct {
+	ID    uint   `gorm:"autoIncrement;primaryKey"`
+	Name  string `gorm:"size:256;not null;unique"`
+	Scope string
+	Kind  string
+}
+
+func (baseResource) TableName() string { return "resources" }
+
+type baseTag struct {
+	ID        uint   `gorm:"autoIncrement;primaryKey"`
+	Name      string `gorm:"size:256;not null;unique"`
+	Sort      int
+	SourceKey string `gorm:"size:100"`
+	IconColor string `gorm:"size:100"`
+	CreatorID uint
+	Creator   baseUser `gorm:"foreignKey:CreatorID"`
+	BaseModel
+}
+
+func (baseTag) TableName() string { return "tags" }
+
+type baseHotSearch struct {
+	ID    uint   `gorm:"autoIncrement;primaryKey"`
+	Title string `gorm:"size:512;not null"`
+	Link  string `gorm:"size:512;not null"`
+	Extra string `gorm:"size:256"`
+	TagID uint
+	Tag   baseTag `gorm:"foreignKey:TagID"`
+	BaseModel
+}
+
+func (baseHotSearch) TableName() string { return "hot_searches" }
+
+// 迁移完成后需要的全部模型
+var models = []interface{}{
+	&model.User{}, &model.AuthInfo{}, &model.RecoveryCode{}, &model.AccessToken{},
+	&model.Group{}, &model.Role{}, &model.Resource{},
+	&model.Tag{}, &model.HotSearch{},
+	&model.Room{}, &model.RoomMember{}, &model.Message{}, &model.LinkPreview{},
+	&model.Comment{}, &model.CommentReport{}, &model.Reaction{},
+	&model.TagFollow{}, &model.KeywordFollow{}, &model.Bookmark{},
+	&model.AlertRule{}, &model.AlertHit{}, &model.Notification{},
+	&model.Webhook{}, &model.WebhookDelivery{},
+}
+
+func newTestMigrator(t *testing.T) (*gorm.DB, *Migrator) {
+	t.Helper()
+	db, err := database.NewSQLite(&config.DBConfig{Driver: config.DriverSQLite, Name: ":memory:"})
+	if errors.Is(err, database.ErrSQLiteUnavailable) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	migrator, err := New(db, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, migrator
+}
+
+// checkSchema 每个模型的表、列和多对多的关联表都存在
+func checkSchema(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			t.Fatal(err)
+		}
+		table := stmt.Schema.Table
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s is missing", table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(m, field.DBName) {
+				t.Errorf("column %s.%s is missing", table, field.DBName)
+			}
+		}
+		for _, rel := range stmt.Schema.Relationships.Relations {
+			if rel.JoinTable != nil && !db.Migrator().HasTable(rel.JoinTable.Table) {
+				t.Errorf("join table %s is missing", rel.JoinTable.Table)
+			}
+		}
+	}
+}
+
+func TestUp(t *testing.T) {
+	db, migrator := newTestMigrator(t)
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	checkSchema(t, db)
+
+	version, err := migrator.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != migrator.Latest() {
+		t.Errorf("version = %d, want %d", version, migrator.Latest())
+	}
+}
+
+// TestUpgradeFromAutoMigrate 引入迁移之前由 AutoMigrate 创建的数据库执行迁移后，表结构和新建的数据库相同
+func TestUpgradeFromAutoMigrate(t *testing.T) {
+	db, migrator := newTestMigrator(t)
+	base := []interface{}{&baseUser{}, &baseAuthInfo{}, &baseTag{}, &baseHotSearch{}, &baseGroup{}, &baseRole{}, &baseResource{}}
+	if err := db.AutoMigrate(base...); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&baseUser{Name: "admin", Password: "secret"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	checkSchema(t, db)
+
+	// 已有的行使用新增列的默认值
+	user := new(model.User)
+	if err := db.First(user, "name = ?", "admin").Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.ResourceVersion != 1 || user.MFAEnabled {
+		t.Errorf("user = %+v, want resource version 1 without MFA", user)
+	}
+}
+
+func TestDown(t *testing.T) {
+	db, migrator := newTestMigrator(t)
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.To(1); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("webhooks") || db.Migrator().HasColumn("users", "mfa_enabled") {
+		t.Error("0002 was not rolled back")
+	}
+	if !db.Migrator().HasTable("users") {
+		t.Error("0001 was rolled back")
+	}
+
+	if err := migrator.To(0); err != nil {
+		t.Fatal(err)
+	}
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range tables {
+		if table != "schema_migrations" && table != "sqlite_sequence" {
+			t.Errorf("table %s left after rolling back all versions", table)
+		}
+	}
+}
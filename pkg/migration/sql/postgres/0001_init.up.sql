@@ -0,0 +1,116 @@
+-- 初始的表结构，与引入迁移之前 AutoMigrate 创建的表完全相同。
+-- 使用 IF NOT EXISTS，已经由 AutoMigrate 创建过表的数据库执行后只会记录版本，之后增加的列和表由 0002 创建
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "password" varchar(256),
+    "email" varchar(256),
+    "avatar" varchar(256),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "roles" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "scope" varchar(100),
+    "namespace" varchar(100),
+    "rules" json,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_roles_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "user_roles" (
+    "user_id" bigint,
+    "role_id" bigint,
+    PRIMARY KEY ("user_id", "role_id"),
+    CONSTRAINT "fk_user_roles_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id"),
+    CONSTRAINT "fk_user_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "groups" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "kind" varchar(100),
+    "describe" varchar(1024),
+    "creator_id" bigint,
+    "updater_id" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_groups_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "user_groups" (
+    "group_id" bigint,
+    "user_id" bigint,
+    PRIMARY KEY ("group_id", "user_id"),
+    CONSTRAINT "fk_user_groups_group" FOREIGN KEY ("group_id") REFERENCES "groups" ("id"),
+    CONSTRAINT "fk_user_groups_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "group_roles" (
+    "group_id" bigint,
+    "role_id" bigint,
+    PRIMARY KEY ("group_id", "role_id"),
+    CONSTRAINT "fk_group_roles_group" FOREIGN KEY ("group_id") REFERENCES "groups" ("id"),
+    CONSTRAINT "fk_group_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "resources" (
+    "id" bigserial,
+    "name" varchar(256) NOT NULL,
+    "scope" text,
+    "kind" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_resources_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "auth_infos" (
+    "id" bigserial,
+    "user_id" bigint,
+    "url" varchar(256),
+    "auth_type" varchar(256),
+    "auth_id" varchar(256),
+    "access_token" varchar(256),
+    "refresh_token" varchar(256),
+    "expiry" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_auth_infos" FOREIGN KEY ("user_id") REFERENCES "users" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "tags" (
+    "id" bigserial,
+    "name" varchar(256) NOT NULL,
+    "sort" bigint,
+    "source_key" varchar(100),
+    "icon_color" varchar(100),
+    "creator_id" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_tags_creator" FOREIGN KEY ("creator_id") REFERENCES "users" ("id"),
+    CONSTRAINT "uni_tags_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "hot_searches" (
+    "id" bigserial,
+    "title" varchar(512) NOT NULL,
+    "link" varchar(512) NOT NULL,
+    "extra" varchar(256),
+    "tag_id" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_hot_searches_tag" FOREIGN KEY ("tag_id") REFERENCES "tags" ("id")
+);
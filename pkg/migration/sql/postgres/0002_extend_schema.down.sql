@@ -0,0 +1,33 @@
+-- 按依赖的相反顺序删除 0002_extend_schema 创建的表，再删除增加的列
+
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhooks";
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "alert_hits";
+DROP TABLE IF EXISTS "alert_rules";
+DROP TABLE IF EXISTS "bookmarks";
+DROP TABLE IF EXISTS "keyword_follows";
+DROP TABLE IF EXISTS "tag_follows";
+DROP TABLE IF EXISTS "reactions";
+DROP TABLE IF EXISTS "comment_reports";
+DROP TABLE IF EXISTS "comments";
+DROP TABLE IF EXISTS "link_previews";
+DROP TABLE IF EXISTS "messages";
+DROP TABLE IF EXISTS "room_members";
+DROP TABLE IF EXISTS "rooms";
+DROP TABLE IF EXISTS "access_token_roles";
+DROP TABLE IF EXISTS "access_tokens";
+DROP TABLE IF EXISTS "recovery_codes";
+DROP INDEX IF EXISTS "idx_hot_searches_rank";
+ALTER TABLE "hot_searches" DROP COLUMN IF EXISTS "rank";
+ALTER TABLE "tags" DROP COLUMN IF EXISTS "resource_version";
+ALTER TABLE "resources" DROP COLUMN IF EXISTS "managed_by";
+ALTER TABLE "groups" DROP COLUMN IF EXISTS "resource_version";
+ALTER TABLE "groups" DROP COLUMN IF EXISTS "managed_by";
+ALTER TABLE "roles" DROP COLUMN IF EXISTS "resource_version";
+ALTER TABLE "roles" DROP COLUMN IF EXISTS "managed_by";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "resource_version";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "mfa_secret";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "mfa_required";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "mfa_enabled";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "service_account";
@@ -0,0 +1,244 @@
+-- 0001_init 之后增加的列和表：MFA、服务账号、访问令牌、乐观锁、配置文件管理的 RBAC、热搜排名、聊天室、评论、回应、关注、收藏、提醒、通知和 webhook。
+-- 使用 IF NOT EXISTS，AutoMigrate 已经创建过其中一部分列和表的数据库也可以执行
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "service_account" boolean;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_enabled" boolean;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_required" boolean;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_secret" varchar(256);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "resource_version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "roles" ADD COLUMN IF NOT EXISTS "managed_by" varchar(100);
+ALTER TABLE "roles" ADD COLUMN IF NOT EXISTS "resource_version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "groups" ADD COLUMN IF NOT EXISTS "managed_by" varchar(100);
+ALTER TABLE "groups" ADD COLUMN IF NOT EXISTS "resource_version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "resources" ADD COLUMN IF NOT EXISTS "managed_by" varchar(100);
+ALTER TABLE "tags" ADD COLUMN IF NOT EXISTS "resource_version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "hot_searches" ADD COLUMN IF NOT EXISTS "rank" bigint;
+CREATE INDEX IF NOT EXISTS "idx_hot_searches_rank" ON "hot_searches" ("rank");
+
+CREATE TABLE IF NOT EXISTS "recovery_codes" (
+    "id" bigserial,
+    "user_id" bigint,
+    "code" varchar(256) NOT NULL,
+    "used" boolean,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_recovery_codes" FOREIGN KEY ("user_id") REFERENCES "users" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "access_tokens" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "user_id" bigint,
+    "prefix" varchar(32),
+    "hash" varchar(256) NOT NULL,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_access_tokens_hash" UNIQUE ("hash")
+);
+CREATE INDEX IF NOT EXISTS "idx_access_tokens_user_id" ON "access_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "access_token_roles" (
+    "access_token_id" bigint,
+    "role_id" bigint,
+    PRIMARY KEY ("access_token_id", "role_id"),
+    CONSTRAINT "fk_access_token_roles_access_token" FOREIGN KEY ("access_token_id") REFERENCES "access_tokens" ("id"),
+    CONSTRAINT "fk_access_token_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "rooms" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "describe" varchar(1024),
+    "group_id" bigint,
+    "creator_id" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_rooms_group_id" ON "rooms" ("group_id");
+
+CREATE TABLE IF NOT EXISTS "room_members" (
+    "room_id" bigint,
+    "user_id" bigint,
+    "last_read_id" bigint,
+    "joined_at" timestamptz,
+    PRIMARY KEY ("room_id", "user_id"),
+    CONSTRAINT "fk_rooms_members" FOREIGN KEY ("room_id") REFERENCES "rooms" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_room_members_user_id" ON "room_members" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "messages" (
+    "id" bigserial,
+    "room_id" bigint NOT NULL,
+    "sender_id" bigint NOT NULL,
+    "content" text NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_messages_sender" FOREIGN KEY ("sender_id") REFERENCES "users" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_messages_room_id" ON "messages" ("room_id");
+
+CREATE TABLE IF NOT EXISTS "link_previews" (
+    "id" bigserial,
+    "message_id" bigint NOT NULL,
+    "url" varchar(2048) NOT NULL,
+    "title" varchar(512),
+    "description" varchar(1024),
+    "image" varchar(2048),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_messages_link_previews" FOREIGN KEY ("message_id") REFERENCES "messages" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_link_previews_message_id" ON "link_previews" ("message_id");
+
+CREATE TABLE IF NOT EXISTS "comments" (
+    "id" bigserial,
+    "target_type" varchar(32) NOT NULL,
+    "target_id" bigint NOT NULL,
+    "parent_id" bigint,
+    "root_id" bigint,
+    "user_id" bigint NOT NULL,
+    "content" text NOT NULL,
+    "deleted_by" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_comments_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_user_id" ON "comments" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_root_id" ON "comments" ("root_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_parent_id" ON "comments" ("parent_id");
+CREATE INDEX IF NOT EXISTS "idx_comment_target" ON "comments" ("target_type", "target_id");
+
+CREATE TABLE IF NOT EXISTS "comment_reports" (
+    "id" bigserial,
+    "comment_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "reason" varchar(512),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_comment_reports_comment" FOREIGN KEY ("comment_id") REFERENCES "comments" ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_comment_report" ON "comment_reports" ("comment_id", "user_id");
+
+CREATE TABLE IF NOT EXISTS "reactions" (
+    "target_type" varchar(32),
+    "target_id" bigint,
+    "user_id" bigint,
+    "emoji" varchar(32),
+    "created_at" timestamptz,
+    PRIMARY KEY ("target_type", "target_id", "user_id", "emoji")
+);
+CREATE INDEX IF NOT EXISTS "idx_reactions_user_id" ON "reactions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "tag_follows" (
+    "user_id" bigint,
+    "tag_id" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("user_id", "tag_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tag_follows_tag_id" ON "tag_follows" ("tag_id");
+
+CREATE TABLE IF NOT EXISTS "keyword_follows" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "keyword" varchar(64) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_keyword_follow" ON "keyword_follows" ("user_id", "keyword");
+
+CREATE TABLE IF NOT EXISTS "bookmarks" (
+    "user_id" bigint,
+    "hot_search_id" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("user_id", "hot_search_id"),
+    CONSTRAINT "fk_bookmarks_hot_search" FOREIGN KEY ("hot_search_id") REFERENCES "hot_searches" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "alert_rules" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "keywords" text,
+    "tag_ids" text,
+    "min_rank" bigint,
+    "channels" text,
+    "webhook_url" varchar(2048),
+    "enabled" boolean NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_alert_rules_user_id" ON "alert_rules" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "alert_hits" (
+    "rule_id" bigint,
+    "tag_id" bigint,
+    "link" varchar(512),
+    "hot_search_id" bigint NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("rule_id", "tag_id", "link")
+);
+
+CREATE TABLE IF NOT EXISTS "notifications" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "rule_id" bigint,
+    "rule_name" varchar(100),
+    "tag_id" bigint,
+    "hot_search_id" bigint,
+    "title" varchar(512),
+    "link" varchar(512),
+    "rank" bigint,
+    "keyword" varchar(64),
+    "read" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_id" ON "notifications" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "webhooks" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "url" varchar(2048) NOT NULL,
+    "secret" varchar(128) NOT NULL,
+    "events" text,
+    "enabled" boolean NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" bigserial,
+    "webhook_id" bigint NOT NULL,
+    "event" varchar(64) NOT NULL,
+    "payload" text NOT NULL,
+    "status" varchar(16) NOT NULL,
+    "attempts" bigint NOT NULL,
+    "next_attempt_at" timestamptz,
+    "redelivery_of" bigint,
+    "response_code" bigint,
+    "response_body" text,
+    "error" text,
+    "duration" bigint,
+    "delivered_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_deliveries_webhook" FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_delivery_due" ON "webhook_deliveries" ("status", "next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
+
@@ -0,0 +1,3 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "mfa_last_step";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "mfa_failures_since";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "mfa_failures";
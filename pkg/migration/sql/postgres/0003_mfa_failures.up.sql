@@ -0,0 +1,5 @@
+-- 二次验证的失败次数和最后一次使用的 TOTP 周期，用于限制尝试次数和防止一次性密码被重复使用
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_failures" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_failures_since" timestamptz;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_last_step" bigint NOT NULL DEFAULT 0;
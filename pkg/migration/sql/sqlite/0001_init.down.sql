@@ -0,0 +1,12 @@
+-- 按依赖的相反顺序删除 0001_init 创建的表
+
+DROP TABLE IF EXISTS "hot_searches";
+DROP TABLE IF EXISTS "tags";
+DROP TABLE IF EXISTS "auth_infos";
+DROP TABLE IF EXISTS "resources";
+DROP TABLE IF EXISTS "group_roles";
+DROP TABLE IF EXISTS "user_groups";
+DROP TABLE IF EXISTS "groups";
+DROP TABLE IF EXISTS "user_roles";
+DROP TABLE IF EXISTS "roles";
+DROP TABLE IF EXISTS "users";
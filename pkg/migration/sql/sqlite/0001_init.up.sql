@@ -0,0 +1,108 @@
+-- 初始的表结构，与 PostgreSQL 的 0001_init 相同，类型使用 SQLite 的类型
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" text NOT NULL,
+    "password" text,
+    "email" text,
+    "avatar" text,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    CONSTRAINT "uni_users_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "roles" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" text NOT NULL,
+    "scope" text,
+    "namespace" text,
+    "rules" json,
+    CONSTRAINT "uni_roles_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "user_roles" (
+    "user_id" integer,
+    "role_id" integer,
+    PRIMARY KEY ("user_id", "role_id"),
+    CONSTRAINT "fk_user_roles_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id"),
+    CONSTRAINT "fk_user_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "groups" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" text NOT NULL,
+    "kind" text,
+    "describe" text,
+    "creator_id" integer,
+    "updater_id" integer,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    CONSTRAINT "uni_groups_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "user_groups" (
+    "group_id" integer,
+    "user_id" integer,
+    PRIMARY KEY ("group_id", "user_id"),
+    CONSTRAINT "fk_user_groups_group" FOREIGN KEY ("group_id") REFERENCES "groups" ("id"),
+    CONSTRAINT "fk_user_groups_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "auth_infos" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer,
+    "url" text,
+    "auth_type" text,
+    "auth_id" text,
+    "access_token" text,
+    "refresh_token" text,
+    "expiry" datetime,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    CONSTRAINT "fk_users_auth_infos" FOREIGN KEY ("user_id") REFERENCES "users" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "tags" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" text NOT NULL,
+    "sort" integer,
+    "source_key" text,
+    "icon_color" text,
+    "creator_id" integer,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    CONSTRAINT "fk_tags_creator" FOREIGN KEY ("creator_id") REFERENCES "users" ("id"),
+    CONSTRAINT "uni_tags_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "hot_searches" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "title" text NOT NULL,
+    "link" text NOT NULL,
+    "extra" text,
+    "tag_id" integer,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    CONSTRAINT "fk_hot_searches_tag" FOREIGN KEY ("tag_id") REFERENCES "tags" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "group_roles" (
+    "group_id" integer,
+    "role_id" integer,
+    PRIMARY KEY ("group_id", "role_id"),
+    CONSTRAINT "fk_group_roles_group" FOREIGN KEY ("group_id") REFERENCES "groups" ("id"),
+    CONSTRAINT "fk_group_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "resources" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" text NOT NULL,
+    "scope" text,
+    "kind" text,
+    CONSTRAINT "uni_resources_name" UNIQUE ("name")
+);
@@ -0,0 +1,231 @@
+-- 与 PostgreSQL 的 0002_extend_schema 相同。SQLite 的 ADD COLUMN 不支持 IF NOT EXISTS，SQLite 数据库只由迁移创建，不需要兼容 AutoMigrate 创建的表
+
+ALTER TABLE "users" ADD COLUMN "service_account" numeric;
+ALTER TABLE "users" ADD COLUMN "mfa_enabled" numeric;
+ALTER TABLE "users" ADD COLUMN "mfa_required" numeric;
+ALTER TABLE "users" ADD COLUMN "mfa_secret" text;
+ALTER TABLE "users" ADD COLUMN "resource_version" integer NOT NULL DEFAULT 1;
+ALTER TABLE "roles" ADD COLUMN "managed_by" text;
+ALTER TABLE "roles" ADD COLUMN "resource_version" integer NOT NULL DEFAULT 1;
+ALTER TABLE "groups" ADD COLUMN "managed_by" text;
+ALTER TABLE "groups" ADD COLUMN "resource_version" integer NOT NULL DEFAULT 1;
+ALTER TABLE "tags" ADD COLUMN "resource_version" integer NOT NULL DEFAULT 1;
+ALTER TABLE "hot_searches" ADD COLUMN "rank" integer;
+ALTER TABLE "resources" ADD COLUMN "managed_by" text;
+CREATE INDEX IF NOT EXISTS "idx_hot_searches_rank" ON "hot_searches" ("rank");
+
+CREATE TABLE IF NOT EXISTS "recovery_codes" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer,
+    "code" text NOT NULL,
+    "used" numeric,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    CONSTRAINT "fk_users_recovery_codes" FOREIGN KEY ("user_id") REFERENCES "users" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "access_tokens" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" text NOT NULL,
+    "user_id" integer,
+    "prefix" text,
+    "hash" text NOT NULL,
+    "expires_at" datetime,
+    "last_used_at" datetime,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    CONSTRAINT "uni_access_tokens_hash" UNIQUE ("hash")
+);
+CREATE INDEX IF NOT EXISTS "idx_access_tokens_user_id" ON "access_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "access_token_roles" (
+    "access_token_id" integer,
+    "role_id" integer,
+    PRIMARY KEY ("access_token_id", "role_id"),
+    CONSTRAINT "fk_access_token_roles_access_token" FOREIGN KEY ("access_token_id") REFERENCES "access_tokens" ("id"),
+    CONSTRAINT "fk_access_token_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "rooms" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" text NOT NULL,
+    "describe" text,
+    "group_id" integer,
+    "creator_id" integer,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_rooms_group_id" ON "rooms" ("group_id");
+
+CREATE TABLE IF NOT EXISTS "room_members" (
+    "room_id" integer,
+    "user_id" integer,
+    "last_read_id" integer,
+    "joined_at" datetime,
+    PRIMARY KEY ("room_id", "user_id"),
+    CONSTRAINT "fk_rooms_members" FOREIGN KEY ("room_id") REFERENCES "rooms" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_room_members_user_id" ON "room_members" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "messages" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "room_id" integer NOT NULL,
+    "sender_id" integer NOT NULL,
+    "content" text NOT NULL,
+    "created_at" datetime,
+    CONSTRAINT "fk_messages_sender" FOREIGN KEY ("sender_id") REFERENCES "users" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_messages_room_id" ON "messages" ("room_id");
+
+CREATE TABLE IF NOT EXISTS "link_previews" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "message_id" integer NOT NULL,
+    "url" text NOT NULL,
+    "title" text,
+    "description" text,
+    "image" text,
+    CONSTRAINT "fk_messages_link_previews" FOREIGN KEY ("message_id") REFERENCES "messages" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_link_previews_message_id" ON "link_previews" ("message_id");
+
+CREATE TABLE IF NOT EXISTS "comments" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "target_type" text NOT NULL,
+    "target_id" integer NOT NULL,
+    "parent_id" integer,
+    "root_id" integer,
+    "user_id" integer NOT NULL,
+    "content" text NOT NULL,
+    "deleted_by" integer,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    CONSTRAINT "fk_comments_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_user_id" ON "comments" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_root_id" ON "comments" ("root_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_parent_id" ON "comments" ("parent_id");
+CREATE INDEX IF NOT EXISTS "idx_comment_target" ON "comments" ("target_type", "target_id");
+
+CREATE TABLE IF NOT EXISTS "comment_reports" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "comment_id" integer NOT NULL,
+    "user_id" integer NOT NULL,
+    "reason" text,
+    "created_at" datetime,
+    CONSTRAINT "fk_comment_reports_comment" FOREIGN KEY ("comment_id") REFERENCES "comments" ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_comment_report" ON "comment_reports" ("comment_id", "user_id");
+
+CREATE TABLE IF NOT EXISTS "reactions" (
+    "target_type" text,
+    "target_id" integer,
+    "user_id" integer,
+    "emoji" text,
+    "created_at" datetime,
+    PRIMARY KEY ("target_type", "target_id", "user_id", "emoji")
+);
+CREATE INDEX IF NOT EXISTS "idx_reactions_user_id" ON "reactions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "tag_follows" (
+    "user_id" integer,
+    "tag_id" integer,
+    "created_at" datetime,
+    PRIMARY KEY ("user_id", "tag_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tag_follows_tag_id" ON "tag_follows" ("tag_id");
+
+CREATE TABLE IF NOT EXISTS "keyword_follows" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "keyword" text NOT NULL,
+    "created_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_keyword_follow" ON "keyword_follows" ("user_id", "keyword");
+
+CREATE TABLE IF NOT EXISTS "bookmarks" (
+    "user_id" integer,
+    "hot_search_id" integer,
+    "created_at" datetime,
+    PRIMARY KEY ("user_id", "hot_search_id"),
+    CONSTRAINT "fk_bookmarks_hot_search" FOREIGN KEY ("hot_search_id") REFERENCES "hot_searches" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "alert_rules" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "name" text NOT NULL,
+    "keywords" text,
+    "tag_ids" text,
+    "min_rank" integer,
+    "channels" text,
+    "webhook_url" text,
+    "enabled" numeric NOT NULL,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_alert_rules_user_id" ON "alert_rules" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "alert_hits" (
+    "rule_id" integer,
+    "tag_id" integer,
+    "link" text,
+    "hot_search_id" integer NOT NULL,
+    "created_at" datetime,
+    PRIMARY KEY ("rule_id", "tag_id", "link")
+);
+
+CREATE TABLE IF NOT EXISTS "notifications" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "rule_id" integer,
+    "rule_name" text,
+    "tag_id" integer,
+    "hot_search_id" integer,
+    "title" text,
+    "link" text,
+    "rank" integer,
+    "keyword" text,
+    "read" numeric NOT NULL DEFAULT false,
+    "created_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_id" ON "notifications" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "webhooks" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" text NOT NULL,
+    "url" text NOT NULL,
+    "secret" text NOT NULL,
+    "events" text,
+    "enabled" numeric NOT NULL,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "webhook_id" integer NOT NULL,
+    "event" text NOT NULL,
+    "payload" text NOT NULL,
+    "status" text NOT NULL,
+    "attempts" integer NOT NULL,
+    "next_attempt_at" datetime,
+    "redelivery_of" integer,
+    "response_code" integer,
+    "response_body" text,
+    "error" text,
+    "duration" integer,
+    "delivered_at" datetime,
+    "created_at" datetime,
+    "updated_at" datetime,
+    CONSTRAINT "fk_webhook_deliveries_webhook" FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_delivery_due" ON "webhook_deliveries" ("status", "next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
+
@@ -0,0 +1,3 @@
+ALTER TABLE "users" DROP COLUMN "mfa_last_step";
+ALTER TABLE "users" DROP COLUMN "mfa_failures_since";
+ALTER TABLE "users" DROP COLUMN "mfa_failures";
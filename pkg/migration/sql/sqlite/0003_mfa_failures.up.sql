@@ -0,0 +1,5 @@
+-- 与 PostgreSQL 的 0003_mfa_failures 相同
+
+ALTER TABLE "users" ADD COLUMN "mfa_failures" integer NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN "mfa_failures_since" datetime;
+ALTER TABLE "users" ADD COLUMN "mfa_last_step" integer NOT NULL DEFAULT 0;
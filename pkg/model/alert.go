@@ -0,0 +1,151 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// 提醒的发送渠道
+const (
+	AlertChannelInbox   = "inbox"   // 站内信
+	AlertChannelWebhook = "webhook" // 推送到 user 指定的 URL
+	AlertChannelEmail   = "email"   // 发送到 user 的邮箱
+)
+
+// AlertRule user 定义的热搜提醒规则：热搜属于 TagIDs 中的 tag 并且标题包含 Keywords 中的任一关键词时提醒。
+// Keywords 或 TagIDs 为空时不按它过滤，但不能同时为空
+type AlertRule struct {
+	ID         uint     `json:"id" gorm:"autoIncrement;primaryKey"`
+	UserID     uint     `json:"userId" gorm:"not null;index"`
+	Name       string   `json:"name" gorm:"size:100;not null"`
+	Keywords   []string `json:"keywords" gorm:"type:text;serializer:json"` // 小写
+	TagIDs     []uint   `json:"tagIds" gorm:"type:text;serializer:json"`
+	MinRank    int      `json:"minRank"` // 排名在前 MinRank 名时才提醒，0 不限制
+	Channels   []string `json:"channels" gorm:"type:text;serializer:json"`
+	WebhookURL string   `json:"webhookUrl" gorm:"size:2048"`
+	Enabled    bool     `json:"enabled" gorm:"not null"`
+
+	BaseModel
+}
+
+// Match 判断热搜是否符合规则，返回匹配的关键词，没有关键词条件时为空
+func (r *AlertRule) Match(hotSearch *HotSearch) (string, bool) {
+	if r.MinRank > 0 && (hotSearch.Rank <= 0 || hotSearch.Rank > r.MinRank) {
+		return "", false
+	}
+	if len(r.TagIDs) > 0 {
+		found := false
+		for _, tid := range r.TagIDs {
+			found = found || tid == hotSearch.TagID
+		}
+		if !found {
+			return "", false
+		}
+	}
+	if len(r.Keywords) == 0 {
+		return "", true
+	}
+	title := strings.ToLower(hotSearch.Title)
+	for _, keyword := range r.Keywords {
+		if strings.Contains(title, keyword) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+// HasChannel 判断规则是否使用 channel 发送提醒
+func (r *AlertRule) HasChannel(channel string) bool {
+	for _, c := range r.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatedAlertRule 创建或修改提醒规则的参数
+type CreatedAlertRule struct {
+	Name       string   `json:"name" binding:"required,max=100"`
+	Keywords   []string `json:"keywords" binding:"max=20,dive,required,max=64"`
+	TagIDs     []uint   `json:"tagIds" binding:"max=50"`
+	MinRank    int      `json:"minRank" binding:"min=0,max=200"`
+	Channels   []string `json:"channels" binding:"required,min=1,max=3,dive,oneof=inbox webhook email"`
+	WebhookURL string   `json:"webhookUrl" binding:"omitempty,url,max=2048"`
+	Enabled    *bool    `json:"enabled"` // 为空时启用
+}
+
+// GetAlertRule 转换为 user 的提醒规则，关键词去掉首尾空白后转为小写并去重
+func (c *CreatedAlertRule) GetAlertRule(uid uint) *AlertRule {
+	keywords := make([]string, 0, len(c.Keywords))
+	seen := make(map[string]bool)
+	for _, keyword := range c.Keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" || seen[keyword] {
+			continue
+		}
+		seen[keyword] = true
+		keywords = append(keywords, keyword)
+	}
+	tagIDs := c.TagIDs
+	if tagIDs == nil {
+		tagIDs = make([]uint, 0)
+	}
+	enabled := true
+	if c.Enabled != nil {
+		enabled = *c.Enabled
+	}
+	return &AlertRule{
+		UserID:     uid,
+		Name:       c.Name,
+		Keywords:   keywords,
+		TagIDs:     tagIDs,
+		MinRank:    c.MinRank,
+		Channels:   c.Channels,
+		WebhookURL: c.WebhookURL,
+		Enabled:    enabled,
+	}
+}
+
+// AlertHit 已经提醒过的规则和热搜，用于去重：同一条热搜（tag 和 link 相同）对每个规则只提醒一次
+type AlertHit struct {
+	RuleID      uint   `gorm:"primaryKey"`
+	TagID       uint   `gorm:"primaryKey"`
+	Link        string `gorm:"primaryKey;size:512"`
+	HotSearchID uint   `gorm:"not null"`
+	CreatedAt   time.Time
+}
+
+// Alert 发送给 user 的一条提醒
+type Alert struct {
+	RuleID      uint      `json:"ruleId"`
+	RuleName    string    `json:"ruleName"`
+	UserID      uint      `json:"userId"`
+	TagID       uint      `json:"tagId"`
+	HotSearchID uint      `json:"hotSearchId"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	Extra       string    `json:"extra"`
+	Rank        int       `json:"rank"`
+	Keyword     string    `json:"keyword,omitempty"` // 匹配的关键词
+	CreatedAt   time.Time `json:"createdAt"`
+
+	Rule *AlertRule `json:"-"` // 发送渠道需要的规则配置
+	User *User      `json:"-"` // 提醒的 user
+}
+
+// Notification 站内信中的一条提醒
+type Notification struct {
+	ID          uint      `json:"id" gorm:"autoIncrement;primaryKey"`
+	UserID      uint      `json:"userId" gorm:"not null;index"`
+	RuleID      uint      `json:"ruleId"`
+	RuleName    string    `json:"ruleName" gorm:"size:100"`
+	TagID       uint      `json:"tagId"`
+	HotSearchID uint      `json:"hotSearchId"`
+	Title       string    `json:"title" gorm:"size:512"`
+	Link        string    `json:"link" gorm:"size:512"`
+	Rank        int       `json:"rank"`
+	Keyword     string    `json:"keyword" gorm:"size:64"`
+	Read        bool      `json:"read" gorm:"not null;default:false"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,66 @@
+package model
+
+// 批量导入时每一行的操作
+const (
+	ImportCreate = "create" // 名称不存在，创建
+	ImportUpdate = "update" // 名称已存在，修改
+)
+
+// BulkResources 可以批量导入导出的资源，导入时按这个顺序处理，保证引用的 role 和 group 先存在
+var BulkResources = []string{RoleResource, GroupResource, UserResource}
+
+// BulkRole 批量导入导出的 role，按名称匹配已有的 role
+type BulkRole struct {
+	Name      string `json:"name" yaml:"name" binding:"required,max=100"`
+	Scope     Scope  `json:"scope" yaml:"scope" binding:"required,scope"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty" binding:"required_if=Scope namespace,max=100"`
+	Rules     Rules  `json:"rules" yaml:"rules" binding:"dive"`
+}
+
+// BulkGroup 批量导入导出的 group，按名称匹配已有的 group。
+// Roles 为 nil 时不修改已有 group 的 role，为空列表时清空
+type BulkGroup struct {
+	Name     string   `json:"name" yaml:"name" binding:"required,max=100"`
+	Describe string   `json:"describe,omitempty" yaml:"describe,omitempty" binding:"max=1024"`
+	Roles    []string `json:"roles" yaml:"roles"` // role 名称
+}
+
+// BulkUser 批量导入导出的 user，按名称匹配已有的 user。
+// 新建的 user 必须有密码（服务账号除外），已有的 user 密码为空时不修改；导出时不包含密码。
+// Groups、Roles 为 nil 时不修改已有 user 的 group 和 role，为空列表时清空
+type BulkUser struct {
+	Name           string   `json:"name" yaml:"name" binding:"required,max=100"`
+	Email          string   `json:"email,omitempty" yaml:"email,omitempty" binding:"omitempty,max=256,email"`
+	Avatar         string   `json:"avatar,omitempty" yaml:"avatar,omitempty" binding:"max=256"`
+	Password       string   `json:"password,omitempty" yaml:"password,omitempty" binding:"password"`
+	ServiceAccount bool     `json:"serviceAccount,omitempty" yaml:"serviceAccount,omitempty"` // 只能在创建时指定
+	Groups         []string `json:"groups" yaml:"groups"`                                     // group 名称
+	Roles          []string `json:"roles" yaml:"roles"`                                       // role 名称
+}
+
+// BulkDocument 批量导入导出的 RBAC 配置。
+// 名称的格式只在创建时按创建接口的规则校验，所以已有的系统 group（例如 system:authenticated）也可以导入
+type BulkDocument struct {
+	Roles  []BulkRole  `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Groups []BulkGroup `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Users  []BulkUser  `json:"users,omitempty" yaml:"users,omitempty"`
+}
+
+// ImportRow 批量导入中一行的处理结果
+type ImportRow struct {
+	Resource string   `json:"resource"`         // roles、groups 或 users
+	Row      int      `json:"row"`              // 在该资源中的序号，从 1 开始，CSV 不包括表头
+	Name     string   `json:"name"`             // 名称
+	Action   string   `json:"action,omitempty"` // create 或 update，有错误时为空
+	Errors   []string `json:"errors,omitempty"` // 校验错误
+}
+
+// ImportResult 批量导入的结果。有任何一行出错时不导入任何数据
+type ImportResult struct {
+	DryRun  bool        `json:"dryRun"`  // 只校验，不导入
+	Applied bool        `json:"applied"` // 是否已导入
+	Created int         `json:"created"` // 创建的数量
+	Updated int         `json:"updated"` // 修改的数量
+	Failed  int         `json:"failed"`  // 出错的行数
+	Rows    []ImportRow `json:"rows"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+const (
+	CommentUserAssociation   = "User"    // 评论作者关联
+	ReportCommentAssociation = "Comment" // 举报的评论关联
+)
+
+// CommentTargets 可以评论的对象：热搜和话题（tag）
+var CommentTargets = []string{HotSearchResource, TagResource}
+
+// ReactionTargets 可以回应的对象：热搜、话题（tag）和评论
+var ReactionTargets = []string{HotSearchResource, TagResource, CommentResource}
+
+// Comment 热搜或话题下的评论。
+// RootID 是所在楼层的顶层评论，回复通过 ParentID 组成树，按楼层分页
+type Comment struct {
+	ID         uint   `json:"id" gorm:"autoIncrement;primaryKey"`
+	TargetType string `json:"targetType" gorm:"size:32;not null;index:idx_comment_target"` // hotsearches 或 tags
+	TargetID   uint   `json:"targetId" gorm:"not null;index:idx_comment_target"`
+	ParentID   *uint  `json:"parentId" gorm:"index"` // 回复的评论，为空时是顶层评论
+	RootID     *uint  `json:"rootId" gorm:"index"`   // 所在楼层的顶层评论，为空时是顶层评论
+	UserID     uint   `json:"userId" gorm:"not null;index"`
+	User       *User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Content    string `json:"content" gorm:"type:text;not null"`
+	DeletedBy  *uint  `json:"-"` // 删除评论的 user，作者或管理员
+
+	Deleted   bool             `json:"deleted,omitempty" gorm:"-"`   // 已删除但还有回复，只保留位置
+	Reactions map[string]int64 `json:"reactions,omitempty" gorm:"-"` // emoji -> 回应数
+	Replies   []Comment        `json:"replies,omitempty" gorm:"-"`
+
+	BaseModel
+}
+
+// CreatedComment 发表评论的参数
+type CreatedComment struct {
+	Content  string `json:"content" binding:"required,max=2000"`
+	ParentID *uint  `json:"parentId"` // 回复的评论
+}
+
+// CommentPage 一页顶层评论和它们的回复
+type CommentPage struct {
+	Items      []Comment `json:"items"`
+	NextBefore uint      `json:"nextBefore,omitempty"` // 下一页的 before，为 0 时没有更多评论
+}
+
+// CommentReport user 对评论的举报，每个 user 对同一条评论只能举报一次
+type CommentReport struct {
+	ID        uint      `json:"id" gorm:"autoIncrement;primaryKey"`
+	CommentID uint      `json:"commentId" gorm:"not null;uniqueIndex:idx_comment_report"`
+	Comment   *Comment  `json:"comment,omitempty"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_comment_report"`
+	Reason    string    `json:"reason" gorm:"size:512"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreatedCommentReport 举报评论的参数
+type CreatedCommentReport struct {
+	Reason string `json:"reason" binding:"required,max=512"`
+}
+
+// Reaction user 对热搜、话题或评论的 emoji 回应，每个 user 对同一个对象的同一个 emoji 只能回应一次
+type Reaction struct {
+	TargetType string    `json:"targetType" gorm:"primaryKey;size:32"`
+	TargetID   uint      `json:"targetId" gorm:"primaryKey"`
+	UserID     uint      `json:"userId" gorm:"primaryKey;index"`
+	Emoji      string    `json:"emoji" gorm:"primaryKey;size:32"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
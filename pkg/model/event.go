@@ -0,0 +1,19 @@
+package model
+
+// EventType 资源变更事件的类型
+type EventType string
+
+const (
+	EventAdded    EventType = "ADDED"    // 创建
+	EventModified EventType = "MODIFIED" // 修改
+	EventDeleted  EventType = "DELETED"  // 删除
+)
+
+// Event 资源变更事件，由仓库在写入成功后发布
+type Event struct {
+	Type            EventType   `json:"type"`
+	Resource        string      `json:"resource"`        // 资源名称，如 users、groups
+	ResourceVersion uint64      `json:"resourceVersion"` // 事件序号，单调递增，用于断线后从该序号之后继续 watch
+	ObjectID        uint        `json:"objectId"`        // 发生变更的对象 id
+	Object          interface{} `json:"object"`          // 变更后的对象，删除事件只包含 id
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const BookmarkHotSearchAssociation = "HotSearch" // 收藏的热搜关联
+
+// TagFollow user 关注的 tag
+type TagFollow struct {
+	UserID    uint      `json:"userId" gorm:"primaryKey"`
+	TagID     uint      `json:"tagId" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// KeywordFollow user 关注的关键词，标题包含关键词（不区分大小写）的热搜会出现在个性化热搜中
+type KeywordFollow struct {
+	ID        uint      `json:"id" gorm:"autoIncrement;primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_keyword_follow"`
+	Keyword   string    `json:"keyword" gorm:"size:64;not null;uniqueIndex:idx_keyword_follow"` // 小写
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreatedKeywordFollow 关注关键词的参数
+type CreatedKeywordFollow struct {
+	Keyword string `json:"keyword" binding:"required,max=64"`
+}
+
+// Follows user 关注的 tag 和关键词
+type Follows struct {
+	Tags     []Tag           `json:"tags"`
+	Keywords []KeywordFollow `json:"keywords"`
+}
+
+// Bookmark user 收藏的热搜，热搜下榜后仍然保留
+type Bookmark struct {
+	UserID      uint       `json:"userId" gorm:"primaryKey"`
+	HotSearchID uint       `json:"hotSearchId" gorm:"primaryKey"`
+	HotSearch   *HotSearch `json:"hotSearch,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// FeedItem 个性化热搜中的一条，Reasons 说明推荐的原因：tag 或者 keyword:<关键词>
+type FeedItem struct {
+	HotSearch
+	Score      float64  `json:"score"`
+	Reasons    []string `json:"reasons"`
+	Bookmarked bool     `json:"bookmarked"`
+}
+
+// Feed user 的个性化热搜，按得分从高到低排序
+type Feed struct {
+	Items       []FeedItem `json:"items"`
+	GeneratedAt time.Time  `json:"generatedAt"` // 计算时间，缓存期间不变
+}
+
+// FeedCacheKey 返回 user 个性化热搜在 Redis 中的 key，格式： feeds:uid
+func FeedCacheKey(uid uint) string {
+	return "feeds:" + strconv.Itoa(int(uid))
+}
+
+// MarshalBinary 设置 Redis 时使用
+func (f *Feed) MarshalBinary() ([]byte, error) {
+	return json.Marshal(f)
+}
+
+// UnmarshalBinary 获取 Redis 时使用
+func (f *Feed) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, f)
+}
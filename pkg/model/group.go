@@ -10,6 +10,8 @@
  */
 package model
 
+import "strings"
+
 const (
 	RootGroup            = "root"                   // 根用户组
 	AuthenticatedGroup   = "system:authenticated"   // 系统已认证
@@ -18,6 +20,14 @@ const (
 	CustomGroup          = "custom"                 // 自定义
 )
 
+// IsSystemGroup root 和 system: 开头的 group 由系统创建，root 组的成员是管理员，普通用户不能创建或改名成这些 group
+func IsSystemGroup(name string) bool {
+	return name == RootGroup || strings.HasPrefix(name, SystemGroup+":")
+}
+
+// GroupPatchFields PATCH 时允许修改的 group 字段，json 字段名和数据库列名相同
+var GroupPatchFields = []string{"name", "describe"}
+
 type Group struct {
 	ID        uint   `json:"id" gorm:"autoIncrement;primaryKey"`
 	Name      string `json:"name" gorm:"size:100;not null;unique"`
@@ -27,15 +37,18 @@ type Group struct {
 	UpdaterId uint   `json:"updaterId"`                           // 更新 Id
 	Users     []User `json:"users" gorm:"many2many:user_groups;"` // 用户集合
 	Roles     []Role `json:"roles" gorm:"many2many:group_roles;"` // 角色组集合
+	ManagedBy string `json:"managedBy,omitempty" gorm:"size:100"` // 管理者，由声明式 RBAC 配置文件创建时为 rbac-file
+
+	ResourceVersion uint64 `json:"resourceVersion" gorm:"not null;default:1"` // 版本号，每次修改加 1，用作 ETag
 
 	BaseModel
 }
 
 // CreatedGroup 创建分组结构体
 type CreatedGroup struct {
-	Name      string `json:"name"`
-	Describe  string `json:"describe"`  // 描述
-	CreatorId uint   `json:"creatorId"` // 创建者ID
+	Name      string `json:"name" binding:"required,max=100,name"`
+	Describe  string `json:"describe" binding:"max=1024"` // 描述
+	CreatorId uint   `json:"creatorId"`                   // 创建者ID
 }
 
 /**
@@ -53,8 +66,8 @@ func (g *CreatedGroup) GetGroup(uid uint) *Group {
 
 // UpdatedGroup 修改分组结构体
 type UpdatedGroup struct {
-	Name      string `json:"name"`
-	Describe  string `json:"describe"`
+	Name      string `json:"name" binding:"required,max=100,name"`
+	Describe  string `json:"describe" binding:"max=1024"`
 	UpdaterId uint   `json:"updaterId"`
 }
 
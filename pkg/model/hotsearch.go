@@ -1,14 +1,64 @@
 package model
 
+import "time"
+
 // HostList 热搜列表结构
 type HotSearch struct {
 	ID    uint   `json:"id" gorm:"autoIncrement;primaryKey"`
 	Title string `json:"title" gorm:"size:512;not null"`
 	Link  string `json:"link" gorm:"size:512;not null"`
 	Extra string `json:"extra" gorm:"size:256"`
+	Rank  int    `json:"rank" gorm:"index"` // 在 tag 热搜榜中的排名，从 1 开始
 
 	Tag   Tag  `json:"tag" gorm:"foreignKey:TagID"`
 	TagID uint `json:"tagId"`
 
+	CommentCount int64            `json:"commentCount" gorm:"-"` // 评论数
+	Reactions    map[string]int64 `json:"reactions" gorm:"-"`    // emoji -> 回应数
+
 	BaseModel
 }
+
+// CreatedHotSearch 采集器提交的热搜快照中的一条，排名由在快照中的顺序决定
+type CreatedHotSearch struct {
+	Title string `json:"title" binding:"required,max=512"`
+	Link  string `json:"link" binding:"required,max=512"`
+	Extra string `json:"extra" binding:"max=256"`
+}
+
+// HotSearchSnapshot 采集器提交的 tag 热搜快照，按排名排序
+type HotSearchSnapshot struct {
+	Items []CreatedHotSearch `json:"items" binding:"required,max=200,dive"`
+}
+
+// GetHotSearches 转换为 tag 下的热搜列表，排名从 1 开始
+func (s *HotSearchSnapshot) GetHotSearches(tagID uint) []HotSearch {
+	hotSearches := make([]HotSearch, 0, len(s.Items))
+	for i, item := range s.Items {
+		hotSearches = append(hotSearches, HotSearch{
+			Title: item.Title,
+			Link:  item.Link,
+			Extra: item.Extra,
+			Rank:  i + 1,
+			TagID: tagID,
+		})
+	}
+	return hotSearches
+}
+
+// RankChange 热搜排名的变化，PreviousRank 为 0 表示新上榜，Rank 为 0 表示已下榜
+type RankChange struct {
+	Title        string `json:"title"`
+	Link         string `json:"link"`
+	Extra        string `json:"extra"`
+	Rank         int    `json:"rank"`
+	PreviousRank int    `json:"previousRank"`
+}
+
+// HotSearchUpdate tag 热搜榜的增量更新，只包含排名或内容有变化的热搜
+type HotSearchUpdate struct {
+	TagID     uint         `json:"tagId"`
+	Changes   []RankChange `json:"changes"`
+	Total     int          `json:"total"` // 更新后榜单的热搜数量
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
@@ -0,0 +1,50 @@
+package model
+
+// RecoveryCode 二次验证的一次性恢复码，只保存哈希值
+type RecoveryCode struct {
+	ID     uint   `json:"id" gorm:"autoIncrement;primaryKey"`
+	UserId uint   `json:"userId" gorm:"index"`
+	Code   string `json:"-" gorm:"size:256;not null"` // 恢复码的 sha256 哈希
+	Used   bool   `json:"used"`
+
+	BaseModel
+}
+
+func (*RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// MFAEnrollment 开启二次验证时返回的密钥和二维码 URI
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth URI，前端渲染为二维码
+}
+
+// MFACode 绑定前端传入的一次性密码
+type MFACode struct {
+	Code string `json:"code"`
+}
+
+// MFARecoveryCodes 恢复码明文，只在生成时返回一次
+type MFARecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
+
+// MFARequirement 管理员设置 user 是否必须开启二次验证
+type MFARequirement struct {
+	Required bool `json:"required"`
+}
+
+// MFAChallenge 登录需要二次验证时返回的挑战
+type MFAChallenge struct {
+	Challenge      string `json:"challenge"`      // 挑战 token
+	EnrollRequired bool   `json:"enrollRequired"` // 是否需要先绑定身份验证器
+	Describe       string `json:"describe"`
+}
+
+// MFALogin 完成二次验证，code 可以是一次性密码或恢复码
+type MFALogin struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+	SetCookie bool   `json:"setCookie"`
+}
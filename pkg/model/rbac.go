@@ -33,13 +33,24 @@ const (
 	NamespaceScope Scope = "namespace" // 命名空间范围
 )
 
+const (
+	ClusterAdminRole       = "cluster-admin"       // 管理员角色
+	HotSearchCollectorRole = "hotsearch-collector" // 热搜采集器的角色，拥有它的 user 可以提交热搜快照
+)
+
+// RolePatchFields PATCH 时允许修改的 role 字段，json 字段名和数据库列名相同
+var RolePatchFields = []string{"name", "scope", "namespace", "rules"}
+
 // Role 角色 结构体
 type Role struct {
 	ID        uint   `json:"id" gorm:"autoIncrement;primaryKey"`
-	Name      string `json:"name" gorm:"size:100;not null;unique"`
-	Scope     Scope  `json:"scope" gorm:"size:100"`      // Scope 表示范围，string类型
-	Namespace string `json:"namespace"  gorm:"size:100"` // 表示命名空间
-	Rules     Rules  `json:"rules" gorm:"type:json"`     // Rules 表示规则集合，是切片类型
+	Name      string `json:"name" gorm:"size:100;not null;unique" binding:"required,max=100,name"`
+	Scope     Scope  `json:"scope" gorm:"size:100" binding:"required,scope"`                           // Scope 表示范围，string类型
+	Namespace string `json:"namespace"  gorm:"size:100" binding:"required_if=Scope namespace,max=100"` // 表示命名空间，namespace 范围时必填
+	Rules     Rules  `json:"rules" gorm:"type:json" binding:"dive"`                                    // Rules 表示规则集合，是切片类型
+	ManagedBy string `json:"managedBy,omitempty" gorm:"size:100"`                                      // 管理者，由声明式 RBAC 配置文件创建时为 rbac-file
+
+	ResourceVersion uint64 `json:"resourceVersion" gorm:"not null;default:1"` // 版本号，每次修改加 1，用作 ETag
 }
 
 // Operation 表示操作，是自定义 string 类型
@@ -54,10 +65,10 @@ const (
 
 // 全局变量
 var (
-	// 设置编辑操作（创建、删除、更新、补丁，获取，列表）
-	EditOperationSet = set.NewString(request.CreateOperation, request.DeleteOperation, request.UpdateOperation, request.PatchOperation, request.GetOperation, request.ListOperation)
-	// 设置查看操作（获取、列表）
-	ViewOperationSet = set.NewString(request.GetOperation, request.ListOperation)
+	// 设置编辑操作（创建、删除、更新、补丁，获取，列表，监听）
+	EditOperationSet = set.NewString(request.CreateOperation, request.DeleteOperation, request.UpdateOperation, request.PatchOperation, request.GetOperation, request.ListOperation, request.WatchOperation)
+	// 设置查看操作（获取、列表，监听）
+	ViewOperationSet = set.NewString(request.GetOperation, request.ListOperation, request.WatchOperation)
 )
 
 /**
@@ -81,8 +92,8 @@ func (op Operation) Contain(verb string) bool {
 
 // Rule 规则结构体
 type Rule struct {
-	Resource  string    `json:"resource"`  // 资源
-	Operation Operation `json:"operation"` // 操作
+	Resource  string    `json:"resource" binding:"required"`  // 资源
+	Operation Operation `json:"operation" binding:"required"` // 操作
 }
 
 // Rules 表示规则集合： Rule 切片
@@ -95,8 +106,14 @@ type Rules []Rule
  * @Date: 2023-11-10 11:06:36
  */
 func (r *Rules) Scan(value interface{}) error {
-	bytes, ok := value.([]byte)
-	if !ok {
+	// PostgreSQL 返回 []byte，SQLite 返回 string
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
 		return fmt.Errorf("failed to numarshal JSONB value:%v", value)
 	}
 
@@ -124,11 +141,23 @@ const (
 const (
 	ContainerResource = "containers" // 容器资源
 	// PostResource      = "posts"      // post资源
-	UserResource      = "users"      // user资源
-	GroupResource     = "groups"     // 组资源
-	RoleResource      = "roles"      // Role角色资源
-	AuthResource      = "auth"       // 授权资源
-	NamespaceResource = "namespaces" // 命名空间资源
+	UserResource         = "users"         // user资源
+	GroupResource        = "groups"        // 组资源
+	RoleResource         = "roles"         // Role角色资源
+	AuthResource         = "auth"          // 授权资源
+	NamespaceResource    = "namespaces"    // 命名空间资源
+	TagResource          = "tags"          // 标签资源
+	HotSearchResource    = "hotsearches"   // 热搜资源
+	RoomResource         = "rooms"         // 聊天室资源
+	MessageResource      = "messages"      // 聊天消息资源
+	CommentResource      = "comments"      // 评论资源
+	ReactionResource     = "reactions"     // 回应资源
+	FollowResource       = "follows"       // 关注资源
+	BookmarkResource     = "bookmarks"     // 收藏资源
+	FeedResource         = "feed"          // 个性化热搜资源
+	AlertResource        = "alerts"        // 热搜提醒规则资源
+	NotificationResource = "notifications" // 站内信资源
+	WebhookResource      = "webhooks"      // webhook 资源
 )
 
 // Resource 资源结构体
@@ -137,4 +166,6 @@ type Resource struct {
 	Name  string `json:"name" gorm:"size:256;not null;unique"`
 	Scope Scope  `json:"scope"`
 	Kind  string `json:"kind"`
+
+	ManagedBy string `json:"managedBy,omitempty" gorm:"size:100"` // 管理者，由声明式 RBAC 配置文件创建时为 rbac-file
 }
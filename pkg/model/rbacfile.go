@@ -0,0 +1,86 @@
+package model
+
+// ManagedByRBACFile 由声明式 RBAC 配置文件管理的 resource、role 和 group 的 managedBy。
+// 同步时只会修改和删除自己管理的对象，和配置文件中同名但不是它管理的对象视为冲突
+const ManagedByRBACFile = "rbac-file"
+
+// 同步声明式 RBAC 配置时的操作
+const (
+	RBACCreate = "create"
+	RBACUpdate = "update"
+	RBACDelete = "delete"
+)
+
+// 同步声明式 RBAC 配置时修改的对象种类，除了 roles 和 groups 之外还有资源、绑定和 group 成员
+const (
+	RBACResourceKind   = "resources"   // 资源
+	RBACBindingKind    = "bindings"    // role 绑定到 user 或 group
+	RBACMembershipKind = "memberships" // user 加入 group
+)
+
+// RBACFile 声明式 RBAC 配置文件
+type RBACFile struct {
+	Resources []RBACFileResource `json:"resources" yaml:"resources"`
+	Roles     []BulkRole         `json:"roles" yaml:"roles"`
+	Groups    []RBACFileGroup    `json:"groups" yaml:"groups"`
+	Bindings  []RBACFileBinding  `json:"bindings" yaml:"bindings"`
+}
+
+// RBACFileResource 配置文件中的资源
+type RBACFileResource struct {
+	Name  string `json:"name" yaml:"name" binding:"required,max=256"`
+	Scope Scope  `json:"scope" yaml:"scope" binding:"required,scope"`
+	Kind  string `json:"kind,omitempty" yaml:"kind,omitempty" binding:"omitempty,oneof=resource menu"`
+}
+
+// RBACFileGroup 配置文件中的 group。Users 为 nil 时不管理 group 的成员，否则成员和 Users 一致
+type RBACFileGroup struct {
+	Name     string   `json:"name" yaml:"name" binding:"required,max=100,name"`
+	Describe string   `json:"describe,omitempty" yaml:"describe,omitempty" binding:"max=1024"`
+	Users    []string `json:"users,omitempty" yaml:"users,omitempty"` // user 名称
+}
+
+// RBACFileBinding 把配置文件中的 role 绑定到 user 和 group。
+// 配置文件管理的 role 的全部绑定都由配置文件决定，同一个 role 可以有多个绑定
+type RBACFileBinding struct {
+	Role   string   `json:"role" yaml:"role" binding:"required"`
+	Users  []string `json:"users,omitempty" yaml:"users,omitempty"`   // user 名称
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"` // group 名称
+}
+
+// RBACState 同步前数据库中的 RBAC 对象
+type RBACState struct {
+	Resources []Resource
+	Roles     []Role
+	Groups    []Group // 包括成员和 role
+	Users     []User  // 包括 role
+}
+
+// RBACBinding 绑定的两端。Role 和 User 或 Group 表示 role 绑定，Role 为空时表示 User 加入 Group
+type RBACBinding struct {
+	Role  string `json:"role,omitempty"`
+	Group string `json:"group,omitempty"`
+	User  string `json:"user,omitempty"`
+}
+
+// RBACChange 同步声明式 RBAC 配置时的一个修改
+type RBACChange struct {
+	Action  string       `json:"action"`            // create、update 或 delete
+	Kind    string       `json:"kind"`              // resources、roles、groups、bindings 或 memberships
+	Name    string       `json:"name"`              // 对象的名称
+	Fields  []string     `json:"fields,omitempty"`  // 修改的字段
+	Binding *RBACBinding `json:"binding,omitempty"` // 绑定和成员的两端
+
+	Resource *Resource `json:"-"` // 创建或修改后的对象
+	Role     *Role     `json:"-"`
+	Group    *Group    `json:"-"`
+}
+
+// RBACPlan 声明式 RBAC 配置和数据库的差异。有冲突时不会同步
+type RBACPlan struct {
+	File      string       `json:"file"`                // 配置文件路径
+	DryRun    bool         `json:"dryRun"`              // 只计算差异，不同步
+	Applied   bool         `json:"applied"`             // 是否已同步
+	Changes   []RBACChange `json:"changes"`             // 按执行顺序排列的修改
+	Conflicts []string     `json:"conflicts,omitempty"` // 配置文件要修改不是它管理的对象等冲突
+}
@@ -0,0 +1,88 @@
+package model
+
+import "time"
+
+const (
+	RoomMemberAssociation     = "Members"      // 聊天室成员关联
+	MessageSenderAssociation  = "Sender"       // 消息发送者关联
+	MessagePreviewAssociation = "LinkPreviews" // 消息链接预览关联
+)
+
+// Room 聊天室。GroupID 不为空时绑定 group，group 中的 user 都是成员；
+// 否则是临时聊天室，成员保存在 room_members 中
+type Room struct {
+	ID        uint         `json:"id" gorm:"autoIncrement;primaryKey"`
+	Name      string       `json:"name" gorm:"size:100;not null"`
+	Describe  string       `json:"describe" gorm:"size:1024"`
+	GroupID   *uint        `json:"groupId" gorm:"uniqueIndex"` // 每个 group 最多绑定一个聊天室
+	CreatorID uint         `json:"creatorId"`
+	Members   []RoomMember `json:"members,omitempty"`
+
+	UnreadCount int64 `json:"unreadCount" gorm:"-"` // 当前 user 的未读消息数
+
+	BaseModel
+}
+
+// IsGroupRoom 判断是不是绑定 group 的聊天室
+func (r *Room) IsGroupRoom() bool {
+	return r.GroupID != nil
+}
+
+// RoomMember 聊天室成员和已读位置。
+// group 聊天室的成员由 group 决定，这里只记录已读位置
+type RoomMember struct {
+	RoomID     uint      `json:"roomId" gorm:"primaryKey"`
+	UserID     uint      `json:"userId" gorm:"primaryKey;index"`
+	LastReadID uint      `json:"lastReadId"` // 最后已读消息的 id
+	JoinedAt   time.Time `json:"joinedAt"`
+}
+
+// Message 聊天消息
+type Message struct {
+	ID           uint          `json:"id" gorm:"autoIncrement;primaryKey"`
+	RoomID       uint          `json:"roomId" gorm:"index;not null"`
+	SenderID     uint          `json:"senderId" gorm:"not null"`
+	Sender       *User         `json:"sender,omitempty" gorm:"foreignKey:SenderID"`
+	Content      string        `json:"content" gorm:"type:text;not null"`
+	LinkPreviews []LinkPreview `json:"linkPreviews" gorm:"foreignKey:MessageID"`
+	CreatedAt    time.Time     `json:"createdAt"`
+}
+
+// LinkPreview 消息中链接的预览，发送消息后异步获取
+type LinkPreview struct {
+	ID          uint   `json:"id" gorm:"autoIncrement;primaryKey"`
+	MessageID   uint   `json:"messageId" gorm:"index;not null"`
+	URL         string `json:"url" gorm:"size:2048;not null"`
+	Title       string `json:"title" gorm:"size:512"`
+	Description string `json:"description" gorm:"size:1024"`
+	Image       string `json:"image" gorm:"size:2048"`
+}
+
+// CreatedRoom 创建聊天室的参数，GroupID 和 Members 只能设置一个
+type CreatedRoom struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Describe string `json:"describe" binding:"max=1024"`
+	GroupID  *uint  `json:"groupId"`                   // 绑定的 group
+	Members  []uint `json:"members" binding:"max=500"` // 临时聊天室的成员，创建者自动加入
+}
+
+// GetRoom 返回 uid 创建的聊天室
+func (r *CreatedRoom) GetRoom(uid uint) *Room {
+	return &Room{
+		Name:      r.Name,
+		Describe:  r.Describe,
+		GroupID:   r.GroupID,
+		CreatorID: uid,
+	}
+}
+
+// CreatedMessage 发送消息的参数
+type CreatedMessage struct {
+	Content string `json:"content" binding:"required,max=4000"`
+}
+
+// MessagePage 分页的历史消息，按 id 从新到旧排序，NextBefore 为 0 表示没有更早的消息
+type MessagePage struct {
+	Items      []Message `json:"items"`
+	NextBefore uint      `json:"nextBefore"` // 下一页的 before 参数
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Session 登录会话，每次签发 token 都会创建一个会话，保存在 Redis 中
+type Session struct {
+	ID         string    `json:"id"`
+	UserId     uint      `json:"userId"`
+	UserAgent  string    `json:"userAgent"` // 设备（客户端的用户代理）
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"` // 是否是当前请求使用的会话
+}
+
+// SessionCacheKey 返回 user 会话的 key，格式： sessions:uid
+func SessionCacheKey(uid uint) string {
+	return fmt.Sprintf("sessions:%d", uid)
+}
+
+// CacheKey 返回会话所在的 key
+func (s *Session) CacheKey() string {
+	return SessionCacheKey(s.UserId)
+}
+
+// Expired 判断会话是否已过期
+func (s *Session) Expired() bool {
+	return s.ExpiresAt.Before(time.Now())
+}
+
+// MarshalBinary 设置 Redis 时使用
+func (s *Session) MarshalBinary() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// UnmarshalBinary 获取 Redis 时使用
+func (s *Session) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, s)
+}
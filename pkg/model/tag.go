@@ -11,5 +11,7 @@ type Tag struct {
 	Creator   User `json:"creator" gorm:"foreignKey:CreatorID"`
 	CreatorID uint `json:"creatorId"`
 
+	ResourceVersion uint64 `json:"resourceVersion" gorm:"not null;default:1"` // 版本号，每次修改加 1，用作 ETag
+
 	BaseModel
 }
@@ -1,6 +1,52 @@
 package model
 
+import "time"
+
+const (
+	AccessTokenPrefix           = "cct_"  // 个人访问 token 的前缀，用于区分 JWT
+	AccessTokenRoleAssociation  = "Roles" // 访问 token 角色关联
+	AccessTokenDisplayPrefixLen = 8       // 列表中展示的 token 前缀长度
+)
+
 type JWTToken struct {
-	Token    string `json:"token"`
-	Describe string `json:"describe"`
+	Token         string   `json:"token"`
+	Describe      string   `json:"describe"`
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"` // 登录时完成二次验证绑定才会返回
+	CSRFToken     string   `json:"csrfToken,omitempty"`     // 设置 cookie 时返回，使用 cookie 登录的修改请求需要放在 X-CSRF-Token 请求头中
+}
+
+// AccessToken 个人访问 token，只保存哈希值
+type AccessToken struct {
+	ID         uint       `json:"id" gorm:"autoIncrement;primaryKey"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	UserId     uint       `json:"userId" gorm:"index"`
+	Prefix     string     `json:"prefix" gorm:"size:32"`             // token 的前几位，便于识别
+	Hash       string     `json:"-" gorm:"size:256;not null;unique"` // token 的 sha256 哈希
+	Roles      []Role     `json:"roles" gorm:"many2many:access_token_roles;"`
+	ExpiresAt  *time.Time `json:"expiresAt"`  // 为空表示永不过期
+	LastUsedAt *time.Time `json:"lastUsedAt"` // 最后使用时间
+
+	BaseModel
+}
+
+func (*AccessToken) TableName() string {
+	return "access_tokens"
+}
+
+// Expired 判断 token 是否已过期
+func (t *AccessToken) Expired() bool {
+	return t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now())
+}
+
+// CreatedAccessToken 绑定前端传入的参数
+type CreatedAccessToken struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	RoleIds   []uint     `json:"roleIds" binding:"required,min=1"` // 必须是 user 角色的子集
+	ExpiresAt *time.Time `json:"expiresAt"`                        // 为空表示永不过期
+}
+
+// IssuedAccessToken 创建 token 时返回，Token 明文只返回一次
+type IssuedAccessToken struct {
+	AccessToken
+	Token string `json:"token"`
 }
@@ -6,11 +6,15 @@ import (
 )
 
 const (
-	UserAssociation         = "Users"     // user 关联
-	UserAuthInfoAssociation = "AuthInfos" // user 授权信息关联
-	GroupAssociation        = "Groups"    // 组关联
+	UserAssociation             = "Users"         // user 关联
+	UserAuthInfoAssociation     = "AuthInfos"     // user 授权信息关联
+	GroupAssociation            = "Groups"        // 组关联
+	UserRecoveryCodeAssociation = "RecoveryCodes" // user 恢复码关联
 )
 
+// UserPatchFields PATCH 时允许修改的 user 字段，json 字段名和数据库列名相同
+var UserPatchFields = []string{"name", "email", "avatar"}
+
 // User 用户结构
 type User struct {
 	ID       uint   `json:"id" gorm:"autoIncrement;primaryKey"`
@@ -19,10 +23,23 @@ type User struct {
 	Email    string `json:"email" gorm:"size:256"`
 	Avatar   string `json:"avatar" gorm:"size:256"` // 头像
 
+	ServiceAccount bool `json:"serviceAccount"` // 服务账号不能交互式登录，只能使用访问 token
+
+	MFAEnabled    bool           `json:"mfaEnabled"`                               // 是否已开启二次验证
+	MFARequired   bool           `json:"mfaRequired"`                              // 是否被管理员要求开启二次验证
+	MFASecret     string         `json:"-" gorm:"size:256"`                        // TOTP 密钥
+	RecoveryCodes []RecoveryCode `json:"-" gorm:"foreignKey:UserId;references:ID"` // 恢复码
+
+	MFAFailures      int        `json:"-" gorm:"not null;default:0"` // MFAFailuresSince 之后二次验证失败的次数
+	MFAFailuresSince *time.Time `json:"-"`                           // 第一次失败的时间，超过锁定时间后重新计数
+	MFALastStep      int64      `json:"-" gorm:"not null;default:0"` // 最后一次使用的 TOTP 周期，同一个周期的密码不能重复使用
+
 	AuthInfos []AuthInfo `json:"authInfos" gorm:"foreignKey:UserId;references:ID"`
 	Groups    []Group    `json:"groups" gorm:"many2many:user_groups;"`
 	Roles     []Role     `json:"roles" gorm:"many2many:user_roles;"`
 
+	ResourceVersion uint64 `json:"resourceVersion" gorm:"not null;default:1"` // 版本号，每次修改加 1，用作 ETag
+
 	BaseModel
 }
 
@@ -67,28 +84,30 @@ func (*AuthInfo) TableName() string {
 
 // CreatedUser 结构模型用于绑定前端传入的参数
 type CreatedUser struct {
-	Name     string `json:"name"`
-	Password string `json:"password"`
-	Email    string `json:"email"`
-	Avatar   string `json:"avatar"`
+	Name           string `json:"name" binding:"required,max=100,name"`
+	Password       string `json:"password" binding:"required_unless=ServiceAccount true,password"` // 服务账号不需要密码
+	Email          string `json:"email" binding:"omitempty,max=256,email"`
+	Avatar         string `json:"avatar" binding:"max=256"`
+	ServiceAccount bool   `json:"serviceAccount"`
 }
 
 // GetUser 使用 CreateUser 中的数据给 User 用户结构模型进行赋值，
 // 返回 User 用户结构模型
 func (u *CreatedUser) GetUser() *User {
 	return &User{
-		Name:     u.Name,
-		Password: u.Password,
-		Email:    u.Email,
-		Avatar:   u.Avatar,
+		Name:           u.Name,
+		Password:       u.Password,
+		Email:          u.Email,
+		Avatar:         u.Avatar,
+		ServiceAccount: u.ServiceAccount,
 	}
 }
 
 // UpdatedUser 结构用于绑定前端传入的参数
 type UpdatedUser struct {
-	Name     string `json:"name"`
-	Password string `json:"password"`
-	Email    string `json:"email"`
+	Name     string `json:"name" binding:"required,max=100,name"`
+	Password string `json:"password" binding:"password"` // 为空时不修改密码
+	Email    string `json:"email" binding:"omitempty,max=256,email"`
 }
 
 // GetUser 返回一个 User，使用UpdatedUser中的数据
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// WebhookEventResources 可以通过 webhook 订阅变更事件的资源
+var WebhookEventResources = []string{UserResource, GroupResource, RoleResource, TagResource, HotSearchResource}
+
+// 投递记录的状态
+const (
+	DeliveryPending   = "pending"   // 等待投递或等待重试
+	DeliverySucceeded = "succeeded" // 接收方返回 2xx
+	DeliveryFailed    = "failed"    // 重试次数用完或 webhook 已停用
+)
+
+// WebhookEventName 返回事件在 webhook 中的名称，格式：resource.type，如 users.added
+func WebhookEventName(resource string, eventType EventType) string {
+	return resource + "." + strings.ToLower(string(eventType))
+}
+
+// Webhook 管理员注册的 webhook，资源变更时把事件 POST 到 URL，使用 Secret 对请求体签名
+type Webhook struct {
+	ID      uint     `json:"id" gorm:"autoIncrement;primaryKey"`
+	Name    string   `json:"name" gorm:"size:100;not null"`
+	URL     string   `json:"url" gorm:"size:2048;not null"`
+	Secret  string   `json:"secret,omitempty" gorm:"size:128;not null"` // 只在创建和修改密钥时返回
+	Events  []string `json:"events" gorm:"type:text;serializer:json"`   // 订阅的事件：*、users.* 或 users.added
+	Enabled bool     `json:"enabled" gorm:"not null"`
+
+	BaseModel
+}
+
+// Subscribed 判断 webhook 是否订阅了事件
+func (w *Webhook) Subscribed(resource string, eventType EventType) bool {
+	name := WebhookEventName(resource, eventType)
+	for _, event := range w.Events {
+		if event == "*" || event == resource+".*" || event == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatedWebhook 创建或修改 webhook 的参数
+type CreatedWebhook struct {
+	Name    string   `json:"name" binding:"required,max=100"`
+	URL     string   `json:"url" binding:"required,url,max=2048"`
+	Secret  string   `json:"secret" binding:"omitempty,min=16,max=128"` // 创建时为空则生成，修改时为空则不变
+	Events  []string `json:"events" binding:"required,min=1,max=20,dive,required,max=64"`
+	Enabled *bool    `json:"enabled"` // 为空时启用
+}
+
+// GetWebhook 转换为 webhook
+func (c *CreatedWebhook) GetWebhook() *Webhook {
+	enabled := c.Enabled == nil || *c.Enabled
+	events := make([]string, 0, len(c.Events))
+	seen := make(map[string]bool, len(c.Events))
+	for _, event := range c.Events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	return &Webhook{
+		Name:    c.Name,
+		URL:     c.URL,
+		Secret:  c.Secret,
+		Events:  events,
+		Enabled: enabled,
+	}
+}
+
+// WebhookPayload 投递给接收方的请求体
+type WebhookPayload struct {
+	Event           string      `json:"event"`           // 事件名称，如 users.added
+	Resource        string      `json:"resource"`        // 资源名称
+	Type            EventType   `json:"type"`            // ADDED、MODIFIED 或 DELETED
+	ResourceVersion uint64      `json:"resourceVersion"` // 事件序号，只在同一个副本内递增
+	ObjectID        uint        `json:"objectId"`
+	Object          interface{} `json:"object"`
+	Timestamp       time.Time   `json:"timestamp"` // 事件发生的时间
+}
+
+// WebhookDelivery webhook 的投递记录，同时作为持久化的投递队列：
+// 状态为 pending 并且 NextAttemptAt 已到的记录会被投递，失败后按指数退避重试
+type WebhookDelivery struct {
+	ID            uint       `json:"id" gorm:"autoIncrement;primaryKey"`
+	WebhookID     uint       `json:"webhookId" gorm:"not null;index"`
+	Webhook       *Webhook   `json:"-"`
+	Event         string     `json:"event" gorm:"size:64;not null"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"` // 请求体，重新投递时原样发送
+	Status        string     `json:"status" gorm:"size:16;not null;index:idx_delivery_due,priority:1"`
+	Attempts      int        `json:"attempts" gorm:"not null"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"index:idx_delivery_due,priority:2"`
+	RedeliveryOf  *uint      `json:"redeliveryOf,omitempty"` // 手动重新投递时原投递记录的 id
+	ResponseCode  int        `json:"responseCode"`           // 最后一次投递的响应状态码
+	ResponseBody  string     `json:"responseBody" gorm:"type:text"`
+	Error         string     `json:"error" gorm:"type:text"`
+	Duration      int64      `json:"duration"` // 最后一次投递的耗时，毫秒
+	DeliveredAt   *time.Time `json:"deliveredAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// WebhookAssociation 投递记录的 webhook 关联
+const WebhookAssociation = "Webhook"
@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"chitchat4.0/pkg/config"
+	"chitchat4.0/pkg/model"
+)
+
+const emailTimeout = 10 * time.Second // 发送一封提醒邮件的超时时间
+
+// headerCleaner 去掉邮件头中的换行，防止热搜标题注入邮件头
+var headerCleaner = strings.NewReplacer("\r", " ", "\n", " ")
+
+// EmailNotifier 通过 SMTP 把提醒发送到 user 的邮箱
+type EmailNotifier struct {
+	conf *config.SMTPConfig
+}
+
+// NewEmailNotifier 创建邮件渠道
+func NewEmailNotifier(conf *config.SMTPConfig) *EmailNotifier {
+	return &EmailNotifier{conf: conf}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, alert *model.Alert) error {
+	if alert.User == nil || alert.User.Email == "" {
+		return errors.New("user has no email")
+	}
+	to, err := mail.ParseAddress(alert.User.Email)
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(n.conf.From)
+	if err != nil {
+		return err
+	}
+
+	subject := headerCleaner.Replace(fmt.Sprintf("[chitchat] %s: %s", alert.RuleName, alert.Title))
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", to.String())
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", alert.CreatedAt.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\n", alert.Title)
+	fmt.Fprintf(&msg, "rank: %d\r\n", alert.Rank)
+	if alert.Keyword != "" {
+		fmt.Fprintf(&msg, "keyword: %s\r\n", alert.Keyword)
+	}
+	fmt.Fprintf(&msg, "link: %s\r\n", alert.Link)
+
+	return n.send(ctx, from.Address, to.Address, msg.Bytes())
+}
+
+// send 和 smtp.SendMail 相同，但是连接和整个会话都受 ctx 和 emailTimeout 的限制，
+// SMTP 服务没有响应时不会一直占用检查提醒的 goroutine
+func (n *EmailNotifier) send(ctx context.Context, from, to string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, emailTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(n.conf.Host, strconv.Itoa(n.conf.Port))
+	dialer := &net.Dialer{Timeout: emailTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, n.conf.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.conf.Host}); err != nil {
+			return err
+		}
+	}
+	if n.conf.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.conf.Username, n.conf.Password, n.conf.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"chitchat4.0/pkg/config"
+	"chitchat4.0/pkg/model"
+)
+
+// TestEmailNotifierTimeout SMTP 服务接受连接后不响应时，发送在 ctx 的截止时间返回
+func TestEmailNotifierTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	n := NewEmailNotifier(&config.SMTPConfig{Host: addr.IP.String(), Port: addr.Port, From: "chitchat@example.com"})
+	alert := &model.Alert{Title: "hot", User: &model.User{Email: "alice@example.com"}, CreatedAt: time.Now()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := n.Notify(ctx, alert); err == nil {
+		t.Fatal("notify succeeded without a SMTP greeting")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("notify returned after %v", elapsed)
+	}
+}
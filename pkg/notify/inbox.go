@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"context"
+
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+)
+
+// InboxNotifier 把提醒保存为 user 的站内信
+type InboxNotifier struct {
+	alertRepository repository.AlertRepository
+}
+
+// NewInboxNotifier 创建站内信渠道
+func NewInboxNotifier(alertRepository repository.AlertRepository) *InboxNotifier {
+	return &InboxNotifier{alertRepository: alertRepository}
+}
+
+func (n *InboxNotifier) Notify(ctx context.Context, alert *model.Alert) error {
+	return n.alertRepository.CreateNotification(&model.Notification{
+		UserID:      alert.UserID,
+		RuleID:      alert.RuleID,
+		RuleName:    alert.RuleName,
+		TagID:       alert.TagID,
+		HotSearchID: alert.HotSearchID,
+		Title:       alert.Title,
+		Link:        alert.Link,
+		Rank:        alert.Rank,
+		Keyword:     alert.Keyword,
+		CreatedAt:   alert.CreatedAt,
+	})
+}
@@ -0,0 +1,67 @@
+// Package notify 通过可插拔的渠道（站内信、webhook、邮件）发送热搜提醒
+package notify
+
+import (
+	"context"
+	"sort"
+
+	"chitchat4.0/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier 提醒的发送渠道
+type Notifier interface {
+	Notify(ctx context.Context, alert *model.Alert) error
+}
+
+// Dispatcher 按规则选择的渠道发送提醒
+type Dispatcher struct {
+	notifiers map[string]Notifier
+}
+
+// NewDispatcher 创建没有任何渠道的提醒分发器，需要通过 Register 注册渠道
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		notifiers: make(map[string]Notifier),
+	}
+}
+
+// Register 注册渠道，同名的渠道会被替换，只能在开始分发前调用
+func (d *Dispatcher) Register(channel string, notifier Notifier) {
+	d.notifiers[channel] = notifier
+}
+
+// Has 判断渠道是否可用
+func (d *Dispatcher) Has(channel string) bool {
+	_, ok := d.notifiers[channel]
+	return ok
+}
+
+// Channels 返回已注册的渠道
+func (d *Dispatcher) Channels() []string {
+	channels := make([]string, 0, len(d.notifiers))
+	for channel := range d.notifiers {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// Dispatch 通过规则的每个渠道发送提醒，返回发送成功的渠道数。
+// 一个渠道失败不影响其他渠道，未注册的渠道会被跳过
+func (d *Dispatcher) Dispatch(ctx context.Context, alert *model.Alert) int {
+	sent := 0
+	for _, channel := range alert.Rule.Channels {
+		notifier, ok := d.notifiers[channel]
+		if !ok {
+			logrus.Warnf("alert channel %s of rule %d is not available", channel, alert.RuleID)
+			continue
+		}
+		if err := notifier.Notify(ctx, alert); err != nil {
+			logrus.Warnf("send alert of rule %d by %s failed: %v", alert.RuleID, channel, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
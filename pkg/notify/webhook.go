@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/utils/netutil"
+)
+
+const webhookTimeout = 5 * time.Second // 推送一条提醒的超时时间
+
+// WebhookNotifier 把提醒以 JSON 格式 POST 到规则的 WebhookURL。
+// URL 由 user 填写，只允许访问公网地址并且不跟随重定向
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier 创建 webhook 渠道
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{
+		client: &http.Client{
+			Timeout:   webhookTimeout,
+			Transport: netutil.PublicTransport(webhookTimeout),
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert *model.Alert) error {
+	if alert.Rule.WebhookURL == "" {
+		return errors.New("webhook url is empty")
+	}
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, alert.Rule.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "chitchat-alert/4.0")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("post %s: %s", alert.Rule.WebhookURL, resp.Status)
+	}
+	return nil
+}
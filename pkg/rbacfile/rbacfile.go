@@ -0,0 +1,375 @@
+// Package rbacfile 读取声明式 RBAC 配置文件，计算配置文件和数据库的差异。
+// 配置文件描述资源、role、group 和 role 的绑定，同步时创建缺少的对象、修改不一致的对象，
+// 并删除配置文件曾经创建但已经从文件中删掉的对象；不是配置文件创建的同名对象视为冲突，不会修改
+package rbacfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// Load 读取并校验配置文件，文件为空时表示不管理任何对象
+func Load(path string) (*model.RBACFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := new(model.RBACFile)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(file); err != nil && err != io.EOF {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid rbac file %s: %v", path, err))
+	}
+	if err := Validate(file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Validate 校验每个对象、重复的名称和绑定的 role，返回包含全部错误的 Validation 错误
+func Validate(file *model.RBACFile) error {
+	details := make([]apierrors.FieldError, 0)
+	check := func(field string, obj interface{}) {
+		if err := validation.Struct(obj); err != nil {
+			for _, msg := range validation.Messages(err) {
+				details = append(details, apierrors.FieldError{Field: field, Message: msg})
+			}
+		}
+	}
+	duplicate := func(kind string) func(field, name string) {
+		seen := make(map[string]bool)
+		return func(field, name string) {
+			if seen[name] {
+				details = append(details, apierrors.FieldError{Field: field, Message: fmt.Sprintf("duplicate %s %q", kind, name)})
+			}
+			seen[name] = true
+		}
+	}
+
+	resources := duplicate("resource")
+	for i := range file.Resources {
+		field := fmt.Sprintf("resources[%d]", i)
+		check(field, &file.Resources[i])
+		resources(field, file.Resources[i].Name)
+	}
+	roles := duplicate("role")
+	declared := make(map[string]bool)
+	for i, role := range file.Roles {
+		field := fmt.Sprintf("roles[%d]", i)
+		check(field, &model.Role{Name: role.Name, Scope: role.Scope, Namespace: role.Namespace, Rules: role.Rules})
+		roles(field, role.Name)
+		declared[role.Name] = true
+	}
+	groups := duplicate("group")
+	for i := range file.Groups {
+		field := fmt.Sprintf("groups[%d]", i)
+		check(field, &file.Groups[i])
+		groups(field, file.Groups[i].Name)
+	}
+	for i := range file.Bindings {
+		field := fmt.Sprintf("bindings[%d]", i)
+		binding := &file.Bindings[i]
+		check(field, binding)
+		if binding.Role != "" && !declared[binding.Role] {
+			details = append(details, apierrors.FieldError{Field: field + ".role", Message: fmt.Sprintf("role %q is not declared in the rbac file", binding.Role)})
+		}
+	}
+
+	if len(details) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(details))
+	for _, detail := range details {
+		messages = append(messages, detail.Field+": "+detail.Message)
+	}
+	return apierrors.NewValidation("invalid rbac file: "+strings.Join(messages, "; "), details...)
+}
+
+// Plan 计算把数据库同步成配置文件需要的修改，按创建和修改资源、role、group，修改绑定，
+// 再删除 group、role、资源的顺序排列
+func Plan(file *model.RBACFile, state *model.RBACState) *model.RBACPlan {
+	p := &planner{plan: &model.RBACPlan{Changes: make([]model.RBACChange, 0)}}
+
+	resources := make(map[string]*model.Resource, len(state.Resources))
+	for i := range state.Resources {
+		resources[state.Resources[i].Name] = &state.Resources[i]
+	}
+	roles := make(map[string]*model.Role, len(state.Roles))
+	for i := range state.Roles {
+		roles[state.Roles[i].Name] = &state.Roles[i]
+	}
+	groups := make(map[string]*model.Group, len(state.Groups))
+	for i := range state.Groups {
+		groups[state.Groups[i].Name] = &state.Groups[i]
+	}
+	users := make(map[string]*model.User, len(state.Users))
+	for i := range state.Users {
+		users[state.Users[i].Name] = &state.Users[i]
+	}
+
+	// 资源
+	declaredResources := make(map[string]bool)
+	for _, item := range file.Resources {
+		declaredResources[item.Name] = true
+		desired := &model.Resource{Name: item.Name, Scope: item.Scope, Kind: item.Kind, ManagedBy: model.ManagedByRBACFile}
+		current, ok := resources[item.Name]
+		if !ok {
+			p.add(model.RBACChange{Action: model.RBACCreate, Kind: model.RBACResourceKind, Name: item.Name, Resource: desired})
+			continue
+		}
+		if !p.managed("resource", item.Name, current.ManagedBy) {
+			continue
+		}
+		fields := make([]string, 0)
+		if current.Scope != desired.Scope {
+			fields = append(fields, "scope")
+		}
+		if current.Kind != desired.Kind {
+			fields = append(fields, "kind")
+		}
+		if len(fields) > 0 {
+			desired.ID = current.ID
+			p.add(model.RBACChange{Action: model.RBACUpdate, Kind: model.RBACResourceKind, Name: item.Name, Fields: fields, Resource: desired})
+		}
+	}
+
+	// role
+	declaredRoles := make(map[string]bool)
+	for _, item := range file.Roles {
+		declaredRoles[item.Name] = true
+		rules := item.Rules
+		if rules == nil {
+			rules = model.Rules{}
+		}
+		desired := &model.Role{Name: item.Name, Scope: item.Scope, Namespace: item.Namespace, Rules: rules, ManagedBy: model.ManagedByRBACFile}
+		current, ok := roles[item.Name]
+		if !ok {
+			p.add(model.RBACChange{Action: model.RBACCreate, Kind: model.RoleResource, Name: item.Name, Role: desired})
+			continue
+		}
+		if !p.managed("role", item.Name, current.ManagedBy) {
+			continue
+		}
+		fields := make([]string, 0)
+		if current.Scope != desired.Scope {
+			fields = append(fields, "scope")
+		}
+		if current.Namespace != desired.Namespace {
+			fields = append(fields, "namespace")
+		}
+		if !sameRules(current.Rules, desired.Rules) {
+			fields = append(fields, "rules")
+		}
+		if len(fields) > 0 {
+			desired.ID = current.ID
+			p.add(model.RBACChange{Action: model.RBACUpdate, Kind: model.RoleResource, Name: item.Name, Fields: fields, Role: desired})
+		}
+	}
+
+	// group
+	declaredGroups := make(map[string]*model.RBACFileGroup)
+	for i, item := range file.Groups {
+		declaredGroups[item.Name] = &file.Groups[i]
+		desired := &model.Group{Name: item.Name, Describe: item.Describe, ManagedBy: model.ManagedByRBACFile}
+		current, ok := groups[item.Name]
+		if !ok {
+			p.add(model.RBACChange{Action: model.RBACCreate, Kind: model.GroupResource, Name: item.Name, Group: desired})
+			continue
+		}
+		if !p.managed("group", item.Name, current.ManagedBy) {
+			continue
+		}
+		if current.Describe != desired.Describe {
+			desired.ID = current.ID
+			p.add(model.RBACChange{Action: model.RBACUpdate, Kind: model.GroupResource, Name: item.Name, Fields: []string{"describe"}, Group: desired})
+		}
+	}
+
+	// role 绑定：配置文件中的 role 的绑定和配置文件一致
+	desiredBindings := make(map[model.RBACBinding]bool)
+	for _, binding := range file.Bindings {
+		if current, ok := roles[binding.Role]; ok && current.ManagedBy != model.ManagedByRBACFile {
+			continue // 已经记录为冲突
+		}
+		for _, name := range binding.Users {
+			if _, ok := users[name]; !ok {
+				p.conflict("binding of role %q: user %q does not exist", binding.Role, name)
+				continue
+			}
+			desiredBindings[model.RBACBinding{Role: binding.Role, User: name}] = true
+		}
+		for _, name := range binding.Groups {
+			_, exists := groups[name]
+			if _, declared := declaredGroups[name]; !exists && !declared {
+				p.conflict("binding of role %q: group %q does not exist", binding.Role, name)
+				continue
+			}
+			desiredBindings[model.RBACBinding{Role: binding.Role, Group: name}] = true
+		}
+	}
+	currentBindings := make(map[model.RBACBinding]bool)
+	for _, user := range state.Users {
+		for _, role := range user.Roles {
+			if declaredRoles[role.Name] && role.ManagedBy == model.ManagedByRBACFile {
+				currentBindings[model.RBACBinding{Role: role.Name, User: user.Name}] = true
+			}
+		}
+	}
+	for _, group := range state.Groups {
+		for _, role := range group.Roles {
+			if declaredRoles[role.Name] && role.ManagedBy == model.ManagedByRBACFile {
+				currentBindings[model.RBACBinding{Role: role.Name, Group: group.Name}] = true
+			}
+		}
+	}
+	p.bindings(model.RBACBindingKind, desiredBindings, currentBindings)
+
+	// group 成员：配置文件中列出了成员的 group 的成员和配置文件一致
+	desiredMembers := make(map[model.RBACBinding]bool)
+	currentMembers := make(map[model.RBACBinding]bool)
+	for _, item := range file.Groups {
+		if item.Users == nil {
+			continue
+		}
+		current, ok := groups[item.Name]
+		if ok && current.ManagedBy != model.ManagedByRBACFile {
+			continue
+		}
+		for _, name := range item.Users {
+			if _, ok := users[name]; !ok {
+				p.conflict("members of group %q: user %q does not exist", item.Name, name)
+				continue
+			}
+			desiredMembers[model.RBACBinding{Group: item.Name, User: name}] = true
+		}
+		if ok {
+			for _, user := range current.Users {
+				currentMembers[model.RBACBinding{Group: item.Name, User: user.Name}] = true
+			}
+		}
+	}
+	p.bindings(model.RBACMembershipKind, desiredMembers, currentMembers)
+
+	// 删除配置文件创建但已经从文件中删掉的对象，删除时同时删除它们的绑定和成员
+	for _, name := range sortedKeys(groups) {
+		if groups[name].ManagedBy == model.ManagedByRBACFile && declaredGroups[name] == nil {
+			p.add(model.RBACChange{Action: model.RBACDelete, Kind: model.GroupResource, Name: name, Group: groups[name]})
+		}
+	}
+	for _, name := range sortedKeys(roles) {
+		if roles[name].ManagedBy == model.ManagedByRBACFile && !declaredRoles[name] {
+			p.add(model.RBACChange{Action: model.RBACDelete, Kind: model.RoleResource, Name: name, Role: roles[name]})
+		}
+	}
+	for _, name := range sortedKeys(resources) {
+		if resources[name].ManagedBy == model.ManagedByRBACFile && !declaredResources[name] {
+			p.add(model.RBACChange{Action: model.RBACDelete, Kind: model.RBACResourceKind, Name: name, Resource: resources[name]})
+		}
+	}
+	return p.plan
+}
+
+// Describe 返回一行修改的描述，用于日志，例如 "+ roles viewer"、"~ roles viewer (rules)"、"- bindings viewer -> user alice"
+func Describe(change *model.RBACChange) string {
+	sign := map[string]string{model.RBACCreate: "+", model.RBACUpdate: "~", model.RBACDelete: "-"}[change.Action]
+	switch {
+	case change.Binding != nil && change.Binding.Role == "":
+		return fmt.Sprintf("%s %s %s <- user %s", sign, change.Kind, change.Binding.Group, change.Binding.User)
+	case change.Binding != nil && change.Binding.User != "":
+		return fmt.Sprintf("%s %s %s -> user %s", sign, change.Kind, change.Binding.Role, change.Binding.User)
+	case change.Binding != nil:
+		return fmt.Sprintf("%s %s %s -> group %s", sign, change.Kind, change.Binding.Role, change.Binding.Group)
+	case len(change.Fields) > 0:
+		return fmt.Sprintf("%s %s %s (%s)", sign, change.Kind, change.Name, strings.Join(change.Fields, ", "))
+	}
+	return fmt.Sprintf("%s %s %s", sign, change.Kind, change.Name)
+}
+
+type planner struct {
+	plan *model.RBACPlan
+}
+
+func (p *planner) add(change model.RBACChange) {
+	p.plan.Changes = append(p.plan.Changes, change)
+}
+
+func (p *planner) conflict(format string, args ...interface{}) {
+	p.plan.Conflicts = append(p.plan.Conflicts, fmt.Sprintf(format, args...))
+}
+
+// managed 同名对象是否由配置文件管理，不是时记录冲突
+func (p *planner) managed(kind, name, managedBy string) bool {
+	if managedBy == model.ManagedByRBACFile {
+		return true
+	}
+	p.conflict("%s %q already exists and is not managed by the rbac file", kind, name)
+	return false
+}
+
+// bindings 添加 desired 中缺少的绑定，删除 current 中多余的绑定
+func (p *planner) bindings(kind string, desired, current map[model.RBACBinding]bool) {
+	for _, binding := range sortedBindings(desired) {
+		if !current[binding] {
+			binding := binding
+			p.add(model.RBACChange{Action: model.RBACCreate, Kind: kind, Name: bindingName(binding), Binding: &binding})
+		}
+	}
+	for _, binding := range sortedBindings(current) {
+		if !desired[binding] {
+			binding := binding
+			p.add(model.RBACChange{Action: model.RBACDelete, Kind: kind, Name: bindingName(binding), Binding: &binding})
+		}
+	}
+}
+
+func bindingName(binding model.RBACBinding) string {
+	if binding.Role == "" {
+		return binding.Group
+	}
+	return binding.Role
+}
+
+func sortedBindings(bindings map[model.RBACBinding]bool) []model.RBACBinding {
+	sorted := make([]model.RBACBinding, 0, len(bindings))
+	for binding := range bindings {
+		sorted = append(sorted, binding)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		return a.User < b.User
+	})
+	return sorted
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sameRules 比较规则，nil 和空列表相同
+func sameRules(a, b model.Rules) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// alertRepository 热搜提醒规则、提醒去重记录和站内信仓库
+type alertRepository struct {
+	db  *gorm.DB
+	rdb *database.RedisDB
+}
+
+// newAlertRepository 返回一个热搜提醒仓库
+func newAlertRepository(db *gorm.DB, rdb *database.RedisDB) AlertRepository {
+	return &alertRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// ListRules 获取 user 的提醒规则
+func (a *alertRepository) ListRules(uid uint) ([]model.AlertRule, error) {
+	rules := make([]model.AlertRule, 0)
+	if err := a.db.Where("user_id = ?", uid).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ListEnabledRules 获取全部启用的提醒规则
+func (a *alertRepository) ListEnabledRules() ([]model.AlertRule, error) {
+	rules := make([]model.AlertRule, 0)
+	if err := a.db.Where("enabled = ?", true).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetRuleByID 通过id获取提醒规则
+func (a *alertRepository) GetRuleByID(id uint) (*model.AlertRule, error) {
+	rule := new(model.AlertRule)
+	if err := a.db.First(rule, id).Error; err != nil {
+		return nil, dbError(err, "alert rule", id)
+	}
+	return rule, nil
+}
+
+// CreateRule 创建提醒规则
+func (a *alertRepository) CreateRule(rule *model.AlertRule) (*model.AlertRule, error) {
+	if err := a.db.Create(rule).Error; err != nil {
+		return nil, dbError(err, "alert rule", rule.Name)
+	}
+	return rule, nil
+}
+
+// UpdateRule 修改提醒规则的全部字段
+func (a *alertRepository) UpdateRule(rule *model.AlertRule) (*model.AlertRule, error) {
+	if err := a.db.Save(rule).Error; err != nil {
+		return nil, dbError(err, "alert rule", rule.ID)
+	}
+	return rule, nil
+}
+
+// DeleteRule 删除提醒规则和它的去重记录，已经发送的站内信保留
+func (a *alertRepository) DeleteRule(id uint) error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rule_id = ?", id).Delete(&model.AlertHit{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.AlertRule{}, id).Error
+	})
+	return dbError(err, "alert rule", id)
+}
+
+// Hit 记录规则提醒过的热搜，已经提醒过时返回 false。
+// 依靠主键冲突去重，多个副本同时处理快照时也只有一个会提醒
+func (a *alertRepository) Hit(hit *model.AlertHit) (bool, error) {
+	result := a.db.Clauses(clause.OnConflict{DoNothing: true}).Create(hit)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteHit 删除提醒记录，发送失败后下一次快照可以重新提醒
+func (a *alertRepository) DeleteHit(hit *model.AlertHit) error {
+	return a.db.Where("rule_id = ? AND tag_id = ? AND link = ?", hit.RuleID, hit.TagID, hit.Link).Delete(&model.AlertHit{}).Error
+}
+
+// CreateNotification 保存站内信
+func (a *alertRepository) CreateNotification(notification *model.Notification) error {
+	return a.db.Create(notification).Error
+}
+
+// ListNotifications 获取 user 最近的 limit 条站内信，unreadOnly 时只获取未读的
+func (a *alertRepository) ListNotifications(uid uint, unreadOnly bool, limit int) ([]model.Notification, error) {
+	notifications := make([]model.Notification, 0)
+	query := a.db.Where("user_id = ?", uid)
+	if unreadOnly {
+		query = query.Where("read = ?", false)
+	}
+	if err := query.Order("id DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// MarkRead 把 user 的站内信标记为已读
+func (a *alertRepository) MarkRead(uid, id uint) error {
+	result := a.db.Model(&model.Notification{}).Where("user_id = ? AND id = ?", uid, id).Update("read", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apierrors.NewNotFound("notification", id)
+	}
+	return nil
+}
+
+// MarkAllRead 把 user 的全部站内信标记为已读
+func (a *alertRepository) MarkAllRead(uid uint) error {
+	return a.db.Model(&model.Notification{}).Where("user_id = ? AND read = ?", uid, false).Update("read", true).Error
+}
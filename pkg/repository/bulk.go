@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/watch"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	bulkGroupUpdateFields = []string{"describe", "updater_id"}
+	bulkUserUpdateFields  = []string{"email", "avatar"}
+)
+
+// bulkRepository 批量导入导出 role、group 和 user
+type bulkRepository struct {
+	db     *gorm.DB
+	rdb    *database.RedisDB
+	events *watch.Broadcaster // 资源变更事件
+}
+
+func newBulkRepository(db *gorm.DB, rdb *database.RedisDB, events *watch.Broadcaster) BulkRepository {
+	return &bulkRepository{
+		db:     db,
+		rdb:    rdb,
+		events: events,
+	}
+}
+
+// ListRoles 全部 role，按名称排序
+func (b *bulkRepository) ListRoles() ([]model.Role, error) {
+	roles := make([]model.Role, 0)
+	if err := b.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// ListGroups 全部 group 和它们的 role，按名称排序
+func (b *bulkRepository) ListGroups() ([]model.Group, error) {
+	groups := make([]model.Group, 0)
+	if err := b.db.Order("name").Preload("Roles").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// ListUsers 全部 user 和它们的 group、role，按名称排序，不包括密码
+func (b *bulkRepository) ListUsers() ([]model.User, error) {
+	users := make([]model.User, 0)
+	if err := b.db.Omit("Password").Order("name").Preload(model.GroupAssociation).Preload("Roles").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Import 在一个事务中按名称创建或修改 doc 中的 role、group 和 user，任何一个失败时全部回滚。
+// user 的密码需要已经加密；提交成功后才发布资源变更事件
+func (b *bulkRepository) Import(creator *model.User, doc *model.BulkDocument) error {
+	im := &importer{creator: creator}
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		im.tx = tx
+		if err := im.roles(doc.Roles); err != nil {
+			return err
+		}
+		if err := im.groups(doc.Groups); err != nil {
+			return err
+		}
+		return im.users(doc.Users)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range im.events {
+		if e.resource == model.UserResource && e.eventType == model.EventModified {
+			b.rdb.HDel((&model.User{}).CacheKey(), strconv.Itoa(int(e.id)))
+		}
+		b.events.Publish(e.eventType, e.resource, e.id, e.obj)
+	}
+	return nil
+}
+
+// importer 在事务中导入，记录提交后需要发布的事件
+type importer struct {
+	tx      *gorm.DB
+	creator *model.User
+	events  []importEvent
+}
+
+type importEvent struct {
+	eventType model.EventType
+	resource  string
+	id        uint
+	obj       interface{}
+}
+
+func (im *importer) publish(eventType model.EventType, resource string, id uint, obj interface{}) {
+	im.events = append(im.events, importEvent{eventType: eventType, resource: resource, id: id, obj: obj})
+}
+
+func (im *importer) roles(items []model.BulkRole) error {
+	for _, item := range items {
+		role := new(model.Role)
+		found, err := im.take(role, item.Name)
+		if err != nil {
+			return dbError(err, "role", item.Name)
+		}
+		role.Scope, role.Namespace, role.Rules = item.Scope, item.Namespace, item.Rules
+		if !found {
+			role.Name, role.ResourceVersion = item.Name, 1
+			if err := im.tx.Create(role).Error; err != nil {
+				return dbError(err, "role", item.Name)
+			}
+			im.publish(model.EventAdded, model.RoleResource, role.ID, role)
+			continue
+		}
+		if err := updateWithVersion(im.tx, role, role.ID, &role.ResourceVersion, roleUpdateFields, "role"); err != nil {
+			return dbError(err, "role", item.Name)
+		}
+		im.publish(model.EventModified, model.RoleResource, role.ID, role)
+	}
+	return nil
+}
+
+func (im *importer) groups(items []model.BulkGroup) error {
+	for _, item := range items {
+		group := new(model.Group)
+		found, err := im.take(group, item.Name)
+		if err != nil {
+			return dbError(err, "group", item.Name)
+		}
+		group.Describe = item.Describe
+		if !found {
+			group.Name, group.CreatorId, group.ResourceVersion = item.Name, im.creator.ID, 1
+			if err := im.tx.Omit(clause.Associations).Create(group).Error; err != nil {
+				return dbError(err, "group", item.Name)
+			}
+		} else {
+			group.UpdaterId = im.creator.ID
+			if err := updateWithVersion(im.tx, group, group.ID, &group.ResourceVersion, bulkGroupUpdateFields, "group"); err != nil {
+				return dbError(err, "group", item.Name)
+			}
+		}
+
+		if item.Roles != nil {
+			roles := make([]model.Role, 0, len(item.Roles))
+			if err := im.findByNames(&roles, item.Roles, "role"); err != nil {
+				return err
+			}
+			if err := im.replace(group, "Roles", roles, len(roles)); err != nil {
+				return err
+			}
+			group.Roles = roles
+		}
+
+		if found {
+			im.publish(model.EventModified, model.GroupResource, group.ID, group)
+		} else {
+			im.publish(model.EventAdded, model.GroupResource, group.ID, group)
+		}
+	}
+	return nil
+}
+
+func (im *importer) users(items []model.BulkUser) error {
+	for _, item := range items {
+		user := new(model.User)
+		found, err := im.take(user, item.Name)
+		if err != nil {
+			return dbError(err, "user", item.Name)
+		}
+		user.Email, user.Avatar = item.Email, item.Avatar
+		if !found {
+			user.Name, user.Password, user.ServiceAccount, user.ResourceVersion = item.Name, item.Password, item.ServiceAccount, 1
+			if err := im.tx.Select(userCreateField).Create(user).Error; err != nil {
+				return dbError(err, "user", item.Name)
+			}
+		} else {
+			fields := bulkUserUpdateFields
+			if item.Password != "" {
+				user.Password = item.Password
+				fields = append([]string{"password"}, fields...)
+			}
+			if err := updateWithVersion(im.tx, user, user.ID, &user.ResourceVersion, fields, "user"); err != nil {
+				return dbError(err, "user", item.Name)
+			}
+		}
+
+		if item.Groups != nil {
+			groups := make([]model.Group, 0, len(item.Groups))
+			if err := im.findByNames(&groups, item.Groups, "group"); err != nil {
+				return err
+			}
+			if err := im.replace(user, model.GroupAssociation, groups, len(groups)); err != nil {
+				return err
+			}
+			user.Groups = groups
+		}
+		if item.Roles != nil {
+			roles := make([]model.Role, 0, len(item.Roles))
+			if err := im.findByNames(&roles, item.Roles, "role"); err != nil {
+				return err
+			}
+			if err := im.replace(user, "Roles", roles, len(roles)); err != nil {
+				return err
+			}
+			user.Roles = roles
+		}
+
+		if found {
+			im.publish(model.EventModified, model.UserResource, user.ID, user)
+		} else {
+			im.publish(model.EventAdded, model.UserResource, user.ID, user)
+		}
+	}
+	return nil
+}
+
+// take 按名称查询，不存在时返回 false
+func (im *importer) take(obj interface{}, name string) (bool, error) {
+	err := im.tx.Where("name = ?", name).Take(obj).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// findByNames 按名称查询全部记录，有名称不存在时返回 NotFound
+func (im *importer) findByNames(dest interface{}, names []string, resource string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	result := im.tx.Where("name IN ?", names).Find(dest)
+	if result.Error != nil {
+		return result.Error
+	}
+	if int(result.RowsAffected) != len(names) {
+		return apierrors.NewNotFound(resource, names)
+	}
+	return nil
+}
+
+// replace 替换关联，n 为 0 时清空
+func (im *importer) replace(obj interface{}, association string, values interface{}, n int) error {
+	if n == 0 {
+		return im.tx.Model(obj).Association(association).Clear()
+	}
+	return im.tx.Model(obj).Association(association).Replace(values)
+}
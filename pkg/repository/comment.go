@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"gorm.io/gorm"
+)
+
+// commentRepository 评论仓库。
+// 和聊天消息一样不发布资源变更事件，避免大量评论挤掉事件历史中的其他资源
+type commentRepository struct {
+	db  *gorm.DB
+	rdb *database.RedisDB
+}
+
+// newCommentRepository 返回一个评论仓库
+func newCommentRepository(db *gorm.DB, rdb *database.RedisDB) CommentRepository {
+	return &commentRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// Create 保存评论，返回带作者的评论
+func (c *commentRepository) Create(comment *model.Comment) (*model.Comment, error) {
+	if err := c.db.Omit(model.CommentUserAssociation).Create(comment).Error; err != nil {
+		return nil, dbError(err, "comment", comment.TargetID)
+	}
+	return c.GetCommentByID(comment.ID)
+}
+
+// GetCommentByID 通过id获取评论和作者
+func (c *commentRepository) GetCommentByID(id uint) (*model.Comment, error) {
+	comment := new(model.Comment)
+	if err := c.withUser(c.db).First(comment, id).Error; err != nil {
+		return nil, dbError(err, "comment", id)
+	}
+	return comment, nil
+}
+
+// ListRoots 获取对象下 id 小于 before 的最近 limit 条顶层评论，before 为 0 时从最新的开始，按 id 从新到旧排序。
+// 已删除的顶层评论也会返回，由调用方决定是否保留位置
+func (c *commentRepository) ListRoots(targetType string, targetID, before uint, limit int) ([]model.Comment, error) {
+	comments := make([]model.Comment, 0, limit)
+	query := c.withUser(c.db.Unscoped()).
+		Where("target_type = ? AND target_id = ? AND parent_id IS NULL", targetType, targetID)
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+	if err := query.Order("id DESC").Limit(limit).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// ListReplies 按 id 顺序获取楼层中的全部回复，包括已删除的
+func (c *commentRepository) ListReplies(rootIDs []uint) ([]model.Comment, error) {
+	comments := make([]model.Comment, 0)
+	if len(rootIDs) == 0 {
+		return comments, nil
+	}
+	if err := c.withUser(c.db.Unscoped()).Where("root_id IN ?", rootIDs).Order("id").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// Counts 统计对象下未删除的评论数，返回 target id -> 评论数
+func (c *commentRepository) Counts(targetType string, targetIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(targetIDs))
+	if len(targetIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		TargetID uint
+		Count    int64
+	}
+	err := c.db.Model(&model.Comment{}).Select("target_id, COUNT(*) AS count").
+		Where("target_type = ? AND target_id IN ?", targetType, targetIDs).
+		Group("target_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.TargetID] = row.Count
+	}
+	return counts, nil
+}
+
+// Delete 软删除评论并记录删除者，评论的举报随之处理完毕
+func (c *commentRepository) Delete(id, deletedBy uint) error {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Comment{ID: id}).Update("deleted_by", deletedBy).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.Comment{}, id).Error; err != nil {
+			return err
+		}
+		return tx.Where("comment_id = ?", id).Delete(&model.CommentReport{}).Error
+	})
+	return dbError(err, "comment", id)
+}
+
+// CreateReport 保存举报，同一个 user 重复举报时返回冲突
+func (c *commentRepository) CreateReport(report *model.CommentReport) error {
+	if err := c.db.Omit(model.ReportCommentAssociation).Create(report).Error; err != nil {
+		return dbError(err, "comment report", report.CommentID)
+	}
+	return nil
+}
+
+// ListReports 获取未删除评论的举报和被举报的评论，按举报时间排序
+func (c *commentRepository) ListReports() ([]model.CommentReport, error) {
+	reports := make([]model.CommentReport, 0)
+	err := c.db.Preload(model.ReportCommentAssociation, func(db *gorm.DB) *gorm.DB {
+		return c.withUser(db)
+	}).Where("comment_id IN (?)", c.db.Model(&model.Comment{}).Select("id")).Order("id").Find(&reports).Error
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// DeleteReports 删除评论的全部举报
+func (c *commentRepository) DeleteReports(commentID uint) error {
+	return c.db.Where("comment_id = ?", commentID).Delete(&model.CommentReport{}).Error
+}
+
+// withUser 预加载评论作者，不加载密码
+func (c *commentRepository) withUser(db *gorm.DB) *gorm.DB {
+	return db.Preload(model.CommentUserAssociation, func(db *gorm.DB) *gorm.DB {
+		return db.Omit("Password")
+	})
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"errors"
+
+	"chitchat4.0/pkg/apierrors"
+	"gorm.io/gorm"
+)
+
+const (
+	pgUniqueViolation = "23505" // PostgreSQL 唯一约束冲突的错误码
+)
+
+// sqlStateError 数据库驱动返回的带 SQLSTATE 的错误（如 pgconn.PgError）
+type sqlStateError interface {
+	SQLState() string
+}
+
+// dbError 把数据库错误转换为 apierrors 中的错误，
+// 记录不存在转换为 NotFound，唯一约束冲突转换为 Conflict
+func dbError(err error, resource string, name interface{}) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apierrors.NewNotFound(resource, name)
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return apierrors.NewConflict(resource, name, err)
+	}
+	var state sqlStateError
+	if errors.As(err, &state) && state.SQLState() == pgUniqueViolation {
+		return apierrors.NewConflict(resource, name, err)
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// followRepository 关注和收藏仓库，关注保存在数据库中，计算好的个性化热搜缓存在 Redis 中。
+// 关注和收藏变化时删除 user 的个性化热搜缓存
+type followRepository struct {
+	db  *gorm.DB
+	rdb *database.RedisDB
+}
+
+// newFollowRepository 返回一个关注和收藏仓库
+func newFollowRepository(db *gorm.DB, rdb *database.RedisDB) FollowRepository {
+	return &followRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// ListTags 获取 user 关注的 tag
+func (f *followRepository) ListTags(uid uint) ([]model.Tag, error) {
+	tags := make([]model.Tag, 0)
+	err := f.db.Where("id IN (?)", f.db.Model(&model.TagFollow{}).Select("tag_id").Where("user_id = ?", uid)).
+		Order("sort").Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// FollowTag 关注 tag，已经关注时忽略
+func (f *followRepository) FollowTag(uid, tagID uint) error {
+	follow := &model.TagFollow{UserID: uid, TagID: tagID}
+	if err := f.db.Clauses(clause.OnConflict{DoNothing: true}).Create(follow).Error; err != nil {
+		return dbError(err, "tag follow", tagID)
+	}
+	f.delFeed(uid)
+	return nil
+}
+
+// UnfollowTag 取消关注 tag，没有关注时忽略
+func (f *followRepository) UnfollowTag(uid, tagID uint) error {
+	if err := f.db.Where("user_id = ? AND tag_id = ?", uid, tagID).Delete(&model.TagFollow{}).Error; err != nil {
+		return err
+	}
+	f.delFeed(uid)
+	return nil
+}
+
+// ListKeywords 获取 user 关注的关键词
+func (f *followRepository) ListKeywords(uid uint) ([]model.KeywordFollow, error) {
+	keywords := make([]model.KeywordFollow, 0)
+	if err := f.db.Where("user_id = ?", uid).Order("id").Find(&keywords).Error; err != nil {
+		return nil, err
+	}
+	return keywords, nil
+}
+
+// CreateKeyword 关注关键词，已经关注时返回冲突
+func (f *followRepository) CreateKeyword(keyword *model.KeywordFollow) (*model.KeywordFollow, error) {
+	if err := f.db.Create(keyword).Error; err != nil {
+		return nil, dbError(err, "keyword follow", keyword.Keyword)
+	}
+	f.delFeed(keyword.UserID)
+	return keyword, nil
+}
+
+// DeleteKeyword 取消关注 user 的关键词
+func (f *followRepository) DeleteKeyword(uid, id uint) error {
+	result := f.db.Where("user_id = ?", uid).Delete(&model.KeywordFollow{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apierrors.NewNotFound("keyword follow", id)
+	}
+	f.delFeed(uid)
+	return nil
+}
+
+// ListBookmarks 获取 user 收藏的热搜，已下榜的热搜也会返回，按收藏时间从新到旧排序
+func (f *followRepository) ListBookmarks(uid uint) ([]model.Bookmark, error) {
+	bookmarks := make([]model.Bookmark, 0)
+	err := f.db.Preload(model.BookmarkHotSearchAssociation, func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Where("user_id = ?", uid).Order("created_at DESC").Find(&bookmarks).Error
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// Bookmark 收藏热搜，已经收藏时忽略
+func (f *followRepository) Bookmark(uid, hotSearchID uint) error {
+	bookmark := &model.Bookmark{UserID: uid, HotSearchID: hotSearchID}
+	if err := f.db.Omit(model.BookmarkHotSearchAssociation).Clauses(clause.OnConflict{DoNothing: true}).Create(bookmark).Error; err != nil {
+		return dbError(err, "bookmark", hotSearchID)
+	}
+	f.delFeed(uid)
+	return nil
+}
+
+// Unbookmark 取消收藏热搜，没有收藏时忽略
+func (f *followRepository) Unbookmark(uid, hotSearchID uint) error {
+	if err := f.db.Where("user_id = ? AND hot_search_id = ?", uid, hotSearchID).Delete(&model.Bookmark{}).Error; err != nil {
+		return err
+	}
+	f.delFeed(uid)
+	return nil
+}
+
+// GetFeed 获取缓存的个性化热搜，没有缓存时返回 NotFound，redis 禁用时返回 database.RedisDisableError
+func (f *followRepository) GetFeed(uid uint) (*model.Feed, error) {
+	feed := new(model.Feed)
+	if err := f.rdb.Get(model.FeedCacheKey(uid), feed); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, apierrors.NewNotFound("feed", uid)
+		}
+		return nil, err
+	}
+	return feed, nil
+}
+
+// SetFeed 缓存个性化热搜 ttl 时间
+func (f *followRepository) SetFeed(uid uint, feed *model.Feed, ttl time.Duration) error {
+	return f.rdb.Set(model.FeedCacheKey(uid), feed, ttl)
+}
+
+// delFeed 删除 user 的个性化热搜缓存
+func (f *followRepository) delFeed(uid uint) {
+	f.rdb.Del(model.FeedCacheKey(uid))
+}
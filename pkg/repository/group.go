@@ -13,6 +13,7 @@ package repository
 import (
 	"chitchat4.0/pkg/database"
 	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/watch"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -23,8 +24,9 @@ var (
 
 // group 数据库仓库
 type groupRepository struct {
-	db  *gorm.DB
-	rdb *database.RedisDB
+	db     *gorm.DB
+	rdb    *database.RedisDB
+	events *watch.Broadcaster // 资源变更事件
 }
 
 /**
@@ -33,10 +35,11 @@ type groupRepository struct {
  * @param {*database.RedisDB} rdb
  * @return {*}
  */
-func newGroupRepository(db *gorm.DB, rdb *database.RedisDB) GroupRepository {
+func newGroupRepository(db *gorm.DB, rdb *database.RedisDB, events *watch.Broadcaster) GroupRepository {
 	return &groupRepository{
-		db:  db,
-		rdb: rdb,
+		db:     db,
+		rdb:    rdb,
+		events: events,
 	}
 }
 
@@ -49,8 +52,11 @@ func newGroupRepository(db *gorm.DB, rdb *database.RedisDB) GroupRepository {
 func (g *groupRepository) Create(user *model.User, group *model.Group) (*model.Group, error) {
 	group.CreatorId = user.ID
 	group.Users = []model.User{*user}
-	err := g.db.Create(group).Error
-	return group, err
+	if err := g.db.Create(group).Error; err != nil {
+		return nil, dbError(err, "group", group.Name)
+	}
+	g.events.Publish(model.EventAdded, model.GroupResource, group.ID, group)
+	return group, nil
 }
 
 /**
@@ -63,14 +69,6 @@ func (g *groupRepository) RoleBinding(role *model.Role, group *model.Group) erro
 	return g.db.Model(group).Association("Roles").Append(role)
 }
 
-/**
- * @description: 对 Group 模型进行数据库迁移
- * @return {error}
- */
-func (g *groupRepository) Migrate() error {
-	return g.db.AutoMigrate(&model.Group{})
-}
-
 /**
  * @description: GetGroupByID()实现id查询group，以及与之联系的User和Role
  * @param {uint} id
@@ -79,7 +77,7 @@ func (g *groupRepository) Migrate() error {
 func (g *groupRepository) GetGroupByID(id uint) (*model.Group, error) {
 	group := new(model.Group)
 	if err := g.db.Preload("Users").Preload("Roles").First(group, id).Error; err != nil {
-		return nil, err
+		return nil, dbError(err, "group", id)
 	}
 	return group, nil
 }
@@ -96,13 +94,39 @@ func (g *groupRepository) List() ([]model.Group, error) {
 	return groups, nil
 }
 
+// Update 修改 group，group.ResourceVersion 不为 0 时和数据库中的版本号比较
 func (g *groupRepository) Update(group *model.Group) (*model.Group, error) {
-	err := g.db.Model(group).Select(groupUpdateFields).Updates(group).Error
-	return group, err
+	if err := updateWithVersion(g.db, group, group.ID, &group.ResourceVersion, groupUpdateFields, "group"); err != nil {
+		return nil, dbError(err, "group", group.Name)
+	}
+	return g.updated(group.ID)
+}
+
+// Patch 只保存 fields 中的字段，字段为零值时也会保存
+func (g *groupRepository) Patch(group *model.Group, fields []string) (*model.Group, error) {
+	if err := updateWithVersion(g.db, group, group.ID, &group.ResourceVersion, fields, "group"); err != nil {
+		return nil, dbError(err, "group", group.Name)
+	}
+	return g.updated(group.ID)
+}
+
+// updated 重新查询修改后的 group 并发布修改事件
+func (g *groupRepository) updated(id uint) (*model.Group, error) {
+	group, err := g.GetGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+	g.events.Publish(model.EventModified, model.GroupResource, group.ID, group)
+	return group, nil
 }
 
-func (g *groupRepository) Delete(id uint) error {
-	return g.db.Delete(&model.Group{}, id).Error
+// Delete 删除 group，version 不为 0 时和数据库中的版本号比较
+func (g *groupRepository) Delete(id uint, version uint64) error {
+	if err := deleteWithVersion(g.db, &model.Group{}, id, version, "group"); err != nil {
+		return err
+	}
+	g.events.Publish(model.EventDeleted, model.GroupResource, id, &model.Group{ID: id})
+	return nil
 }
 
 func (g *groupRepository) GetUsers(group *model.Group) (model.Users, error) {
@@ -133,7 +157,7 @@ func (g *groupRepository) AddRole(role *model.Role, group *model.Group) error {
 func (g *groupRepository) GetGroupByName(name string) (*model.Group, error) {
 	group := new(model.Group)
 	if err := g.db.Preload("Users").Preload("Roles").Where("name = ?", name).First(group).Error; err != nil {
-		return nil, err
+		return nil, dbError(err, "group", name)
 	}
 	return group, nil
 }
@@ -1,32 +1,194 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
 	"chitchat4.0/pkg/database"
 	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/watch"
 	"gorm.io/gorm"
 )
 
+// hotSearchRepository 热搜仓库，热搜榜的列表查询可以容忍复制延迟，配置了只读副本时从只读副本读取
 type hotSearchRepository struct {
-	db  *gorm.DB
-	rdb *database.RedisDB
+	db     *gorm.DB
+	rdb    *database.RedisDB
+	events *watch.Broadcaster // 资源变更事件
 }
 
-func newHotSearchRepository(db *gorm.DB, rdb *database.RedisDB) HotSearchRepository {
+func newHotSearchRepository(db *gorm.DB, rdb *database.RedisDB, events *watch.Broadcaster) HotSearchRepository {
 	return &hotSearchRepository{
-		db:  db,
-		rdb: rdb,
+		db:     db,
+		rdb:    rdb,
+		events: events,
 	}
 }
 func (h *hotSearchRepository) List() ([]model.HotSearch, error) {
 	hotSearchs := make([]model.HotSearch, 0)
-
+	if err := database.Replica(h.db).Order("id").Find(&hotSearchs).Error; err != nil {
+		return nil, err
+	}
 	return hotSearchs, nil
 }
 
-func (h *hotSearchRepository) Create(user *model.Tag, hotSearch *model.HotSearch) (*model.HotSearch, error) {
+// Create 创建 tag 下的热搜
+func (h *hotSearchRepository) Create(tag *model.Tag, hotSearch *model.HotSearch) (*model.HotSearch, error) {
+	hotSearch.TagID = tag.ID
+	if err := h.db.Omit("Tag").Create(hotSearch).Error; err != nil {
+		return nil, dbError(err, "hotsearch", hotSearch.Title)
+	}
+	h.events.Publish(model.EventAdded, model.HotSearchResource, hotSearch.ID, hotSearch)
 	return hotSearch, nil
 }
 
-func (h *hotSearchRepository) Migrate() error {
-	return h.db.AutoMigrate(&model.HotSearch{}, &model.Tag{})
+// GetHotSearchByID 通过id获取热搜，已下榜的热搜不存在
+func (h *hotSearchRepository) GetHotSearchByID(id uint) (*model.HotSearch, error) {
+	hotSearch := new(model.HotSearch)
+	if err := h.db.First(hotSearch, id).Error; err != nil {
+		return nil, dbError(err, "hotsearch", id)
+	}
+	return hotSearch, nil
+}
+
+// ListByTag 按排名获取 tag 的热搜榜
+func (h *hotSearchRepository) ListByTag(tagID uint) ([]model.HotSearch, error) {
+	hotSearchs := make([]model.HotSearch, 0)
+	if err := database.Replica(h.db).Where("tag_id = ?", tagID).Order("rank").Find(&hotSearchs).Error; err != nil {
+		return nil, err
+	}
+	return hotSearchs, nil
+}
+
+// LastModified 返回 tag 热搜榜最后一次变化的时间：最后创建、修改或下榜的热搜的时间，没有热搜时返回零值
+func (h *hotSearchRepository) LastModified(tagID uint) (time.Time, error) {
+	// 不使用 MAX：SQLite 的聚合结果没有列类型，时间会以字符串返回
+	latest := func(column string) (time.Time, error) {
+		times := make([]time.Time, 0, 1)
+		err := database.Replica(h.db).Unscoped().Model(&model.HotSearch{}).
+			Where("tag_id = ? AND "+column+" IS NOT NULL", tagID).
+			Order(column+" DESC").Limit(1).Pluck(column, &times).Error
+		if err != nil || len(times) == 0 {
+			return time.Time{}, err
+		}
+		return times[0], nil
+	}
+	updated, err := latest("updated_at")
+	if err != nil {
+		return time.Time{}, err
+	}
+	deleted, err := latest("deleted_at")
+	if err != nil {
+		return time.Time{}, err
+	}
+	if deleted.After(updated) {
+		return deleted, nil
+	}
+	return updated, nil
+}
+
+// ListForFeed 按排名获取属于 tagIDs 或者标题包含任一关键词（不区分大小写）的热搜，最多 limit 条
+func (h *hotSearchRepository) ListForFeed(tagIDs []uint, keywords []string, limit int) ([]model.HotSearch, error) {
+	hotSearchs := make([]model.HotSearch, 0)
+	if len(tagIDs) == 0 && len(keywords) == 0 {
+		return hotSearchs, nil
+	}
+	cond := h.db.Where("1 = 0")
+	if len(tagIDs) > 0 {
+		cond = cond.Or("tag_id IN ?", tagIDs)
+	}
+	for _, keyword := range keywords {
+		cond = cond.Or("LOWER(title) LIKE ? ESCAPE '\\'", "%"+likeEscaper.Replace(strings.ToLower(keyword))+"%")
+	}
+	if err := database.Replica(h.db).Where(cond).Order("rank").Order("id").Limit(limit).Find(&hotSearchs).Error; err != nil {
+		return nil, err
+	}
+	return hotSearchs, nil
+}
+
+// likeEscaper 转义 LIKE 模式中的通配符
+var likeEscaper = strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+
+// ReplaceSnapshot 在事务中用新的快照更新 tag 的热搜榜，返回按排名排序的替换前的热搜榜。
+// 按 link 匹配已有的热搜（包括已下榜的），沿用原来的 id，使评论和回应跟随热搜；不在快照中的热搜软删除，重新上榜时恢复
+func (h *hotSearchRepository) ReplaceSnapshot(tagID uint, hotSearches []model.HotSearch) ([]model.HotSearch, error) {
+	previous := make([]model.HotSearch, 0)
+	added := make([]*model.HotSearch, 0)
+	modified := make([]*model.HotSearch, 0)
+	removed := make([]model.HotSearch, 0)
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		existing := make([]model.HotSearch, 0)
+		if err := tx.Unscoped().Where("tag_id = ?", tagID).Order("rank").Find(&existing).Error; err != nil {
+			return err
+		}
+		byLink := make(map[string]*model.HotSearch, len(existing))
+		for i := range existing {
+			byLink[existing[i].Link] = &existing[i]
+			if !existing[i].DeletedAt.Valid {
+				previous = append(previous, existing[i])
+			}
+		}
+
+		created := make([]*model.HotSearch, 0)
+		for i := range hotSearches {
+			hotSearch := &hotSearches[i]
+			old, ok := byLink[hotSearch.Link]
+			if !ok {
+				created = append(created, hotSearch)
+				continue
+			}
+			delete(byLink, hotSearch.Link)
+			hotSearch.ID, hotSearch.CreatedAt, hotSearch.UpdatedAt = old.ID, old.CreatedAt, old.UpdatedAt
+			restored := old.DeletedAt.Valid
+			if !restored && old.Title == hotSearch.Title && old.Extra == hotSearch.Extra && old.Rank == hotSearch.Rank {
+				continue
+			}
+			err := tx.Unscoped().Model(hotSearch).Omit("Tag").Updates(map[string]interface{}{
+				"title":      hotSearch.Title,
+				"extra":      hotSearch.Extra,
+				"rank":       hotSearch.Rank,
+				"deleted_at": nil,
+			}).Error
+			if err != nil {
+				return err
+			}
+			if restored {
+				added = append(added, hotSearch)
+			} else {
+				modified = append(modified, hotSearch)
+			}
+		}
+		if len(created) > 0 {
+			if err := tx.Omit("Tag").Create(created).Error; err != nil {
+				return err
+			}
+			added = append(added, created...)
+		}
+
+		ids := make([]uint, 0)
+		for _, old := range byLink {
+			if !old.DeletedAt.Valid {
+				removed = append(removed, *old)
+				ids = append(ids, old.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Delete(&model.HotSearch{}, ids).Error
+	})
+	if err != nil {
+		return nil, dbError(err, "hotsearch", tagID)
+	}
+
+	for _, hotSearch := range removed {
+		h.events.Publish(model.EventDeleted, model.HotSearchResource, hotSearch.ID, &model.HotSearch{ID: hotSearch.ID, TagID: tagID})
+	}
+	for _, hotSearch := range modified {
+		h.events.Publish(model.EventModified, model.HotSearchResource, hotSearch.ID, hotSearch)
+	}
+	for _, hotSearch := range added {
+		h.events.Publish(model.EventAdded, model.HotSearchResource, hotSearch.ID, hotSearch)
+	}
+	return previous, nil
 }
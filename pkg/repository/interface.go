@@ -12,8 +12,10 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/watch"
 	"gorm.io/gorm/clause"
 )
 
@@ -21,20 +23,26 @@ type Repository interface {
 	User() UserRepository   // 实现
 	Group() GroupRepository //
 	RBAC() RBACRepository   //
-	Close() error           // -
-
-	// Tag() TagRepository
-	// HotSearch() HotSearchRepository
+	AccessToken() AccessTokenRepository
+	Session() SessionRepository
+	Tag() TagRepository
+	HotSearch() HotSearchRepository
+	Room() RoomRepository
+	Message() MessageRepository
+	Comment() CommentRepository
+	Reaction() ReactionRepository
+	Follow() FollowRepository
+	Alert() AlertRepository
+	Webhook() WebhookRepository
+	Bulk() BulkRepository
+	RBACFile() RBACFileRepository
+	Events() *watch.Broadcaster // 资源变更事件
+	Close() error               // -
 
 	Ping(ctx context.Context) error
 
 	Init() error // -
 
-	Migrant
-}
-
-type Migrant interface {
-	Migrate() error
 }
 
 // User 用户接口13
@@ -45,6 +53,7 @@ type UserRepository interface {
 	List() (model.Users, error)                                   // 获取user列表
 	Create(*model.User) (*model.User, error)                      // 创建user
 	Update(*model.User) (*model.User, error)                      // 修改user
+	Patch(user *model.User, fields []string) (*model.User, error) // 修改user的部分字段，零值也会保存
 	Delete(*model.User) error                                     // 删除user
 
 	GetGroups(*model.User) ([]model.Group, error)     // 获取user的全部group
@@ -54,7 +63,33 @@ type UserRepository interface {
 	AddAuthInfo(authInfo *model.AuthInfo) error // 添加授权信息
 	DelAuthInfo(authInfo *model.AuthInfo) error // 删除授权信息
 
-	Migrate() error // 自动迁移
+	UpdateMFA(user *model.User) error                                    // 修改user的二次验证设置
+	SetRecoveryCodes(user *model.User, codes []model.RecoveryCode) error // 替换user的全部恢复码
+	GetRecoveryCodes(user *model.User) ([]model.RecoveryCode, error)     // 获取user未使用的恢复码
+	UseRecoveryCode(code *model.RecoveryCode) error                      // 标记恢复码已使用
+	AddMFAFailure(user *model.User, max int, window time.Duration) error // 记录一次二次验证尝试，window 内已经失败 max 次时返回错误
+	ResetMFAFailures(user *model.User) error                             // 二次验证通过后清除失败次数
+	UseTOTPStep(user *model.User, step int64) error                      // 记录已使用的 TOTP 周期，已经使用过时返回错误
+
+}
+
+// AccessTokenRepository 个人访问 token 仓库接口
+type AccessTokenRepository interface {
+	Create(*model.AccessToken) (*model.AccessToken, error)  // 创建访问 token
+	List(uid uint) ([]model.AccessToken, error)             // 获取 user 的访问 token 列表
+	GetByHash(hash string) (*model.AccessToken, error)      // 通过哈希值获取访问 token
+	Delete(uid, id uint) error                              // 删除 user 的访问 token
+	Touch(token *model.AccessToken, usedAt time.Time) error // 更新最后使用时间
+}
+
+// SessionRepository 会话仓库接口
+type SessionRepository interface {
+	Create(*model.Session) error                     // 保存新的会话
+	Update(*model.Session) error                     // 修改会话
+	Get(uid uint, id string) (*model.Session, error) // 获取会话
+	List(uid uint) ([]model.Session, error)          // 获取 user 的会话列表
+	Delete(uid uint, id string) error                // 删除会话
+	DeleteAll(uid uint) error                        // 删除 user 的全部会话
 }
 
 // 分组14-14
@@ -65,16 +100,16 @@ type GroupRepository interface {
 	List() ([]model.Group, error)                           // 获取group列表
 	Create(*model.User, *model.Group) (*model.Group, error) // 创建group
 
-	Update(*model.Group) (*model.Group, error)          // 修改group
-	Delete(uint) error                                  // 删除group
-	GetUsers(*model.Group) (model.Users, error)         // 获取group下的全部user
-	AddUser(user *model.User, group *model.Group) error // 给group添加user
-	DelUser(user *model.User, group *model.Group) error // 删除group下的user
-	AddRole(role *model.Role, group *model.Group) error // 给group添加role
-	DelRole(role *model.Role, group *model.Group) error // 删除group对应的role
+	Update(*model.Group) (*model.Group, error)                       // 修改group
+	Patch(group *model.Group, fields []string) (*model.Group, error) // 修改group的部分字段，零值也会保存
+	Delete(id uint, version uint64) error                            // 删除group，version 不为 0 时比较版本号
+	GetUsers(*model.Group) (model.Users, error)                      // 获取group下的全部user
+	AddUser(user *model.User, group *model.Group) error              // 给group添加user
+	DelUser(user *model.User, group *model.Group) error              // 删除group下的user
+	AddRole(role *model.Role, group *model.Group) error              // 给group添加role
+	DelRole(role *model.Role, group *model.Group) error              // 删除group对应的role
 
 	RoleBinding(role *model.Role, group *model.Group) error // 创建默认group时，绑定role
-	Migrate() error
 
 	CreateGroups(groups []model.Group, conds ...clause.Expression) error // -
 }
@@ -82,26 +117,133 @@ type GroupRepository interface {
 // Tag 标签接口
 type TagRepository interface {
 	List() ([]model.Tag, error)
+	GetTagByID(id uint) (*model.Tag, error) // 通过id获取tag
 	Create(*model.User, *model.Tag) (*model.Tag, error)
-	Migrate() error
 }
 
 // HotSearchRepository 热搜列表仓库接口
 type HotSearchRepository interface {
 	List() ([]model.HotSearch, error)
 	Create(*model.Tag, *model.HotSearch) (*model.HotSearch, error)
-	Migrate() error
+	GetHotSearchByID(id uint) (*model.HotSearch, error)                                   // 通过id获取热搜
+	ListByTag(tagID uint) ([]model.HotSearch, error)                                      // 按排名获取 tag 的热搜榜
+	ReplaceSnapshot(tagID uint, hotSearches []model.HotSearch) ([]model.HotSearch, error) // 用采集器的快照替换 tag 的热搜榜，返回替换前的热搜榜
+	ListForFeed(tagIDs []uint, keywords []string, limit int) ([]model.HotSearch, error)   // 获取属于 tag 或者标题包含关键词的热搜
+	LastModified(tagID uint) (time.Time, error)                                           // tag 热搜榜最后一次变化的时间
+}
+
+// RoomRepository 聊天室仓库接口
+type RoomRepository interface {
+	Create(room *model.Room, members []uint) (*model.Room, error)  // 创建聊天室，members 是临时聊天室的成员
+	GetRoomByID(id uint) (*model.Room, error)                      // 通过id获取聊天室
+	ListForUser(uid uint, groupIDs []uint) ([]model.Room, error)   // 获取 user 能进入的聊天室
+	UnreadCounts(uid uint, roomIDs []uint) (map[uint]int64, error) // 统计 user 的未读消息数
+	Delete(id uint) error                                          // 删除聊天室和消息
+	AddMember(roomID, uid uint) error                              // 添加临时聊天室成员
+	DelMember(roomID, uid uint) error                              // 删除临时聊天室成员
+	IsMember(roomID, uid uint) (bool, error)                       // 判断 user 是不是临时聊天室成员
+	MarkRead(roomID, uid, messageID uint) error                    // 修改 user 的已读位置
+}
+
+// MessageRepository 聊天消息仓库接口
+type MessageRepository interface {
+	Create(*model.Message) (*model.Message, error)                                        // 保存消息
+	GetMessageByID(id uint) (*model.Message, error)                                       // 通过id获取消息
+	List(roomID, before uint, limit int) ([]model.Message, error)                         // 分页获取历史消息
+	LastID(roomID uint) (uint, error)                                                     // 最新消息的 id
+	AddLinkPreviews(messageID uint, previews []model.LinkPreview) (*model.Message, error) // 保存链接预览
+}
+
+// CommentRepository 评论仓库接口
+type CommentRepository interface {
+	Create(*model.Comment) (*model.Comment, error)                                          // 发表评论
+	GetCommentByID(id uint) (*model.Comment, error)                                         // 通过id获取评论
+	ListRoots(targetType string, targetID, before uint, limit int) ([]model.Comment, error) // 分页获取顶层评论，包括已删除的
+	ListReplies(rootIDs []uint) ([]model.Comment, error)                                    // 获取楼层中的回复，包括已删除的
+	Counts(targetType string, targetIDs []uint) (map[uint]int64, error)                     // 统计评论数
+	Delete(id, deletedBy uint) error                                                        // 软删除评论
+	CreateReport(*model.CommentReport) error                                                // 举报评论
+	ListReports() ([]model.CommentReport, error)                                            // 未删除评论的举报
+	DeleteReports(commentID uint) error                                                     // 忽略评论的举报
+}
+
+// ReactionRepository 回应仓库接口
+type ReactionRepository interface {
+	Add(*model.Reaction) error                                                     // 添加回应，已经存在时忽略
+	Remove(*model.Reaction) error                                                  // 取消回应
+	Counts(targetType string, targetIDs []uint) (map[uint]map[string]int64, error) // 统计 emoji 回应数
+}
+
+// FollowRepository 关注、收藏和个性化热搜缓存仓库接口
+type FollowRepository interface {
+	ListTags(uid uint) ([]model.Tag, error)                                   // 关注的 tag
+	FollowTag(uid, tagID uint) error                                          // 关注 tag
+	UnfollowTag(uid, tagID uint) error                                        // 取消关注 tag
+	ListKeywords(uid uint) ([]model.KeywordFollow, error)                     // 关注的关键词
+	CreateKeyword(keyword *model.KeywordFollow) (*model.KeywordFollow, error) // 关注关键词
+	DeleteKeyword(uid, id uint) error                                         // 取消关注关键词
+	ListBookmarks(uid uint) ([]model.Bookmark, error)                         // 收藏的热搜
+	Bookmark(uid, hotSearchID uint) error                                     // 收藏热搜
+	Unbookmark(uid, hotSearchID uint) error                                   // 取消收藏热搜
+	GetFeed(uid uint) (*model.Feed, error)                                    // 获取缓存的个性化热搜
+	SetFeed(uid uint, feed *model.Feed, ttl time.Duration) error              // 缓存个性化热搜
+}
+
+// AlertRepository 热搜提醒规则、提醒去重记录和站内信仓库接口
+type AlertRepository interface {
+	ListRules(uid uint) ([]model.AlertRule, error)                                        // user 的提醒规则
+	ListEnabledRules() ([]model.AlertRule, error)                                         // 全部启用的提醒规则
+	GetRuleByID(id uint) (*model.AlertRule, error)                                        // 通过id获取提醒规则
+	CreateRule(rule *model.AlertRule) (*model.AlertRule, error)                           // 创建提醒规则
+	UpdateRule(rule *model.AlertRule) (*model.AlertRule, error)                           // 修改提醒规则
+	DeleteRule(id uint) error                                                             // 删除提醒规则
+	Hit(hit *model.AlertHit) (bool, error)                                                // 记录提醒过的热搜，已经提醒过时返回 false
+	DeleteHit(hit *model.AlertHit) error                                                  // 删除提醒记录
+	CreateNotification(notification *model.Notification) error                            // 保存站内信
+	ListNotifications(uid uint, unreadOnly bool, limit int) ([]model.Notification, error) // 获取站内信
+	MarkRead(uid, id uint) error                                                          // 标记站内信已读
+	MarkAllRead(uid uint) error                                                           // 标记全部站内信已读
+}
+
+// BulkRepository 批量导入导出 role、group 和 user 仓库接口
+type BulkRepository interface {
+	ListRoles() ([]model.Role, error)                          // 全部 role
+	ListGroups() ([]model.Group, error)                        // 全部 group 和它们的 role
+	ListUsers() ([]model.User, error)                          // 全部 user 和它们的 group、role
+	Import(creator *model.User, doc *model.BulkDocument) error // 在一个事务中按名称创建或修改
+}
+
+// RBACFileRepository 同步声明式 RBAC 配置文件仓库接口
+type RBACFileRepository interface {
+	State() (*model.RBACState, error) // 数据库中全部的资源、role、group 和 user
+	Apply(plan *model.RBACPlan) error // 在一个事务中执行 plan 中的修改
+}
+
+// WebhookRepository webhook 和投递记录仓库接口
+type WebhookRepository interface {
+	List() ([]model.Webhook, error)                                               // 全部 webhook
+	ListEnabled() ([]model.Webhook, error)                                        // 全部启用的 webhook
+	GetWebhookByID(id uint) (*model.Webhook, error)                               // 通过id获取 webhook
+	Create(webhook *model.Webhook) (*model.Webhook, error)                        // 创建 webhook
+	Update(webhook *model.Webhook) (*model.Webhook, error)                        // 修改 webhook
+	Delete(id uint) error                                                         // 删除 webhook 和投递记录
+	CreateDeliveries(deliveries []model.WebhookDelivery) error                    // 加入投递队列
+	ListDeliveries(webhookID uint, limit int) ([]model.WebhookDelivery, error)    // 最近的投递记录
+	GetDelivery(webhookID, id uint) (*model.WebhookDelivery, error)               // 获取投递记录
+	ListDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error)  // 到了投递时间的记录
+	ClaimDelivery(delivery *model.WebhookDelivery, lease time.Time) (bool, error) // 领取投递记录
+	UpdateDelivery(delivery *model.WebhookDelivery) error                         // 保存投递结果
 }
 
 // 12-7
 type RBACRepository interface {
-	List() ([]model.Role, error)                  // 获取role列表
-	ListResources() ([]model.Resource, error)     // resource 列表
-	Create(role *model.Role) (*model.Role, error) // 创建role
-	GetRoleByID(id int) (*model.Role, error)      // 通过id获取role
-	Update(role *model.Role) (*model.Role, error) // 修改role
-	Delete(id uint) error                         // 删除role
-	Migrate() error                               // 自动迁移
+	List() ([]model.Role, error)                                  // 获取role列表
+	ListResources() ([]model.Resource, error)                     // resource 列表
+	Create(role *model.Role) (*model.Role, error)                 // 创建role
+	GetRoleByID(id int) (*model.Role, error)                      // 通过id获取role
+	Update(role *model.Role) (*model.Role, error)                 // 修改role
+	Patch(role *model.Role, fields []string) (*model.Role, error) // 修改role的部分字段，零值也会保存
+	Delete(id uint, version uint64) error                         // 删除role，version 不为 0 时比较版本号
 
 	CreateResource(resource *model.Resource) (*model.Resource, error)
 	CreateResources(resource []model.Resource, conds ...clause.Expression) error
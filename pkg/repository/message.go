@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"gorm.io/gorm"
+)
+
+// messageRepository 聊天消息仓库
+type messageRepository struct {
+	db  *gorm.DB
+	rdb *database.RedisDB
+}
+
+// newMessageRepository 返回一个聊天消息仓库
+func newMessageRepository(db *gorm.DB, rdb *database.RedisDB) MessageRepository {
+	return &messageRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// Create 保存消息，返回带发送者的消息
+func (m *messageRepository) Create(message *model.Message) (*model.Message, error) {
+	if err := m.db.Omit(model.MessageSenderAssociation, model.MessagePreviewAssociation).Create(message).Error; err != nil {
+		return nil, dbError(err, "message", message.RoomID)
+	}
+	created, err := m.GetMessageByID(message.ID)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetMessageByID 通过id获取消息、发送者和链接预览
+func (m *messageRepository) GetMessageByID(id uint) (*model.Message, error) {
+	message := new(model.Message)
+	err := m.db.Preload(model.MessageSenderAssociation, func(db *gorm.DB) *gorm.DB {
+		return db.Omit("Password")
+	}).Preload(model.MessagePreviewAssociation).First(message, id).Error
+	if err != nil {
+		return nil, dbError(err, "message", id)
+	}
+	return message, nil
+}
+
+// List 获取聊天室中 id 小于 before 的最近 limit 条消息，before 为 0 时从最新的消息开始，按 id 从新到旧排序
+func (m *messageRepository) List(roomID, before uint, limit int) ([]model.Message, error) {
+	messages := make([]model.Message, 0, limit)
+	query := m.db.Preload(model.MessageSenderAssociation, func(db *gorm.DB) *gorm.DB {
+		return db.Omit("Password")
+	}).Preload(model.MessagePreviewAssociation).Where("room_id = ?", roomID)
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+	if err := query.Order("id DESC").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// LastID 返回聊天室最新消息的 id，没有消息时返回 0
+func (m *messageRepository) LastID(roomID uint) (uint, error) {
+	var id uint
+	err := m.db.Model(&model.Message{}).Select("COALESCE(MAX(id), 0)").Where("room_id = ?", roomID).Scan(&id).Error
+	return id, err
+}
+
+// AddLinkPreviews 保存消息的链接预览，返回更新后的消息
+func (m *messageRepository) AddLinkPreviews(messageID uint, previews []model.LinkPreview) (*model.Message, error) {
+	for i := range previews {
+		previews[i].MessageID = messageID
+	}
+	if err := m.db.Create(&previews).Error; err != nil {
+		return nil, dbError(err, "link preview", messageID)
+	}
+	message, err := m.GetMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
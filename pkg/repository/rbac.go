@@ -11,14 +11,20 @@ package repository
 import (
 	"chitchat4.0/pkg/database"
 	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/watch"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+var (
+	roleUpdateFields = []string{"name", "scope", "namespace", "rules"}
+)
+
 // rbac 数据库仓库
 type rbacRepository struct {
-	db  *gorm.DB
-	rdb *database.RedisDB
+	db     *gorm.DB
+	rdb    *database.RedisDB
+	events *watch.Broadcaster // 资源变更事件
 }
 
 /**
@@ -27,10 +33,11 @@ type rbacRepository struct {
  * @param {*database.RedisDB} rdb
  * @return {*}
  */
-func newRBACRepository(db *gorm.DB, rdb *database.RedisDB) RBACRepository {
+func newRBACRepository(db *gorm.DB, rdb *database.RedisDB, events *watch.Broadcaster) RBACRepository {
 	return &rbacRepository{
-		db:  db,
-		rdb: rdb,
+		db:     db,
+		rdb:    rdb,
+		events: events,
 	}
 }
 
@@ -56,8 +63,12 @@ func (rbac *rbacRepository) ListResources() ([]model.Resource, error) {
  * @return {*}
  */
 func (rbac *rbacRepository) Create(role *model.Role) (*model.Role, error) {
-	err := rbac.db.Create(role).Error
-	return role, err
+	role.ResourceVersion = 1
+	if err := rbac.db.Create(role).Error; err != nil {
+		return nil, dbError(err, "role", role.Name)
+	}
+	rbac.events.Publish(model.EventAdded, model.RoleResource, role.ID, role)
+	return role, nil
 }
 
 /**
@@ -68,29 +79,47 @@ func (rbac *rbacRepository) Create(role *model.Role) (*model.Role, error) {
 func (rbac *rbacRepository) GetRoleByID(id int) (*model.Role, error) {
 	role := &model.Role{}
 	err := rbac.db.First(role, id).Error
-	return role, err
+	return role, dbError(err, "role", id)
 }
 
+// Update 修改 role，role.ResourceVersion 不为 0 时和数据库中的版本号比较
 func (rbac *rbacRepository) Update(role *model.Role) (*model.Role, error) {
-	err := rbac.db.Updates(role).Error
-	return role, err
+	if err := updateWithVersion(rbac.db, role, role.ID, &role.ResourceVersion, roleUpdateFields, "role"); err != nil {
+		return nil, dbError(err, "role", role.Name)
+	}
+	return rbac.updated(role.ID)
 }
 
-func (rbac *rbacRepository) Delete(id uint) error {
-	return rbac.db.Delete(&model.Role{}, id).Error
+// Patch 只保存 fields 中的字段，字段为零值时也会保存
+func (rbac *rbacRepository) Patch(role *model.Role, fields []string) (*model.Role, error) {
+	if err := updateWithVersion(rbac.db, role, role.ID, &role.ResourceVersion, fields, "role"); err != nil {
+		return nil, dbError(err, "role", role.Name)
+	}
+	return rbac.updated(role.ID)
 }
 
-/**
- * @description: Migrate 实现数据库model迁移
- * @return {*}
- */
-func (rbac *rbacRepository) Migrate() error {
-	return rbac.db.AutoMigrate(&model.Role{}, &model.Resource{})
+// updated 重新查询修改后的 role 并发布修改事件
+func (rbac *rbacRepository) updated(id uint) (*model.Role, error) {
+	role, err := rbac.GetRoleByID(int(id))
+	if err != nil {
+		return nil, err
+	}
+	rbac.events.Publish(model.EventModified, model.RoleResource, role.ID, role)
+	return role, nil
+}
+
+// Delete 删除 role，version 不为 0 时和数据库中的版本号比较
+func (rbac *rbacRepository) Delete(id uint, version uint64) error {
+	if err := deleteWithVersion(rbac.db, &model.Role{}, id, version, "role"); err != nil {
+		return err
+	}
+	rbac.events.Publish(model.EventDeleted, model.RoleResource, id, &model.Role{ID: id})
+	return nil
 }
 
 func (rbac *rbacRepository) CreateResource(resource *model.Resource) (*model.Resource, error) {
 	err := rbac.db.Create(resource).Error
-	return resource, err
+	return resource, dbError(err, "resource", resource.Name)
 }
 
 // 在repository仓库Init时调用
@@ -102,13 +131,13 @@ func (rbac *rbacRepository) CreateResources(resources []model.Resource, conds ..
 func (rbac *rbacRepository) GetResource(id int) (*model.Resource, error) {
 	res := &model.Resource{}
 	err := rbac.db.First(res, id).Error
-	return res, err
+	return res, dbError(err, "resource", id)
 }
 
 func (rbac *rbacRepository) GetRoleByName(name string) (*model.Role, error) {
 	role := new(model.Role)
-	if err := rbac.db.Preload(model.UserAssociation).Where("name = ?", name).First(role).Error; err != nil {
-		return nil, err
+	if err := rbac.db.Where("name = ?", name).First(role).Error; err != nil {
+		return nil, dbError(err, "role", name)
 	}
 
 	return role, nil
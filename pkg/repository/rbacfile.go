@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/watch"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var resourceUpdateFields = []string{"scope", "kind"}
+
+// rbacFileRepository 同步声明式 RBAC 配置文件
+type rbacFileRepository struct {
+	db     *gorm.DB
+	rdb    *database.RedisDB
+	events *watch.Broadcaster // 资源变更事件
+}
+
+func newRBACFileRepository(db *gorm.DB, rdb *database.RedisDB, events *watch.Broadcaster) RBACFileRepository {
+	return &rbacFileRepository{
+		db:     db,
+		rdb:    rdb,
+		events: events,
+	}
+}
+
+// State 返回全部资源、role、group（包括成员和 role）和 user（包括 role），不包括密码
+func (r *rbacFileRepository) State() (*model.RBACState, error) {
+	state := &model.RBACState{
+		Resources: make([]model.Resource, 0),
+		Roles:     make([]model.Role, 0),
+		Groups:    make([]model.Group, 0),
+		Users:     make([]model.User, 0),
+	}
+	if err := r.db.Order("name").Find(&state.Resources).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Order("name").Find(&state.Roles).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Order("name").Preload(model.UserAssociation, func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name")
+	}).Preload("Roles").Find(&state.Groups).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Select("id", "name").Order("name").Preload("Roles").Find(&state.Users).Error; err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Apply 在一个事务中按顺序执行 plan 中的修改，任何一个失败时全部回滚；提交成功后才发布资源变更事件
+func (r *rbacFileRepository) Apply(plan *model.RBACPlan) error {
+	events := make([]importEvent, 0)
+	publish := func(eventType model.EventType, resource string, id uint, obj interface{}) {
+		events = append(events, importEvent{eventType: eventType, resource: resource, id: id, obj: obj})
+	}
+	users := make(map[uint]bool) // 绑定修改过的 user，提交后删除缓存
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range plan.Changes {
+			change := &plan.Changes[i]
+			switch change.Kind {
+			case model.RBACResourceKind:
+				if err := applyResource(tx, change); err != nil {
+					return err
+				}
+			case model.RoleResource:
+				role := change.Role
+				switch change.Action {
+				case model.RBACCreate:
+					role.ResourceVersion = 1
+					if err := tx.Create(role).Error; err != nil {
+						return dbError(err, "role", role.Name)
+					}
+					publish(model.EventAdded, model.RoleResource, role.ID, role)
+				case model.RBACUpdate:
+					if err := updateWithVersion(tx, role, role.ID, &role.ResourceVersion, roleUpdateFields, "role"); err != nil {
+						return dbError(err, "role", role.Name)
+					}
+					publish(model.EventModified, model.RoleResource, role.ID, role)
+				case model.RBACDelete:
+					for _, table := range []string{"user_roles", "group_roles"} {
+						if err := tx.Table(table).Where("role_id = ?", role.ID).Delete(nil).Error; err != nil {
+							return err
+						}
+					}
+					if err := tx.Delete(&model.Role{}, role.ID).Error; err != nil {
+						return err
+					}
+					publish(model.EventDeleted, model.RoleResource, role.ID, &model.Role{ID: role.ID})
+				}
+			case model.GroupResource:
+				group := change.Group
+				switch change.Action {
+				case model.RBACCreate:
+					group.ResourceVersion = 1
+					if err := tx.Omit(clause.Associations).Create(group).Error; err != nil {
+						return dbError(err, "group", group.Name)
+					}
+					publish(model.EventAdded, model.GroupResource, group.ID, group)
+				case model.RBACUpdate:
+					if err := updateWithVersion(tx, group, group.ID, &group.ResourceVersion, []string{"describe"}, "group"); err != nil {
+						return dbError(err, "group", group.Name)
+					}
+					publish(model.EventModified, model.GroupResource, group.ID, group)
+				case model.RBACDelete:
+					// 彻底删除，之后可以在配置文件中重新创建同名的 group
+					for _, association := range []string{model.UserAssociation, "Roles"} {
+						if err := tx.Model(group).Association(association).Clear(); err != nil {
+							return err
+						}
+					}
+					if err := tx.Unscoped().Delete(&model.Group{}, group.ID).Error; err != nil {
+						return err
+					}
+					publish(model.EventDeleted, model.GroupResource, group.ID, &model.Group{ID: group.ID})
+				}
+			case model.RBACBindingKind, model.RBACMembershipKind:
+				user, err := applyBinding(tx, change)
+				if err != nil {
+					return err
+				}
+				if user != nil {
+					users[user.ID] = true
+				}
+			default:
+				return fmt.Errorf("unknown rbac change kind %q", change.Kind)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for id := range users {
+		r.rdb.HDel((&model.User{}).CacheKey(), strconv.Itoa(int(id)))
+	}
+	for _, e := range events {
+		r.events.Publish(e.eventType, e.resource, e.id, e.obj)
+	}
+	return nil
+}
+
+func applyResource(tx *gorm.DB, change *model.RBACChange) error {
+	resource := change.Resource
+	switch change.Action {
+	case model.RBACCreate:
+		if err := tx.Create(resource).Error; err != nil {
+			return dbError(err, "resource", resource.Name)
+		}
+	case model.RBACUpdate:
+		if err := tx.Model(resource).Select(resourceUpdateFields).Updates(resource).Error; err != nil {
+			return dbError(err, "resource", resource.Name)
+		}
+	case model.RBACDelete:
+		if err := tx.Delete(&model.Resource{}, resource.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyBinding 添加或删除 role 绑定和 group 成员，返回绑定修改的 user
+func applyBinding(tx *gorm.DB, change *model.RBACChange) (*model.User, error) {
+	binding := change.Binding
+	var user *model.User
+	if binding.User != "" {
+		user = new(model.User)
+		if err := tx.Select("id", "name").Where("name = ?", binding.User).Take(user).Error; err != nil {
+			return nil, dbError(err, "user", binding.User)
+		}
+	}
+	var group *model.Group
+	if binding.Group != "" {
+		group = new(model.Group)
+		if err := tx.Where("name = ?", binding.Group).Take(group).Error; err != nil {
+			return nil, dbError(err, "group", binding.Group)
+		}
+	}
+
+	var association *gorm.Association
+	var value interface{}
+	switch {
+	case binding.Role == "": // user 加入 group
+		association, value = tx.Model(group).Association(model.UserAssociation), user
+	default:
+		role := new(model.Role)
+		if err := tx.Where("name = ?", binding.Role).Take(role).Error; err != nil {
+			return nil, dbError(err, "role", binding.Role)
+		}
+		value = role
+		if user != nil {
+			association = tx.Model(user).Association("Roles")
+		} else {
+			association = tx.Model(group).Association("Roles")
+		}
+	}
+
+	var err error
+	switch change.Action {
+	case model.RBACCreate:
+		err = association.Append(value)
+	case model.RBACDelete:
+		err = association.Delete(value)
+	default:
+		err = apierrors.NewBadRequest("unsupported binding action " + change.Action)
+	}
+	return user, err
+}
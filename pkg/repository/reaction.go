@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reactionRepository 回应仓库
+type reactionRepository struct {
+	db  *gorm.DB
+	rdb *database.RedisDB
+}
+
+// newReactionRepository 返回一个回应仓库
+func newReactionRepository(db *gorm.DB, rdb *database.RedisDB) ReactionRepository {
+	return &reactionRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// Add 添加回应，user 已经用同一个 emoji 回应过时忽略
+func (r *reactionRepository) Add(reaction *model.Reaction) error {
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error; err != nil {
+		return dbError(err, "reaction", reaction.Emoji)
+	}
+	return nil
+}
+
+// Remove 取消回应，没有回应过时忽略
+func (r *reactionRepository) Remove(reaction *model.Reaction) error {
+	return r.db.Where("target_type = ? AND target_id = ? AND user_id = ? AND emoji = ?",
+		reaction.TargetType, reaction.TargetID, reaction.UserID, reaction.Emoji).Delete(&model.Reaction{}).Error
+}
+
+// Counts 统计对象的 emoji 回应数，返回 target id -> emoji -> 回应数
+func (r *reactionRepository) Counts(targetType string, targetIDs []uint) (map[uint]map[string]int64, error) {
+	counts := make(map[uint]map[string]int64, len(targetIDs))
+	if len(targetIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		TargetID uint
+		Emoji    string
+		Count    int64
+	}
+	err := r.db.Model(&model.Reaction{}).Select("target_id, emoji, COUNT(*) AS count").
+		Where("target_type = ? AND target_id IN ?", targetType, targetIDs).
+		Group("target_id, emoji").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if counts[row.TargetID] == nil {
+			counts[row.TargetID] = make(map[string]int64)
+		}
+		counts[row.TargetID][row.Emoji] = row.Count
+	}
+	return counts, nil
+}
@@ -5,52 +5,58 @@ import (
 
 	"chitchat4.0/pkg/database"
 	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/watch"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 func NewRepository(db *gorm.DB, rdb *database.RedisDB) Repository {
+	events := watch.NewBroadcaster(watch.DefaultHistorySize) // 仓库写入成功后发布资源变更事件
 	r := &repository{
 		db:        db,
 		rdb:       rdb,
-		user:      newUserRepository(db, rdb), // user 数据仓库
-		group:     newGroupRepository(db, rdb),
-		rbac:      newRBACRepository(db, rdb),
-		tag:       newTagRepository(db, rdb),
-		hotSearch: newHotSearchRepository(db, rdb),
+		events:    events,
+		user:      newUserRepository(db, rdb, events), // user 数据仓库
+		group:     newGroupRepository(db, rdb, events),
+		rbac:      newRBACRepository(db, rdb, events),
+		tag:       newTagRepository(db, rdb, events),
+		hotSearch: newHotSearchRepository(db, rdb, events),
+		room:      newRoomRepository(db, rdb),
+		message:   newMessageRepository(db, rdb),
+		comment:   newCommentRepository(db, rdb),
+		reaction:  newReactionRepository(db, rdb),
+		follow:    newFollowRepository(db, rdb),
+		alert:     newAlertRepository(db, rdb),
+		webhook:   newWebhookRepository(db, rdb),
+		bulk:      newBulkRepository(db, rdb, events),
+		rbacFile:  newRBACFileRepository(db, rdb, events),
+		token:     newAccessTokenRepository(db, rdb),
+		session:   newSessionRepository(rdb),
 	}
-	r.migrates = getMigrants(
-		r.user,
-		r.tag,
-		r.hotSearch,
-		r.group,
-		r.rbac,
-	)
-
 	return r
 }
 
-func getMigrants(objs ...interface{}) []Migrant {
-	var migrants []Migrant
-	for _, obj := range objs {
-		if m, ok := obj.(Migrant); ok {
-			migrants = append(migrants, m)
-		}
-	}
-	return migrants
-}
-
 type repository struct {
 	user      UserRepository
 	group     GroupRepository
 	rbac      RBACRepository
 	tag       TagRepository
 	hotSearch HotSearchRepository
+	room      RoomRepository
+	message   MessageRepository
+	comment   CommentRepository
+	reaction  ReactionRepository
+	follow    FollowRepository
+	alert     AlertRepository
+	webhook   WebhookRepository
+	bulk      BulkRepository
+	rbacFile  RBACFileRepository
+	token     AccessTokenRepository
+	session   SessionRepository
 
-	db  *gorm.DB
-	rdb *database.RedisDB
-
-	migrates []Migrant // 用于各模型迁移
+	db     *gorm.DB
+	rdb    *database.RedisDB
+	events *watch.Broadcaster
 }
 
 func (r *repository) User() UserRepository {
@@ -63,6 +69,18 @@ func (r *repository) RBAC() RBACRepository {
 	return r.rbac
 }
 
+func (r *repository) AccessToken() AccessTokenRepository {
+	return r.token
+}
+
+func (r *repository) Session() SessionRepository {
+	return r.session
+}
+
+func (r *repository) Events() *watch.Broadcaster {
+	return r.events
+}
+
 func (r *repository) Tag() TagRepository {
 	return r.tag
 }
@@ -71,6 +89,42 @@ func (r *repository) HotSearch() HotSearchRepository {
 	return r.hotSearch
 }
 
+func (r *repository) Room() RoomRepository {
+	return r.room
+}
+
+func (r *repository) Message() MessageRepository {
+	return r.message
+}
+
+func (r *repository) Comment() CommentRepository {
+	return r.comment
+}
+
+func (r *repository) Reaction() ReactionRepository {
+	return r.reaction
+}
+
+func (r *repository) Follow() FollowRepository {
+	return r.follow
+}
+
+func (r *repository) Alert() AlertRepository {
+	return r.alert
+}
+
+func (r *repository) Webhook() WebhookRepository {
+	return r.webhook
+}
+
+func (r *repository) Bulk() BulkRepository {
+	return r.bulk
+}
+
+func (r *repository) RBACFile() RBACFileRepository {
+	return r.rbacFile
+}
+
 // Ping 是使用 *repository 接收器定义的方法，
 // 作用：实现了 Repository 仓库接口的 Ping 方法
 // 查看数据库的连接状态
@@ -79,7 +133,7 @@ func (r *repository) Ping(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	// PingContext 验证到 Postgres 数据库的连接是否仍然存在，并在必要时建立连接
+	// PingContext 验证到数据库的连接是否仍然存在，并在必要时建立连接
 	if err = db.PingContext(ctx); err != nil {
 		return err
 	}
@@ -88,22 +142,13 @@ func (r *repository) Ping(ctx context.Context) error {
 		return nil
 	}
 	// 查看 redis 的连接状态
-	if _, err := r.rdb.Ping(ctx).Result(); err != nil {
+	if err := r.rdb.Ping(ctx); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (r *repository) Migrate() error {
-	for _, m := range r.migrates {
-		if err := m.Migrate(); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func (r *repository) Close() error {
 	db, _ := r.db.DB()
 	if db != nil {
@@ -162,6 +207,54 @@ func (r *repository) Init() error {
 			Name:  model.NamespaceResource,
 			Scope: model.ClusterScope,
 		},
+		{
+			Name:  model.TagResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.HotSearchResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.RoomResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.MessageResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.CommentResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.ReactionResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.FollowResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.BookmarkResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.FeedResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.AlertResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.NotificationResource,
+			Scope: model.ClusterScope,
+		},
+		{
+			Name:  model.WebhookResource,
+			Scope: model.ClusterScope,
+		},
 		// {
 		// 	Name:  model.KubeDeployment,
 		// 	Scope: model.NamespaceScope,
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// roomRepository 聊天室仓库
+type roomRepository struct {
+	db  *gorm.DB
+	rdb *database.RedisDB
+}
+
+// newRoomRepository 返回一个聊天室仓库
+func newRoomRepository(db *gorm.DB, rdb *database.RedisDB) RoomRepository {
+	return &roomRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// Create 创建聊天室，临时聊天室同时保存成员
+func (r *roomRepository) Create(room *model.Room, members []uint) (*model.Room, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(model.RoomMemberAssociation).Create(room).Error; err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		now := time.Now()
+		rows := make([]model.RoomMember, 0, len(members))
+		for _, uid := range members {
+			rows = append(rows, model.RoomMember{RoomID: room.ID, UserID: uid, JoinedAt: now})
+		}
+		room.Members = rows
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+	})
+	if err != nil {
+		return nil, dbError(err, "room", room.Name)
+	}
+	return room, nil
+}
+
+// GetRoomByID 通过id获取聊天室和成员
+func (r *roomRepository) GetRoomByID(id uint) (*model.Room, error) {
+	room := new(model.Room)
+	if err := r.db.Preload(model.RoomMemberAssociation).First(room, id).Error; err != nil {
+		return nil, dbError(err, "room", id)
+	}
+	return room, nil
+}
+
+// ListForUser 获取 user 能进入的聊天室：临时聊天室的成员，或者绑定了 user 所在的 group
+func (r *roomRepository) ListForUser(uid uint, groupIDs []uint) ([]model.Room, error) {
+	rooms := make([]model.Room, 0)
+	query := r.db.Where("group_id IS NULL AND id IN (?)", r.db.Model(&model.RoomMember{}).Select("room_id").Where("user_id = ?", uid))
+	if len(groupIDs) > 0 {
+		query = query.Or("group_id IN ?", groupIDs)
+	}
+	if err := query.Order("id").Find(&rooms).Error; err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+// UnreadCounts 统计 user 在聊天室中的未读消息数（不包括自己发送的），返回 room id -> 未读数
+func (r *roomRepository) UnreadCounts(uid uint, roomIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(roomIDs))
+	if len(roomIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		RoomID uint
+		Count  int64
+	}
+	err := r.db.Model(&model.Message{}).
+		Select("messages.room_id, count(*) AS count").
+		Joins("LEFT JOIN room_members ON room_members.room_id = messages.room_id AND room_members.user_id = ?", uid).
+		Where("messages.room_id IN ? AND messages.sender_id <> ? AND messages.id > COALESCE(room_members.last_read_id, 0)", roomIDs, uid).
+		Group("messages.room_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.RoomID] = row.Count
+	}
+	return counts, nil
+}
+
+// Delete 删除聊天室以及成员、消息
+func (r *roomRepository) Delete(id uint) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		messages := tx.Model(&model.Message{}).Select("id").Where("room_id = ?", id)
+		if err := tx.Where("message_id IN (?)", messages).Delete(&model.LinkPreview{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("room_id = ?", id).Delete(&model.Message{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("room_id = ?", id).Delete(&model.RoomMember{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.Room{}, id)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return apierrors.NewNotFound("room", id)
+		}
+		return result.Error
+	})
+	if err != nil {
+		return dbError(err, "room", id)
+	}
+	return nil
+}
+
+// AddMember 把 user 加入临时聊天室
+func (r *roomRepository) AddMember(roomID, uid uint) error {
+	member := &model.RoomMember{RoomID: roomID, UserID: uid, JoinedAt: time.Now()}
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error
+	return dbError(err, "room member", uid)
+}
+
+// DelMember 把 user 移出临时聊天室
+func (r *roomRepository) DelMember(roomID, uid uint) error {
+	result := r.db.Where("room_id = ? AND user_id = ?", roomID, uid).Delete(&model.RoomMember{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return apierrors.NewNotFound("room member", uid)
+	}
+	return result.Error
+}
+
+// IsMember 判断 user 是不是临时聊天室的成员
+func (r *roomRepository) IsMember(roomID, uid uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.RoomMember{}).Where("room_id = ? AND user_id = ?", roomID, uid).Count(&count).Error
+	return count > 0, err
+}
+
+// MarkRead 把 user 在聊天室的已读位置移动到 messageID，已读位置只会前进
+func (r *roomRepository) MarkRead(roomID, uid, messageID uint) error {
+	member := &model.RoomMember{RoomID: roomID, UserID: uid, LastReadID: messageID, JoinedAt: time.Now()}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "room_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_id": gorm.Expr("GREATEST(room_members.last_read_id, excluded.last_read_id)"),
+		}),
+	}).Create(member).Error
+}
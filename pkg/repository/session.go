@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"github.com/go-redis/redis/v8"
+)
+
+// sessionRepository 会话仓库，会话只保存在 Redis 中。
+// redis 禁用时不保存会话，也无法撤销会话，Delete 和 DeleteAll 返回 database.RedisDisableError
+type sessionRepository struct {
+	rdb *database.RedisDB
+}
+
+// newSessionRepository 返回一个会话仓库
+func newSessionRepository(rdb *database.RedisDB) SessionRepository {
+	return &sessionRepository{
+		rdb: rdb,
+	}
+}
+
+// Create 保存新的会话，新会话过期时间最晚，
+// 所以 user 的会话 key 会在最后一个会话过期后一起过期
+func (s *sessionRepository) Create(session *model.Session) error {
+	if err := s.rdb.HSet(session.CacheKey(), session.ID, session); err != nil {
+		return err
+	}
+	return s.rdb.Expire(session.CacheKey(), time.Until(session.ExpiresAt))
+}
+
+// Update 修改会话（最后活跃时间、IP 等），会话已被撤销时返回 NotFound，不会重新写入
+func (s *sessionRepository) Update(session *model.Session) error {
+	updated, err := s.rdb.HSetIfExists(session.CacheKey(), session.ID, session, time.Until(session.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	if !updated && s.rdb.Enabled() {
+		return apierrors.NewNotFound("session", session.ID)
+	}
+	return nil
+}
+
+// Get 获取会话，redis 禁用时返回 database.RedisDisableError
+func (s *sessionRepository) Get(uid uint, id string) (*model.Session, error) {
+	session := new(model.Session)
+	if err := s.rdb.HGet(model.SessionCacheKey(uid), id, session); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, apierrors.NewNotFound("session", id)
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+// List 获取 user 的全部会话，顺便清理已过期的会话
+func (s *sessionRepository) List(uid uint) ([]model.Session, error) {
+	values, err := s.rdb.HGetAll(model.SessionCacheKey(uid))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]model.Session, 0, len(values))
+	expired := make([]string, 0)
+	for id, value := range values {
+		session := model.Session{}
+		if err := session.UnmarshalBinary([]byte(value)); err != nil || session.Expired() {
+			expired = append(expired, id)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	if len(expired) > 0 {
+		s.rdb.HDel(model.SessionCacheKey(uid), expired...)
+	}
+	return sessions, nil
+}
+
+// Delete 删除会话
+func (s *sessionRepository) Delete(uid uint, id string) error {
+	if !s.rdb.Enabled() {
+		return database.RedisDisableError
+	}
+	return s.rdb.HDel(model.SessionCacheKey(uid), id)
+}
+
+// DeleteAll 删除 user 的全部会话
+func (s *sessionRepository) DeleteAll(uid uint) error {
+	if !s.rdb.Enabled() {
+		return database.RedisDisableError
+	}
+	return s.rdb.Del(model.SessionCacheKey(uid))
+}
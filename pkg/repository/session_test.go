@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/config"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestSessionRepository(t *testing.T) (*miniredis.Miniredis, SessionRepository) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, err := strconv.Atoi(mr.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rdb, err := database.NewRedisClient(&config.RedisConfig{Enable: true, Host: mr.Host(), Port: port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+	return mr, newSessionRepository(rdb)
+}
+
+func newTestSession(id string, ttl time.Duration) *model.Session {
+	now := time.Now()
+	return &model.Session{ID: id, UserId: 1, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(ttl)}
+}
+
+// TestSessionUpdateRevoked 撤销后的会话不会被并发的 Update 重新写入
+func TestSessionUpdateRevoked(t *testing.T) {
+	mr, sessions := newTestSessionRepository(t)
+	session := newTestSession("a", time.Hour)
+	if err := sessions.Create(session); err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions.Delete(session.UserId, session.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	session.IP = "10.0.0.1"
+	if err := sessions.Update(session); !apierrors.IsNotFound(err) {
+		t.Fatalf("update revoked session: err = %v, want NotFound", err)
+	}
+	if mr.Exists(session.CacheKey()) {
+		t.Fatal("revoked session was written back")
+	}
+}
+
+// TestSessionUpdateExpire Update 只会延长 key 的过期时间，不会缩短
+func TestSessionUpdateExpire(t *testing.T) {
+	mr, sessions := newTestSessionRepository(t)
+	short := newTestSession("short", time.Minute)
+	long := newTestSession("long", time.Hour)
+	for _, s := range []*model.Session{long, short} {
+		if err := sessions.Create(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ttl := mr.TTL(short.CacheKey()); ttl > time.Minute {
+		t.Fatalf("ttl after create = %v", ttl)
+	}
+
+	long.IP = "10.0.0.1"
+	if err := sessions.Update(long); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL(long.CacheKey()); ttl <= 59*time.Minute {
+		t.Fatalf("ttl after updating the long session = %v, want about an hour", ttl)
+	}
+	if err := sessions.Update(short); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL(long.CacheKey()); ttl <= 59*time.Minute {
+		t.Fatalf("ttl after updating the short session = %v, want about an hour", ttl)
+	}
+	got, err := sessions.Get(long.UserId, long.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.IP != "10.0.0.1" {
+		t.Fatalf("updated session = %+v", got)
+	}
+}
+
+// TestSessionRedisDisabled redis 禁用时撤销会话返回错误，不能静默成功
+func TestSessionRedisDisabled(t *testing.T) {
+	rdb, err := database.NewRedisClient(&config.RedisConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := newSessionRepository(rdb)
+	if err := sessions.Delete(1, "a"); !errors.Is(err, database.RedisDisableError) {
+		t.Fatalf("delete: err = %v, want RedisDisableError", err)
+	}
+	if err := sessions.DeleteAll(1); !errors.Is(err, database.RedisDisableError) {
+		t.Fatalf("delete all: err = %v, want RedisDisableError", err)
+	}
+}
@@ -3,30 +3,48 @@ package repository
 import (
 	"chitchat4.0/pkg/database"
 	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/watch"
 	"gorm.io/gorm"
 )
 
 type tagRepository struct {
-	db  *gorm.DB
-	rdb *database.RedisDB
+	db     *gorm.DB
+	rdb    *database.RedisDB
+	events *watch.Broadcaster // 资源变更事件
 }
 
-func newTagRepository(db *gorm.DB, rdb *database.RedisDB) TagRepository {
+func newTagRepository(db *gorm.DB, rdb *database.RedisDB, events *watch.Broadcaster) TagRepository {
 	return &tagRepository{
-		db:  db,
-		rdb: rdb,
+		db:     db,
+		rdb:    rdb,
+		events: events,
 	}
 }
 
 func (t *tagRepository) List() ([]model.Tag, error) {
 	tags := make([]model.Tag, 0)
+	if err := t.db.Order("sort").Find(&tags).Error; err != nil {
+		return nil, err
+	}
 	return tags, nil
 }
 
-func (t *tagRepository) Create(user *model.User, tag *model.Tag) (*model.Tag, error) {
+// GetTagByID 通过id获取tag
+func (t *tagRepository) GetTagByID(id uint) (*model.Tag, error) {
+	tag := new(model.Tag)
+	if err := t.db.First(tag, id).Error; err != nil {
+		return nil, dbError(err, "tag", id)
+	}
 	return tag, nil
 }
 
-func (t *tagRepository) Migrate() error {
-	return t.db.AutoMigrate(&model.Tag{}, &model.User{})
+// Create 创建 tag，创建者为 user
+func (t *tagRepository) Create(user *model.User, tag *model.Tag) (*model.Tag, error) {
+	tag.CreatorID = user.ID
+	tag.ResourceVersion = 1
+	if err := t.db.Omit("Creator").Create(tag).Error; err != nil {
+		return nil, dbError(err, "tag", tag.Name)
+	}
+	t.events.Publish(model.EventAdded, model.TagResource, tag.ID, tag)
+	return tag, nil
 }
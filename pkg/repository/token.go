@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"gorm.io/gorm"
+)
+
+// accessTokenRepository 个人访问 token 仓库
+type accessTokenRepository struct {
+	db  *gorm.DB
+	rdb *database.RedisDB
+}
+
+// newAccessTokenRepository 返回一个访问 token 仓库
+func newAccessTokenRepository(db *gorm.DB, rdb *database.RedisDB) AccessTokenRepository {
+	return &accessTokenRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// Create 创建访问 token，同时绑定角色
+func (a *accessTokenRepository) Create(token *model.AccessToken) (*model.AccessToken, error) {
+	err := a.db.Create(token).Error
+	return token, dbError(err, "access token", token.Name)
+}
+
+// List 获取 user 的全部访问 token
+func (a *accessTokenRepository) List(uid uint) ([]model.AccessToken, error) {
+	tokens := make([]model.AccessToken, 0)
+	if err := a.db.Preload(model.AccessTokenRoleAssociation).Where("user_id = ?", uid).Order("id").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// GetByHash 通过哈希值获取访问 token
+func (a *accessTokenRepository) GetByHash(hash string) (*model.AccessToken, error) {
+	token := new(model.AccessToken)
+	if err := a.db.Preload(model.AccessTokenRoleAssociation).Where("hash = ?", hash).First(token).Error; err != nil {
+		return nil, dbError(err, "access token", nil)
+	}
+	return token, nil
+}
+
+// Delete 删除 user 的访问 token
+func (a *accessTokenRepository) Delete(uid, id uint) error {
+	result := a.db.Select(model.AccessTokenRoleAssociation).Delete(&model.AccessToken{ID: id, UserId: uid}, "user_id = ?", uid)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return apierrors.NewNotFound("access token", id)
+	}
+	return result.Error
+}
+
+// Touch 更新最后使用时间
+func (a *accessTokenRepository) Touch(token *model.AccessToken, usedAt time.Time) error {
+	return a.db.Model(token).UpdateColumn("last_used_at", usedAt).Error
+}
@@ -3,33 +3,40 @@ package repository
 import (
 	"fmt"
 	"strconv"
+	"time"
 
+	"chitchat4.0/pkg/apierrors"
 	"chitchat4.0/pkg/database"
 	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/watch"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 var (
-	userCreateField = []string{"name", "email", "password", "avatar"}
+	userCreateField    = []string{"name", "email", "password", "avatar", "service_account", "resource_version"}
+	userUpdateField    = []string{"name", "email"}
+	userMFAUpdateField = []string{"MFAEnabled", "MFARequired", "MFASecret"}
 )
 
 // userRepository 是定义的用户仓库（属于大仓库中的小仓库），
 // userRepository 中的 db|rdb 是结构体类型
 // 作用：userRepository 实现了 UserRepository 接口
 type userRepository struct {
-	db  *gorm.DB
-	rdb *database.RedisDB
+	db     *gorm.DB
+	rdb    *database.RedisDB
+	events *watch.Broadcaster // 资源变更事件
 }
 
-// newUserRepository 接受两个参数，
-// 参数1 是 *gorm.DB 结构体，参数2 是 *database.RedisDB 结构体。
+// newUserRepository 接受三个参数，
+// 参数1 是 *gorm.DB 结构体，参数2 是 *database.RedisDB 结构体，参数3 是资源变更事件的广播器。
 // 作用：newUserRepository 内部实现了对 userRepository 结构体赋值，
 // 返回结果是 userRepository 结构体地址，类型是 UserRepository 用户仓库接口
-func newUserRepository(db *gorm.DB, rdb *database.RedisDB) UserRepository {
+func newUserRepository(db *gorm.DB, rdb *database.RedisDB, events *watch.Broadcaster) UserRepository {
 	return &userRepository{
-		db:  db,
-		rdb: rdb,
+		db:     db,
+		rdb:    rdb,
+		events: events,
 	}
 }
 
@@ -49,8 +56,9 @@ func (u *userRepository) List() (model.Users, error) {
 // 是使用 *userRepository 接收器定义的方法，
 // 作用：实现了 user 仓库接口的 Create 方法，用于创建用户
 func (u *userRepository) Create(user *model.User) (*model.User, error) {
+	user.ResourceVersion = 1
 	if err := u.db.Select(userCreateField).Create(user).Error; err != nil {
-		return nil, err
+		return nil, dbError(err, "user", user.Name)
 	}
 
 	// 缓存用户信息
@@ -58,26 +66,52 @@ func (u *userRepository) Create(user *model.User) (*model.User, error) {
 	if err := u.setCacheUser(user); err != nil {
 		logrus.Errorf("redis 无法设置用户缓存：%v", err)
 	}
+	u.events.Publish(model.EventAdded, model.UserResource, user.ID, user)
 	return user, nil
 }
 
-// Update 通过id修改用户，实现了修改用户的服务
+// Update 通过id修改用户，name、email 为空时也会保存，password 为空时不修改；
+// user.ResourceVersion 不为 0 时和数据库中的版本号比较
 func (u *userRepository) Update(user *model.User) (*model.User, error) {
-	if err := u.db.Model(&model.User{}).Where("id = ?", user.ID).Updates(user).Error; err != nil {
+	fields := userUpdateField
+	if user.Password != "" {
+		fields = append([]string{"password"}, fields...)
+	}
+	if err := updateWithVersion(u.db, user, user.ID, &user.ResourceVersion, fields, "user"); err != nil {
+		return nil, dbError(err, "user", user.Name)
+	}
+	u.rdb.HDel(user.CacheKey(), strconv.Itoa(int(user.ID)))
+	updated, err := u.GetUserByID(user.ID)
+	if err != nil {
 		return nil, err
 	}
+	u.events.Publish(model.EventModified, model.UserResource, updated.ID, updated)
+	return updated, nil
+}
+
+// Patch 只保存 fields 中的字段，字段为零值时也会保存
+func (u *userRepository) Patch(user *model.User, fields []string) (*model.User, error) {
+	if err := updateWithVersion(u.db, user, user.ID, &user.ResourceVersion, fields, "user"); err != nil {
+		return nil, dbError(err, "user", user.Name)
+	}
 	u.rdb.HDel(user.CacheKey(), strconv.Itoa(int(user.ID)))
-	return user, nil
+	patched, err := u.GetUserByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.events.Publish(model.EventModified, model.UserResource, patched.ID, patched)
+	return patched, nil
 }
 
 func (u *userRepository) Delete(user *model.User) error {
-	// 删掉授权信息
-	err := u.db.Select(model.UserAuthInfoAssociation).Delete(user).Error
+	// 删掉授权信息，user.ResourceVersion 不为 0 时和数据库中的版本号比较
+	err := deleteWithVersion(u.db.Select(model.UserAuthInfoAssociation), user, user.ID, user.ResourceVersion, "user")
 	if err != nil {
-		return err
+		return dbError(err, "user", user.ID)
 	}
 	// 删掉Redis缓存
 	u.rdb.HDel(user.CacheKey(), strconv.Itoa(int(user.ID)))
+	u.events.Publish(model.EventDeleted, model.UserResource, user.ID, &model.User{ID: user.ID})
 	return nil
 }
 
@@ -87,7 +121,7 @@ func (u *userRepository) GetUserByID(id uint) (*model.User, error) {
 	user := new(model.User)
 	// Qmit 查询时省略password
 	if err := u.db.Omit("Password").Preload(model.UserAuthInfoAssociation).Preload("Groups").Preload("Groups.Roles").Preload("Roles").First(user, id).Error; err != nil {
-		return nil, err
+		return nil, dbError(err, "user", id)
 	}
 	// 设置用户的redis缓存
 	if err := u.setCacheUser(user); err != nil {
@@ -100,7 +134,7 @@ func (u *userRepository) GetUserByID(id uint) (*model.User, error) {
 func (u *userRepository) GetUserByAuthID(authType, authID string) (*model.User, error) {
 	authInfo := new(model.AuthInfo)
 	if err := u.db.Where("auth_type = ? and auth_id = ?", authType, authID).First(authInfo).Error; err != nil {
-		return nil, err
+		return nil, dbError(err, "auth info", authID)
 	}
 
 	return u.GetUserByID(authInfo.UserId)
@@ -111,7 +145,7 @@ func (u *userRepository) GetUserByName(name string) (*model.User, error) {
 	user := new(model.User)
 	// 关联 auth_infos | groups | group_roles | roles
 	if err := u.db.Preload(model.UserAuthInfoAssociation).Preload("Groups").Preload("Groups.Roles").Preload("Roles").Where("name=?", name).First(user).Error; err != nil {
-		return nil, err
+		return nil, dbError(err, "user", name)
 	}
 	return user, nil
 }
@@ -127,10 +161,6 @@ func (u *userRepository) setCacheUser(user *model.User) error {
 	return u.rdb.HSet(user.CacheKey(), strconv.Itoa(int(user.ID)), user)
 }
 
-func (u *userRepository) Migrate() error {
-	return u.db.AutoMigrate(&model.User{}, &model.AuthInfo{})
-}
-
 func (u *userRepository) GetGroups(user *model.User) ([]model.Group, error) {
 	groups := make([]model.Group, 0)
 	err := u.db.Model(user).Association(model.GroupAssociation).Find(&groups)
@@ -150,7 +180,7 @@ func (u *userRepository) AddAuthInfo(authInfo *model.AuthInfo) error {
 		return nil
 	}
 	if authInfo.UserId == 0 {
-		return fmt.Errorf("empty user id")
+		return apierrors.NewFieldInvalid("userId", "empty user id")
 	}
 	return u.db.Create(authInfo).Error
 }
@@ -162,3 +192,88 @@ func (u *userRepository) DelAuthInfo(authInfo *model.AuthInfo) error {
 
 	return u.db.Delete(authInfo).Error
 }
+
+// UpdateMFA 修改二次验证设置，使用 Select 保证 false 和空字符串也会写入
+func (u *userRepository) UpdateMFA(user *model.User) error {
+	if err := u.db.Model(&model.User{ID: user.ID}).Select(userMFAUpdateField).Updates(user).Error; err != nil {
+		return dbError(err, "user", user.ID)
+	}
+	u.rdb.HDel(user.CacheKey(), strconv.Itoa(int(user.ID)))
+	return nil
+}
+
+// SetRecoveryCodes 删除旧的恢复码后保存新的恢复码
+func (u *userRepository) SetRecoveryCodes(user *model.User, codes []model.RecoveryCode) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		for i := range codes {
+			codes[i].UserId = user.ID
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (u *userRepository) GetRecoveryCodes(user *model.User) ([]model.RecoveryCode, error) {
+	codes := make([]model.RecoveryCode, 0)
+	if err := u.db.Where("user_id = ? and used = ?", user.ID, false).Find(&codes).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode 标记恢复码已使用，并发使用同一个恢复码时只有一次成功
+func (u *userRepository) UseRecoveryCode(code *model.RecoveryCode) error {
+	result := u.db.Model(code).Where("used = ?", false).Update("used", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apierrors.NewConflict("recovery code", nil, nil)
+	}
+	return nil
+}
+
+// AddMFAFailure 校验一次性密码之前先计入一次失败，通过后再清除，并发的尝试也不会超过 max 次。
+// 第一次失败超过 window 后重新计数；window 内已经失败 max 次时返回 TooManyRequests
+func (u *userRepository) AddMFAFailure(user *model.User, max int, window time.Duration) error {
+	now := time.Now()
+	cutoff := now.Add(-window)
+	expired := "mfa_failures_since IS NULL OR mfa_failures_since < ?"
+	result := u.db.Model(&model.User{}).
+		Where("id = ?", user.ID).
+		Where("("+expired+" OR mfa_failures < ?)", cutoff, max).
+		Updates(map[string]interface{}{
+			"mfa_failures":       gorm.Expr("CASE WHEN "+expired+" THEN 1 ELSE mfa_failures + 1 END", cutoff),
+			"mfa_failures_since": gorm.Expr("CASE WHEN "+expired+" THEN ? ELSE mfa_failures_since END", cutoff, now),
+		})
+	if result.Error != nil {
+		return dbError(result.Error, "user", user.ID)
+	}
+	if result.RowsAffected == 0 {
+		return apierrors.NewTooManyRequests("too many failed mfa attempts, try again later")
+	}
+	return nil
+}
+
+func (u *userRepository) ResetMFAFailures(user *model.User) error {
+	err := u.db.Model(&model.User{}).Where("id = ?", user.ID).
+		Updates(map[string]interface{}{"mfa_failures": 0, "mfa_failures_since": nil}).Error
+	return dbError(err, "user", user.ID)
+}
+
+// UseTOTPStep 只有比最后一次使用的周期更新时才记录，同一个一次性密码并发使用时只有一次成功
+func (u *userRepository) UseTOTPStep(user *model.User, step int64) error {
+	result := u.db.Model(&model.User{}).Where("id = ? AND mfa_last_step < ?", user.ID, step).Update("mfa_last_step", step)
+	if result.Error != nil {
+		return dbError(result.Error, "user", user.ID)
+	}
+	if result.RowsAffected == 0 {
+		return apierrors.NewFieldInvalid("code", "mfa code already used")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"chitchat4.0/pkg/apierrors"
+	"gorm.io/gorm"
+)
+
+const resourceVersionColumn = "resource_version" // 版本号列名
+
+// updateWithVersion 使用版本号做 compare-and-swap 修改，只保存 fields 中的字段（零值也会保存）。
+// version 指向 value 的版本号，为 0 时使用数据库中当前的版本号；修改成功后版本号加 1，
+// 版本号不匹配时返回 PreconditionFailed
+func updateWithVersion(db *gorm.DB, value interface{}, id uint, version *uint64, fields []string, resource string) error {
+	expected := *version
+	if expected == 0 {
+		if err := db.Model(value).Where("id = ?", id).Select(resourceVersionColumn).Scan(&expected).Error; err != nil {
+			return err
+		}
+	}
+
+	*version = expected + 1
+	columns := append(append(make([]string, 0, len(fields)+1), fields...), resourceVersionColumn)
+	result := db.Model(value).Where("id = ? AND resource_version = ?", id, expected).Select(columns).Updates(value)
+	if result.Error != nil {
+		*version = expected
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		*version = expected
+		return versionMismatch(db, value, id, resource)
+	}
+	return nil
+}
+
+// deleteWithVersion 删除时比较版本号，version 为 0 时不比较
+func deleteWithVersion(db *gorm.DB, value interface{}, id uint, version uint64, resource string) error {
+	if version != 0 {
+		db = db.Where("resource_version = ?", version)
+	}
+	result := db.Delete(value, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return versionMismatch(db.Session(&gorm.Session{NewDB: true}), value, id, resource)
+	}
+	return nil
+}
+
+// versionMismatch 没有修改任何记录时，区分记录不存在和版本号不匹配
+func versionMismatch(db *gorm.DB, value interface{}, id uint, resource string) error {
+	var count int64
+	if err := db.Model(value).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return apierrors.NewNotFound(resource, id)
+	}
+	return apierrors.NewPreconditionFailed(resource, id)
+}
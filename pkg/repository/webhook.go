@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"time"
+
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"gorm.io/gorm"
+)
+
+// webhookRepository webhook 和投递记录仓库，投递记录同时是持久化的投递队列
+type webhookRepository struct {
+	db  *gorm.DB
+	rdb *database.RedisDB
+}
+
+// newWebhookRepository 返回一个 webhook 仓库
+func newWebhookRepository(db *gorm.DB, rdb *database.RedisDB) WebhookRepository {
+	return &webhookRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// List 获取全部 webhook
+func (w *webhookRepository) List() ([]model.Webhook, error) {
+	webhooks := make([]model.Webhook, 0)
+	if err := w.db.Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// ListEnabled 获取全部启用的 webhook
+func (w *webhookRepository) ListEnabled() ([]model.Webhook, error) {
+	webhooks := make([]model.Webhook, 0)
+	if err := w.db.Where("enabled = ?", true).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// GetWebhookByID 通过id获取 webhook
+func (w *webhookRepository) GetWebhookByID(id uint) (*model.Webhook, error) {
+	webhook := new(model.Webhook)
+	if err := w.db.First(webhook, id).Error; err != nil {
+		return nil, dbError(err, "webhook", id)
+	}
+	return webhook, nil
+}
+
+// Create 创建 webhook
+func (w *webhookRepository) Create(webhook *model.Webhook) (*model.Webhook, error) {
+	if err := w.db.Create(webhook).Error; err != nil {
+		return nil, dbError(err, "webhook", webhook.Name)
+	}
+	return webhook, nil
+}
+
+// Update 修改 webhook 的全部字段
+func (w *webhookRepository) Update(webhook *model.Webhook) (*model.Webhook, error) {
+	if err := w.db.Save(webhook).Error; err != nil {
+		return nil, dbError(err, "webhook", webhook.ID)
+	}
+	return webhook, nil
+}
+
+// Delete 删除 webhook 和它的投递记录，未投递的事件不再投递
+func (w *webhookRepository) Delete(id uint) error {
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Webhook{}, id).Error
+	})
+	return dbError(err, "webhook", id)
+}
+
+// CreateDeliveries 把投递记录加入投递队列
+func (w *webhookRepository) CreateDeliveries(deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return w.db.Create(&deliveries).Error
+}
+
+// ListDeliveries 获取 webhook 最近的 limit 条投递记录
+func (w *webhookRepository) ListDeliveries(webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := make([]model.WebhookDelivery, 0)
+	if err := w.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// GetDelivery 获取 webhook 的投递记录
+func (w *webhookRepository) GetDelivery(webhookID, id uint) (*model.WebhookDelivery, error) {
+	delivery := new(model.WebhookDelivery)
+	if err := w.db.Where("webhook_id = ?", webhookID).First(delivery, id).Error; err != nil {
+		return nil, dbError(err, "webhook delivery", id)
+	}
+	return delivery, nil
+}
+
+// ListDueDeliveries 获取到了投递时间的 limit 条待投递记录，包含 webhook
+func (w *webhookRepository) ListDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := make([]model.WebhookDelivery, 0)
+	err := w.db.Preload(model.WebhookAssociation).
+		Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, now).
+		Order("next_attempt_at").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDelivery 领取投递记录，把下次投递时间推迟到 lease，投递中途进程退出时到期后会再次投递。
+// 依靠 NextAttemptAt 做乐观锁，多个副本同时领取时只有一个成功
+func (w *webhookRepository) ClaimDelivery(delivery *model.WebhookDelivery, lease time.Time) (bool, error) {
+	result := w.db.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, model.DeliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		delivery.NextAttemptAt = lease
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateDelivery 保存投递结果
+func (w *webhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return w.db.Omit(model.WebhookAssociation).Save(delivery).Error
+}
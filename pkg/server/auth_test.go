@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"chitchat4.0/pkg/model"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	ts := newTestServer(t)
+
+	user := new(model.User)
+	ts.anonymous().do(http.MethodPost, "/api/v1/auth/user", model.CreatedUser{
+		Name:     "alice",
+		Password: testPassword,
+		Email:    "alice@example.com",
+	}).expect(http.StatusOK).decode(user)
+	if user.ID == 0 || user.Name != "alice" {
+		t.Fatalf("registered user = %+v", user)
+	}
+	if user.Password == testPassword {
+		t.Fatal("password is returned in plain text")
+	}
+
+	token := ts.token("alice", testPassword)
+	alice := &client{ts: ts, user: user, token: token}
+	got := new(model.User)
+	alice.do(http.MethodGet, "/api/v1/users/"+alice.id(), nil).expect(http.StatusOK).decode(got)
+	if got.Name != "alice" {
+		t.Fatalf("get self = %+v", got)
+	}
+}
+
+func TestRegisterInvalid(t *testing.T) {
+	ts := newTestServer(t)
+	tests := map[string]model.CreatedUser{
+		"empty name":     {Password: testPassword},
+		"empty password": {Name: "bob"},
+		"invalid email":  {Name: "bob", Password: testPassword, Email: "bob"},
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp := ts.anonymous().do(http.MethodPost, "/api/v1/auth/user", body)
+			resp.expect(http.StatusBadRequest)
+		})
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	ts := newTestServer(t)
+	ts.login("alice")
+
+	ts.anonymous().do(http.MethodPost, "/api/v1/auth/token", model.AuthUser{Name: "alice", Password: "wrong-Passw0rd"}).
+		expect(http.StatusUnauthorized)
+	ts.anonymous().do(http.MethodPost, "/api/v1/auth/token", model.AuthUser{Name: "nobody", Password: testPassword}).
+		expect(http.StatusUnauthorized)
+}
+
+func TestInvalidToken(t *testing.T) {
+	ts := newTestServer(t)
+	// 无法解析的 token 按匿名请求处理，需要登录的接口返回 401
+	c := &client{ts: ts, token: "not-a-token"}
+	c.do(http.MethodGet, "/api/v1/rbac/plan", nil).expect(http.StatusUnauthorized)
+}
+
+func TestLogout(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+
+	alice.do(http.MethodGet, "/api/v1/users/"+alice.id()+"/sessions", nil).expect(http.StatusOK)
+	alice.do(http.MethodDelete, "/api/v1/auth/token", nil).expect(http.StatusOK)
+	// 退出后会话被撤销，原来的 token 不能再使用
+	alice.do(http.MethodGet, "/api/v1/users/"+alice.id()+"/sessions", nil).expect(http.StatusUnauthorized)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"chitchat4.0/pkg/model"
+)
+
+// TestAuthorizationDenied 没有权限的请求被拒绝，管理员的同样请求被允许
+func TestAuthorizationDenied(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	bob := ts.login("bob")
+	admin := ts.login("admin", "cluster-admin")
+	anonymous := ts.anonymous()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		caller *client
+		want   int
+	}{
+		{"tokens of another user", http.MethodGet, "/api/v1/users/" + bob.id() + "/tokens", alice, http.StatusForbidden},
+		{"create token for another user", http.MethodPost, "/api/v1/users/" + bob.id() + "/tokens", alice, http.StatusForbidden},
+		{"sessions of another user", http.MethodGet, "/api/v1/users/" + bob.id() + "/sessions", alice, http.StatusForbidden},
+		{"revoke sessions of another user", http.MethodDelete, "/api/v1/users/" + bob.id() + "/sessions", alice, http.StatusForbidden},
+		{"anonymous sessions", http.MethodGet, "/api/v1/users/" + bob.id() + "/sessions", anonymous, http.StatusForbidden},
+		{"export by user", http.MethodGet, "/api/v1/export", alice, http.StatusForbidden},
+		{"export roles by user", http.MethodGet, "/api/v1/export/roles", alice, http.StatusForbidden},
+		{"export by anonymous", http.MethodGet, "/api/v1/export", anonymous, http.StatusUnauthorized},
+		{"rbac plan by user", http.MethodGet, "/api/v1/rbac/plan", alice, http.StatusForbidden},
+		{"rbac reload by user", http.MethodPost, "/api/v1/rbac/reload", alice, http.StatusForbidden},
+		{"rbac reload by anonymous", http.MethodPost, "/api/v1/rbac/reload", anonymous, http.StatusUnauthorized},
+
+		{"own tokens", http.MethodGet, "/api/v1/users/" + alice.id() + "/tokens", alice, http.StatusOK},
+		{"own sessions", http.MethodGet, "/api/v1/users/" + alice.id() + "/sessions", alice, http.StatusOK},
+		{"sessions by admin", http.MethodGet, "/api/v1/users/" + bob.id() + "/sessions", admin, http.StatusOK},
+		{"export by admin", http.MethodGet, "/api/v1/export", admin, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.caller.do(tt.method, tt.path, nil).expect(tt.want)
+		})
+	}
+}
+
+// TestAuthorizationRevokedRole 删除角色后立即失去它的权限
+func TestAuthorizationRevokedRole(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.login("admin", "cluster-admin")
+	bob := ts.login("bob")
+
+	admin.do(http.MethodGet, "/api/v1/users/"+bob.id()+"/tokens", nil).expect(http.StatusOK)
+	if err := ts.server.repository.User().DelRole(ts.role("cluster-admin"), admin.user); err != nil {
+		t.Fatal(err)
+	}
+	admin.do(http.MethodGet, "/api/v1/users/"+bob.id()+"/tokens", nil).expect(http.StatusForbidden)
+}
+
+// TestAccessTokenRevokedRole 访问 token 绑定的角色被 user 失去后，token 也失去它的权限
+func TestAccessTokenRevokedRole(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.login("admin", "cluster-admin")
+	bob := ts.login("bob")
+
+	issued := new(model.IssuedAccessToken)
+	admin.do(http.MethodPost, "/api/v1/users/"+admin.id()+"/tokens", model.CreatedAccessToken{
+		Name:    "ci",
+		RoleIds: []uint{ts.role("cluster-admin").ID},
+	}).expect(http.StatusOK).decode(issued)
+	ci := &client{ts: ts, user: admin.user, token: issued.Token}
+
+	ci.do(http.MethodGet, "/api/v1/users/"+bob.id()+"/tokens", nil).expect(http.StatusOK)
+	if err := ts.server.repository.User().DelRole(ts.role("cluster-admin"), admin.user); err != nil {
+		t.Fatal(err)
+	}
+	ci.do(http.MethodGet, "/api/v1/users/"+bob.id()+"/tokens", nil).expect(http.StatusForbidden)
+}
+
+// TestAccessTokenOtherUser 只有管理员可以管理其他 user 的 token，而且只能绑定自己也拥有的角色
+func TestAccessTokenOtherUser(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	admin := ts.login("admin", "cluster-admin")
+	bot := ts.login("bot", "editor")
+	clusterAdmin := model.CreatedAccessToken{Name: "escalate", RoleIds: []uint{ts.role("cluster-admin").ID}}
+	editor := model.CreatedAccessToken{Name: "ci", RoleIds: []uint{ts.role("editor").ID}}
+
+	alice.do(http.MethodPost, "/api/v1/users/"+admin.id()+"/tokens", clusterAdmin).expect(http.StatusForbidden)
+	// root 组的成员不是管理员，也不能管理其他 user 的 token
+	ts.joinRoot(alice)
+	alice.do(http.MethodPost, "/api/v1/users/"+admin.id()+"/tokens", clusterAdmin).expect(http.StatusForbidden)
+	alice.do(http.MethodGet, "/api/v1/users/"+admin.id()+"/tokens", nil).expect(http.StatusForbidden)
+
+	// 管理员没有 editor 角色，不能绑定到 bot 的 token 上
+	admin.do(http.MethodPost, "/api/v1/users/"+bot.id()+"/tokens", editor).expect(http.StatusForbidden)
+	if err := ts.server.repository.User().AddRole(ts.role("editor"), admin.user); err != nil {
+		t.Fatal(err)
+	}
+	admin.do(http.MethodPost, "/api/v1/users/"+bot.id()+"/tokens", editor).expect(http.StatusOK)
+}
+
+// TestSessionOtherUser root 组的成员不是管理员，不能查看或撤销其他 user 的会话
+func TestSessionOtherUser(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	admin := ts.login("admin", "cluster-admin")
+	ts.joinRoot(alice)
+
+	alice.do(http.MethodGet, "/api/v1/users/"+admin.id()+"/sessions", nil).expect(http.StatusForbidden)
+	alice.do(http.MethodDelete, "/api/v1/users/"+admin.id()+"/sessions", nil).expect(http.StatusForbidden)
+	admin.do(http.MethodGet, "/api/v1/users/"+admin.id()+"/sessions", nil).expect(http.StatusOK)
+
+	admin.do(http.MethodDelete, "/api/v1/users/"+alice.id()+"/sessions", nil).expect(http.StatusOK)
+	alice.do(http.MethodGet, "/api/v1/users/"+alice.id()+"/sessions", nil).expect(http.StatusUnauthorized)
+}
+
+// joinRoot 把 c 的 user 加入启动时创建的 root 组
+func (ts *testServer) joinRoot(c *client) {
+	ts.t.Helper()
+	groups := ts.server.repository.Group()
+	root, err := groups.GetGroupByName(model.RootGroup)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	if err := groups.AddUser(c.user, root); err != nil {
+		ts.t.Fatal(err)
+	}
+}
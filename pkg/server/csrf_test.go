@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/model"
+)
+
+// cookieLogin 使用 cookie 登录，返回 token 和 csrfToken cookie
+func cookieLogin(ts *testServer, name string) (token, csrf string) {
+	ts.t.Helper()
+	resp := ts.anonymous().do(http.MethodPost, "/api/v1/auth/token", model.AuthUser{Name: name, Password: testPassword, SetCookie: true}).
+		expect(http.StatusOK)
+	for _, cookie := range resp.recorder.Result().Cookies() {
+		switch cookie.Name {
+		case common.CookieTokenName:
+			token = cookie.Value
+		case common.CookieCSRFToken:
+			csrf = cookie.Value
+		}
+	}
+	if token == "" || csrf == "" {
+		ts.t.Fatalf("cookie login %s: token %q, csrf token %q", name, token, csrf)
+	}
+	return token, csrf
+}
+
+func cookies(token, csrf string) string {
+	return common.CookieTokenName + "=" + token + "; " + common.CookieCSRFToken + "=" + csrf
+}
+
+func TestCSRF(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	token, csrf := cookieLogin(ts, "alice")
+	_, otherCSRF := cookieLogin(ts, "alice")
+	anonymous := ts.anonymous()
+	logout := "/api/v1/auth/token"
+
+	// 修改请求没有 CSRF token
+	anonymous.do(http.MethodDelete, logout, nil, "Cookie", cookies(token, csrf)).expect(http.StatusForbidden)
+	// 请求头和 cookie 中的 token 不同
+	anonymous.do(http.MethodDelete, logout, nil, "Cookie", cookies(token, csrf), common.HeaderCSRFToken, otherCSRF).
+		expect(http.StatusForbidden)
+	// 其他会话签发的 token，即使请求头和 cookie 相同也不能使用
+	anonymous.do(http.MethodDelete, logout, nil, "Cookie", cookies(token, otherCSRF), common.HeaderCSRFToken, otherCSRF).
+		expect(http.StatusForbidden)
+
+	// GET 请求不检查 CSRF token
+	anonymous.do(http.MethodGet, "/api/v1/users/"+alice.id()+"/sessions", nil, "Cookie", cookies(token, "")).
+		expect(http.StatusOK)
+	// Authorization 请求头中的 token 不检查 CSRF token
+	alice.do(http.MethodPost, "/api/v1/auth/token", model.AuthUser{Name: "alice", Password: testPassword}).
+		expect(http.StatusOK)
+
+	anonymous.do(http.MethodDelete, logout, nil, "Cookie", cookies(token, csrf), common.HeaderCSRFToken, csrf).
+		expect(http.StatusOK)
+	anonymous.do(http.MethodGet, "/api/v1/users/"+alice.id()+"/sessions", nil, "Cookie", cookies(token, csrf)).
+		expect(http.StatusUnauthorized)
+}
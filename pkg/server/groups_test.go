@@ -0,0 +1,247 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"chitchat4.0/pkg/model"
+)
+
+// createGroup 创建 group 并返回它的 id
+func (c *client) createGroup(name string) string {
+	c.ts.t.Helper()
+	group := new(model.Group)
+	c.do(http.MethodPost, "/api/v1/groups", model.CreatedGroup{Name: name, Describe: name + " group"}).
+		expect(http.StatusOK).
+		decode(group)
+	if group.ID == 0 {
+		c.ts.t.Fatalf("create group %s: empty id", name)
+	}
+	return strconv.Itoa(int(group.ID))
+}
+
+func TestGroupCreate(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	id := alice.createGroup("dev")
+
+	group := new(model.Group)
+	resp := alice.do(http.MethodGet, "/api/v1/groups/"+id, nil).expect(http.StatusOK).decode(group)
+	if group.Name != "dev" || group.CreatorId != alice.user.ID {
+		t.Fatalf("group = %+v", group)
+	}
+	if resp.header("ETag") == "" {
+		t.Fatal("missing ETag")
+	}
+	// 新建的 group 绑定了默认的 namespace 角色
+	if len(group.Roles) == 0 {
+		t.Fatal("group has no default roles")
+	}
+	for _, role := range group.Roles {
+		if role.Scope != model.NamespaceScope || role.Namespace != "dev" {
+			t.Fatalf("default role = %+v", role)
+		}
+	}
+
+	groups := make([]model.Group, 0)
+	alice.do(http.MethodGet, "/api/v1/groups", nil).expect(http.StatusOK).decode(&groups)
+	found := false
+	for _, g := range groups {
+		found = found || g.Name == "dev"
+	}
+	if !found {
+		t.Fatalf("groups = %+v, want dev", groups)
+	}
+
+	// 名字重复
+	alice.do(http.MethodPost, "/api/v1/groups", model.CreatedGroup{Name: "dev"}).expect(http.StatusConflict)
+}
+
+// TestGroupUpdateAnonymous 没有登录时不能修改 group
+func TestGroupUpdateAnonymous(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	id := alice.createGroup("dev")
+
+	patch := map[string]string{"describe": "patched"}
+	ts.anonymous().do(http.MethodPatch, "/api/v1/groups/"+id, patch).expect(http.StatusUnauthorized)
+	ts.anonymous().do(http.MethodPut, "/api/v1/groups/"+id, model.UpdatedGroup{Name: "dev", Describe: "updated"}).
+		expect(http.StatusUnauthorized)
+
+	group := new(model.Group)
+	alice.do(http.MethodPatch, "/api/v1/groups/"+id, patch).expect(http.StatusOK).decode(group)
+	if group.Describe != "patched" {
+		t.Fatalf("patched group = %+v", group)
+	}
+}
+
+// TestGroupUpdateForbidden 只有创建者和管理员可以修改 group
+func TestGroupUpdateForbidden(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	bob := ts.login("bob")
+	admin := ts.login("admin", "cluster-admin")
+	id := alice.createGroup("dev")
+
+	patch := map[string]string{"describe": "patched"}
+	bob.do(http.MethodPatch, "/api/v1/groups/"+id, patch).expect(http.StatusForbidden)
+	bob.do(http.MethodPut, "/api/v1/groups/"+id, model.UpdatedGroup{Name: "dev", Describe: "updated"}).
+		expect(http.StatusForbidden)
+
+	admin.do(http.MethodPatch, "/api/v1/groups/"+id, patch).expect(http.StatusOK)
+	admin.do(http.MethodPut, "/api/v1/groups/"+id, model.UpdatedGroup{Name: "dev", Describe: "updated"}).
+		expect(http.StatusOK)
+}
+
+func TestGroupUsers(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	bob := ts.login("bob")
+	id := alice.createGroup("dev")
+
+	usersOf := func() map[string]bool {
+		users := make([]model.User, 0)
+		alice.do(http.MethodGet, "/api/v1/groups/"+id+"/users", nil).expect(http.StatusOK).decode(&users)
+		names := make(map[string]bool, len(users))
+		for _, u := range users {
+			names[u.Name] = true
+		}
+		return names
+	}
+	// 创建者是 group 的成员
+	if names := usersOf(); len(names) != 1 || !names["alice"] {
+		t.Fatalf("users = %v, want alice", names)
+	}
+
+	alice.do(http.MethodPost, "/api/v1/groups/"+id+"/users", bob.user).expect(http.StatusOK)
+	if names := usersOf(); !names["bob"] {
+		t.Fatalf("users = %v, want bob", names)
+	}
+	groups := make([]model.Group, 0)
+	bob.do(http.MethodGet, "/api/v1/users/"+bob.id()+"/groups", nil).expect(http.StatusOK).decode(&groups)
+	if len(groups) != 1 || groups[0].Name != "dev" {
+		t.Fatalf("groups of bob = %+v", groups)
+	}
+
+	alice.do(http.MethodDelete, "/api/v1/groups/"+id+"/users?uid="+bob.id(), nil).expect(http.StatusOK)
+	if names := usersOf(); names["bob"] {
+		t.Fatalf("users = %v, bob is not removed", names)
+	}
+}
+
+// TestGroupUsersForbidden 只有创建者和管理员可以修改 group 的成员
+func TestGroupUsersForbidden(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	bob := ts.login("bob")
+	carol := ts.login("carol")
+	admin := ts.login("admin", "cluster-admin")
+	id := alice.createGroup("dev")
+
+	// 其他 user 不能把自己或别人加入 group，也不能移除成员
+	bob.do(http.MethodPost, "/api/v1/groups/"+id+"/users", bob.user).expect(http.StatusForbidden)
+	bob.do(http.MethodPost, "/api/v1/groups/"+id+"/users", carol.user).expect(http.StatusForbidden)
+	bob.do(http.MethodDelete, "/api/v1/groups/"+id+"/users?uid="+alice.id(), nil).expect(http.StatusForbidden)
+
+	admin.do(http.MethodPost, "/api/v1/groups/"+id+"/users", bob.user).expect(http.StatusOK)
+	// 成员也不能修改其他成员
+	bob.do(http.MethodDelete, "/api/v1/groups/"+id+"/users?uid="+alice.id(), nil).expect(http.StatusForbidden)
+	admin.do(http.MethodDelete, "/api/v1/groups/"+id+"/users?uid="+bob.id(), nil).expect(http.StatusOK)
+}
+
+func TestGroupRoles(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	bob := ts.login("bob")
+	admin := ts.login("admin", "cluster-admin")
+	id := alice.createGroup("ops")
+	alice.do(http.MethodPost, "/api/v1/groups/"+id+"/users", alice.user).expect(http.StatusOK)
+
+	// 普通用户不能给自己的 group 授予角色
+	rid := strconv.Itoa(int(ts.role("cluster-admin").ID))
+	alice.do(http.MethodPost, "/api/v1/groups/"+id+"/roles/"+rid, nil).expect(http.StatusForbidden)
+
+	// group 的 cluster-admin 角色对它的成员生效
+	alice.do(http.MethodPut, "/api/v1/users/"+bob.id(), model.UpdatedUser{Name: "bob"}).expect(http.StatusForbidden)
+	admin.do(http.MethodPost, "/api/v1/groups/"+id+"/roles/"+rid, nil).expect(http.StatusOK)
+	alice.do(http.MethodPut, "/api/v1/users/"+bob.id(), model.UpdatedUser{Name: "bob"}).expect(http.StatusOK)
+
+	admin.do(http.MethodDelete, "/api/v1/groups/"+id+"/roles/"+rid, nil).expect(http.StatusOK)
+	alice.do(http.MethodPut, "/api/v1/users/"+bob.id(), model.UpdatedUser{Name: "bob"}).expect(http.StatusForbidden)
+}
+
+func TestGroupDelete(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	bob := ts.login("bob")
+	id := alice.createGroup("dev")
+
+	// 其他 user 不能删除 group
+	bob.do(http.MethodDelete, "/api/v1/groups/"+id, nil).expect(http.StatusForbidden)
+	alice.do(http.MethodDelete, "/api/v1/groups/"+id, nil, "If-Match", `"99"`).expect(http.StatusPreconditionFailed)
+	alice.do(http.MethodDelete, "/api/v1/groups/"+id, nil).expect(http.StatusOK)
+	alice.do(http.MethodGet, "/api/v1/groups/"+id, nil).expect(http.StatusNotFound)
+}
+
+// TestGroupSystem 普通用户不能创建或改名成系统 group，也不能把自己加入 root 组获得管理员权限
+func TestGroupSystem(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	bob := ts.login("bob")
+	admin := ts.login("admin", "cluster-admin")
+
+	for _, name := range []string{model.RootGroup, model.AuthenticatedGroup} {
+		alice.do(http.MethodPost, "/api/v1/groups", model.CreatedGroup{Name: name}).expect(http.StatusBadRequest)
+	}
+	id := alice.createGroup("dev")
+	alice.do(http.MethodPut, "/api/v1/groups/"+id, model.UpdatedGroup{Name: model.RootGroup}).expect(http.StatusBadRequest)
+	alice.do(http.MethodPatch, "/api/v1/groups/"+id, map[string]string{"name": model.RootGroup}).expect(http.StatusBadRequest)
+
+	root, err := ts.server.repository.Group().GetGroupByName(model.RootGroup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootID := strconv.Itoa(int(root.ID))
+	required := model.MFARequirement{Required: true}
+
+	alice.do(http.MethodPost, "/api/v1/groups/"+rootID+"/users", alice.user).expect(http.StatusForbidden)
+	alice.do(http.MethodPut, "/api/v1/users/"+bob.id()+"/mfa/required", required).expect(http.StatusForbidden)
+
+	// 管理员加入 root 组的 user 可以要求其他 user 开启二次验证
+	admin.do(http.MethodPost, "/api/v1/groups/"+rootID+"/users", alice.user).expect(http.StatusOK)
+	alice.do(http.MethodPut, "/api/v1/users/"+bob.id()+"/mfa/required", required).expect(http.StatusOK)
+	alice.do(http.MethodDelete, "/api/v1/groups/"+rootID+"/users?uid="+alice.id(), nil).expect(http.StatusForbidden)
+}
+
+// TestGroupSeed 启动时创建系统 group，重复初始化不会报错
+func TestGroupSeed(t *testing.T) {
+	ts := newTestServer(t)
+	for _, name := range []string{model.RootGroup, model.AuthenticatedGroup, model.UnAuthenticatedGroup} {
+		group, err := ts.server.repository.Group().GetGroupByName(name)
+		if err != nil {
+			t.Fatalf("group %s: %v", name, err)
+		}
+		if group.Kind != model.SystemGroup {
+			t.Fatalf("group %s kind = %q", name, group.Kind)
+		}
+	}
+
+	resources, err := ts.server.repository.RBAC().ListResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		names[r.Name] = true
+	}
+	for _, name := range []string{model.RoomResource, model.MessageResource, model.GroupResource} {
+		if !names[name] {
+			t.Fatalf("resources = %v, want %s", names, name)
+		}
+	}
+
+	if err := ts.server.repository.Init(); err != nil {
+		t.Fatal(err)
+	}
+}
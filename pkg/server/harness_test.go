@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"chitchat4.0/pkg/common"
+	"chitchat4.0/pkg/config"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// testPassword 测试用户统一使用的密码
+const testPassword = "Passw0rd!x"
+
+// testServer 使用内存中的 SQLite 和 miniredis 启动的完整服务，请求直接交给 http.Handler 处理，不监听端口
+type testServer struct {
+	t       *testing.T
+	server  *Server
+	handler http.Handler
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, err := strconv.Atoi(mr.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &config.Config{
+		Server: config.ServerConfig{
+			ENV:       "test",
+			JWTSecret: "chitchat-test-secret",
+		},
+		DB: config.DBConfig{
+			Driver:  config.DriverSQLite,
+			Name:    ":memory:",
+			Migrate: true,
+		},
+		Redis: config.RedisConfig{
+			Enable: true,
+			Host:   mr.Host(),
+			Port:   port,
+		},
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	s, err := New(conf, logger)
+	if errors.Is(err, database.ErrSQLiteUnavailable) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := s.Close(); err != nil {
+			t.Errorf("close server: %v", err)
+		}
+	})
+	return &testServer{t: t, server: s, handler: s.Handler()}
+}
+
+// anonymous 返回没有登录的客户端
+func (ts *testServer) anonymous() *client {
+	return &client{ts: ts}
+}
+
+// login 注册名为 name 的用户，给它添加 roles 中的角色后登录。
+// 角色不存在时创建一个 cluster 范围、拥有全部权限的同名角色
+func (ts *testServer) login(name string, roles ...string) *client {
+	ts.t.Helper()
+	anonymous := ts.anonymous()
+	resp := anonymous.do(http.MethodPost, "/api/v1/auth/user", model.CreatedUser{
+		Name:     name,
+		Password: testPassword,
+		Email:    name + "@example.com",
+	})
+	resp.expect(http.StatusOK)
+
+	user, err := ts.server.repository.User().GetUserByName(name)
+	if err != nil {
+		ts.t.Fatalf("get user %s: %v", name, err)
+	}
+	for _, name := range roles {
+		role := ts.role(name)
+		if err := ts.server.repository.User().AddRole(role, user); err != nil {
+			ts.t.Fatalf("add role %s: %v", name, err)
+		}
+	}
+
+	c := &client{ts: ts, user: user}
+	c.token = ts.token(name, testPassword)
+	return c
+}
+
+// token 登录并返回 JWT
+func (ts *testServer) token(name, password string) string {
+	ts.t.Helper()
+	token := new(model.JWTToken)
+	ts.anonymous().do(http.MethodPost, "/api/v1/auth/token", model.AuthUser{Name: name, Password: password}).
+		expect(http.StatusOK).
+		decode(token)
+	if token.Token == "" {
+		ts.t.Fatalf("login %s: empty token", name)
+	}
+	return token.Token
+}
+
+func (ts *testServer) role(name string) *model.Role {
+	ts.t.Helper()
+	rbac := ts.server.repository.RBAC()
+	if role, err := rbac.GetRoleByName(name); err == nil {
+		return role
+	}
+	role, err := rbac.Create(&model.Role{
+		Name:  name,
+		Scope: model.ClusterScope,
+		Rules: []model.Rule{{Resource: model.All, Operation: model.All}},
+	})
+	if err != nil {
+		ts.t.Fatalf("create role %s: %v", name, err)
+	}
+	return role
+}
+
+// client 调用接口的客户端，token 不为空时使用 Bearer 认证
+type client struct {
+	ts    *testServer
+	user  *model.User
+	token string
+}
+
+// id 返回登录用户的 id
+func (c *client) id() string {
+	return strconv.Itoa(int(c.user.ID))
+}
+
+// do 发送请求，body 不为 nil 时编码为 JSON；headers 为请求头的键值对
+func (c *client) do(method, path string, body interface{}, headers ...string) *response {
+	c.ts.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.ts.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	c.ts.handler.ServeHTTP(w, req)
+	resp := &response{t: c.ts.t, method: method, path: path, recorder: w}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp.body); err != nil {
+		c.ts.t.Fatalf("%s %s: decode response %q: %v", method, path, w.Body.String(), err)
+	}
+	return resp
+}
+
+// response 接口的响应，body.Data 保留原始的 JSON
+type response struct {
+	t        *testing.T
+	method   string
+	path     string
+	recorder *httptest.ResponseRecorder
+	body     struct {
+		common.Response
+		Data json.RawMessage `json:"data"`
+	}
+}
+
+// expect 状态码不是 code 时终止测试
+func (r *response) expect(code int) *response {
+	r.t.Helper()
+	if r.recorder.Code != code {
+		r.t.Fatalf("%s %s: status %d, want %d: %s", r.method, r.path, r.recorder.Code, code, r.recorder.Body.String())
+	}
+	return r
+}
+
+// decode 把 data 解码到 v
+func (r *response) decode(v interface{}) *response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.body.Data, v); err != nil {
+		r.t.Fatalf("%s %s: decode data %s: %v", r.method, r.path, r.body.Data, err)
+	}
+	return r
+}
+
+func (r *response) header(key string) string {
+	return r.recorder.Header().Get(key)
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"chitchat4.0/pkg/model"
+	"github.com/gorilla/websocket"
+)
+
+// TestHotSearchSubscribe 订阅热搜榜不需要登录，但跨域的连接会被拒绝
+func TestHotSearchSubscribe(t *testing.T) {
+	ts := newTestServer(t)
+	srv := httptest.NewServer(ts.handler)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/hotsearches/ws?tags=1"
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("anonymous subscribe: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("anonymous subscribe: status %d", resp.StatusCode)
+	}
+	conn.Close()
+
+	conn, resp, err = websocket.DefaultDialer.Dial(url, http.Header{"Origin": {srv.URL}})
+	if err != nil {
+		t.Fatalf("same origin subscribe: %v", err)
+	}
+	conn.Close()
+
+	_, resp, err = websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.com"}})
+	if err == nil {
+		t.Fatal("cross origin subscribe succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("cross origin subscribe: resp = %v, want 403", resp)
+	}
+}
+
+// TestHotSearchFeedETag 订阅源的 ETag 区分格式和访问地址，X-Forwarded-Proto 不会改变订阅源中的地址
+func TestHotSearchFeedETag(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.login("admin", "cluster-admin")
+	tag, err := ts.server.repository.Tag().Create(admin.user, &model.Tag{Name: "weibo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/v1/tags/" + strconv.Itoa(int(tag.ID)) + "/feed."
+
+	get := func(format, host string, headers ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+path+format, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		ts.handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK && w.Code != http.StatusNotModified {
+			t.Fatalf("GET %s from %s: status %d: %s", format, host, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	rss := get("rss", "a.example.com")
+	etag := rss.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("feed has no ETag")
+	}
+	if w := get("rss", "a.example.com", "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("same feed: status %d, want 304", w.Code)
+	}
+	if w := get("atom", "a.example.com", "If-None-Match", etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Fatalf("other format: status %d, ETag %s", w.Code, w.Header().Get("ETag"))
+	}
+	if w := get("rss", "b.example.com", "If-None-Match", etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Fatalf("other host: status %d, ETag %s", w.Code, w.Header().Get("ETag"))
+	}
+
+	w := get("rss", "a.example.com", "X-Forwarded-Proto", "https")
+	if w.Header().Get("ETag") != etag || !strings.Contains(w.Body.String(), "http://a.example.com/") {
+		t.Fatalf("X-Forwarded-Proto changed the feed: ETag %s, body %s", w.Header().Get("ETag"), w.Body.String())
+	}
+}
+
+// TestHotSearchSnapshotForbidden 只有拥有 hotsearch-collector 角色的 user 和管理员可以提交快照，服务账号本身没有权限
+func TestHotSearchSnapshotForbidden(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.login("admin", "cluster-admin", "viewer")
+	collector := ts.login("collector", model.HotSearchCollectorRole)
+	tag, err := ts.server.repository.Tag().Create(admin.user, &model.Tag{Name: "weibo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/v1/tags/" + strconv.Itoa(int(tag.ID)) + "/hotsearches"
+	snapshot := model.HotSearchSnapshot{Items: []model.CreatedHotSearch{{Title: "hot", Link: "https://example.com/hot"}}}
+
+	// 服务账号使用只绑定了 viewer 角色的访问 token
+	bot, err := ts.server.repository.User().Create(&model.User{Name: "bot", Email: "bot@example.com", ServiceAccount: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.server.repository.User().AddRole(ts.role("viewer"), bot); err != nil {
+		t.Fatal(err)
+	}
+	issued := new(model.IssuedAccessToken)
+	admin.do(http.MethodPost, "/api/v1/users/"+strconv.Itoa(int(bot.ID))+"/tokens", model.CreatedAccessToken{
+		Name:    "scraper",
+		RoleIds: []uint{ts.role("viewer").ID},
+	}).expect(http.StatusOK).decode(issued)
+	scraper := &client{ts: ts, user: bot, token: issued.Token}
+
+	scraper.do(http.MethodPut, path, snapshot).expect(http.StatusForbidden)
+	collector.do(http.MethodPut, path, snapshot).expect(http.StatusOK)
+	admin.do(http.MethodPut, path, snapshot).expect(http.StatusOK)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"chitchat4.0/pkg/authentication"
+	"chitchat4.0/pkg/model"
+)
+
+// enableMFA 绑定并开启二次验证，返回 TOTP 密钥；开启时使用了 at 所在周期的一次性密码
+func enableMFA(c *client, at time.Time) string {
+	c.ts.t.Helper()
+	enrollment := new(model.MFAEnrollment)
+	c.do(http.MethodPost, "/api/v1/users/"+c.id()+"/mfa", nil).expect(http.StatusOK).decode(enrollment)
+	c.do(http.MethodPost, "/api/v1/users/"+c.id()+"/mfa/verify", model.MFACode{Code: totp(c.ts.t, enrollment.Secret, at)}).
+		expect(http.StatusOK)
+	return enrollment.Secret
+}
+
+func totp(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := authentication.GenerateTOTP(secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// challenge 使用密码登录，返回二次验证的挑战 token
+func (ts *testServer) challenge(name string) string {
+	ts.t.Helper()
+	challenge := new(model.MFAChallenge)
+	ts.anonymous().do(http.MethodPost, "/api/v1/auth/token", model.AuthUser{Name: name, Password: testPassword}).
+		expect(http.StatusOK).
+		decode(challenge)
+	if challenge.Challenge == "" {
+		ts.t.Fatalf("login %s: no mfa challenge", name)
+	}
+	return challenge.Challenge
+}
+
+func TestMFALoginReplay(t *testing.T) {
+	ts := newTestServer(t)
+	now := time.Now()
+	secret := enableMFA(ts.login("alice"), now)
+	mfaLogin := func(code string) *response {
+		return ts.anonymous().do(http.MethodPost, "/api/v1/auth/mfa", model.MFALogin{Challenge: ts.challenge("alice"), Code: code})
+	}
+
+	// 开启时已经使用过的密码不能用于登录
+	mfaLogin(totp(t, secret, now)).expect(http.StatusBadRequest)
+
+	next := totp(t, secret, now.Add(30*time.Second))
+	token := new(model.JWTToken)
+	mfaLogin(next).expect(http.StatusOK).decode(token)
+	if token.Token == "" {
+		t.Fatal("mfa login returned empty token")
+	}
+
+	// 同一个周期和更早周期的密码都不能再使用
+	mfaLogin(next).expect(http.StatusBadRequest)
+	mfaLogin(totp(t, secret, now.Add(-30*time.Second))).expect(http.StatusBadRequest)
+}
+
+func TestMFALoginLockout(t *testing.T) {
+	ts := newTestServer(t)
+	now := time.Now()
+	secret := enableMFA(ts.login("alice"), now)
+	valid := totp(t, secret, now.Add(30*time.Second))
+	wrong := "000000"
+	if wrong == valid {
+		wrong = "111111"
+	}
+
+	challenge := ts.challenge("alice")
+	for i := 0; i < 5; i++ {
+		ts.anonymous().do(http.MethodPost, "/api/v1/auth/mfa", model.MFALogin{Challenge: challenge, Code: wrong}).
+			expect(http.StatusBadRequest)
+	}
+	// 失败次数达到上限后，正确的密码也不能使用，重新登录获取的挑战也一样
+	ts.anonymous().do(http.MethodPost, "/api/v1/auth/mfa", model.MFALogin{Challenge: challenge, Code: valid}).
+		expect(http.StatusTooManyRequests)
+	ts.anonymous().do(http.MethodPost, "/api/v1/auth/mfa", model.MFALogin{Challenge: ts.challenge("alice"), Code: valid}).
+		expect(http.StatusTooManyRequests)
+}
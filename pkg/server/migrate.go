@@ -0,0 +1,32 @@
+package server
+
+import (
+	"chitchat4.0/pkg/migration"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// migrate 启动时检查数据库的版本：比程序更新时拒绝启动；
+// 有未执行的版本时，apply 为 true 则执行，否则只记录警告，可以使用 migrate up 子命令执行
+func migrate(db *gorm.DB, apply bool, logger *logrus.Logger) error {
+	migrator, err := migration.New(db, logger)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Check(); err != nil {
+		return errors.Wrap(err, "拒绝在更新的数据库版本上启动")
+	}
+	if apply {
+		return errors.Wrap(migrator.Up(), "数据库迁移失败")
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+	for _, m := range pending {
+		logger.Warnf("数据库迁移 %04d_%s 还没有执行", m.Version, m.Name)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"reflect"
+
+	"chitchat4.0/pkg/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Reload 热加载配置中可以热加载的部分：速率限制、日志级别、跨域允许的来源和 OAuth 授权配置，
+// 并重新同步声明式 RBAC 配置文件；其他修改过的配置只记录警告，需要重启服务器才能生效。
+// 先同步 RBAC 配置文件，失败时不修改任何配置
+func (s *Server) Reload(conf *config.Config) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if s.config.RBAC.File != "" {
+		if _, err := s.rbacFileService.Reconcile(false); err != nil {
+			return errors.Wrap(err, "同步 RBAC 配置文件失败")
+		}
+	}
+	// 速率限制没有修改时保留原来的计数，否则每次热加载都会清空计数
+	if err := s.rateLimits.Update(conf.Server.LimitConfig); err != nil {
+		return errors.Wrap(err, "热加载速率限制失败")
+	}
+	setLogLevel(s.logger, conf.Server.LogLevel)
+	s.cors.Update(conf.Server.CORS)
+	s.oauthManager.Update(conf.OAuthConfig)
+
+	for _, name := range restartRequired(s.config, conf) {
+		s.logger.Warnf("配置 %s 已修改，需要重启服务器才能生效", name)
+	}
+
+	current := *s.config
+	current.Server.LimitConfig = conf.Server.LimitConfig
+	current.Server.LogLevel = conf.Server.LogLevel
+	current.Server.CORS = conf.Server.CORS
+	current.OAuthConfig = conf.OAuthConfig
+	s.config = &current
+	return nil
+}
+
+// restartRequired 返回修改过但不能热加载的配置
+func restartRequired(old, new *config.Config) []string {
+	sections := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"server.env", old.Server.ENV, new.Server.ENV},
+		{"server.address", old.Server.Address, new.Server.Address},
+		{"server.port", old.Server.Port, new.Server.Port},
+		{"server.gracefulShutdownPeriod", old.Server.GracefulShutdownPeriod, new.Server.GracefulShutdownPeriod},
+		{"server.jwtSecret", old.Server.JWTSecret, new.Server.JWTSecret},
+		{"server.cookie", old.Server.Cookie, new.Server.Cookie},
+		{"db", old.DB, new.DB},
+		{"redis", old.Redis, new.Redis},
+		{"docker", old.Docker, new.Docker},
+		{"kubernetes", old.Kubernetes, new.Kubernetes},
+		{"smtp", old.SMTP, new.SMTP},
+		{"rbac", old.RBAC, new.RBAC},
+	}
+	changed := make([]string, 0)
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.old, section.new) {
+			changed = append(changed, section.name)
+		}
+	}
+	return changed
+}
+
+// setLogLevel 设置日志级别，为空时使用 info，配置已经校验过级别
+func setLogLevel(logger *logrus.Logger, level string) {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		lvl = logrus.InfoLevel
+	}
+	logger.SetLevel(lvl)
+}
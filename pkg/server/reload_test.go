@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+
+	"chitchat4.0/pkg/service"
+	"github.com/sirupsen/logrus"
+)
+
+// TestReloadReconcileFailed 同步 RBAC 配置文件失败时不修改任何配置
+func TestReloadReconcileFailed(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.server
+	s.config.RBAC.File = t.TempDir() + "/missing.yaml"
+	s.rbacFileService = service.NewRBACFileService(s.config.RBAC.File, s.repository.RBACFile(), s.logger)
+	level := s.logger.GetLevel()
+
+	conf := *s.config
+	conf.Server.LogLevel = "trace"
+	if err := s.Reload(&conf); err == nil {
+		t.Fatal("reload succeeded with a missing rbac file")
+	}
+	if s.logger.GetLevel() != level || s.config.Server.LogLevel == "trace" {
+		t.Fatalf("log level changed to %s after a failed reload", s.logger.GetLevel())
+	}
+
+	s.config.RBAC.File = ""
+	if err := s.Reload(&conf); err != nil {
+		t.Fatal(err)
+	}
+	if s.logger.GetLevel() != logrus.TraceLevel {
+		t.Fatalf("log level = %s, want trace", s.logger.GetLevel())
+	}
+}
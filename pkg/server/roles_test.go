@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"chitchat4.0/pkg/model"
+)
+
+func TestRoleCRUD(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	admin := ts.login("admin", "cluster-admin")
+
+	auditor := model.Role{
+		Name:  "auditor",
+		Scope: model.ClusterScope,
+		Rules: []model.Rule{{Resource: model.All, Operation: model.ViewOperation}},
+	}
+	// 普通用户不能创建角色
+	alice.do(http.MethodPost, "/api/v1/roles", auditor).expect(http.StatusForbidden)
+
+	role := new(model.Role)
+	resp := admin.do(http.MethodPost, "/api/v1/roles", auditor).expect(http.StatusOK).decode(role)
+	id := strconv.Itoa(int(role.ID))
+	etag := resp.header("ETag")
+	if role.ID == 0 || etag == "" {
+		t.Fatalf("created role = %+v, ETag %q", role, etag)
+	}
+
+	got := new(model.Role)
+	alice.do(http.MethodGet, "/api/v1/roles/"+id, nil).expect(http.StatusOK).decode(got)
+	if got.Name != "auditor" || len(got.Rules) != 1 || got.Rules[0].Operation != model.ViewOperation {
+		t.Fatalf("role = %+v", got)
+	}
+
+	updated := model.Role{
+		Name:  "auditor",
+		Scope: model.ClusterScope,
+		Rules: []model.Rule{{Resource: model.All, Operation: model.EditOperation}},
+	}
+	alice.do(http.MethodPut, "/api/v1/roles/"+id, updated, "If-Match", etag).expect(http.StatusForbidden)
+	alice.do(http.MethodPatch, "/api/v1/roles/"+id, map[string]string{"namespace": "ops"}).expect(http.StatusForbidden)
+	alice.do(http.MethodDelete, "/api/v1/roles/"+id, nil, "If-Match", etag).expect(http.StatusForbidden)
+	resp = admin.do(http.MethodPut, "/api/v1/roles/"+id, updated, "If-Match", etag).expect(http.StatusOK).decode(got)
+	if got.Rules[0].Operation != model.EditOperation {
+		t.Fatalf("updated rules = %+v", got.Rules)
+	}
+	// 使用旧的版本号修改和删除时返回 412
+	admin.do(http.MethodPut, "/api/v1/roles/"+id, updated, "If-Match", etag).expect(http.StatusPreconditionFailed)
+	admin.do(http.MethodDelete, "/api/v1/roles/"+id, nil, "If-Match", etag).expect(http.StatusPreconditionFailed)
+
+	admin.do(http.MethodDelete, "/api/v1/roles/"+id, nil, "If-Match", resp.header("ETag")).expect(http.StatusOK)
+	alice.do(http.MethodGet, "/api/v1/roles/"+id, nil).expect(http.StatusNotFound)
+}
+
+func TestRoleList(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice", "viewer", "editor")
+
+	roles := make([]model.Role, 0)
+	alice.do(http.MethodGet, "/api/v1/roles", nil).expect(http.StatusOK).decode(&roles)
+	names := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		names[role.Name] = true
+	}
+	if !names["viewer"] || !names["editor"] {
+		t.Fatalf("roles = %v, want viewer and editor", names)
+	}
+}
+
+func TestRoleInvalid(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.login("admin", "cluster-admin")
+
+	tests := map[string]model.Role{
+		"empty scope":       {Name: "r1"},
+		"unknown scope":     {Name: "r1", Scope: "galaxy"},
+		"missing namespace": {Name: "r1", Scope: model.NamespaceScope},
+		"empty rule":        {Name: "r1", Scope: model.ClusterScope, Rules: []model.Rule{{}}},
+	}
+	for name, role := range tests {
+		t.Run(name, func(t *testing.T) {
+			admin.do(http.MethodPost, "/api/v1/roles", role).expect(http.StatusBadRequest)
+		})
+	}
+
+	ts.role("viewer")
+	admin.do(http.MethodPost, "/api/v1/roles", model.Role{Name: "viewer", Scope: model.ClusterScope}).expect(http.StatusConflict)
+}
@@ -4,21 +4,29 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	docs "chitchat4.0/docs"
 
 	"chitchat4.0/pkg/authentication"
+	"chitchat4.0/pkg/authentication/oauth"
+	"chitchat4.0/pkg/chat"
 	"chitchat4.0/pkg/common"
 	"chitchat4.0/pkg/config"
 	"chitchat4.0/pkg/controller"
 	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/hotsearch"
 	"chitchat4.0/pkg/middleware"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/notify"
 	"chitchat4.0/pkg/repository"
 	"chitchat4.0/pkg/service"
 	"chitchat4.0/pkg/utils/request"
 	"chitchat4.0/pkg/utils/set"
+	"chitchat4.0/pkg/validation"
 	"chitchat4.0/pkg/version"
+	"chitchat4.0/pkg/webhook"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -33,13 +41,15 @@ import (
 // 作用：返回一个配置好的服务 *Server
 func New(conf *config.Config, logger *logrus.Logger) (*Server, error) {
 	// 1. 限速的中间件
-	rateLimitMiddleware, err := middleware.RateLimitMiddleware(conf.Server.LimitConfig)
+	rateLimits, err := middleware.NewRateLimits(conf.Server.LimitConfig)
 	if err != nil {
 		return nil, err
 	}
+	setLogLevel(logger, conf.Server.LogLevel)
+	common.SetCookieSameSite(conf.Server.Cookie.SameSiteMode())
 	fmt.Println("logger=", logger)
 
-	db, err := database.NewPostgres(&conf.DB)
+	db, err := database.New(&conf.DB)
 	if err != nil {
 		return nil, errors.Wrap(err, "db 初始化失败")
 	}
@@ -47,44 +57,91 @@ func New(conf *config.Config, logger *logrus.Logger) (*Server, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "创建 Reids 客户端失败")
 	}
+	if err := migrate(db, conf.DB.Migrate, logger); err != nil {
+		return nil, err
+	}
 	// 创建仓库
 	repository := repository.NewRepository(db, rdb)
-	if conf.DB.Migrate {
-		if err := repository.Migrate(); err != nil {
-			return nil, err
-		}
+	// 迁移之后写入内置的资源和系统 group，已经存在的跳过
+	if err := repository.Init(); err != nil {
+		return nil, errors.Wrap(err, "初始化内置数据失败")
+	}
+
+	// 注册请求参数的校验规则
+	if err := validation.Init(); err != nil {
+		return nil, errors.Wrap(err, "注册校验规则失败")
 	}
 
 	// 创建服务
 	userService := service.NewUserService(repository.User())
+	mfaService := service.NewMFAService(repository.User())
+	tokenService := service.NewAccessTokenService(repository.AccessToken(), repository.User())
 	groupService := service.NewGroupService(repository.Group(), repository.User(), repository.RBAC())
 	jwtService := authentication.NewJWTService(conf.Server.JWTSecret)
+	oauthManager := oauth.NewOAuthManager(conf.OAuthConfig)
+	sessionService := service.NewSessionService(repository.Session(), jwtService.ExpireDuration())
 	// tagService := service.NewTagService(repository.Tag())
-	// hotSearchService := service.NewHotSearchService(repository.HotSearch())
+	alertDispatcher := notify.NewDispatcher() // 热搜提醒的发送渠道
+	alertDispatcher.Register(model.AlertChannelInbox, notify.NewInboxNotifier(repository.Alert()))
+	alertDispatcher.Register(model.AlertChannelWebhook, notify.NewWebhookNotifier())
+	if conf.SMTP.Enable {
+		alertDispatcher.Register(model.AlertChannelEmail, notify.NewEmailNotifier(&conf.SMTP))
+	}
+	alertService := service.NewAlertService(repository.Alert(), repository.User(), repository.Tag(), alertDispatcher)
+	hotSearchHub := hotsearch.NewHub(rdb) // 通过 Redis pub/sub 在副本间分发热搜榜的更新
+	hotSearchService := service.NewHotSearchService(repository.HotSearch(), repository.Tag(), repository.Comment(), repository.Reaction(), hotSearchHub, alertService)
+	commentService := service.NewCommentService(repository.Comment(), repository.Reaction(), repository.HotSearch(), repository.Tag())
+	feedService := service.NewFeedService(repository.Follow(), repository.HotSearch(), repository.Tag())
 	rbacService := service.NewRBACService(repository.RBAC())
+	webhookDispatcher := webhook.NewDispatcher(repository.Webhook(), repository.Events(), nil) // 把资源变更事件投递到 webhook
+	webhookService := service.NewWebhookService(repository.Webhook(), webhookDispatcher)
+	bulkService := service.NewBulkService(repository.Bulk())
+	rbacFileService := service.NewRBACFileService(conf.RBAC.File, repository.RBACFile(), logger)
+	if conf.RBAC.File != "" {
+		// 启动时同步声明式 RBAC 配置文件，有冲突时拒绝启动
+		if _, err := rbacFileService.Reconcile(false); err != nil {
+			return nil, errors.Wrap(err, "同步 RBAC 配置文件失败")
+		}
+	}
+	chatHub := chat.NewHub(rdb) // 通过 Redis pub/sub 在副本间分发聊天消息
+	roomService := service.NewRoomService(repository.Room(), repository.Message(), repository.User(), repository.Group(), chatHub, chat.NewPreviewFetcher())
+
+	// 跨域配置可以热加载，WebSocket 接口共用它的来源检查
+	cors := middleware.NewCORS(conf.Server.CORS)
 
 	// 创建控制器
 	userController := controller.NewUserController(userService)
 	groupController := controller.NewGroupController(groupService)
-	authController := controller.NewAuthController(userService, jwtService)
+	authController := controller.NewAuthController(userService, mfaService, sessionService, jwtService, oauthManager)
+	mfaController := controller.NewMFAController(mfaService)
+	tokenController := controller.NewAccessTokenController(tokenService)
+	sessionController := controller.NewSessionController(sessionService)
 	// tagController := controller.NewTagController(tagService)
-	// hotSearchController := controller.NewHotSearchController(hotSearchService)
+	hotSearchController := controller.NewHotSearchController(hotSearchService, hotSearchHub, cors.Upgrader(), conf.Server.PublicURL)
+	roomController := controller.NewRoomController(roomService, chatHub, cors.Upgrader())
+	commentController := controller.NewCommentController(commentService)
+	feedController := controller.NewFeedController(feedService)
+	alertController := controller.NewAlertController(alertService)
+	webhookController := controller.NewWebhookController(webhookService)
+	bulkController := controller.NewBulkController(bulkService)
+	rbacFileController := controller.NewRBACFileController(rbacFileService)
 	rbacController := controller.NewRbacController(rbacService)
+	watchController := controller.NewWatchController(repository.Events(), cors.Upgrader())
 
 	// 控制器汇总
-	controllers := []controller.Controller{userController, groupController, authController, rbacController}
+	controllers := []controller.Controller{userController, groupController, authController, rbacController, mfaController, tokenController, sessionController, watchController, hotSearchController, roomController, commentController, feedController, alertController, webhookController, bulkController, rbacFileController}
 
 	gin.SetMode(conf.Server.ENV) // 设置应用的模式(debug|release)
 
 	e := gin.New() // 定义一个 gin 引擎 (不带中间件的路由) ，返回一个没有注册中间件的gin.Engine对象,
 	e.Use(         // 挂载中间件
 		// 限速
-		rateLimitMiddleware,
+		rateLimits.Middleware(),
 
 		gin.Recovery(), // Recovery 返回一个中间件，可以从任何恐慌中恢复
 
 		// 设置运行的请求源、方法、请求头等
-		middleware.CORSMiddleware(), // CORSMiddleware() 加载cors跨域中间件
+		cors.Middleware(), // 加载cors跨域中间件，允许的来源可以热加载
 
 		//  当前次http请求中的部分信息放到Context
 		middleware.RequestInfoMiddleware(&request.RequestInfoFactory{APIPrefixes: set.NewString("api")}), // 请求信息处理中间件
@@ -93,7 +150,7 @@ func New(conf *config.Config, logger *logrus.Logger) (*Server, error) {
 		middleware.LogMiddleware(logger, "/"), // 日志中间件
 
 		// 获取Token，解析出Token中的user后加入Context
-		middleware.AuthenticationMiddleware(jwtService, repository.User()), // 身份验证： JWT 中间件（jwtService服务和user仓库）
+		middleware.AuthenticationMiddleware(jwtService, tokenService, sessionService, repository.User()), // 身份验证： JWT 和访问 token 中间件（jwtService服务、访问token服务、会话服务和user仓库）
 
 		// 验证上一步Context中存入的user，以及上上上一步在Context中存入的当前次http请求中的部分信息，
 		// middleware.AuthorizationMiddleware(), // 检查当前user的当前次请求是否被允许
@@ -107,7 +164,17 @@ func New(conf *config.Config, logger *logrus.Logger) (*Server, error) {
 		engine:      e,
 		config:      conf,
 		logger:      logger,
+		repository:  repository,
 		controllers: controllers,
+
+		hotSearchHub:      hotSearchHub,
+		chatHub:           chatHub,
+		webhookDispatcher: webhookDispatcher,
+
+		rateLimits:      rateLimits,
+		cors:            cors,
+		oauthManager:    oauthManager,
+		rbacFileService: rbacFileService,
 	}, nil
 }
 
@@ -119,22 +186,64 @@ type Server struct {
 
 	repository  repository.Repository
 	controllers []controller.Controller
+	routerOnce  sync.Once // 路由只注册一次
+
+	hotSearchHub *hotsearch.Hub // 热搜榜推送
+	chatHub      *chat.Hub      // 聊天消息推送
+
+	webhookDispatcher *webhook.Dispatcher // webhook 投递
+
+	// 可以热加载的部分
+	reloadMu        sync.Mutex
+	rateLimits      *middleware.RateLimits
+	cors            *middleware.CORS
+	oauthManager    *oauth.OAuthManager
+	rbacFileService service.RBACFileService
 }
 
 func (s *Server) Run() error {
-	s.initRouter()
 	// var err error
 
 	addr := fmt.Sprintf("%s:%d", s.config.Server.Address, s.config.Server.Port)
 	s.logger.Infof("启动服务器：%s", addr)
 	server := &http.Server{
 		Addr:    addr,
-		Handler: s.engine,
+		Handler: s.Handler(),
 	}
+
+	// 接收其他副本发布的热搜更新
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := s.hotSearchHub.Run(ctx); err != nil && err != context.Canceled {
+			s.logger.Errorf("热搜推送订阅 redis 失败：%v", err)
+		}
+	}()
+	go func() {
+		if err := s.chatHub.Run(ctx); err != nil && err != context.Canceled {
+			s.logger.Errorf("聊天消息推送订阅 redis 失败：%v", err)
+		}
+	}()
+	go func() {
+		if err := s.webhookDispatcher.Run(ctx); err != nil && err != context.Canceled {
+			s.logger.Errorf("webhook 投递失败：%v", err)
+		}
+	}()
 	server.ListenAndServe()
 	return nil
 }
 
+// Handler 注册全部路由并返回处理请求的 http.Handler，测试可以不监听端口直接调用
+func (s *Server) Handler() http.Handler {
+	s.routerOnce.Do(s.initRouter)
+	return s.engine
+}
+
+// Close 关闭数据库和 redis 的连接
+func (s *Server) Close() error {
+	return s.repository.Close()
+}
+
 func (s *Server) initRouter() {
 
 	root := s.engine
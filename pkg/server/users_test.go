@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"chitchat4.0/pkg/model"
+)
+
+func TestUserList(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	ts.login("bob")
+
+	users := make([]model.User, 0)
+	alice.do(http.MethodGet, "/api/v1/users", nil).expect(http.StatusOK).decode(&users)
+	names := make(map[string]bool, len(users))
+	for _, u := range users {
+		names[u.Name] = true
+	}
+	if !names["alice"] || !names["bob"] {
+		t.Fatalf("users = %v, want alice and bob", names)
+	}
+}
+
+func TestUserUpdateSelf(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+
+	resp := alice.do(http.MethodGet, "/api/v1/users/"+alice.id(), nil).expect(http.StatusOK)
+	etag := resp.header("ETag")
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+
+	updated := new(model.User)
+	resp = alice.do(http.MethodPut, "/api/v1/users/"+alice.id(), model.UpdatedUser{Name: "alice", Email: "alice@example.org"}, "If-Match", etag).
+		expect(http.StatusOK).
+		decode(updated)
+	if updated.Email != "alice@example.org" {
+		t.Fatalf("email = %q", updated.Email)
+	}
+	if resp.header("ETag") == etag {
+		t.Fatal("ETag is not changed after update")
+	}
+
+	// 使用旧的版本号修改时返回 412
+	alice.do(http.MethodPut, "/api/v1/users/"+alice.id(), model.UpdatedUser{Name: "alice"}, "If-Match", etag).
+		expect(http.StatusPreconditionFailed)
+}
+
+func TestUserUpdateOther(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	bob := ts.login("bob")
+	admin := ts.login("admin", "cluster-admin")
+
+	alice.do(http.MethodPut, "/api/v1/users/"+bob.id(), model.UpdatedUser{Name: "bob", Email: "alice@example.com"}).
+		expect(http.StatusForbidden)
+	alice.do(http.MethodPatch, "/api/v1/users/"+bob.id(), map[string]string{"email": "alice@example.com"}).
+		expect(http.StatusForbidden)
+
+	updated := new(model.User)
+	admin.do(http.MethodPut, "/api/v1/users/"+bob.id(), model.UpdatedUser{Name: "bob", Email: "bob@example.org"}).
+		expect(http.StatusOK).
+		decode(updated)
+	if updated.Email != "bob@example.org" {
+		t.Fatalf("email = %q", updated.Email)
+	}
+}
+
+func TestUserDelete(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	bob := ts.login("bob")
+	admin := ts.login("admin", "cluster-admin")
+
+	// 不能删除其他用户
+	alice.do(http.MethodDelete, "/api/v1/users/"+bob.id(), nil).expect(http.StatusForbidden)
+	alice.do(http.MethodGet, "/api/v1/users/"+bob.id(), nil).expect(http.StatusOK)
+
+	admin.do(http.MethodDelete, "/api/v1/users/"+bob.id(), nil).expect(http.StatusOK)
+	if _, err := ts.server.repository.User().GetUserByID(bob.user.ID); err == nil {
+		t.Fatal("bob is not deleted")
+	}
+}
+
+func TestUserRoles(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.login("alice")
+	admin := ts.login("admin", "cluster-admin")
+	role := ts.role("viewer")
+	rid := strconv.Itoa(int(role.ID))
+
+	// 普通用户不能给自己授予角色
+	alice.do(http.MethodPost, "/api/v1/users/"+alice.id()+"/roles/"+rid, nil).expect(http.StatusForbidden)
+	admin.do(http.MethodPost, "/api/v1/users/"+alice.id()+"/roles/"+rid, nil).expect(http.StatusOK)
+	user := new(model.User)
+	alice.do(http.MethodGet, "/api/v1/users/"+alice.id(), nil).expect(http.StatusOK).decode(user)
+	if len(user.Roles) != 1 || user.Roles[0].Name != "viewer" {
+		t.Fatalf("roles = %+v", user.Roles)
+	}
+
+	alice.do(http.MethodDelete, "/api/v1/users/"+alice.id()+"/roles/"+rid, nil).expect(http.StatusForbidden)
+	admin.do(http.MethodDelete, "/api/v1/users/"+alice.id()+"/roles/"+rid, nil).expect(http.StatusOK)
+	user = new(model.User)
+	alice.do(http.MethodGet, "/api/v1/users/"+alice.id(), nil).expect(http.StatusOK).decode(user)
+	if len(user.Roles) != 0 {
+		t.Fatalf("roles = %+v", user.Roles)
+	}
+}
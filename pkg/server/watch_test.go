@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"chitchat4.0/pkg/model"
+	"github.com/gorilla/websocket"
+)
+
+// watchRoles 使用 WebSocket watch roles，返回连接
+func watchRoles(t *testing.T, srv *httptest.Server, c *client) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/watch/roles"
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer " + c.token}})
+	if err != nil {
+		t.Fatalf("watch roles as %s: %v", c.user.Name, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// nextEvent 读取下一个事件，超时时终止测试
+func nextEvent(t *testing.T, conn *websocket.Conn) model.Event {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event model.Event
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	return event
+}
+
+// TestWatchVisible 没有权限的 user 收不到其他角色的事件，只能收到自己拥有的角色的事件
+func TestWatchVisible(t *testing.T) {
+	ts := newTestServer(t)
+	srv := httptest.NewServer(ts.handler)
+	defer srv.Close()
+	admin := ts.login("admin", "cluster-admin")
+	alice := ts.login("alice", "editor")
+	editor := ts.role("editor")
+
+	adminWatch := watchRoles(t, srv, admin)
+	aliceWatch := watchRoles(t, srv, alice)
+
+	auditor := new(model.Role)
+	admin.do(http.MethodPost, "/api/v1/roles", model.Role{
+		Name:  "auditor",
+		Scope: model.ClusterScope,
+		Rules: []model.Rule{{Resource: model.All, Operation: model.ViewOperation}},
+	}).expect(http.StatusOK).decode(auditor)
+	admin.do(http.MethodPut, "/api/v1/roles/"+strconv.Itoa(int(editor.ID)), model.Role{
+		Name:  "editor",
+		Scope: model.ClusterScope,
+		Rules: []model.Rule{{Resource: model.All, Operation: model.EditOperation}},
+	}).expect(http.StatusOK)
+
+	if event := nextEvent(t, adminWatch); event.ObjectID != auditor.ID {
+		t.Fatalf("admin: first event = %+v, want role %d", event, auditor.ID)
+	}
+	if event := nextEvent(t, adminWatch); event.ObjectID != editor.ID {
+		t.Fatalf("admin: second event = %+v, want role %d", event, editor.ID)
+	}
+	if event := nextEvent(t, aliceWatch); event.ObjectID != editor.ID {
+		t.Fatalf("alice: first event = %+v, want only role %d", event, editor.ID)
+	}
+}
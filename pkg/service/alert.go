@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/notify"
+	"chitchat4.0/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	MaxAlertRules               = 20  // 每个 user 最多的提醒规则数
+	DefaultNotificationPageSize = 50  // 站内信默认返回的数量
+	MaxNotificationPageSize     = 200 // 站内信最多返回的数量
+
+	alertEvaluateTimeout = time.Minute // 处理一次快照的全部提醒的超时时间
+)
+
+type alertService struct {
+	alertRepository repository.AlertRepository
+	userRepository  repository.UserRepository
+	tagRepository   repository.TagRepository
+	dispatcher      *notify.Dispatcher
+
+	mu      sync.Mutex
+	pending map[uint][]model.HotSearch // 等待检查的快照，同一个 tag 只保留最新的
+	running bool                       // 是否有 goroutine 正在检查快照
+}
+
+// NewAlertService 返回热搜提醒服务，dispatcher 决定可用的发送渠道
+func NewAlertService(alertRepository repository.AlertRepository, userRepository repository.UserRepository,
+	tagRepository repository.TagRepository, dispatcher *notify.Dispatcher) AlertService {
+	return &alertService{
+		alertRepository: alertRepository,
+		userRepository:  userRepository,
+		tagRepository:   tagRepository,
+		dispatcher:      dispatcher,
+		pending:         make(map[uint][]model.HotSearch),
+	}
+}
+
+// List 获取 user 的提醒规则
+func (a *alertService) List(user *model.User) ([]model.AlertRule, error) {
+	return a.alertRepository.ListRules(user.ID)
+}
+
+// Create 创建提醒规则
+func (a *alertService) Create(user *model.User, created *model.CreatedAlertRule) (*model.AlertRule, error) {
+	rules, err := a.alertRepository.ListRules(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) >= MaxAlertRules {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("at most %d alert rules per user", MaxAlertRules))
+	}
+	rule := created.GetAlertRule(user.ID)
+	if err := a.validate(user, rule); err != nil {
+		return nil, err
+	}
+	return a.alertRepository.CreateRule(rule)
+}
+
+// Get 获取 user 的提醒规则
+func (a *alertService) Get(user *model.User, id string) (*model.AlertRule, error) {
+	return a.getRule(user, id)
+}
+
+// Update 修改 user 的提醒规则，修改后之前提醒过的热搜不会再次提醒
+func (a *alertService) Update(user *model.User, id string, updated *model.CreatedAlertRule) (*model.AlertRule, error) {
+	old, err := a.getRule(user, id)
+	if err != nil {
+		return nil, err
+	}
+	rule := updated.GetAlertRule(user.ID)
+	rule.ID, rule.BaseModel = old.ID, old.BaseModel
+	if err := a.validate(user, rule); err != nil {
+		return nil, err
+	}
+	return a.alertRepository.UpdateRule(rule)
+}
+
+// Delete 删除 user 的提醒规则
+func (a *alertService) Delete(user *model.User, id string) error {
+	rule, err := a.getRule(user, id)
+	if err != nil {
+		return err
+	}
+	return a.alertRepository.DeleteRule(rule.ID)
+}
+
+// ListNotifications 获取 user 最近的站内信
+func (a *alertService) ListNotifications(user *model.User, unreadOnly bool, limit int) ([]model.Notification, error) {
+	if limit <= 0 {
+		limit = DefaultNotificationPageSize
+	}
+	if limit > MaxNotificationPageSize {
+		limit = MaxNotificationPageSize
+	}
+	return a.alertRepository.ListNotifications(user.ID, unreadOnly, limit)
+}
+
+// MarkRead 把站内信标记为已读，id 为空时全部标记为已读
+func (a *alertService) MarkRead(user *model.User, id string) error {
+	if id == "" {
+		return a.alertRepository.MarkAllRead(user.ID)
+	}
+	nid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return a.alertRepository.MarkRead(user.ID, uint(nid))
+}
+
+// Evaluate 在后台用启用的提醒规则检查 tag 的新快照，不会阻塞调用者。
+// 同时只有一个 goroutine 检查快照，检查期间同一个 tag 提交的多个快照只检查最新的
+func (a *alertService) Evaluate(tagID uint, hotSearches []model.HotSearch) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending[tagID] = hotSearches
+	if !a.running {
+		a.running = true
+		go a.run()
+	}
+}
+
+// run 依次检查等待中的快照，没有等待的快照时退出
+func (a *alertService) run() {
+	for {
+		a.mu.Lock()
+		if len(a.pending) == 0 {
+			a.running = false
+			a.mu.Unlock()
+			return
+		}
+		var tagID uint
+		var hotSearches []model.HotSearch
+		for tagID, hotSearches = range a.pending {
+			break
+		}
+		delete(a.pending, tagID)
+		a.mu.Unlock()
+
+		a.evaluate(tagID, hotSearches)
+	}
+}
+
+// evaluate 符合规则并且没有提醒过的热搜通过规则的渠道发送提醒。
+// 先写入去重记录占用这条热搜，多个副本同时处理快照时只有一个会发送；所有渠道都发送失败时删除记录，下一次快照会重新提醒
+func (a *alertService) evaluate(tagID uint, hotSearches []model.HotSearch) {
+	rules, err := a.alertRepository.ListEnabledRules()
+	if err != nil {
+		logrus.Errorf("list alert rules failed: %v", err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), alertEvaluateTimeout)
+	defer cancel()
+	users := make(map[uint]*model.User)
+	for i := range rules {
+		rule := &rules[i]
+		for j := range hotSearches {
+			hotSearch := &hotSearches[j]
+			keyword, ok := rule.Match(hotSearch)
+			if !ok {
+				continue
+			}
+			hit := &model.AlertHit{RuleID: rule.ID, TagID: tagID, Link: hotSearch.Link, HotSearchID: hotSearch.ID}
+			first, err := a.alertRepository.Hit(hit)
+			if err != nil {
+				logrus.Errorf("record alert of rule %d failed: %v", rule.ID, err)
+				continue
+			}
+			if !first {
+				continue
+			}
+			user, ok := users[rule.UserID]
+			if !ok {
+				if user, err = a.userRepository.GetUserByID(rule.UserID); err != nil {
+					logrus.Warnf("get user %d of alert rule %d failed: %v", rule.UserID, rule.ID, err)
+				}
+				users[rule.UserID] = user
+			}
+			sent := a.dispatcher.Dispatch(ctx, &model.Alert{
+				RuleID:      rule.ID,
+				RuleName:    rule.Name,
+				UserID:      rule.UserID,
+				TagID:       tagID,
+				HotSearchID: hotSearch.ID,
+				Title:       hotSearch.Title,
+				Link:        hotSearch.Link,
+				Extra:       hotSearch.Extra,
+				Rank:        hotSearch.Rank,
+				Keyword:     keyword,
+				CreatedAt:   time.Now(),
+				Rule:        rule,
+				User:        user,
+			})
+			if sent > 0 {
+				continue
+			}
+			if err := a.alertRepository.DeleteHit(hit); err != nil {
+				logrus.Errorf("delete alert record of rule %d failed: %v", rule.ID, err)
+			}
+		}
+	}
+}
+
+// validate 检查规则的条件和发送渠道
+func (a *alertService) validate(user *model.User, rule *model.AlertRule) error {
+	if len(rule.Keywords) == 0 && len(rule.TagIDs) == 0 {
+		return apierrors.NewFieldInvalid("keywords", "keywords and tagIds can not both be empty")
+	}
+	for _, tid := range rule.TagIDs {
+		if _, err := a.tagRepository.GetTagByID(tid); err != nil {
+			return err
+		}
+	}
+	for _, channel := range rule.Channels {
+		if !a.dispatcher.Has(channel) {
+			return apierrors.NewFieldInvalid("channels", "channel "+channel+" is not available")
+		}
+	}
+	if rule.HasChannel(model.AlertChannelWebhook) {
+		u, err := url.Parse(rule.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return apierrors.NewFieldInvalid("webhookUrl", "webhook channel requires a http or https url")
+		}
+	}
+	if rule.HasChannel(model.AlertChannelEmail) && user.Email == "" {
+		return apierrors.NewFieldInvalid("channels", "email channel requires the user to have an email")
+	}
+	return nil
+}
+
+// getRule 获取 user 自己的提醒规则，其他 user 的规则视为不存在
+func (a *alertService) getRule(user *model.User, id string) (*model.AlertRule, error) {
+	rid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := a.alertRepository.GetRuleByID(uint(rid))
+	if err != nil {
+		return nil, err
+	}
+	if rule.UserID != user.ID {
+		return nil, apierrors.NewNotFound("alert rule", id)
+	}
+	return rule, nil
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"fmt"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/validation"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type bulkService struct {
+	bulkRepository repository.BulkRepository
+}
+
+// NewBulkService 返回批量导入导出 role、group 和 user 的服务，只有管理员可以使用
+func NewBulkService(bulkRepository repository.BulkRepository) BulkService {
+	return &bulkService{
+		bulkRepository: bulkRepository,
+	}
+}
+
+// Export 导出 resource 的配置，resource 为空时导出全部的 role、group 和 user，不包括密码
+func (b *bulkService) Export(user *model.User, resource string) (*model.BulkDocument, error) {
+	if err := checkBulkAdmin(user); err != nil {
+		return nil, err
+	}
+	if err := checkBulkResource(resource); err != nil {
+		return nil, err
+	}
+
+	doc := new(model.BulkDocument)
+	if resource == "" || resource == model.RoleResource {
+		roles, err := b.bulkRepository.ListRoles()
+		if err != nil {
+			return nil, err
+		}
+		doc.Roles = make([]model.BulkRole, 0, len(roles))
+		for _, role := range roles {
+			rules := role.Rules
+			if rules == nil {
+				rules = model.Rules{}
+			}
+			doc.Roles = append(doc.Roles, model.BulkRole{Name: role.Name, Scope: role.Scope, Namespace: role.Namespace, Rules: rules})
+		}
+	}
+	if resource == "" || resource == model.GroupResource {
+		groups, err := b.bulkRepository.ListGroups()
+		if err != nil {
+			return nil, err
+		}
+		doc.Groups = make([]model.BulkGroup, 0, len(groups))
+		for _, group := range groups {
+			doc.Groups = append(doc.Groups, model.BulkGroup{Name: group.Name, Describe: group.Describe, Roles: roleNames(group.Roles)})
+		}
+	}
+	if resource == "" || resource == model.UserResource {
+		users, err := b.bulkRepository.ListUsers()
+		if err != nil {
+			return nil, err
+		}
+		doc.Users = make([]model.BulkUser, 0, len(users))
+		for _, u := range users {
+			groups := make([]string, 0, len(u.Groups))
+			for _, group := range u.Groups {
+				groups = append(groups, group.Name)
+			}
+			doc.Users = append(doc.Users, model.BulkUser{
+				Name:           u.Name,
+				Email:          u.Email,
+				Avatar:         u.Avatar,
+				ServiceAccount: u.ServiceAccount,
+				Groups:         groups,
+				Roles:          roleNames(u.Roles),
+			})
+		}
+	}
+	return doc, nil
+}
+
+// Import 校验每一行并按名称创建或修改 role、group 和 user。
+// 有任何一行出错或 dryRun 为 true 时只返回校验结果，否则在一个事务中导入全部数据
+func (b *bulkService) Import(user *model.User, doc *model.BulkDocument, dryRun bool) (*model.ImportResult, error) {
+	if err := checkBulkAdmin(user); err != nil {
+		return nil, err
+	}
+	roles, err := b.bulkRepository.ListRoles()
+	if err != nil {
+		return nil, err
+	}
+	groups, err := b.bulkRepository.ListGroups()
+	if err != nil {
+		return nil, err
+	}
+	users, err := b.bulkRepository.ListUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	// 已有的和本次导入的名称，用于校验引用
+	knownRoles, knownGroups := make(map[string]bool), make(map[string]bool)
+	existingRoles, existingGroups := make(map[string]bool), make(map[string]bool)
+	existingUsers := make(map[string]*model.User)
+	for _, role := range roles {
+		knownRoles[role.Name], existingRoles[role.Name] = true, true
+	}
+	for _, group := range groups {
+		knownGroups[group.Name], existingGroups[group.Name] = true, true
+	}
+	for i := range users {
+		existingUsers[users[i].Name] = &users[i]
+	}
+	for _, role := range doc.Roles {
+		knownRoles[role.Name] = true
+	}
+	for _, group := range doc.Groups {
+		knownGroups[group.Name] = true
+	}
+
+	report := newImportReport(dryRun)
+	for i := range doc.Roles {
+		item := &doc.Roles[i]
+		exists := existingRoles[item.Name]
+		var errs []string
+		if exists {
+			errs = validationMessages(item)
+		} else { // 创建时使用创建 role 的校验规则
+			errs = validationMessages(&model.Role{Name: item.Name, Scope: item.Scope, Namespace: item.Namespace, Rules: item.Rules})
+		}
+		if item.Rules == nil {
+			item.Rules = model.Rules{}
+		}
+		report.add(model.RoleResource, i, item.Name, exists, errs)
+	}
+	for i := range doc.Groups {
+		item := &doc.Groups[i]
+		exists := existingGroups[item.Name]
+		var errs []string
+		if exists {
+			errs = validationMessages(item)
+		} else {
+			errs = validationMessages(&model.CreatedGroup{Name: item.Name, Describe: item.Describe})
+		}
+		item.Roles = uniqueNames(item.Roles)
+		errs = append(errs, unknownNames("roles", item.Roles, knownRoles)...)
+		report.add(model.GroupResource, i, item.Name, exists, errs)
+	}
+	for i := range doc.Users {
+		item := &doc.Users[i]
+		old, exists := existingUsers[item.Name]
+		var errs []string
+		if exists {
+			errs = validationMessages(item)
+			if old.ServiceAccount != item.ServiceAccount {
+				errs = append(errs, "serviceAccount: serviceAccount can not be changed")
+			}
+		} else {
+			errs = validationMessages(&model.CreatedUser{
+				Name:           item.Name,
+				Password:       item.Password,
+				Email:          item.Email,
+				Avatar:         item.Avatar,
+				ServiceAccount: item.ServiceAccount,
+			})
+		}
+		item.Groups, item.Roles = uniqueNames(item.Groups), uniqueNames(item.Roles)
+		errs = append(errs, unknownNames("groups", item.Groups, knownGroups)...)
+		errs = append(errs, unknownNames("roles", item.Roles, knownRoles)...)
+		report.add(model.UserResource, i, item.Name, exists, errs)
+	}
+
+	result := report.result()
+	if result.Failed > 0 || dryRun {
+		return result, nil
+	}
+
+	for i := range doc.Users {
+		item := &doc.Users[i]
+		if item.ServiceAccount || item.Password == "" {
+			// 服务账号不能使用密码登录
+			item.Password = ""
+			continue
+		}
+		password, err := bcrypt.GenerateFromPassword([]byte(item.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		item.Password = string(password)
+	}
+	if err := b.bulkRepository.Import(user, doc); err != nil {
+		return nil, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// importReport 记录每一行的校验结果和同一种资源中重复的名称
+type importReport struct {
+	res   *model.ImportResult
+	names map[string]map[string]int // 资源 -> 名称 -> 第一次出现的行
+}
+
+func newImportReport(dryRun bool) *importReport {
+	return &importReport{
+		res:   &model.ImportResult{DryRun: dryRun, Rows: make([]model.ImportRow, 0)},
+		names: make(map[string]map[string]int),
+	}
+}
+
+// add 记录第 index 行（从 0 开始）的结果
+func (r *importReport) add(resource string, index int, name string, exists bool, errs []string) {
+	row := model.ImportRow{Resource: resource, Row: index + 1, Name: name}
+	if r.names[resource] == nil {
+		r.names[resource] = make(map[string]int)
+	}
+	if first, ok := r.names[resource][name]; ok && name != "" {
+		errs = append(errs, fmt.Sprintf("name: duplicate name, first defined at row %d", first))
+	} else {
+		r.names[resource][name] = row.Row
+	}
+
+	switch {
+	case len(errs) > 0:
+		row.Errors = errs
+		r.res.Failed++
+	case exists:
+		row.Action = model.ImportUpdate
+		r.res.Updated++
+	default:
+		row.Action = model.ImportCreate
+		r.res.Created++
+	}
+	r.res.Rows = append(r.res.Rows, row)
+}
+
+func (r *importReport) result() *model.ImportResult {
+	return r.res
+}
+
+func checkBulkAdmin(user *model.User) error {
+	if !authorization.IsClusterAdmin(user) {
+		return apierrors.NewForbidden("only cluster admins can import or export users, groups and roles")
+	}
+	return nil
+}
+
+// checkBulkResource resource 为空或是可以批量导入导出的资源
+func checkBulkResource(resource string) error {
+	if resource == "" {
+		return nil
+	}
+	for _, r := range model.BulkResources {
+		if r == resource {
+			return nil
+		}
+	}
+	return apierrors.NewNotFound("bulk resource", resource)
+}
+
+func validationMessages(obj interface{}) []string {
+	if err := validation.Struct(obj); err != nil {
+		return validation.Messages(err)
+	}
+	return nil
+}
+
+// unknownNames 返回引用了不存在的名称的错误
+func unknownNames(field string, names []string, known map[string]bool) []string {
+	errs := make([]string, 0)
+	for _, name := range names {
+		if !known[name] {
+			errs = append(errs, fmt.Sprintf("%s: %q does not exist", field, name))
+		}
+	}
+	return errs
+}
+
+// uniqueNames 去掉重复的名称，保留 nil
+func uniqueNames(names []string) []string {
+	if names == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+func roleNames(roles []model.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/utils/ratelimit"
+)
+
+const (
+	DefaultCommentPageSize = 20 // 每页顶层评论的默认数量
+	MaxCommentPageSize     = 50 // 每页顶层评论的最大数量
+	maxEmojiLength         = 32 // emoji 的最大字节数，和数据库字段长度一致
+)
+
+// 每个 user 发表评论、回应、举报的频率限制：平均间隔和允许的连续次数
+const (
+	commentInterval  = 10 * time.Second
+	commentBurst     = 5
+	reactionInterval = time.Second
+	reactionBurst    = 20
+	reportInterval   = time.Minute
+	reportBurst      = 5
+)
+
+type commentService struct {
+	commentRepository   repository.CommentRepository
+	reactionRepository  repository.ReactionRepository
+	hotSearchRepository repository.HotSearchRepository
+	tagRepository       repository.TagRepository
+
+	commentLimiter  *ratelimit.KeyedLimiter // 按 user 限制发表评论的频率
+	reactionLimiter *ratelimit.KeyedLimiter // 按 user 限制回应的频率
+	reportLimiter   *ratelimit.KeyedLimiter // 按 user 限制举报的频率
+}
+
+// NewCommentService 返回评论和回应服务
+func NewCommentService(commentRepository repository.CommentRepository, reactionRepository repository.ReactionRepository,
+	hotSearchRepository repository.HotSearchRepository, tagRepository repository.TagRepository) CommentService {
+	return &commentService{
+		commentRepository:   commentRepository,
+		reactionRepository:  reactionRepository,
+		hotSearchRepository: hotSearchRepository,
+		tagRepository:       tagRepository,
+		commentLimiter:      ratelimit.NewKeyedLimiter(commentInterval, commentBurst, 0),
+		reactionLimiter:     ratelimit.NewKeyedLimiter(reactionInterval, reactionBurst, 0),
+		reportLimiter:       ratelimit.NewKeyedLimiter(reportInterval, reportBurst, 0),
+	}
+}
+
+// List 分页获取对象下的顶层评论和它们的回复。
+// 已删除的评论还有回复时保留位置，不返回内容和作者
+func (s *commentService) List(targetType, targetID, before string, limit int) (*model.CommentPage, error) {
+	tid, err := s.getTarget(model.CommentTargets, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	var beforeID uint64
+	if before != "" {
+		if beforeID, err = strconv.ParseUint(before, 10, 32); err != nil {
+			return nil, apierrors.NewFieldInvalid("before", "invalid comment id "+strconv.Quote(before))
+		}
+	}
+	if limit <= 0 {
+		limit = DefaultCommentPageSize
+	}
+	if limit > MaxCommentPageSize {
+		limit = MaxCommentPageSize
+	}
+
+	roots, err := s.commentRepository.ListRoots(targetType, tid, uint(beforeID), limit)
+	if err != nil {
+		return nil, err
+	}
+	rootIDs := make([]uint, 0, len(roots))
+	for _, root := range roots {
+		rootIDs = append(rootIDs, root.ID)
+	}
+	replies, err := s.commentRepository.ListReplies(rootIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := rootIDs
+	for _, reply := range replies {
+		ids = append(ids, reply.ID)
+	}
+	reactions, err := s.reactionRepository.Counts(model.CommentResource, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.CommentPage{Items: buildThreads(roots, replies, reactions)}
+	if len(roots) == limit {
+		page.NextBefore = roots[len(roots)-1].ID
+	}
+	return page, nil
+}
+
+// Create 在对象下发表评论，或者回复同一个对象下的评论
+func (s *commentService) Create(user *model.User, targetType, targetID string, created *model.CreatedComment) (*model.Comment, error) {
+	tid, err := s.getTarget(model.CommentTargets, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.commentLimiter.Allow(strconv.Itoa(int(user.ID))) {
+		return nil, apierrors.NewTooManyRequests("comment too frequently, please try again later")
+	}
+	comment := &model.Comment{
+		TargetType: targetType,
+		TargetID:   tid,
+		UserID:     user.ID,
+		Content:    created.Content,
+	}
+	if created.ParentID != nil {
+		parent, err := s.commentRepository.GetCommentByID(*created.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.TargetType != targetType || parent.TargetID != tid {
+			return nil, apierrors.NewFieldInvalid("parentId", "comment "+strconv.Itoa(int(parent.ID))+" is not under "+targetType+" "+targetID)
+		}
+		root := parent.ID
+		if parent.RootID != nil {
+			root = *parent.RootID
+		}
+		comment.ParentID = &parent.ID
+		comment.RootID = &root
+	}
+	return s.commentRepository.Create(comment)
+}
+
+// Delete 软删除评论，只有作者和管理员可以删除
+func (s *commentService) Delete(user *model.User, id string) error {
+	comment, err := s.getComment(id)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != user.ID && !authorization.IsClusterAdmin(user) {
+		return apierrors.NewForbidden("only the author can delete comment " + id)
+	}
+	return s.commentRepository.Delete(comment.ID, user.ID)
+}
+
+// Report 举报评论，不能举报自己的评论
+func (s *commentService) Report(user *model.User, id string, created *model.CreatedCommentReport) error {
+	comment, err := s.getComment(id)
+	if err != nil {
+		return err
+	}
+	if comment.UserID == user.ID {
+		return apierrors.NewBadRequest("can not report your own comment")
+	}
+	if !s.reportLimiter.Allow(strconv.Itoa(int(user.ID))) {
+		return apierrors.NewTooManyRequests("report too frequently, please try again later")
+	}
+	return s.commentRepository.CreateReport(&model.CommentReport{
+		CommentID: comment.ID,
+		UserID:    user.ID,
+		Reason:    created.Reason,
+	})
+}
+
+// ListReports 获取待处理的举报，只有管理员可以查看
+func (s *commentService) ListReports(user *model.User) ([]model.CommentReport, error) {
+	if !authorization.IsClusterAdmin(user) {
+		return nil, apierrors.NewForbidden("only cluster admins can list comment reports")
+	}
+	return s.commentRepository.ListReports()
+}
+
+// DismissReports 忽略评论的举报并保留评论，只有管理员可以处理
+func (s *commentService) DismissReports(user *model.User, id string) error {
+	if !authorization.IsClusterAdmin(user) {
+		return apierrors.NewForbidden("only cluster admins can dismiss comment reports")
+	}
+	comment, err := s.getComment(id)
+	if err != nil {
+		return err
+	}
+	return s.commentRepository.DeleteReports(comment.ID)
+}
+
+// React 用 emoji 回应对象，重复回应同一个 emoji 时忽略
+func (s *commentService) React(user *model.User, targetType, targetID, emoji string) error {
+	reaction, err := s.getReaction(user, targetType, targetID, emoji)
+	if err != nil {
+		return err
+	}
+	if !s.reactionLimiter.Allow(strconv.Itoa(int(user.ID))) {
+		return apierrors.NewTooManyRequests("react too frequently, please try again later")
+	}
+	return s.reactionRepository.Add(reaction)
+}
+
+// Unreact 取消对象的 emoji 回应
+func (s *commentService) Unreact(user *model.User, targetType, targetID, emoji string) error {
+	reaction, err := s.getReaction(user, targetType, targetID, emoji)
+	if err != nil {
+		return err
+	}
+	return s.reactionRepository.Remove(reaction)
+}
+
+func (s *commentService) getReaction(user *model.User, targetType, targetID, emoji string) (*model.Reaction, error) {
+	if !validEmoji(emoji) {
+		return nil, apierrors.NewFieldInvalid("emoji", "invalid emoji "+strconv.Quote(emoji))
+	}
+	tid, err := s.getTarget(model.ReactionTargets, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Reaction{TargetType: targetType, TargetID: tid, UserID: user.ID, Emoji: emoji}, nil
+}
+
+// getTarget 检查 targets 中类型为 targetType 的对象存在，返回对象的 id
+func (s *commentService) getTarget(targets []string, targetType, id string) (uint, error) {
+	allowed := false
+	for _, t := range targets {
+		allowed = allowed || t == targetType
+	}
+	if !allowed {
+		return 0, apierrors.NewNotFound("resource", targetType)
+	}
+	tid, err := parseID(id)
+	if err != nil {
+		return 0, err
+	}
+	switch targetType {
+	case model.HotSearchResource:
+		_, err = s.hotSearchRepository.GetHotSearchByID(uint(tid))
+	case model.TagResource:
+		_, err = s.tagRepository.GetTagByID(uint(tid))
+	case model.CommentResource:
+		_, err = s.commentRepository.GetCommentByID(uint(tid))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uint(tid), nil
+}
+
+func (s *commentService) getComment(id string) (*model.Comment, error) {
+	cid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.commentRepository.GetCommentByID(uint(cid))
+}
+
+// buildThreads 把回复挂到父评论下组成楼层，roots 按从新到旧排序，回复按从旧到新排序。
+// 已删除且没有未删除回复的评论不返回
+func buildThreads(roots, replies []model.Comment, reactions map[uint]map[string]int64) []model.Comment {
+	children := make(map[uint][]model.Comment)
+	for _, reply := range replies {
+		if reply.ParentID != nil {
+			children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+		}
+	}
+	var build func(comment model.Comment) (model.Comment, bool)
+	build = func(comment model.Comment) (model.Comment, bool) {
+		comment.Reactions = reactions[comment.ID]
+		for _, child := range children[comment.ID] {
+			if reply, ok := build(child); ok {
+				comment.Replies = append(comment.Replies, reply)
+			}
+		}
+		if comment.DeletedAt.Valid {
+			if len(comment.Replies) == 0 {
+				return comment, false
+			}
+			comment.Deleted = true
+			comment.Content = ""
+			comment.User = nil
+			comment.Reactions = nil
+		}
+		return comment, true
+	}
+
+	threads := make([]model.Comment, 0, len(roots))
+	for _, root := range roots {
+		if thread, ok := build(root); ok {
+			threads = append(threads, thread)
+		}
+	}
+	return threads
+}
+
+// validEmoji 判断是不是一个 emoji：至少包含一个符号，只能由符号、修饰符、变体选择符和零宽连接符组成
+func validEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > maxEmojiLength || !utf8.ValidString(emoji) {
+		return false
+	}
+	symbol := false
+	for _, r := range emoji {
+		switch {
+		case unicode.Is(unicode.So, r) || r == '\u20e3': // 符号，或者组成键帽 emoji（例如 1️⃣）的键帽符号
+			symbol = true
+		case r > unicode.MaxASCII && unicode.In(r, unicode.Sk, unicode.Variation_Selector) || r == '\u200d':
+		case r >= '0' && r <= '9' || r == '#' || r == '*':
+		default:
+			return false
+		}
+	}
+	return symbol
+}
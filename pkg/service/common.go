@@ -0,0 +1,16 @@
+package service
+
+import (
+	"strconv"
+
+	"chitchat4.0/pkg/apierrors"
+)
+
+// parseID 解析路径参数中的 id，解析失败时返回参数校验错误
+func parseID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, apierrors.NewFieldInvalid("id", "invalid id "+strconv.Quote(id))
+	}
+	return n, nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultFeedSize = 50  // 个性化热搜默认返回的数量
+	MaxFeedSize     = 100 // 个性化热搜最多计算和返回的数量
+
+	MaxKeywordFollows = 50 // 每个 user 最多关注的关键词数
+
+	feedCacheTTL   = time.Minute // 个性化热搜的缓存时间，热搜榜更新后最多延迟这么久
+	feedCandidates = 500         // 计算个性化热搜时最多取的候选热搜数
+
+	tagWeight     = 2.0            // 属于关注的 tag 的得分
+	keywordWeight = 3.0            // 每个匹配的关键词的得分
+	feedHalfLife  = 12 * time.Hour // 热搜上榜后得分减半的时间
+)
+
+type feedService struct {
+	followRepository    repository.FollowRepository
+	hotSearchRepository repository.HotSearchRepository
+	tagRepository       repository.TagRepository
+}
+
+// NewFeedService 返回关注、收藏和个性化热搜服务
+func NewFeedService(followRepository repository.FollowRepository, hotSearchRepository repository.HotSearchRepository,
+	tagRepository repository.TagRepository) FeedService {
+	return &feedService{
+		followRepository:    followRepository,
+		hotSearchRepository: hotSearchRepository,
+		tagRepository:       tagRepository,
+	}
+}
+
+// ListFollows 获取 user 关注的 tag 和关键词
+func (f *feedService) ListFollows(user *model.User) (*model.Follows, error) {
+	tags, err := f.followRepository.ListTags(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	keywords, err := f.followRepository.ListKeywords(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Follows{Tags: tags, Keywords: keywords}, nil
+}
+
+// FollowTag 关注 tag
+func (f *feedService) FollowTag(user *model.User, tagID string) error {
+	tag, err := f.getTag(tagID)
+	if err != nil {
+		return err
+	}
+	return f.followRepository.FollowTag(user.ID, tag.ID)
+}
+
+// UnfollowTag 取消关注 tag
+func (f *feedService) UnfollowTag(user *model.User, tagID string) error {
+	tid, err := parseID(tagID)
+	if err != nil {
+		return err
+	}
+	return f.followRepository.UnfollowTag(user.ID, uint(tid))
+}
+
+// FollowKeyword 关注关键词，关键词不区分大小写，每个 user 最多关注 MaxKeywordFollows 个
+func (f *feedService) FollowKeyword(user *model.User, created *model.CreatedKeywordFollow) (*model.KeywordFollow, error) {
+	keyword := strings.ToLower(strings.TrimSpace(created.Keyword))
+	if keyword == "" || utf8.RuneCountInString(keyword) > 64 {
+		return nil, apierrors.NewFieldInvalid("keyword", "keyword must be 1 to 64 characters")
+	}
+	keywords, err := f.followRepository.ListKeywords(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keywords) >= MaxKeywordFollows {
+		return nil, apierrors.NewBadRequest("too many keywords, unfollow some keywords first")
+	}
+	return f.followRepository.CreateKeyword(&model.KeywordFollow{UserID: user.ID, Keyword: keyword})
+}
+
+// UnfollowKeyword 取消关注关键词
+func (f *feedService) UnfollowKeyword(user *model.User, id string) error {
+	kid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return f.followRepository.DeleteKeyword(user.ID, uint(kid))
+}
+
+// ListBookmarks 获取 user 收藏的热搜
+func (f *feedService) ListBookmarks(user *model.User) ([]model.Bookmark, error) {
+	return f.followRepository.ListBookmarks(user.ID)
+}
+
+// Bookmark 收藏热搜，只能收藏在榜的热搜
+func (f *feedService) Bookmark(user *model.User, hotSearchID string) error {
+	hid, err := parseID(hotSearchID)
+	if err != nil {
+		return err
+	}
+	hotSearch, err := f.hotSearchRepository.GetHotSearchByID(uint(hid))
+	if err != nil {
+		return err
+	}
+	return f.followRepository.Bookmark(user.ID, hotSearch.ID)
+}
+
+// Unbookmark 取消收藏热搜，已下榜的热搜也可以取消
+func (f *feedService) Unbookmark(user *model.User, hotSearchID string) error {
+	hid, err := parseID(hotSearchID)
+	if err != nil {
+		return err
+	}
+	return f.followRepository.Unbookmark(user.ID, uint(hid))
+}
+
+// Feed 获取 user 的个性化热搜，优先使用 Redis 中的缓存，没有缓存时计算并缓存 feedCacheTTL 时间
+func (f *feedService) Feed(user *model.User, limit int) (*model.Feed, error) {
+	if limit <= 0 {
+		limit = DefaultFeedSize
+	}
+	if limit > MaxFeedSize {
+		limit = MaxFeedSize
+	}
+
+	feed, err := f.followRepository.GetFeed(user.ID)
+	if err != nil {
+		if apierrors.FromError(err) == nil && !errors.Is(err, database.RedisDisableError) {
+			logrus.Warnf("get feed of user %d from cache failed: %v", user.ID, err)
+		}
+		if feed, err = f.computeFeed(user); err != nil {
+			return nil, err
+		}
+		if err := f.followRepository.SetFeed(user.ID, feed, feedCacheTTL); err != nil {
+			logrus.Warnf("cache feed of user %d failed: %v", user.ID, err)
+		}
+	}
+	if len(feed.Items) > limit {
+		feed.Items = feed.Items[:limit]
+	}
+	return feed, nil
+}
+
+// computeFeed 按关注的 tag、匹配的关键词和上榜时间给热搜打分，取得分最高的 MaxFeedSize 条。
+// 没有任何关注时按排名和上榜时间推荐全部热搜
+func (f *feedService) computeFeed(user *model.User) (*model.Feed, error) {
+	tags, err := f.followRepository.ListTags(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := f.followRepository.ListKeywords(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	bookmarks, err := f.followRepository.ListBookmarks(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	followedTags := make(map[uint]bool, len(tags))
+	tagIDs := make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		followedTags[tag.ID] = true
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	keywords := make([]string, 0, len(follows))
+	for _, follow := range follows {
+		keywords = append(keywords, follow.Keyword)
+	}
+	bookmarked := make(map[uint]bool, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		bookmarked[bookmark.HotSearchID] = true
+	}
+
+	var candidates []model.HotSearch
+	if len(tagIDs) == 0 && len(keywords) == 0 {
+		candidates, err = f.hotSearchRepository.List()
+	} else {
+		candidates, err = f.hotSearchRepository.ListForFeed(tagIDs, keywords, feedCandidates)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	items := make([]model.FeedItem, 0, len(candidates))
+	for _, hotSearch := range candidates {
+		item := scoreFeedItem(hotSearch, followedTags, keywords, now)
+		item.Bookmarked = bookmarked[hotSearch.ID]
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Score > items[j].Score
+	})
+	if len(items) > MaxFeedSize {
+		items = items[:MaxFeedSize]
+	}
+	return &model.Feed{Items: items, GeneratedAt: now}, nil
+}
+
+// scoreFeedItem 计算热搜的得分：关注的 tag 和匹配的关键词加分，排名越高加分越多，再按上榜时间衰减
+func scoreFeedItem(hotSearch model.HotSearch, followedTags map[uint]bool, keywords []string, now time.Time) model.FeedItem {
+	item := model.FeedItem{HotSearch: hotSearch, Reasons: make([]string, 0)}
+	if followedTags[hotSearch.TagID] {
+		item.Score += tagWeight
+		item.Reasons = append(item.Reasons, "tag")
+	}
+	title := strings.ToLower(hotSearch.Title)
+	for _, keyword := range keywords {
+		if strings.Contains(title, keyword) {
+			item.Score += keywordWeight
+			item.Reasons = append(item.Reasons, "keyword:"+keyword)
+		}
+	}
+	if hotSearch.Rank > 0 {
+		item.Score += 1 / float64(hotSearch.Rank)
+	}
+	if age := now.Sub(hotSearch.CreatedAt); age > 0 {
+		item.Score *= math.Pow(0.5, float64(age)/float64(feedHalfLife))
+	}
+	return item
+}
+
+func (f *feedService) getTag(id string) (*model.Tag, error) {
+	tid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return f.tagRepository.GetTagByID(uint(tid))
+}
@@ -12,10 +12,12 @@ package service
 
 import (
 	"fmt"
-	"strconv"
 
+	"chitchat4.0/pkg/apierrors"
 	"chitchat4.0/pkg/model"
 	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/utils/patch"
+	"chitchat4.0/pkg/validation"
 )
 
 type groupService struct {
@@ -45,6 +47,10 @@ func NewGroupService(groupRepository repository.GroupRepository, userRepository
  * @return {*}
  */
 func (g *groupService) Create(user *model.User, group *model.Group) (*model.Group, error) {
+	if model.IsSystemGroup(group.Name) {
+		return nil, reservedGroupError(group.Name)
+	}
+	group.ManagedBy = "" // 只有声明式 RBAC 配置文件可以创建它管理的 group
 	group, err := g.groupRepository.Create(user, group)
 	if err != nil {
 		return nil, err
@@ -113,7 +119,7 @@ func (g *groupService) createDefaultRoles(group *model.Group) error {
  * @return {*}
  */
 func (g *groupService) Get(id string) (*model.Group, error) {
-	gid, err := strconv.Atoi(id)
+	gid, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -129,24 +135,77 @@ func (g *groupService) List() ([]model.Group, error) {
 }
 
 func (g *groupService) Update(id string, group *model.Group) (*model.Group, error) {
-	gid, err := strconv.Atoi(id)
+	gid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := g.groupRepository.GetGroupByID(uint(gid))
 	if err != nil {
 		return nil, err
 	}
+	if err := checkGroupName(current.Name, group.Name); err != nil {
+		return nil, err
+	}
 	group.ID = uint(gid)
 	return g.groupRepository.Update(group)
 }
 
-func (g *groupService) Delete(id string) error {
-	gid, err := strconv.Atoi(id)
+// Patch 使用 JSON Merge Patch 或 JSON Patch 修改 group 的部分字段，version 不为 0 时需要和当前版本号一致
+func (g *groupService) Patch(id string, version uint64, patchType string, data []byte) (*model.Group, error) {
+	group, err := g.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && group.ResourceVersion != version {
+		return nil, apierrors.NewPreconditionFailed("group", id)
+	}
+	name := group.Name
+	fields, err := patch.Apply(group, patchType, data, model.GroupPatchFields...)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return group, nil
+	}
+	if err := checkGroupName(name, group.Name); err != nil {
+		return nil, err
+	}
+	// 使用 UpdatedGroup 的校验规则
+	if err := validation.Struct(&model.UpdatedGroup{Name: group.Name, Describe: group.Describe}); err != nil {
+		return nil, err
+	}
+	return g.groupRepository.Patch(group, fields)
+}
+
+// checkGroupName 不能把 group 改名成系统 group，也不能给系统 group 改名
+func checkGroupName(current, name string) error {
+	if current == name {
+		return nil
+	}
+	if model.IsSystemGroup(current) {
+		return apierrors.NewFieldInvalid("name", fmt.Sprintf("system group %s can not be renamed", current))
+	}
+	if model.IsSystemGroup(name) {
+		return reservedGroupError(name)
+	}
+	return nil
+}
+
+func reservedGroupError(name string) error {
+	return apierrors.NewFieldInvalid("name", fmt.Sprintf("group name %s is reserved for system groups", name))
+}
+
+// Delete 删除 group，version 不为 0 时需要和当前版本号一致
+func (g *groupService) Delete(id string, version uint64) error {
+	gid, err := parseID(id)
 	if err != nil {
 		return err
 	}
-	return g.groupRepository.Delete(uint(gid))
+	return g.groupRepository.Delete(uint(gid), version)
 }
 
 func (g *groupService) GetUsers(id string) (model.Users, error) {
-	gid, err := strconv.Atoi(id)
+	gid, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -156,9 +215,9 @@ func (g *groupService) GetUsers(id string) (model.Users, error) {
 func (g *groupService) AddUser(user *model.User, id string) error {
 	// var err error
 	if user.ID == 0 {
-		return fmt.Errorf("%v", "Group AddUser:invaild user info")
+		return apierrors.NewFieldInvalid("id", "Group AddUser:invaild user info")
 	}
-	gid, err := strconv.Atoi(id)
+	gid, err := parseID(id)
 	if err != nil {
 		return err
 	}
@@ -166,12 +225,12 @@ func (g *groupService) AddUser(user *model.User, id string) error {
 }
 
 func (g *groupService) DelUser(gid, uid string) error {
-	groupId, err := strconv.Atoi(gid)
+	groupId, err := parseID(gid)
 	if err != nil {
 		return err
 	}
 
-	userId, err := strconv.Atoi(uid)
+	userId, err := parseID(uid)
 	if err != nil {
 		return err
 	}
@@ -179,19 +238,19 @@ func (g *groupService) DelUser(gid, uid string) error {
 	user := new(model.User)
 	user.ID = uint(userId)
 	if user.ID == 0 {
-		return fmt.Errorf("%v", "Group DelUser:invaild user info")
+		return apierrors.NewFieldInvalid("id", "Group DelUser:invaild user info")
 	}
 
 	return g.groupRepository.DelUser(user, &model.Group{ID: uint(groupId)})
 }
 
 func (g *groupService) AddRole(id, rid string) error {
-	gid, err := strconv.Atoi(id)
+	gid, err := parseID(id)
 	if err != nil {
 		return err
 	}
 
-	roleId, err := strconv.Atoi(rid)
+	roleId, err := parseID(rid)
 	if err != nil {
 		return err
 	}
@@ -200,11 +259,11 @@ func (g *groupService) AddRole(id, rid string) error {
 }
 
 func (g *groupService) DelRole(id, rid string) error {
-	gid, err := strconv.Atoi(id)
+	gid, err := parseID(id)
 	if err != nil {
 		return err
 	}
-	roleId, err := strconv.Atoi(rid)
+	roleId, err := parseID(rid)
 	if err != nil {
 		return err
 	}
@@ -1,25 +1,199 @@
 package service
 
 import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/hotsearch"
 	"chitchat4.0/pkg/model"
 	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/syndication"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/sirupsen/logrus"
 )
 
+const feedDocumentCacheSize = 256 // 缓存的订阅源数量，按 tag、格式和访问地址区分
+
 type hotSearchService struct {
 	hotSearchRepository repository.HotSearchRepository
+	tagRepository       repository.TagRepository
+	commentRepository   repository.CommentRepository
+	reactionRepository  repository.ReactionRepository
+	hub                 *hotsearch.Hub // 推送热搜榜的增量更新
+	alertService        AlertService   // 检查快照并发送热搜提醒
+
+	documents *lru.Cache[string, *syndication.Document] // 生成的订阅源，热搜榜变化后失效
 }
 
-func NewHotSearchService(hotSearchRepository repository.HotSearchRepository) HotSearchService {
+func NewHotSearchService(hotSearchRepository repository.HotSearchRepository, tagRepository repository.TagRepository,
+	commentRepository repository.CommentRepository, reactionRepository repository.ReactionRepository, hub *hotsearch.Hub, alertService AlertService) HotSearchService {
+	documents, _ := lru.New[string, *syndication.Document](feedDocumentCacheSize)
 	return &hotSearchService{
 		hotSearchRepository: hotSearchRepository,
+		tagRepository:       tagRepository,
+		commentRepository:   commentRepository,
+		reactionRepository:  reactionRepository,
+		hub:                 hub,
+		alertService:        alertService,
+		documents:           documents,
 	}
 }
 
+// List 获取全部热搜和评论数、回应数
 func (h *hotSearchService) List() ([]model.HotSearch, error) {
-	hotSearchs := make([]model.HotSearch, 0)
-	return hotSearchs, nil
+	hotSearches, err := h.hotSearchRepository.List()
+	if err != nil {
+		return nil, err
+	}
+	return h.withCounts(hotSearches)
 }
 
 func (h *hotSearchService) Create(tag *model.Tag, hotSearch *model.HotSearch) (*model.HotSearch, error) {
-	return hotSearch, nil
+	return h.hotSearchRepository.Create(tag, hotSearch)
+}
+
+// ListByTag 按排名获取 tag 的热搜榜和评论数、回应数
+func (h *hotSearchService) ListByTag(tagID string) ([]model.HotSearch, error) {
+	tag, err := h.getTag(tagID)
+	if err != nil {
+		return nil, err
+	}
+	hotSearches, err := h.hotSearchRepository.ListByTag(tag.ID)
+	if err != nil {
+		return nil, err
+	}
+	return h.withCounts(hotSearches)
+}
+
+// TagFeed 生成 tag 热搜榜的订阅源，baseURL 是服务的访问地址。
+// 订阅源按 tag、格式和访问地址缓存，热搜榜变化后重新生成；Last-Modified 是热搜榜最后一次变化的时间，
+// ETag 同时由格式和访问地址决定，不同格式或地址的订阅源不会被当作相同的内容
+func (h *hotSearchService) TagFeed(tagID, format, baseURL string) (*syndication.Document, error) {
+	tag, err := h.getTag(tagID)
+	if err != nil {
+		return nil, err
+	}
+	lastModified, err := h.hotSearchRepository.LastModified(tag.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.UpdatedAt.After(lastModified) {
+		lastModified = tag.UpdatedAt
+	}
+
+	key := fmt.Sprintf("%d:%s:%s", tag.ID, format, baseURL)
+	if document, ok := h.documents.Get(key); ok && document.LastModified.Equal(lastModified) {
+		return document, nil
+	}
+
+	hotSearches, err := h.hotSearchRepository.ListByTag(tag.ID)
+	if err != nil {
+		return nil, err
+	}
+	feed := &syndication.Feed{
+		ID:          fmt.Sprintf("urn:chitchat:tag:%d", tag.ID),
+		Title:       tag.Name + " 热搜榜",
+		Description: tag.Name + " 的实时热搜榜，按排名排序",
+		HomeURL:     fmt.Sprintf("%s/api/v1/tags/%d/hotsearches", baseURL, tag.ID),
+		FeedURL:     fmt.Sprintf("%s/api/v1/tags/%d/feed.%s", baseURL, tag.ID, format),
+		Updated:     lastModified,
+		Items:       make([]syndication.Item, 0, len(hotSearches)),
+	}
+	for _, hotSearch := range hotSearches {
+		feed.Items = append(feed.Items, syndication.Item{
+			ID:        fmt.Sprintf("urn:chitchat:hotsearch:%d", hotSearch.ID),
+			Title:     hotSearch.Title,
+			Link:      hotSearch.Link,
+			Summary:   hotSearch.Extra,
+			Published: hotSearch.CreatedAt,
+			Updated:   hotSearch.UpdatedAt,
+		})
+	}
+	body, err := syndication.Render(format, feed)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	document := &syndication.Document{
+		Body:         body,
+		ContentType:  syndication.ContentType(format),
+		ETag:         feedETag(key, lastModified),
+		LastModified: lastModified,
+	}
+	h.documents.Add(key, document)
+	return document, nil
+}
+
+// Snapshot 保存采集器提交的 tag 热搜快照，并把和上一次快照相比的排名变化推送给订阅者，
+// 同时在后台用提醒规则检查快照
+func (h *hotSearchService) Snapshot(tagID string, snapshot *model.HotSearchSnapshot) (*model.HotSearchUpdate, error) {
+	tag, err := h.getTag(tagID)
+	if err != nil {
+		return nil, err
+	}
+	current := snapshot.GetHotSearches(tag.ID)
+	links := make(map[string]bool, len(current))
+	for i, hotSearch := range current {
+		if links[hotSearch.Link] {
+			return nil, apierrors.NewFieldInvalid(fmt.Sprintf("items[%d].link", i), "duplicate link "+hotSearch.Link)
+		}
+		links[hotSearch.Link] = true
+	}
+
+	// 替换前的热搜榜在同一个事务中读取，不使用可能有复制延迟的只读副本
+	previous, err := h.hotSearchRepository.ReplaceSnapshot(tag.ID, current)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.alertService != nil {
+		h.alertService.Evaluate(tag.ID, current)
+	}
+
+	update := hotsearch.Diff(tag.ID, previous, current)
+	if len(update.Changes) == 0 {
+		return update, nil
+	}
+	if err := h.hub.Publish(update); err != nil {
+		// 快照已经保存，推送失败时客户端重新获取榜单即可
+		logrus.Errorf("push hot search update of tag %d failed: %v", tag.ID, err)
+	}
+	return update, nil
+}
+
+// feedETag 根据订阅源的缓存 key 和最后修改时间计算 ETag
+func feedETag(key string, lastModified time.Time) string {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s:%d", key, lastModified.UnixNano())
+	return fmt.Sprintf(`W/"%x"`, hash.Sum64())
+}
+
+// withCounts 填充热搜的评论数和 emoji 回应数
+func (h *hotSearchService) withCounts(hotSearches []model.HotSearch) ([]model.HotSearch, error) {
+	ids := make([]uint, 0, len(hotSearches))
+	for _, hotSearch := range hotSearches {
+		ids = append(ids, hotSearch.ID)
+	}
+	comments, err := h.commentRepository.Counts(model.HotSearchResource, ids)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := h.reactionRepository.Counts(model.HotSearchResource, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hotSearches {
+		hotSearches[i].CommentCount = comments[hotSearches[i].ID]
+		hotSearches[i].Reactions = reactions[hotSearches[i].ID]
+	}
+	return hotSearches, nil
+}
+
+func (h *hotSearchService) getTag(id string) (*model.Tag, error) {
+	tid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return h.tagRepository.GetTagByID(uint(tid))
 }
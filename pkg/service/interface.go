@@ -10,7 +10,10 @@
  */
 package service
 
-import "chitchat4.0/pkg/model"
+import (
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/syndication"
+)
 
 type UserService interface {
 	List() (model.Users, error)
@@ -18,7 +21,8 @@ type UserService interface {
 	Get(string) (*model.User, error)
 	CreateOAuthUser(user *model.User) (*model.User, error)
 	Update(string, *model.User) (*model.User, error)
-	Delete(string) error
+	Patch(id string, version uint64, patchType string, data []byte) (*model.User, error)
+	Delete(id string, version uint64) error
 	Validate(*model.User) error
 	Auth(*model.AuthUser) (*model.User, error)
 	Default(*model.User)
@@ -27,12 +31,43 @@ type UserService interface {
 	DelRole(id, rid string) error
 }
 
+// AccessTokenService 个人访问 token 服务
+type AccessTokenService interface {
+	List(id string) ([]model.AccessToken, error)
+	Create(user *model.User, id string, created *model.CreatedAccessToken) (*model.IssuedAccessToken, error)
+	Delete(id, tid string) error
+	Authenticate(token string) (*model.User, error)
+}
+
+// SessionService 登录会话服务
+type SessionService interface {
+	Create(user *model.User, userAgent, ip string) (*model.Session, error)
+	List(id, current string) ([]model.Session, error)
+	Delete(id, sid string) error
+	DeleteAll(id string) error
+	Validate(uid uint, sid, userAgent, ip string) error
+}
+
+// MFAService 二次验证服务
+type MFAService interface {
+	Enroll(id string) (*model.MFAEnrollment, error)
+	EnrollUser(user *model.User) (*model.MFAEnrollment, error)
+	Activate(id, code string) (*model.MFARecoveryCodes, error)
+	ActivateUser(user *model.User, code string) (*model.MFARecoveryCodes, error)
+	Disable(id, code string) error
+	RegenerateRecoveryCodes(id, code string) (*model.MFARecoveryCodes, error)
+	SetRequired(id string, required bool) error
+	ChallengeRequired(user *model.User) bool
+	Verify(user *model.User, code string) error
+}
+
 type GroupService interface {
 	List() ([]model.Group, error)
 	Create(*model.User, *model.Group) (*model.Group, error)
 	Get(string) (*model.Group, error)
 	Update(string, *model.Group) (*model.Group, error)
-	Delete(string) error
+	Patch(id string, version uint64, patchType string, data []byte) (*model.Group, error)
+	Delete(id string, version uint64) error
 	GetUsers(string) (model.Users, error)
 	AddUser(user *model.User, gid string) error
 	DelUser(gid, uid string) error
@@ -52,12 +87,90 @@ type TagService interface {
 type HotSearchService interface {
 	List() ([]model.HotSearch, error)
 	Create(*model.Tag, *model.HotSearch) (*model.HotSearch, error)
+	ListByTag(tagID string) ([]model.HotSearch, error)                                        // 按排名获取 tag 的热搜榜
+	Snapshot(tagID string, snapshot *model.HotSearchSnapshot) (*model.HotSearchUpdate, error) // 保存采集器的快照并推送排名变化
+	TagFeed(tagID, format, baseURL string) (*syndication.Document, error)                     // 生成 tag 热搜榜的订阅源
 	// Get(string) (*model.HotSearch, error)
 	// Update(string, *model.HotSearch) (*model.HotSearch, error)
 	// Delete(string) error
 	// Validate(*model.HotSearch) error
 }
 
+// CommentService 热搜和话题（tag）的评论、举报，以及热搜、话题和评论的 emoji 回应
+type CommentService interface {
+	List(targetType, targetID, before string, limit int) (*model.CommentPage, error)                             // 分页获取评论楼层
+	Create(user *model.User, targetType, targetID string, created *model.CreatedComment) (*model.Comment, error) // 发表或回复评论
+	Delete(user *model.User, id string) error                                                                    // 删除评论
+	Report(user *model.User, id string, created *model.CreatedCommentReport) error                               // 举报评论
+	ListReports(user *model.User) ([]model.CommentReport, error)                                                 // 待处理的举报
+	DismissReports(user *model.User, id string) error                                                            // 忽略评论的举报
+	React(user *model.User, targetType, targetID, emoji string) error                                            // emoji 回应
+	Unreact(user *model.User, targetType, targetID, emoji string) error                                          // 取消 emoji 回应
+}
+
+// FeedService 关注 tag 和关键词、收藏热搜，以及根据关注计算的个性化热搜
+type FeedService interface {
+	ListFollows(user *model.User) (*model.Follows, error)                                              // 关注的 tag 和关键词
+	FollowTag(user *model.User, tagID string) error                                                    // 关注 tag
+	UnfollowTag(user *model.User, tagID string) error                                                  // 取消关注 tag
+	FollowKeyword(user *model.User, created *model.CreatedKeywordFollow) (*model.KeywordFollow, error) // 关注关键词
+	UnfollowKeyword(user *model.User, id string) error                                                 // 取消关注关键词
+	ListBookmarks(user *model.User) ([]model.Bookmark, error)                                          // 收藏的热搜
+	Bookmark(user *model.User, hotSearchID string) error                                               // 收藏热搜
+	Unbookmark(user *model.User, hotSearchID string) error                                             // 取消收藏热搜
+	Feed(user *model.User, limit int) (*model.Feed, error)                                             // 个性化热搜
+}
+
+// AlertService 热搜提醒规则和站内信，采集器提交快照后检查规则并发送提醒
+type AlertService interface {
+	List(user *model.User) ([]model.AlertRule, error)                                              // 提醒规则列表
+	Create(user *model.User, created *model.CreatedAlertRule) (*model.AlertRule, error)            // 创建提醒规则
+	Get(user *model.User, id string) (*model.AlertRule, error)                                     // 获取提醒规则
+	Update(user *model.User, id string, updated *model.CreatedAlertRule) (*model.AlertRule, error) // 修改提醒规则
+	Delete(user *model.User, id string) error                                                      // 删除提醒规则
+	ListNotifications(user *model.User, unreadOnly bool, limit int) ([]model.Notification, error)  // 站内信
+	MarkRead(user *model.User, id string) error                                                    // 标记站内信已读
+	Evaluate(tagID uint, hotSearches []model.HotSearch)                                            // 在后台检查 tag 的新快照并发送提醒
+}
+
+// WebhookService webhook 服务，资源变更时把事件投递到管理员注册的 URL
+type WebhookService interface {
+	List(user *model.User) ([]model.Webhook, error)                                            // webhook 列表
+	Create(user *model.User, created *model.CreatedWebhook) (*model.Webhook, error)            // 创建 webhook
+	Get(user *model.User, id string) (*model.Webhook, error)                                   // 获取 webhook
+	Update(user *model.User, id string, updated *model.CreatedWebhook) (*model.Webhook, error) // 修改 webhook
+	Delete(user *model.User, id string) error                                                  // 删除 webhook
+	ListDeliveries(user *model.User, id string, limit int) ([]model.WebhookDelivery, error)    // 投递记录
+	GetDelivery(user *model.User, id, deliveryID string) (*model.WebhookDelivery, error)       // 获取投递记录
+	Redeliver(user *model.User, id, deliveryID string) (*model.WebhookDelivery, error)         // 重新投递
+}
+
+// BulkService 批量导入导出 role、group 和 user，用于在环境之间迁移 RBAC 配置
+type BulkService interface {
+	Export(user *model.User, resource string) (*model.BulkDocument, error)                      // 导出，resource 为空时导出全部
+	Import(user *model.User, doc *model.BulkDocument, dryRun bool) (*model.ImportResult, error) // 校验并导入
+}
+
+// RBACFileService 同步声明式 RBAC 配置文件，启动时和管理员重新加载时执行
+type RBACFileService interface {
+	Reconcile(dryRun bool) (*model.RBACPlan, error)                // 读取配置文件并同步
+	Reload(user *model.User, dryRun bool) (*model.RBACPlan, error) // 管理员重新加载配置文件
+}
+
+// RoomService 聊天室服务，消息属于聊天室
+type RoomService interface {
+	List(user *model.User) ([]model.Room, error)                                                    // user 能进入的聊天室和未读数
+	Create(user *model.User, created *model.CreatedRoom) (*model.Room, error)                       // 创建聊天室
+	Get(user *model.User, id string) (*model.Room, error)                                           // 获取聊天室和未读数
+	Delete(user *model.User, id string) error                                                       // 删除聊天室
+	AddMember(user *model.User, id, uid string) error                                               // 邀请 user 加入临时聊天室
+	DelMember(user *model.User, id, uid string) error                                               // 移出临时聊天室的成员
+	ListMessages(user *model.User, id, before string, limit int) (*model.MessagePage, error)        // 分页获取历史消息
+	SendMessage(user *model.User, id string, created *model.CreatedMessage) (*model.Message, error) // 发送消息
+	MarkRead(user *model.User, id, messageID string) error                                          // 标记已读
+	Join(user *model.User, id string) (*model.Room, error)                                          // 检查能否连接实时推送
+}
+
 /**
  * @description: RBACService 基于角色访问控制的服务
  *
@@ -67,7 +180,8 @@ type RBACService interface {
 	Create(role *model.Role) (*model.Role, error)
 	Get(id string) (*model.Role, error)
 	Update(id string, role *model.Role) (*model.Role, error)
-	Delete(id string) error
+	Patch(id string, version uint64, patchType string, data []byte) (*model.Role, error)
+	Delete(id string, version uint64) error
 	ListResources() ([]model.Resource, error)
 	ListOperations() ([]model.Operation, error)
 }
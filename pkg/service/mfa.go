@@ -0,0 +1,212 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authentication"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+)
+
+const (
+	RecoveryCodeCount  = 10 // 每次生成的恢复码数量
+	recoveryCodeLength = 10 // 恢复码字节数（十六进制后长度翻倍）
+
+	maxMFAFailures = 5               // 锁定时间内允许的二次验证失败次数，超过后挑战 token 不再可用
+	mfaLockout     = 5 * time.Minute // 锁定时间，和挑战 token 的有效期相同
+)
+
+// mfaService 二次验证服务
+type mfaService struct {
+	userRepository repository.UserRepository
+}
+
+// NewMFAService 创建一个二次验证服务
+func NewMFAService(userRepository repository.UserRepository) MFAService {
+	return &mfaService{
+		userRepository: userRepository,
+	}
+}
+
+// Enroll 生成新的 TOTP 密钥，验证通过前不会开启二次验证
+func (m *mfaService) Enroll(id string) (*model.MFAEnrollment, error) {
+	user, err := m.getUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return m.EnrollUser(user)
+}
+
+// EnrollUser 给 user 生成新的 TOTP 密钥，已开启二次验证的 user 需要先关闭
+func (m *mfaService) EnrollUser(user *model.User) (*model.MFAEnrollment, error) {
+	if user.MFAEnabled {
+		return nil, &apierrors.Error{Reason: apierrors.ReasonConflict, Message: "mfa already enabled"}
+	}
+	secret, err := authentication.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.MFASecret = secret
+	if err := m.userRepository.UpdateMFA(user); err != nil {
+		return nil, err
+	}
+	return &model.MFAEnrollment{
+		Secret: secret,
+		URI:    authentication.TOTPProvisioningURI(user.Name, secret),
+	}, nil
+}
+
+// Activate 校验一次性密码后开启二次验证，返回恢复码明文
+func (m *mfaService) Activate(id, code string) (*model.MFARecoveryCodes, error) {
+	user, err := m.getUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return m.ActivateUser(user, code)
+}
+
+// ActivateUser 校验 user 的一次性密码后开启二次验证，返回恢复码明文
+func (m *mfaService) ActivateUser(user *model.User, code string) (*model.MFARecoveryCodes, error) {
+	if user.MFAEnabled {
+		return nil, &apierrors.Error{Reason: apierrors.ReasonConflict, Message: "mfa already enabled"}
+	}
+	if user.MFASecret == "" {
+		return nil, apierrors.NewBadRequest("mfa not enrolled")
+	}
+	if err := m.userRepository.AddMFAFailure(user, maxMFAFailures, mfaLockout); err != nil {
+		return nil, err
+	}
+	step, ok := authentication.ValidateTOTP(user.MFASecret, code)
+	if !ok {
+		return nil, apierrors.NewFieldInvalid("code", "invalid mfa code")
+	}
+	if err := m.useTOTPStep(user, step); err != nil {
+		return nil, err
+	}
+
+	user.MFAEnabled = true
+	if err := m.userRepository.UpdateMFA(user); err != nil {
+		return nil, err
+	}
+	return m.generateRecoveryCodes(user)
+}
+
+// Disable 关闭二次验证，需要一次性密码或恢复码
+func (m *mfaService) Disable(id, code string) error {
+	user, err := m.getUserByID(id)
+	if err != nil {
+		return err
+	}
+	if err := m.Verify(user, code); err != nil {
+		return err
+	}
+
+	user.MFAEnabled = false
+	user.MFASecret = ""
+	if err := m.userRepository.UpdateMFA(user); err != nil {
+		return err
+	}
+	return m.userRepository.SetRecoveryCodes(user, nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部失效
+func (m *mfaService) RegenerateRecoveryCodes(id, code string) (*model.MFARecoveryCodes, error) {
+	user, err := m.getUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Verify(user, code); err != nil {
+		return nil, err
+	}
+	return m.generateRecoveryCodes(user)
+}
+
+// SetRequired 设置 user 是否必须开启二次验证
+func (m *mfaService) SetRequired(id string, required bool) error {
+	user, err := m.getUserByID(id)
+	if err != nil {
+		return err
+	}
+	user.MFARequired = required
+	return m.userRepository.UpdateMFA(user)
+}
+
+// ChallengeRequired 判断 user 登录时是否需要二次验证
+func (m *mfaService) ChallengeRequired(user *model.User) bool {
+	return user != nil && (user.MFAEnabled || user.MFARequired)
+}
+
+// Verify 校验一次性密码，不通过时尝试作为恢复码使用。
+// 锁定时间内失败 maxMFAFailures 次后返回 TooManyRequests，通过的一次性密码不能再次使用
+func (m *mfaService) Verify(user *model.User, code string) error {
+	if !user.MFAEnabled {
+		return apierrors.NewBadRequest("mfa not enabled")
+	}
+	if err := m.userRepository.AddMFAFailure(user, maxMFAFailures, mfaLockout); err != nil {
+		return err
+	}
+	if step, ok := authentication.ValidateTOTP(user.MFASecret, code); ok {
+		return m.useTOTPStep(user, step)
+	}
+
+	codes, err := m.userRepository.GetRecoveryCodes(user)
+	if err != nil {
+		return err
+	}
+	hashed := hashRecoveryCode(code)
+	for i := range codes {
+		if subtle.ConstantTimeCompare([]byte(codes[i].Code), []byte(hashed)) == 1 {
+			if err := m.userRepository.UseRecoveryCode(&codes[i]); err != nil {
+				return err
+			}
+			return m.userRepository.ResetMFAFailures(user)
+		}
+	}
+	return apierrors.NewFieldInvalid("code", "invalid mfa code")
+}
+
+// useTOTPStep 记录通过的 TOTP 周期并清除失败次数，周期已经使用过时按失败处理
+func (m *mfaService) useTOTPStep(user *model.User, step int64) error {
+	if err := m.userRepository.UseTOTPStep(user, step); err != nil {
+		return err
+	}
+	return m.userRepository.ResetMFAFailures(user)
+}
+
+func (m *mfaService) generateRecoveryCodes(user *model.User) (*model.MFARecoveryCodes, error) {
+	plain := make([]string, 0, RecoveryCodeCount)
+	codes := make([]model.RecoveryCode, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		buf := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := hex.EncodeToString(buf)
+		plain = append(plain, code)
+		codes = append(codes, model.RecoveryCode{Code: hashRecoveryCode(code)})
+	}
+	if err := m.userRepository.SetRecoveryCodes(user, codes); err != nil {
+		return nil, err
+	}
+	return &model.MFARecoveryCodes{Codes: plain}, nil
+}
+
+func (m *mfaService) getUserByID(id string) (*model.User, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return m.userRepository.GetUserByID(uint(uid))
+}
+
+// hashRecoveryCode 恢复码随机性足够高，使用 sha256 保存即可
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
@@ -11,11 +11,12 @@
 package service
 
 import (
-	"strconv"
-
+	"chitchat4.0/pkg/apierrors"
 	"chitchat4.0/pkg/model"
 	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/utils/patch"
 	"chitchat4.0/pkg/utils/request"
+	"chitchat4.0/pkg/validation"
 )
 
 /**
@@ -47,6 +48,7 @@ func (rbac *rbacService) List() ([]model.Role, error) {
  * @return {*}
  */
 func (rbac *rbacService) Create(role *model.Role) (*model.Role, error) {
+	role.ManagedBy = "" // 只有声明式 RBAC 配置文件可以创建它管理的 role
 	return rbac.rbacRepository.Create(role)
 }
 
@@ -56,7 +58,7 @@ func (rbac *rbacService) Create(role *model.Role) (*model.Role, error) {
  * @return {*}
  */
 func (rbac *rbacService) Get(id string) (*model.Role, error) {
-	rid, err := strconv.Atoi(id)
+	rid, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +66,7 @@ func (rbac *rbacService) Get(id string) (*model.Role, error) {
 }
 
 func (rbac *rbacService) Update(id string, role *model.Role) (*model.Role, error) {
-	rid, err := strconv.Atoi(id)
+	rid, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -72,12 +74,35 @@ func (rbac *rbacService) Update(id string, role *model.Role) (*model.Role, error
 	return rbac.rbacRepository.Update(role)
 }
 
-func (rbac *rbacService) Delete(id string) error {
-	rid, err := strconv.Atoi(id)
+// Patch 使用 JSON Merge Patch 或 JSON Patch 修改 role 的部分字段，version 不为 0 时需要和当前版本号一致
+func (rbac *rbacService) Patch(id string, version uint64, patchType string, data []byte) (*model.Role, error) {
+	role, err := rbac.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && role.ResourceVersion != version {
+		return nil, apierrors.NewPreconditionFailed("role", id)
+	}
+	fields, err := patch.Apply(role, patchType, data, model.RolePatchFields...)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return role, nil
+	}
+	if err := validation.Struct(role); err != nil {
+		return nil, err
+	}
+	return rbac.rbacRepository.Patch(role, fields)
+}
+
+// Delete 删除 role，version 不为 0 时需要和当前版本号一致
+func (rbac *rbacService) Delete(id string, version uint64) error {
+	rid, err := parseID(id)
 	if err != nil {
 		return err
 	}
-	return rbac.rbacRepository.Delete(uint(rid))
+	return rbac.rbacRepository.Delete(uint(rid), version)
 }
 
 func (rbac *rbacService) ListResources() ([]model.Resource, error) {
@@ -95,6 +120,7 @@ func (rbac *rbacService) ListOperations() ([]model.Operation, error) {
 		request.GetOperation,    // get获取单个（详情）操作
 		request.ListOperation,   // list获取列表操作
 		request.DeleteOperation, // delete删除操作
+		request.WatchOperation,  // watch监听变更操作
 		"log",                   // 日志
 		"exec",                  // 执行
 		"proxy",                 // 代理
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"strings"
+	"sync"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/rbacfile"
+	"chitchat4.0/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type rbacFileService struct {
+	path               string // 配置文件路径，为空时没有配置
+	rbacFileRepository repository.RBACFileRepository
+	logger             *logrus.Logger
+
+	mu sync.Mutex // 同一时间只同步一次
+}
+
+// NewRBACFileService 返回同步声明式 RBAC 配置文件的服务，path 为空时没有配置文件
+func NewRBACFileService(path string, rbacFileRepository repository.RBACFileRepository, logger *logrus.Logger) RBACFileService {
+	return &rbacFileService{
+		path:               path,
+		rbacFileRepository: rbacFileRepository,
+		logger:             logger,
+	}
+}
+
+// Reconcile 重新读取配置文件，计算和数据库的差异并记录到日志；有冲突时返回 plan 和 Conflict 错误，
+// 不修改任何对象，否则 dryRun 为 false 时在一个事务中同步
+func (r *rbacFileService) Reconcile(dryRun bool) (*model.RBACPlan, error) {
+	if r.path == "" {
+		return nil, apierrors.NewNotFound("rbac file", nil)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file, err := rbacfile.Load(r.path)
+	if err != nil {
+		return nil, err
+	}
+	state, err := r.rbacFileRepository.State()
+	if err != nil {
+		return nil, err
+	}
+	plan := rbacfile.Plan(file, state)
+	plan.File = r.path
+	plan.DryRun = dryRun
+
+	for i := range plan.Changes {
+		r.logger.Infof("rbac file %s: %s", r.path, rbacfile.Describe(&plan.Changes[i]))
+	}
+	if len(plan.Conflicts) > 0 {
+		for _, conflict := range plan.Conflicts {
+			r.logger.Warnf("rbac file %s: conflict: %s", r.path, conflict)
+		}
+		return plan, &apierrors.Error{Reason: apierrors.ReasonConflict, Message: "rbac file conflicts with the database, nothing is changed: " + strings.Join(plan.Conflicts, "; ")}
+	}
+	if dryRun || len(plan.Changes) == 0 {
+		return plan, nil
+	}
+	if err := r.rbacFileRepository.Apply(plan); err != nil {
+		return plan, err
+	}
+	plan.Applied = true
+	r.logger.Infof("rbac file %s: applied %d changes", r.path, len(plan.Changes))
+	return plan, nil
+}
+
+// Reload 管理员重新同步配置文件，dryRun 为 true 时只返回差异
+func (r *rbacFileService) Reload(user *model.User, dryRun bool) (*model.RBACPlan, error) {
+	if !authorization.IsClusterAdmin(user) {
+		return nil, apierrors.NewForbidden("only cluster admins can reload the rbac file")
+	}
+	return r.Reconcile(dryRun)
+}
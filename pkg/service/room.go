@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/chat"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultMessagePageSize = 50  // 历史消息默认每页数量
+	MaxMessagePageSize     = 100 // 历史消息每页最大数量
+
+	linkPreviewTimeout = 15 * time.Second // 获取一条消息全部链接预览的超时时间
+)
+
+type roomService struct {
+	roomRepository    repository.RoomRepository
+	messageRepository repository.MessageRepository
+	userRepository    repository.UserRepository
+	groupRepository   repository.GroupRepository
+	hub               *chat.Hub            // 推送聊天室事件
+	previews          *chat.PreviewFetcher // 获取链接预览
+}
+
+// NewRoomService 返回聊天室服务
+func NewRoomService(roomRepository repository.RoomRepository, messageRepository repository.MessageRepository, userRepository repository.UserRepository,
+	groupRepository repository.GroupRepository, hub *chat.Hub, previews *chat.PreviewFetcher) RoomService {
+	return &roomService{
+		roomRepository:    roomRepository,
+		messageRepository: messageRepository,
+		userRepository:    userRepository,
+		groupRepository:   groupRepository,
+		hub:               hub,
+		previews:          previews,
+	}
+}
+
+// List 获取 user 能进入的聊天室和未读消息数
+func (r *roomService) List(user *model.User) ([]model.Room, error) {
+	groupIDs := make([]uint, 0, len(user.Groups))
+	for _, g := range user.Groups {
+		groupIDs = append(groupIDs, g.ID)
+	}
+	rooms, err := r.roomRepository.ListForUser(user.ID, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	roomIDs := make([]uint, 0, len(rooms))
+	for _, room := range rooms {
+		roomIDs = append(roomIDs, room.ID)
+	}
+	counts, err := r.roomRepository.UnreadCounts(user.ID, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rooms {
+		rooms[i].UnreadCount = counts[rooms[i].ID]
+	}
+	return rooms, nil
+}
+
+// Create 创建聊天室。绑定 group 时 user 必须在 group 中；临时聊天室的创建者自动成为成员
+func (r *roomService) Create(user *model.User, created *model.CreatedRoom) (*model.Room, error) {
+	room := created.GetRoom(user.ID)
+	if room.IsGroupRoom() {
+		if len(created.Members) > 0 {
+			return nil, apierrors.NewFieldInvalid("members", "members of group room are managed by its group")
+		}
+		group, err := r.groupRepository.GetGroupByID(*room.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		if !inGroup(user, group.ID) && !authorization.IsClusterAdmin(user) {
+			return nil, apierrors.NewForbidden("user is not in group " + group.Name)
+		}
+		return r.roomRepository.Create(room, nil)
+	}
+
+	members := []uint{user.ID}
+	seen := map[uint]bool{user.ID: true}
+	for _, uid := range created.Members {
+		if seen[uid] {
+			continue
+		}
+		if _, err := r.userRepository.GetUserByID(uid); err != nil {
+			return nil, err
+		}
+		seen[uid] = true
+		members = append(members, uid)
+	}
+	return r.roomRepository.Create(room, members)
+}
+
+// Get 获取聊天室和 user 的未读消息数
+func (r *roomService) Get(user *model.User, id string) (*model.Room, error) {
+	room, err := r.getRoom(user, id)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := r.roomRepository.UnreadCounts(user.ID, []uint{room.ID})
+	if err != nil {
+		return nil, err
+	}
+	room.UnreadCount = counts[room.ID]
+	return room, nil
+}
+
+// Delete 删除聊天室，只有创建者和管理员可以删除
+func (r *roomService) Delete(user *model.User, id string) error {
+	room, err := r.getRoom(user, id)
+	if err != nil {
+		return err
+	}
+	if room.CreatorID != user.ID && !authorization.IsClusterAdmin(user) {
+		return apierrors.NewForbidden("only the creator can delete room " + room.Name)
+	}
+	return r.roomRepository.Delete(room.ID)
+}
+
+// AddMember 邀请 user 加入临时聊天室，成员都可以邀请
+func (r *roomService) AddMember(user *model.User, id, uid string) error {
+	room, err := r.getAdHocRoom(user, id)
+	if err != nil {
+		return err
+	}
+	memberID, err := parseID(uid)
+	if err != nil {
+		return err
+	}
+	if _, err := r.userRepository.GetUserByID(uint(memberID)); err != nil {
+		return err
+	}
+	return r.roomRepository.AddMember(room.ID, uint(memberID))
+}
+
+// DelMember 把 user 移出临时聊天室，成员可以自己退出，创建者和管理员可以移出其他成员
+func (r *roomService) DelMember(user *model.User, id, uid string) error {
+	room, err := r.getAdHocRoom(user, id)
+	if err != nil {
+		return err
+	}
+	memberID, err := parseID(uid)
+	if err != nil {
+		return err
+	}
+	if uint(memberID) != user.ID && room.CreatorID != user.ID && !authorization.IsClusterAdmin(user) {
+		return apierrors.NewForbidden("only the creator can remove other members")
+	}
+	return r.roomRepository.DelMember(room.ID, uint(memberID))
+}
+
+// ListMessages 分页获取历史消息，before 为空时从最新的消息开始
+func (r *roomService) ListMessages(user *model.User, id, before string, limit int) (*model.MessagePage, error) {
+	room, err := r.getRoom(user, id)
+	if err != nil {
+		return nil, err
+	}
+	var beforeID uint64
+	if before != "" {
+		if beforeID, err = strconv.ParseUint(before, 10, 32); err != nil {
+			return nil, apierrors.NewFieldInvalid("before", "invalid message id "+strconv.Quote(before))
+		}
+	}
+	if limit <= 0 {
+		limit = DefaultMessagePageSize
+	}
+	if limit > MaxMessagePageSize {
+		limit = MaxMessagePageSize
+	}
+
+	messages, err := r.messageRepository.List(room.ID, uint(beforeID), limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &model.MessagePage{Items: messages}
+	if len(messages) == limit {
+		page.NextBefore = messages[len(messages)-1].ID
+	}
+	return page, nil
+}
+
+// SendMessage 发送消息并推送给聊天室的客户端，消息中的链接在后台获取预览后再推送一次
+func (r *roomService) SendMessage(user *model.User, id string, created *model.CreatedMessage) (*model.Message, error) {
+	room, err := r.getRoom(user, id)
+	if err != nil {
+		return nil, err
+	}
+	message, err := r.messageRepository.Create(&model.Message{
+		RoomID:   room.ID,
+		SenderID: user.ID,
+		Content:  created.Content,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 自己发送的消息视为已读
+	if err := r.roomRepository.MarkRead(room.ID, user.ID, message.ID); err != nil {
+		logrus.Warnf("mark message %d as read failed: %v", message.ID, err)
+	}
+	r.publish(chat.EventMessage, message)
+
+	if links := chat.ExtractLinks(message.Content); len(links) > 0 && r.previews != nil {
+		go r.fetchPreviews(message.ID, room.ID, links)
+	}
+	return message, nil
+}
+
+// MarkRead 把 user 的已读位置移动到 messageID，messageID 为空时标记全部已读
+func (r *roomService) MarkRead(user *model.User, id, messageID string) error {
+	room, err := r.getRoom(user, id)
+	if err != nil {
+		return err
+	}
+	var last uint
+	if messageID == "" {
+		if last, err = r.messageRepository.LastID(room.ID); err != nil {
+			return err
+		}
+	} else {
+		mid, err := parseID(messageID)
+		if err != nil {
+			return err
+		}
+		message, err := r.messageRepository.GetMessageByID(uint(mid))
+		if err != nil {
+			return err
+		}
+		if message.RoomID != room.ID {
+			return apierrors.NewNotFound("message", messageID)
+		}
+		last = message.ID
+	}
+	if last == 0 {
+		return nil
+	}
+	return r.roomRepository.MarkRead(room.ID, user.ID, last)
+}
+
+// Join 检查 user 能否连接聊天室的实时推送，返回聊天室
+func (r *roomService) Join(user *model.User, id string) (*model.Room, error) {
+	return r.getRoom(user, id)
+}
+
+// fetchPreviews 获取消息中链接的预览，保存后推送消息更新
+func (r *roomService) fetchPreviews(messageID, roomID uint, links []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), linkPreviewTimeout)
+	defer cancel()
+
+	previews := make([]model.LinkPreview, 0, len(links))
+	for _, link := range links {
+		preview, err := r.previews.Fetch(ctx, link)
+		if err != nil {
+			logrus.Debugf("fetch link preview of %s failed: %v", link, err)
+			continue
+		}
+		previews = append(previews, *preview)
+	}
+	if len(previews) == 0 {
+		return
+	}
+	message, err := r.messageRepository.AddLinkPreviews(messageID, previews)
+	if err != nil {
+		logrus.Errorf("save link previews of message %d in room %d failed: %v", messageID, roomID, err)
+		return
+	}
+	r.publish(chat.EventMessageUpdated, message)
+}
+
+func (r *roomService) publish(eventType string, message *model.Message) {
+	if r.hub == nil {
+		return
+	}
+	if err := r.hub.Publish(&chat.Event{Type: eventType, RoomID: message.RoomID, Message: message}); err != nil {
+		logrus.Errorf("push message %d of room %d failed: %v", message.ID, message.RoomID, err)
+	}
+}
+
+// getRoom 获取 user 能进入的聊天室：group 聊天室需要在 group 中，临时聊天室需要是成员，管理员可以进入全部聊天室
+func (r *roomService) getRoom(user *model.User, id string) (*model.Room, error) {
+	rid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	room, err := r.roomRepository.GetRoomByID(uint(rid))
+	if err != nil {
+		return nil, err
+	}
+	if authorization.IsClusterAdmin(user) {
+		return room, nil
+	}
+	if room.IsGroupRoom() {
+		if !inGroup(user, *room.GroupID) {
+			return nil, apierrors.NewForbidden("user is not a member of room " + room.Name)
+		}
+		return room, nil
+	}
+	ok, err := r.roomRepository.IsMember(room.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apierrors.NewForbidden("user is not a member of room " + room.Name)
+	}
+	return room, nil
+}
+
+// getAdHocRoom 获取临时聊天室，group 聊天室的成员由 group 管理
+func (r *roomService) getAdHocRoom(user *model.User, id string) (*model.Room, error) {
+	room, err := r.getRoom(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if room.IsGroupRoom() {
+		return nil, apierrors.NewBadRequest("members of room " + room.Name + " are managed by its group")
+	}
+	return room, nil
+}
+
+// inGroup 判断 user 是不是在 group 中
+func inGroup(user *model.User, gid uint) bool {
+	for _, g := range user.Groups {
+		if g.ID == gid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	sessionIDLength    = 16          // 会话 ID 的字节数
+	sessionTouchPeriod = time.Minute // 最后活跃时间的更新间隔
+)
+
+// sessionService 登录会话服务
+type sessionService struct {
+	sessionRepository repository.SessionRepository
+	expireDuration    time.Duration // 会话有效期，与 token 有效期一致
+}
+
+// NewSessionService 创建一个登录会话服务
+func NewSessionService(sessionRepository repository.SessionRepository, expireDuration time.Duration) SessionService {
+	return &sessionService{
+		sessionRepository: sessionRepository,
+		expireDuration:    expireDuration,
+	}
+}
+
+// Create 签发 token 前创建会话，记录设备和 IP
+func (s *sessionService) Create(user *model.User, userAgent, ip string) (*model.Session, error) {
+	buf := make([]byte, sessionIDLength)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &model.Session{
+		ID:         hex.EncodeToString(buf),
+		UserId:     user.ID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.expireDuration),
+	}
+	if err := s.sessionRepository.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// List 获取 user 的会话列表，current 是当前请求使用的会话
+func (s *sessionService) List(id, current string) ([]model.Session, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessionRepository.List(uint(uid))
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	return sessions, nil
+}
+
+// Delete 撤销会话
+func (s *sessionService) Delete(id, sid string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return sessionError(s.sessionRepository.Delete(uint(uid), sid))
+}
+
+// DeleteAll 撤销 user 的全部会话
+func (s *sessionService) DeleteAll(id string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return sessionError(s.sessionRepository.DeleteAll(uint(uid)))
+}
+
+// sessionError redis 禁用时不保存会话，撤销会话返回错误，不能让调用方误以为 token 已经失效
+func sessionError(err error) error {
+	if errors.Is(err, database.RedisDisableError) {
+		return apierrors.NewNotImplemented("sessions cannot be revoked when redis is disabled, tokens stay valid until they expire")
+	}
+	return err
+}
+
+// Validate 校验会话是否被撤销，并更新最后活跃时间；
+// redis 禁用时无法记录会话，直接通过，token 在过期前一直有效
+func (s *sessionService) Validate(uid uint, sid, userAgent, ip string) error {
+	session, err := s.sessionRepository.Get(uid, sid)
+	if errors.Is(err, database.RedisDisableError) {
+		return nil
+	}
+	if apierrors.IsNotFound(err) {
+		return apierrors.NewUnauthenticated("session revoked")
+	}
+	if err != nil {
+		return err
+	}
+	if session.Expired() {
+		return apierrors.NewUnauthenticated("session expired")
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) > sessionTouchPeriod || session.IP != ip {
+		session.LastSeenAt = now
+		session.UserAgent = userAgent
+		session.IP = ip
+		if err := s.sessionRepository.Update(session); err != nil {
+			logrus.Warnf("failed to update session %s last seen time: %v", sid, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	accessTokenLength      = 20          // token 随机部分的字节数
+	accessTokenTouchPeriod = time.Minute // 最后使用时间的更新间隔，避免每个请求都写数据库
+)
+
+// accessTokenService 个人访问 token 服务
+type accessTokenService struct {
+	tokenRepository repository.AccessTokenRepository
+	userRepository  repository.UserRepository
+}
+
+// NewAccessTokenService 创建一个访问 token 服务
+func NewAccessTokenService(tokenRepository repository.AccessTokenRepository, userRepository repository.UserRepository) AccessTokenService {
+	return &accessTokenService{
+		tokenRepository: tokenRepository,
+		userRepository:  userRepository,
+	}
+}
+
+// List 获取 user 的访问 token 列表
+func (a *accessTokenService) List(id string) ([]model.AccessToken, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return a.tokenRepository.List(uint(uid))
+}
+
+// Create user 给 id 指定的 user 创建访问 token，绑定的角色必须是两个 user 角色（包括所在 group 的角色）的子集，
+// 管理员不能通过给其他 user 创建 token 获得自己没有的角色
+func (a *accessTokenService) Create(user *model.User, id string, created *model.CreatedAccessToken) (*model.IssuedAccessToken, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if created.Name == "" {
+		return nil, apierrors.NewFieldInvalid("name", "token name is empty")
+	}
+	if len(created.RoleIds) == 0 {
+		return nil, apierrors.NewFieldInvalid("roleIds", "token must be bound to at least one role")
+	}
+	if created.ExpiresAt != nil && created.ExpiresAt.Before(time.Now()) {
+		return nil, apierrors.NewFieldInvalid("expiresAt", "token expiry is in the past")
+	}
+
+	owner, err := a.userRepository.GetUserByID(uint(uid))
+	if err != nil {
+		return nil, err
+	}
+	owned, held := ownedRoles(owner), ownedRoles(user)
+	roles := make([]model.Role, 0, len(created.RoleIds))
+	for _, rid := range created.RoleIds {
+		role, ok := owned[rid]
+		if !ok {
+			return nil, apierrors.NewForbidden(fmt.Sprintf("role %d is not owned by user %s", rid, owner.Name))
+		}
+		if _, ok := held[rid]; !ok {
+			return nil, apierrors.NewForbidden(fmt.Sprintf("role %d is not owned by user %s", rid, user.Name))
+		}
+		roles = append(roles, role)
+	}
+
+	buf := make([]byte, accessTokenLength)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	plain := model.AccessTokenPrefix + hex.EncodeToString(buf)
+
+	token, err := a.tokenRepository.Create(&model.AccessToken{
+		Name:      created.Name,
+		UserId:    owner.ID,
+		Prefix:    plain[:len(model.AccessTokenPrefix)+model.AccessTokenDisplayPrefixLen],
+		Hash:      hashAccessToken(plain),
+		Roles:     roles,
+		ExpiresAt: created.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.IssuedAccessToken{AccessToken: *token, Token: plain}, nil
+}
+
+// Delete 删除 user 的访问 token
+func (a *accessTokenService) Delete(id, tid string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	tokenId, err := parseID(tid)
+	if err != nil {
+		return err
+	}
+	return a.tokenRepository.Delete(uint(uid), uint(tokenId))
+}
+
+// Authenticate 校验访问 token，返回的 user 只拥有 token 绑定的角色
+func (a *accessTokenService) Authenticate(plain string) (*model.User, error) {
+	if !IsAccessToken(plain) {
+		return nil, apierrors.NewUnauthenticated("invalid access token")
+	}
+	token, err := a.tokenRepository.GetByHash(hashAccessToken(plain))
+	if err != nil {
+		return nil, apierrors.NewUnauthenticated("invalid access token")
+	}
+	if token.Expired() {
+		return nil, apierrors.NewUnauthenticated(fmt.Sprintf("access token %s expired", token.Name))
+	}
+
+	user, err := a.userRepository.GetUserByID(token.UserId)
+	if err != nil {
+		return nil, err
+	}
+	// token 的权限只来自绑定的角色，user 已经失去的角色不再生效
+	owned := ownedRoles(user)
+	roles := make([]model.Role, 0, len(token.Roles))
+	for _, role := range token.Roles {
+		if _, ok := owned[role.ID]; ok {
+			roles = append(roles, role)
+		}
+	}
+	user.Roles = roles
+	user.Groups = nil
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > accessTokenTouchPeriod {
+		if err := a.tokenRepository.Touch(token, now); err != nil {
+			logrus.Warnf("failed to update access token %d last used time: %v", token.ID, err)
+		}
+	}
+	return user, nil
+}
+
+// ownedRoles 返回 user 的全部角色，包括所在 group 的角色
+func ownedRoles(user *model.User) map[uint]model.Role {
+	owned := make(map[uint]model.Role)
+	for _, role := range user.Roles {
+		owned[role.ID] = role
+	}
+	for _, g := range user.Groups {
+		for _, role := range g.Roles {
+			owned[role.ID] = role
+		}
+	}
+	return owned
+}
+
+// IsAccessToken 判断是否是个人访问 token（而不是 JWT）
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, model.AccessTokenPrefix)
+}
+
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,18 +1,18 @@
 package service
 
 import (
-	"errors"
 	"fmt"
-	"strconv"
 
+	"chitchat4.0/pkg/apierrors"
 	"chitchat4.0/pkg/model"
 	"chitchat4.0/pkg/repository"
-	"github.com/jinzhu/gorm"
+	"chitchat4.0/pkg/utils/patch"
+	"chitchat4.0/pkg/validation"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const (
-	MinPasswordLength = 6 // 密码的长度
+	MinPasswordLength = validation.MinPasswordLength // 密码的长度
 )
 
 // userService 用户服务结构模型
@@ -41,6 +41,11 @@ func (u *userService) Get(id string) (*model.User, error) {
 
 // Create 实现 Create user 的服务
 func (u *userService) Create(user *model.User) (*model.User, error) {
+	if user.ServiceAccount {
+		// 服务账号不能使用密码登录
+		user.Password = ""
+		return u.userRepository.Create(user)
+	}
 	password, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -56,7 +61,7 @@ func (u *userService) Update(id string, new *model.User) (*model.User, error) {
 		return nil, err
 	}
 	if new.ID != 0 && old.ID != new.ID {
-		return nil, fmt.Errorf("update user id %s not match（不匹配）", id)
+		return nil, apierrors.NewFieldInvalid("id", fmt.Sprintf("update user id %s not match（不匹配）", id))
 	}
 	new.ID = old.ID
 
@@ -70,24 +75,52 @@ func (u *userService) Update(id string, new *model.User) (*model.User, error) {
 	return u.userRepository.Update(new)
 }
 
-func (u *userService) Delete(id string) error {
+// Patch 使用 JSON Merge Patch 或 JSON Patch 修改 user 的部分字段，version 不为 0 时需要和当前版本号一致
+func (u *userService) Patch(id string, version uint64, patchType string, data []byte) (*model.User, error) {
+	user, err := u.getUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && user.ResourceVersion != version {
+		return nil, apierrors.NewPreconditionFailed("user", id)
+	}
+	fields, err := patch.Apply(user, patchType, data, model.UserPatchFields...)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return user, nil
+	}
+	// 使用 UpdatedUser 的校验规则
+	if err := validation.Struct(&model.UpdatedUser{Name: user.Name, Email: user.Email}); err != nil {
+		return nil, err
+	}
+	return u.userRepository.Patch(user, fields)
+}
+
+// Delete 删除 user，version 不为 0 时需要和当前版本号一致
+func (u *userService) Delete(id string, version uint64) error {
 	user, err := u.getUser(id)
 	if err != nil {
 		return err
 	}
+	user.ResourceVersion = version
 	return u.userRepository.Delete(user)
 }
 
 // Validate 验证用户数据
 func (u *userService) Validate(user *model.User) error {
 	if user == nil {
-		return errors.New("user 是空的")
+		return apierrors.NewValidation("user 是空的")
 	}
 	if user.Name == "" {
-		return errors.New("user 中 name 是空的")
+		return apierrors.NewFieldInvalid("name", "user 中 name 是空的")
+	}
+	if user.ServiceAccount {
+		return nil
 	}
 	if len(user.Password) < MinPasswordLength {
-		return fmt.Errorf("密码长度不能小于%d", MinPasswordLength)
+		return apierrors.NewFieldInvalid("password", fmt.Sprintf("密码长度不能小于%d", MinPasswordLength))
 	}
 	return nil
 }
@@ -105,16 +138,22 @@ func (u *userService) Default(user *model.User) {
 // Auth() 授权，通过接收到的参数实现登录验证的服务
 func (u *userService) Auth(auser *model.AuthUser) (*model.User, error) {
 	if auser == nil || auser.Name == "" || auser.Password == "" {
-		return nil, fmt.Errorf("name or password is empty")
+		return nil, apierrors.NewValidation("name or password is empty")
 	}
-	// 通过name查询user是否存在
+	// 通过name查询user是否存在，不存在时不区分错误，避免泄露用户名
 	user, err := u.userRepository.GetUserByName(auser.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, apierrors.NewUnauthenticated("invalid name or password")
+	}
 	if err != nil {
 		return nil, err
 	}
+	if user.ServiceAccount {
+		return nil, apierrors.NewForbidden(fmt.Sprintf("service account %s can not login interactively", user.Name))
+	}
 	// 数据库用户密码和登录用户密码进行对比
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(auser.Password)); err != nil {
-		return nil, err
+		return nil, apierrors.NewUnauthenticated("invalid name or password")
 	}
 	user.Password = ""
 	return user, nil
@@ -123,14 +162,14 @@ func (u *userService) Auth(auser *model.AuthUser) (*model.User, error) {
 // 第三方登录
 func (u *userService) CreateOAuthUser(user *model.User) (*model.User, error) {
 	if (len(user.AuthInfos)) == 0 {
-		return nil, fmt.Errorf("empty auth info")
+		return nil, apierrors.NewValidation("empty auth info")
 	}
 
 	authInfo := user.AuthInfos[0]
 	// GetUserByAuthID 先查询 authInfo 再查询 user
 	old, err := u.userRepository.GetUserByAuthID(authInfo.AuthType, authInfo.AuthId)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound { // ErrRecordNotFound返回“记录未找到错误”。
+		if apierrors.IsNotFound(err) { // 记录未找到
 			return u.userRepository.Create(user) //未找到user,则使用 第三方信息注册
 		}
 		return nil, err
@@ -140,7 +179,7 @@ func (u *userService) CreateOAuthUser(user *model.User) (*model.User, error) {
 
 // getUserByID 通过ID获取用户的服务，接收用户id后调用user仓库
 func (u *userService) getUserByID(id string) (*model.User, error) {
-	uid, err := strconv.Atoi(id)
+	uid, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +188,7 @@ func (u *userService) getUserByID(id string) (*model.User, error) {
 }
 
 func (u *userService) getUser(id string) (*model.User, error) {
-	uid, err := strconv.Atoi(id)
+	uid, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -165,11 +204,11 @@ func (u *userService) GetGroups(id string) ([]model.Group, error) {
 }
 
 func (u *userService) AddRole(id, rid string) error {
-	uid, err := strconv.Atoi(id)
+	uid, err := parseID(id)
 	if err != nil {
 		return err
 	}
-	roleId, err := strconv.Atoi(rid)
+	roleId, err := parseID(rid)
 	if err != nil {
 		return err
 	}
@@ -178,13 +217,13 @@ func (u *userService) AddRole(id, rid string) error {
 }
 
 func (u *userService) DelRole(id, rid string) error {
-	uid, err := strconv.Atoi(id)
+	uid, err := parseID(id)
 	if err != nil {
-		return nil
+		return err
 	}
-	roleId, err := strconv.Atoi(rid)
+	roleId, err := parseID(rid)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	return u.userRepository.DelRole(&model.Role{ID: uint(roleId)}, &model.User{ID: uint(uid)})
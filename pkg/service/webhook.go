@@ -0,0 +1,219 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/authorization"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/webhook"
+)
+
+const (
+	DefaultDeliveryPageSize = 50  // 投递记录默认返回的数量
+	MaxDeliveryPageSize     = 200 // 投递记录最多返回的数量
+
+	webhookSecretBytes = 32 // 生成的签名密钥的字节数
+)
+
+type webhookService struct {
+	webhookRepository repository.WebhookRepository
+	dispatcher        *webhook.Dispatcher
+}
+
+// NewWebhookService 返回 webhook 服务，只有管理员可以使用
+func NewWebhookService(webhookRepository repository.WebhookRepository, dispatcher *webhook.Dispatcher) WebhookService {
+	return &webhookService{
+		webhookRepository: webhookRepository,
+		dispatcher:        dispatcher,
+	}
+}
+
+// List 获取全部 webhook，不返回签名密钥
+func (w *webhookService) List(user *model.User) ([]model.Webhook, error) {
+	if err := checkWebhookAdmin(user); err != nil {
+		return nil, err
+	}
+	hooks, err := w.webhookRepository.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+// Create 创建 webhook，没有指定签名密钥时生成一个，密钥只在创建时返回
+func (w *webhookService) Create(user *model.User, created *model.CreatedWebhook) (*model.Webhook, error) {
+	if err := checkWebhookAdmin(user); err != nil {
+		return nil, err
+	}
+	hook := created.GetWebhook()
+	if err := validateWebhook(hook); err != nil {
+		return nil, err
+	}
+	if hook.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		hook.Secret = secret
+	}
+	return w.webhookRepository.Create(hook)
+}
+
+// Get 获取 webhook，不返回签名密钥
+func (w *webhookService) Get(user *model.User, id string) (*model.Webhook, error) {
+	hook, err := w.getWebhook(user, id)
+	if err != nil {
+		return nil, err
+	}
+	hook.Secret = ""
+	return hook, nil
+}
+
+// Update 修改 webhook，签名密钥为空时不修改，修改密钥时返回新的密钥
+func (w *webhookService) Update(user *model.User, id string, updated *model.CreatedWebhook) (*model.Webhook, error) {
+	old, err := w.getWebhook(user, id)
+	if err != nil {
+		return nil, err
+	}
+	hook := updated.GetWebhook()
+	hook.ID, hook.BaseModel = old.ID, old.BaseModel
+	if err := validateWebhook(hook); err != nil {
+		return nil, err
+	}
+	rotated := hook.Secret != ""
+	if !rotated {
+		hook.Secret = old.Secret
+	}
+	if hook, err = w.webhookRepository.Update(hook); err != nil {
+		return nil, err
+	}
+	if !rotated {
+		hook.Secret = ""
+	}
+	return hook, nil
+}
+
+// Delete 删除 webhook 和它的投递记录
+func (w *webhookService) Delete(user *model.User, id string) error {
+	hook, err := w.getWebhook(user, id)
+	if err != nil {
+		return err
+	}
+	return w.webhookRepository.Delete(hook.ID)
+}
+
+// ListDeliveries 获取 webhook 最近的投递记录
+func (w *webhookService) ListDeliveries(user *model.User, id string, limit int) ([]model.WebhookDelivery, error) {
+	hook, err := w.getWebhook(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultDeliveryPageSize
+	}
+	if limit > MaxDeliveryPageSize {
+		limit = MaxDeliveryPageSize
+	}
+	return w.webhookRepository.ListDeliveries(hook.ID, limit)
+}
+
+// GetDelivery 获取 webhook 的投递记录
+func (w *webhookService) GetDelivery(user *model.User, id, deliveryID string) (*model.WebhookDelivery, error) {
+	hook, err := w.getWebhook(user, id)
+	if err != nil {
+		return nil, err
+	}
+	did, err := parseID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return w.webhookRepository.GetDelivery(hook.ID, uint(did))
+}
+
+// Redeliver 用原来的请求体重新投递，创建一条新的投递记录并马上投递
+func (w *webhookService) Redeliver(user *model.User, id, deliveryID string) (*model.WebhookDelivery, error) {
+	original, err := w.GetDelivery(user, id, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := []model.WebhookDelivery{{
+		WebhookID:     original.WebhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  &original.ID,
+	}}
+	if err := w.webhookRepository.CreateDeliveries(deliveries); err != nil {
+		return nil, err
+	}
+	w.dispatcher.Wake()
+	return &deliveries[0], nil
+}
+
+func (w *webhookService) getWebhook(user *model.User, id string) (*model.Webhook, error) {
+	if err := checkWebhookAdmin(user); err != nil {
+		return nil, err
+	}
+	wid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return w.webhookRepository.GetWebhookByID(uint(wid))
+}
+
+func checkWebhookAdmin(user *model.User) error {
+	if !authorization.IsClusterAdmin(user) {
+		return apierrors.NewForbidden("only cluster admins can manage webhooks")
+	}
+	return nil
+}
+
+// validateWebhook 检查 URL 和订阅的事件，事件格式：*、<resource>.* 或 <resource>.<added|modified|deleted>
+func validateWebhook(hook *model.Webhook) error {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return apierrors.NewFieldInvalid("url", "webhook requires a http or https url")
+	}
+	for _, event := range hook.Events {
+		if event == "*" {
+			continue
+		}
+		resource, eventType, _ := strings.Cut(event, ".")
+		if !validWebhookResource(resource) {
+			return apierrors.NewFieldInvalid("events", "unknown resource in event "+event)
+		}
+		switch eventType {
+		case "*", "added", "modified", "deleted":
+		default:
+			return apierrors.NewFieldInvalid("events", "unknown event type in event "+event)
+		}
+	}
+	return nil
+}
+
+func validWebhookResource(resource string) bool {
+	for _, r := range model.WebhookEventResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,252 @@
+// Package syndication 生成 RSS 2.0、Atom 1.0 和 JSON Feed 1.1 格式的订阅源
+package syndication
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// 订阅源的格式
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+// Formats 支持的订阅源格式
+var Formats = []string{FormatRSS, FormatAtom, FormatJSON}
+
+const (
+	generator       = "chitchat"
+	jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+	atomNamespace   = "http://www.w3.org/2005/Atom"
+)
+
+// Feed 和格式无关的订阅源
+type Feed struct {
+	ID          string // 唯一且不变的 IRI，用作 Atom 的 id
+	Title       string
+	Description string
+	HomeURL     string // 订阅源对应的页面
+	FeedURL     string // 订阅源自身的地址
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item 订阅源中的一条
+type Item struct {
+	ID        string // 唯一且不变的 IRI，用作 guid 和 id
+	Title     string
+	Link      string
+	Summary   string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Document 生成的订阅源和用于条件请求的元数据
+type Document struct {
+	Body         []byte
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// mediaTypes 格式对应的媒体类型
+var mediaTypes = map[string]string{
+	FormatRSS:  "application/rss+xml",
+	FormatAtom: "application/atom+xml",
+	FormatJSON: "application/feed+json",
+}
+
+// ContentType 返回格式对应的 Content-Type
+func ContentType(format string) string {
+	return mediaTypes[format] + "; charset=utf-8"
+}
+
+// Render 按格式生成订阅源
+func Render(format string, feed *Feed) ([]byte, error) {
+	switch format {
+	case FormatRSS:
+		return renderXML(newRSS(feed))
+	case FormatAtom:
+		return renderXML(newAtom(feed))
+	case FormatJSON:
+		return json.Marshal(newJSONFeed(feed))
+	}
+	return nil, fmt.Errorf("unsupported feed format %q", format)
+}
+
+func renderXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// RSS 2.0，https://www.rssboard.org/rss-specification
+type rss struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomXMLNS string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      atomLink  `xml:"atom:link"` // 推荐的 self 链接
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+func newRSS(feed *Feed) *rss {
+	items := make([]rssItem, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		items = append(items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Summary,
+			GUID:        rssGUID{Value: item.ID},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return &rss{
+		Version:   "2.0",
+		AtomXMLNS: atomNamespace,
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.HomeURL,
+			Description:   feed.Description,
+			AtomLink:      atomLink{Href: feed.FeedURL, Rel: "self", Type: mediaTypes[FormatRSS]},
+			LastBuildDate: feed.Updated.UTC().Format(time.RFC1123Z),
+			Generator:     generator,
+			Items:         items,
+		},
+	}
+}
+
+// Atom 1.0，RFC 4287
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	XMLNS     string      `xml:"xmlns,attr"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Author    atomAuthor  `xml:"author"` // 条目没有作者，必须在订阅源上指定
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID        string   `xml:"id"`
+	Title     string   `xml:"title"`
+	Link      atomLink `xml:"link"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+	Summary   string   `xml:"summary,omitempty"`
+}
+
+func newAtom(feed *Feed) *atomFeed {
+	entries := make([]atomEntry, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		entries = append(entries, atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Link:      atomLink{Href: item.Link, Rel: "alternate"},
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+			Summary:   item.Summary,
+		})
+	}
+	return &atomFeed{
+		XMLNS:    atomNamespace,
+		ID:       feed.ID,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  feed.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: feed.HomeURL, Rel: "alternate"},
+			{Href: feed.FeedURL, Rel: "self", Type: mediaTypes[FormatAtom]},
+		},
+		Author:    atomAuthor{Name: generator},
+		Generator: generator,
+		Entries:   entries,
+	}
+}
+
+// JSON Feed 1.1，https://www.jsonfeed.org/version/1.1/
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	ContentText   string `json:"content_text"` // content_html 和 content_text 必须有一个
+	Summary       string `json:"summary,omitempty"`
+	DatePublished string `json:"date_published"`
+	DateModified  string `json:"date_modified"`
+}
+
+func newJSONFeed(feed *Feed) *jsonFeed {
+	items := make([]jsonFeedItem, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		content := item.Summary
+		if content == "" {
+			content = item.Title
+		}
+		items = append(items, jsonFeedItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentText:   content,
+			Summary:       item.Summary,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+			DateModified:  item.Updated.UTC().Format(time.RFC3339),
+		})
+	}
+	return &jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       feed.Title,
+		HomePageURL: feed.HomeURL,
+		FeedURL:     feed.FeedURL,
+		Description: feed.Description,
+		Items:       items,
+	}
+}
@@ -0,0 +1,46 @@
+// Package netutil 提供访问用户提供的地址时使用的网络工具
+package netutil
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress 访问的地址不是公网地址
+var ErrPrivateAddress = errors.New("access to private address is not allowed")
+
+// IsPublicIP 判断是不是公网地址
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// PublicDialer 返回只能连接公网地址的 Dialer。
+// 在解析域名之后、建立连接之前检查地址，防止通过用户提供的链接访问内网服务（SSRF）
+func PublicDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		},
+	}
+}
+
+// PublicTransport 返回只能连接公网地址的 http.Transport，不使用代理，保证连接的是检查过的地址
+func PublicTransport(timeout time.Duration) *http.Transport {
+	return &http.Transport{
+		Proxy:               nil,
+		DialContext:         PublicDialer(timeout).DialContext,
+		TLSHandshakeTimeout: timeout,
+	}
+}
@@ -0,0 +1,115 @@
+// Package patch 实现 PATCH 请求，支持 JSON Merge Patch（RFC 7396）和 JSON Patch（RFC 6902）
+package patch
+
+import (
+	"encoding/json"
+	"mime"
+	"reflect"
+	"sort"
+
+	"chitchat4.0/pkg/apierrors"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json" // JSON Merge Patch
+	JSONPatchType  = "application/json-patch+json"  // JSON Patch
+	JSONType       = "application/json"             // 按 JSON Merge Patch 处理
+)
+
+// Apply 把 data 应用到 obj 的 json 文档上，再解码回 obj。
+// 只允许修改 allowed 中的字段（json 字段名），返回被修改的字段；
+// 被删除或设为 null 的字段会变成零值，调用方需要用返回的字段 Select 后再保存，才能清空字段
+func Apply(obj interface{}, contentType string, data []byte, allowed ...string) ([]string, error) {
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := apply(original, contentType, data)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := changedFields(original, patched)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, field := range allowed {
+		allowedSet[field] = true
+	}
+	details := make([]apierrors.FieldError, 0)
+	for _, field := range fields {
+		if !allowedSet[field] {
+			details = append(details, apierrors.FieldError{Field: field, Message: "field can not be patched"})
+		}
+	}
+	if len(details) > 0 {
+		return nil, apierrors.NewValidation("patch contains fields that can not be patched", details...)
+	}
+
+	// 先置为零值再解码，保证被删除的字段也会被清空
+	v := reflect.ValueOf(obj).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	if err := json.Unmarshal(patched, obj); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	return fields, nil
+}
+
+// apply 根据 Content-Type 选择 patch 类型
+func apply(original []byte, contentType string, data []byte) ([]byte, error) {
+	mediaType := JSONType
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, apierrors.NewUnsupportedMediaType(contentType)
+		}
+	}
+
+	switch mediaType {
+	case MergePatchType, JSONType:
+		patched, err := jsonpatch.MergePatch(original, data)
+		if err != nil {
+			return nil, apierrors.NewBadRequest("invalid merge patch: " + err.Error())
+		}
+		return patched, nil
+	case JSONPatchType:
+		p, err := jsonpatch.DecodePatch(data)
+		if err != nil {
+			return nil, apierrors.NewBadRequest("invalid json patch: " + err.Error())
+		}
+		patched, err := p.Apply(original)
+		if err != nil {
+			return nil, apierrors.NewBadRequest("failed to apply json patch: " + err.Error())
+		}
+		return patched, nil
+	}
+	return nil, apierrors.NewUnsupportedMediaType(contentType)
+}
+
+// changedFields 比较两个 json 对象的第一层字段，返回值不同的字段
+func changedFields(original, patched []byte) ([]string, error) {
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	if err := json.Unmarshal(original, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0)
+	for key, value := range after {
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, value) {
+			fields = append(fields, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
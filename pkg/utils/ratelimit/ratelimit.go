@@ -3,6 +3,8 @@ package ratelimit
 import (
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	lru "github.com/hashicorp/golang-lru/v2"
@@ -138,3 +140,33 @@ func (rl *RateLimiter) get(key string) *rate.Limiter {
 	}
 	return value
 }
+
+// KeyedLimiter 按 key（例如 user id）分别限制事件的频率，不依赖 gin.Context，可以在 service 中使用
+type KeyedLimiter struct {
+	cache *lru.Cache[string, *rate.Limiter]
+	limit rate.Limit
+	burst int
+	mu    sync.Mutex // 保证同一个 key 只创建一个限制器
+}
+
+// NewKeyedLimiter 创建按 key 限制频率的限制器，每个 key 平均 every 时间允许一次，最多 burst 次的爆发，
+// 最多保留 cacheSize 个 key 的限制器，为 0 时使用 defaultCacheSize
+func NewKeyedLimiter(every time.Duration, burst, cacheSize int) *KeyedLimiter {
+	if cacheSize <= 0 {
+		cacheSize = defaultCacheSize
+	}
+	c, _ := lru.New[string, *rate.Limiter](cacheSize) // 只在 size 小于等于 0 时出错
+	return &KeyedLimiter{cache: c, limit: rate.Every(every), burst: burst}
+}
+
+// Allow 报告 key 现在能否发生一次事件
+func (kl *KeyedLimiter) Allow(key string) bool {
+	kl.mu.Lock()
+	limiter, found := kl.cache.Get(key)
+	if !found {
+		limiter = rate.NewLimiter(kl.limit, kl.burst)
+		kl.cache.Add(key, limiter)
+	}
+	kl.mu.Unlock()
+	return limiter.Allow()
+}
@@ -19,6 +19,7 @@ const (
 	UpdateOperation = "update" // update更新
 	PatchOperation  = "patch"  // patch更新一部分
 	DeleteOperation = "delete" // delete删除
+	WatchOperation  = "watch"  // watch监听变更
 )
 
 // RequestInfoResolver 请求信息解析接口
@@ -127,6 +128,13 @@ func (r *RequestInfoFactory) NewRequestInfo(req *http.Request) (*RequestInfo, er
 		requestInfo.Verb = ""
 	}
 
+	// handle input of form /watch/{resource} to produce a watch verb
+	// /watch/{resource} 形式的请求，动词为 watch
+	if currentParts[0] == "watch" && len(currentParts) > 1 && requestInfo.Verb == GetOperation {
+		requestInfo.Verb = WatchOperation
+		currentParts = currentParts[1:]
+	}
+
 	// URL forms: /namespaces/{namespace}/{kind}/*, where parts are adjusted to be relative to kind
 	// URL 表单:/namespaces/{namespace}/{kind}/*，其中的part被调整为相对于kind
 
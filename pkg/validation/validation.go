@@ -0,0 +1,222 @@
+// Package validation 注册请求参数的校验规则，
+// 把 gin 绑定时产生的校验错误翻译成中文或英文的 apierrors.Error
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/model"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	zhtranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+const (
+	MinPasswordLength = 6  // 密码的最小长度
+	MaxPasswordLength = 72 // 密码的最大长度，bcrypt 只使用前 72 个字节
+
+	defaultLocale = "zh" // 请求没有指定语言时使用中文
+)
+
+// namePattern user、group、role 名称的格式：字母或数字开头，只能包含字母、数字、'_'、'.'、'-'，
+// ':' 留给 system:authenticated 这类系统分组使用
+var namePattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}_.\-]*$`)
+
+// translation 自定义校验规则的错误信息
+type translation struct {
+	tag string
+	zh  string
+	en  string
+}
+
+var translations = []translation{
+	{tag: "name", zh: "{0}只能包含字母、数字、'_'、'.'、'-'，且必须以字母或数字开头", en: "{0} must start with a letter or digit and contain only letters, digits, '_', '.' or '-'"},
+	{tag: "scope", zh: "{0}必须是cluster或namespace", en: "{0} must be one of cluster or namespace"},
+	{tag: "password", zh: "{0}长度必须在6到72个字符之间", en: "{0} must be between 6 and 72 characters in length"},
+	{tag: "required_unless", zh: "{0}为必填字段", en: "{0} is a required field"},
+}
+
+var uni *ut.UniversalTranslator
+
+// Init 在 gin 的校验器上注册自定义校验规则和中英文翻译，需要在绑定请求参数之前调用
+func Init() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected gin validator engine")
+	}
+
+	// 错误信息中使用 json 字段名
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	validators := map[string]validator.Func{
+		"name":     validateName,
+		"scope":    validateScope,
+		"password": validatePassword,
+	}
+	for tag, fn := range validators {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+
+	zhLocale, enLocale := zh.New(), en.New()
+	uni = ut.New(zhLocale, zhLocale, enLocale)
+	zhTrans, _ := uni.GetTranslator("zh")
+	enTrans, _ := uni.GetTranslator("en")
+	if err := zhtranslations.RegisterDefaultTranslations(v, zhTrans); err != nil {
+		return err
+	}
+	if err := entranslations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return err
+	}
+	for _, t := range translations {
+		if err := registerTranslation(v, zhTrans, t.tag, t.zh); err != nil {
+			return err
+		}
+		if err := registerTranslation(v, enTrans, t.tag, t.en); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BindJSON 绑定并校验请求体，校验失败时返回带有每个字段错误的 apierrors.Error
+func BindJSON(c *gin.Context, obj interface{}) error {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return nil
+	}
+	var errs validator.ValidationErrors
+	if errors.As(err, &errs) {
+		return Translate(c, err)
+	}
+	return apierrors.NewBadRequest(err.Error())
+}
+
+// Struct 校验结构体的 binding 标签，返回的错误需要使用 Translate 翻译
+func Struct(obj interface{}) error {
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// Translate 根据请求头 Accept-Language 翻译校验错误，不是校验错误时原样返回
+func Translate(c *gin.Context, err error) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	details := translate(errs, translator(c.GetHeader("Accept-Language")))
+	messages := make([]string, 0, len(details))
+	for _, detail := range details {
+		messages = append(messages, detail.Message)
+	}
+	return apierrors.NewValidation(strings.Join(messages, "; "), details...)
+}
+
+// Messages 使用默认语言翻译校验错误，返回每个字段的错误信息；不是校验错误时返回错误本身的信息
+func Messages(err error) []string {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return []string{err.Error()}
+	}
+	details := translate(errs, translator(""))
+	messages := make([]string, 0, len(details))
+	for _, detail := range details {
+		messages = append(messages, detail.Field+": "+detail.Message)
+	}
+	return messages
+}
+
+func translate(errs validator.ValidationErrors, trans ut.Translator) []apierrors.FieldError {
+	details := make([]apierrors.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		msg := fe.Error()
+		if trans != nil {
+			msg = fe.Translate(trans)
+		}
+		details = append(details, apierrors.FieldError{Field: fieldPath(fe), Message: msg})
+	}
+	return details
+}
+
+// translator 按 Accept-Language 中的顺序选择翻译器，例如 "en-US,en;q=0.9,zh;q=0.8"
+func translator(acceptLanguage string) ut.Translator {
+	if uni == nil {
+		return nil
+	}
+	locales := make([]string, 0, 2)
+	for _, lang := range strings.Split(acceptLanguage, ",") {
+		lang = strings.TrimSpace(strings.SplitN(lang, ";", 2)[0])
+		lang = strings.ToLower(strings.SplitN(lang, "-", 2)[0])
+		if lang != "" {
+			locales = append(locales, lang)
+		}
+	}
+	locales = append(locales, defaultLocale)
+	trans, _ := uni.FindTranslator(locales...)
+	return trans
+}
+
+// fieldPath 去掉结构体名，返回 json 字段路径，例如 rules[0].resource
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func registerTranslation(v *validator.Validate, trans ut.Translator, tag, text string) error {
+	return v.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(tag, text, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			msg, err := ut.T(tag, fe.Field())
+			if err != nil {
+				return fe.Error()
+			}
+			return msg
+		},
+	)
+}
+
+func validateName(fl validator.FieldLevel) bool {
+	return namePattern.MatchString(fl.Field().String())
+}
+
+func validateScope(fl validator.FieldLevel) bool {
+	switch model.Scope(fl.Field().String()) {
+	case model.ClusterScope, model.NamespaceScope:
+		return true
+	}
+	return false
+}
+
+// validatePassword 空密码交给 required 系列规则处理
+func validatePassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if password == "" {
+		return true
+	}
+	n := utf8.RuneCountInString(password)
+	return n >= MinPasswordLength && len(password) <= MaxPasswordLength
+}
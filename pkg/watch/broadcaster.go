@@ -0,0 +1,146 @@
+// Package watch 实现进程内的资源变更事件总线，
+// 仓库在写入成功后发布事件，watch 接口订阅事件后推送给客户端
+package watch
+
+import (
+	"sync"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultHistorySize = 1024 // 默认保留的历史事件数量，用于断线后继续 watch
+	watcherBufferSize  = 100  // 每个 watcher 的事件缓冲区大小
+)
+
+// Broadcaster 事件广播器，给每个事件分配单调递增的序号（resourceVersion），
+// 保留最近的事件，订阅时可以从指定序号之后继续
+type Broadcaster struct {
+	mu       sync.RWMutex
+	seq      uint64
+	history  []model.Event // 环形缓冲区
+	next     int           // 下一个事件在 history 中的位置
+	size     int           // history 中的事件数量
+	watchers map[*Watcher]struct{}
+}
+
+// NewBroadcaster 创建事件广播器，historySize 为保留的历史事件数量
+func NewBroadcaster(historySize int) *Broadcaster {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Broadcaster{
+		history:  make([]model.Event, historySize),
+		watchers: make(map[*Watcher]struct{}),
+	}
+}
+
+// Publish 发布事件，不会阻塞：处理不及时的 watcher 会被关闭，客户端需要从最后收到的序号重新 watch
+func (b *Broadcaster) Publish(eventType model.EventType, resource string, id uint, obj interface{}) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := model.Event{
+		Type:            eventType,
+		Resource:        resource,
+		ResourceVersion: b.seq,
+		ObjectID:        id,
+		Object:          obj,
+	}
+	b.history[b.next] = event
+	b.next = (b.next + 1) % len(b.history)
+	if b.size < len(b.history) {
+		b.size++
+	}
+
+	for w := range b.watchers {
+		if !w.match(event) {
+			continue
+		}
+		select {
+		case w.result <- event:
+		default:
+			logrus.Warnf("watcher of %s is too slow, closed at resourceVersion %d", w.resource, event.ResourceVersion)
+			b.stop(w)
+		}
+	}
+}
+
+// Watch 订阅 resource 的事件，resource 为空时订阅全部资源；
+// since 不为 0 时先返回序号大于 since 的历史事件，since 已不在历史中时返回 Expired
+func (b *Broadcaster) Watch(resource string, since uint64) (*Watcher, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	w := &Watcher{
+		broadcaster: b,
+		resource:    resource,
+	}
+	var replay []model.Event
+	if since != 0 {
+		if since > b.seq || since < b.oldest()-1 {
+			return nil, apierrors.NewExpired(since)
+		}
+		for i := 0; i < b.size; i++ {
+			event := b.history[(b.next-b.size+i+len(b.history))%len(b.history)]
+			if event.ResourceVersion > since && w.match(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	w.result = make(chan model.Event, watcherBufferSize+len(replay))
+	for _, event := range replay {
+		w.result <- event
+	}
+	b.watchers[w] = struct{}{}
+	return w, nil
+}
+
+// ResourceVersion 返回最后一个事件的序号
+func (b *Broadcaster) ResourceVersion() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.seq
+}
+
+// oldest 返回历史中最早事件的序号，没有历史事件时返回下一个事件的序号
+func (b *Broadcaster) oldest() uint64 {
+	return b.seq - uint64(b.size) + 1
+}
+
+func (b *Broadcaster) stop(w *Watcher) {
+	if _, ok := b.watchers[w]; ok {
+		delete(b.watchers, w)
+		close(w.result)
+	}
+}
+
+// Watcher 事件订阅者
+type Watcher struct {
+	broadcaster *Broadcaster
+	resource    string
+	result      chan model.Event
+}
+
+// ResultChan 返回事件通道，通道关闭表示 watch 结束
+func (w *Watcher) ResultChan() <-chan model.Event {
+	return w.result
+}
+
+// Stop 取消订阅
+func (w *Watcher) Stop() {
+	w.broadcaster.mu.Lock()
+	defer w.broadcaster.mu.Unlock()
+	w.broadcaster.stop(w)
+}
+
+func (w *Watcher) match(event model.Event) bool {
+	return w.resource == "" || w.resource == event.Resource
+}
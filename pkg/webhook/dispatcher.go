@@ -0,0 +1,302 @@
+// Package webhook 把资源变更事件投递到管理员注册的 webhook。
+// 事件先写入数据库中的投递队列，再由后台任务投递，失败后按指数退避重试，进程重启不会丢失待投递的事件
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/watch"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	MaxAttempts = 8 // 每条投递记录最多投递的次数，之后标记为失败
+
+	retryBase       = 30 * time.Second // 第一次重试的间隔，之后每次翻倍
+	retryMax        = time.Hour        // 重试的最大间隔
+	deliveryTimeout = 10 * time.Second // 投递一次的超时时间
+	deliveryLease   = 2 * deliveryTimeout
+	pollInterval    = 5 * time.Second // 检查投递队列的间隔
+	pollBatchSize   = 20              // 每次从投递队列取的记录数
+	concurrency     = 4               // 同时投递的记录数
+	eventBatchSize  = 100             // 每次写入投递队列的最多事件数
+	maxResponseBody = 1024            // 投递记录中保存的响应体长度
+)
+
+// Backoff 返回第 attempts 次投递失败后到下次投递的间隔
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := retryBase
+	for i := 1; i < attempts && delay < retryMax; i++ {
+		delay *= 2
+	}
+	if delay > retryMax {
+		delay = retryMax
+	}
+	return delay
+}
+
+// Dispatcher 订阅仓库的资源变更事件，写入订阅了事件的 webhook 的投递队列，并在后台投递
+type Dispatcher struct {
+	repository repository.WebhookRepository
+	events     *watch.Broadcaster
+	client     *http.Client
+	wake       chan struct{}
+}
+
+// NewDispatcher 创建 webhook 投递器。client 为空时使用默认的客户端：
+// webhook 由管理员注册，可以投递到内网地址（例如测试时的本地接收方），不跟随重定向
+func NewDispatcher(webhookRepository repository.WebhookRepository, events *watch.Broadcaster, client *http.Client) *Dispatcher {
+	if client == nil {
+		client = &http.Client{
+			Timeout: deliveryTimeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &Dispatcher{
+		repository: webhookRepository,
+		events:     events,
+		client:     client,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Run 订阅资源变更事件并投递队列中的记录，直到 ctx 取消
+func (d *Dispatcher) Run(ctx context.Context) error {
+	go d.subscribe(ctx)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		d.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// Wake 通知后台任务马上检查投递队列
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// subscribe 订阅全部资源的变更事件。处理太慢被广播器关闭时从最后处理的序号继续，
+// 序号已不在历史中时从当前开始，期间的事件不会投递
+func (d *Dispatcher) subscribe(ctx context.Context) {
+	since := d.events.ResourceVersion()
+	for ctx.Err() == nil {
+		watcher, err := d.events.Watch("", since)
+		if err != nil {
+			logrus.Warnf("webhook events after resourceVersion %d are expired and will not be delivered", since)
+			since = d.events.ResourceVersion()
+			continue
+		}
+		since = d.consume(ctx, watcher, since)
+	}
+}
+
+func (d *Dispatcher) consume(ctx context.Context, watcher *watch.Watcher, since uint64) uint64 {
+	defer watcher.Stop()
+	ch := watcher.ResultChan()
+	for {
+		select {
+		case <-ctx.Done():
+			return since
+		case event, ok := <-ch:
+			if !ok {
+				return since
+			}
+			// 一次取出已经到达的事件，只查询一次 webhook 并批量写入
+			events := []model.Event{event}
+		drain:
+			for len(events) < eventBatchSize {
+				select {
+				case event, ok := <-ch:
+					if !ok {
+						break drain
+					}
+					events = append(events, event)
+				default:
+					break drain
+				}
+			}
+			d.enqueue(events)
+			since = events[len(events)-1].ResourceVersion
+		}
+	}
+}
+
+// enqueue 把事件写入订阅了事件的 webhook 的投递队列
+func (d *Dispatcher) enqueue(events []model.Event) {
+	webhooks, err := d.repository.ListEnabled()
+	if err != nil {
+		logrus.Errorf("list webhooks failed, %d events are not delivered: %v", len(events), err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	deliveries := make([]model.WebhookDelivery, 0)
+	for _, event := range events {
+		var payload []byte
+		for i := range webhooks {
+			if !webhooks[i].Subscribed(event.Resource, event.Type) {
+				continue
+			}
+			name := model.WebhookEventName(event.Resource, event.Type)
+			if payload == nil {
+				if payload, err = json.Marshal(&model.WebhookPayload{
+					Event:           name,
+					Resource:        event.Resource,
+					Type:            event.Type,
+					ResourceVersion: event.ResourceVersion,
+					ObjectID:        event.ObjectID,
+					Object:          event.Object,
+					Timestamp:       now,
+				}); err != nil {
+					logrus.Errorf("marshal webhook event %s of %d failed: %v", name, event.ObjectID, err)
+					break
+				}
+			}
+			deliveries = append(deliveries, model.WebhookDelivery{
+				WebhookID:     webhooks[i].ID,
+				Event:         name,
+				Payload:       string(payload),
+				Status:        model.DeliveryPending,
+				NextAttemptAt: now,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := d.repository.CreateDeliveries(deliveries); err != nil {
+		logrus.Errorf("enqueue %d webhook deliveries failed: %v", len(deliveries), err)
+		return
+	}
+	d.Wake()
+}
+
+// poll 投递队列中到了投递时间的记录，直到没有到期的记录
+func (d *Dispatcher) poll(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.repository.ListDueDeliveries(time.Now(), pollBatchSize)
+		if err != nil {
+			logrus.Errorf("list webhook deliveries failed: %v", err)
+			return
+		}
+
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			delivery := &deliveries[i]
+			// 等到可以投递时再领取，租约从投递开始时计算，排在后面的记录不会在投递前租约就已经到期
+			sem <- struct{}{}
+			claimed, err := d.repository.ClaimDelivery(delivery, time.Now().Add(deliveryLease))
+			if err != nil {
+				logrus.Errorf("claim webhook delivery %d failed: %v", delivery.ID, err)
+				<-sem
+				continue
+			}
+			if !claimed { // 其他副本已经领取
+				<-sem
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				d.deliver(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+		if len(deliveries) < pollBatchSize {
+			return
+		}
+	}
+}
+
+// deliver 投递一次并保存结果，失败时安排下次重试
+func (d *Dispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+	hook := delivery.Webhook
+	switch {
+	case hook == nil:
+		delivery.Status, delivery.Error = model.DeliveryFailed, "webhook is deleted"
+	case !hook.Enabled:
+		delivery.Status, delivery.Error = model.DeliveryFailed, "webhook is disabled"
+	default:
+		delivery.Attempts++
+		start := time.Now()
+		code, body, err := d.send(ctx, hook, delivery)
+		now := time.Now()
+		delivery.Duration = now.Sub(start).Milliseconds()
+		delivery.ResponseCode, delivery.ResponseBody = code, body
+		if err == nil && (code < 200 || code >= 300) {
+			err = fmt.Errorf("unexpected status code %d", code)
+		}
+		switch {
+		case err == nil:
+			delivery.Status, delivery.Error, delivery.DeliveredAt = model.DeliverySucceeded, "", &now
+		case delivery.Attempts >= MaxAttempts:
+			delivery.Status, delivery.Error = model.DeliveryFailed, err.Error()
+		default:
+			delivery.Error = err.Error()
+			delivery.NextAttemptAt = now.Add(Backoff(delivery.Attempts))
+		}
+	}
+	if err := d.repository.UpdateDelivery(delivery); err != nil {
+		logrus.Errorf("save webhook delivery %d failed: %v", delivery.ID, err)
+	}
+}
+
+// send 发送签名后的投递请求，返回响应状态码和截断后的响应体
+func (d *Dispatcher) send(ctx context.Context, hook *model.Webhook, delivery *model.WebhookDelivery) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "chitchat-webhook/4.0")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024)) // 读完响应体以复用连接
+	// 数据库的 text 不能保存 NUL 和非法的 UTF-8
+	text := strings.ToValidUTF8(strings.ReplaceAll(string(data), "\x00", ""), "�")
+	return resp.StatusCode, text, nil
+}
@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"chitchat4.0/pkg/config"
+	"chitchat4.0/pkg/database"
+	"chitchat4.0/pkg/model"
+	"chitchat4.0/pkg/repository"
+	"chitchat4.0/pkg/watch"
+	"gorm.io/gorm"
+)
+
+const testSecret = "chitchat-webhook-secret"
+
+func newTestRepository(t *testing.T) (*gorm.DB, repository.WebhookRepository) {
+	t.Helper()
+	db, err := database.NewSQLite(&config.DBConfig{Driver: config.DriverSQLite, Name: ":memory:"})
+	if errors.Is(err, database.ErrSQLiteUnavailable) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&model.Webhook{}, &model.WebhookDelivery{}); err != nil {
+		t.Fatal(err)
+	}
+	rdb, err := database.NewRedisClient(&config.RedisConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, repository.NewRepository(db, rdb).Webhook()
+}
+
+// newTestDeliveries 创建投递到 url 的 webhook 和 n 条到期的投递记录
+func newTestDeliveries(t *testing.T, repo repository.WebhookRepository, url string, n int) []model.WebhookDelivery {
+	t.Helper()
+	hook, err := repo.Create(&model.Webhook{Name: "test", URL: url, Secret: testSecret, Events: []string{"*"}, Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliveries := make([]model.WebhookDelivery, n)
+	for i := range deliveries {
+		deliveries[i] = model.WebhookDelivery{
+			WebhookID:     hook.ID,
+			Event:         "users.added",
+			Payload:       `{"event":"users.added","objectId":` + strconv.Itoa(i+1) + `}`,
+			Status:        model.DeliveryPending,
+			NextAttemptAt: time.Now().Add(-time.Second),
+		}
+	}
+	if err := repo.CreateDeliveries(deliveries); err != nil {
+		t.Fatal(err)
+	}
+	return deliveries
+}
+
+func getDelivery(t *testing.T, repo repository.WebhookRepository, delivery *model.WebhookDelivery) *model.WebhookDelivery {
+	t.Helper()
+	got, err := repo.GetDelivery(delivery.WebhookID, delivery.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"users.added"}`)
+	signature := Sign(testSecret, 1700000000, body)
+	if !Verify(testSecret, 1700000000, body, signature) {
+		t.Fatal("valid signature is rejected")
+	}
+	if Verify("other-secret", 1700000000, body, signature) {
+		t.Error("signature of other secret is accepted")
+	}
+	if Verify(testSecret, 1700000001, body, signature) {
+		t.Error("signature of other timestamp is accepted")
+	}
+	if Verify(testSecret, 1700000000, []byte(`{"event":"users.deleted"}`), signature) {
+		t.Error("signature of other body is accepted")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, retryBase},
+		{1, retryBase},
+		{2, 2 * retryBase},
+		{3, 4 * retryBase},
+		{MaxAttempts, retryMax},
+		{100, retryMax},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// TestDeliverRetry 投递请求带有正确的签名，失败后按退避时间重试，超过最多次数后标记为失败
+func TestDeliverRetry(t *testing.T) {
+	_, repo := newTestRepository(t)
+	var mu sync.Mutex
+	status := http.StatusInternalServerError
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if r.Header.Get(EventHeader) != "users.added" || !Verify(testSecret, timestamp, body, r.Header.Get(SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, "received")
+	}))
+	defer receiver.Close()
+
+	d := NewDispatcher(repo, watch.NewBroadcaster(16), nil)
+	delivery := &newTestDeliveries(t, repo, receiver.URL, 1)[0]
+	ctx := context.Background()
+
+	start := time.Now()
+	d.poll(ctx)
+	got := getDelivery(t, repo, delivery)
+	if got.Status != model.DeliveryPending || got.Attempts != 1 || got.ResponseCode != http.StatusInternalServerError || got.Error == "" {
+		t.Fatalf("after failed attempt: %+v", got)
+	}
+	if next := got.NextAttemptAt.Sub(start); next < Backoff(1) || next > Backoff(1)+5*time.Second {
+		t.Fatalf("next attempt after %v, want %v", next, Backoff(1))
+	}
+
+	// 还没到重试时间不会投递
+	d.poll(ctx)
+	if got := getDelivery(t, repo, delivery); got.Attempts != 1 {
+		t.Fatalf("delivered before the next attempt: %+v", got)
+	}
+
+	got.NextAttemptAt = time.Now().Add(-time.Second)
+	if err := repo.UpdateDelivery(got); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	status = http.StatusNoContent
+	mu.Unlock()
+	d.poll(ctx)
+	got = getDelivery(t, repo, delivery)
+	if got.Status != model.DeliverySucceeded || got.Attempts != 2 || got.DeliveredAt == nil || got.Error != "" {
+		t.Fatalf("after retry: %+v", got)
+	}
+
+	mu.Lock()
+	status = http.StatusBadGateway
+	mu.Unlock()
+	failed := &newTestDeliveries(t, repo, receiver.URL, 1)[0]
+	failed.Attempts = MaxAttempts - 1
+	if err := repo.UpdateDelivery(failed); err != nil {
+		t.Fatal(err)
+	}
+	d.poll(ctx)
+	if got := getDelivery(t, repo, failed); got.Status != model.DeliveryFailed || got.Attempts != MaxAttempts {
+		t.Fatalf("after last attempt: %+v", got)
+	}
+}
+
+// TestDeliverClaim 两个副本同时投递时每条记录只投递一次，并且投递时租约还剩完整的时间
+func TestDeliverClaim(t *testing.T) {
+	db, repo := newTestRepository(t)
+	var mu sync.Mutex
+	received := make(map[string]int)
+	var shortLeases []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(DeliveryHeader)
+		delivery := new(model.WebhookDelivery)
+		if err := db.First(delivery, id).Error; err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		lease := time.Until(delivery.NextAttemptAt)
+		time.Sleep(100 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		received[id]++
+		// 排在后面的记录也要在领取时才开始计算租约
+		if lease < deliveryLease-50*time.Millisecond {
+			shortLeases = append(shortLeases, id+": "+lease.String())
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	deliveries := newTestDeliveries(t, repo, receiver.URL, pollBatchSize)
+	events := watch.NewBroadcaster(16)
+	replicas := []*Dispatcher{NewDispatcher(repo, events, nil), NewDispatcher(repo, events, nil)}
+	var wg sync.WaitGroup
+	for _, d := range replicas {
+		wg.Add(1)
+		go func(d *Dispatcher) {
+			defer wg.Done()
+			d.poll(context.Background())
+		}(d)
+	}
+	wg.Wait()
+
+	for i := range deliveries {
+		id := strconv.Itoa(int(deliveries[i].ID))
+		if received[id] != 1 {
+			t.Errorf("delivery %s received %d times", id, received[id])
+		}
+		if got := getDelivery(t, repo, &deliveries[i]); got.Status != model.DeliverySucceeded {
+			t.Errorf("delivery %s status = %s", id, got.Status)
+		}
+	}
+	if len(shortLeases) > 0 {
+		t.Errorf("deliveries sent with a short lease: %v", shortLeases)
+	}
+}
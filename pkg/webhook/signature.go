@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// 投递请求的请求头
+const (
+	EventHeader     = "X-Chitchat-Event"     // 事件名称，如 users.added
+	DeliveryHeader  = "X-Chitchat-Delivery"  // 投递记录的 id，重试时不变，接收方可以用来去重
+	TimestampHeader = "X-Chitchat-Timestamp" // 发送时间，Unix 秒
+	SignatureHeader = "X-Chitchat-Signature" // 签名，格式：sha256=<hex>
+
+	signaturePrefix = "sha256="
+)
+
+// Sign 计算投递请求的签名：HMAC-SHA256(secret, timestamp + "." + body)。
+// 签名包含时间戳，接收方可以拒绝时间太久的请求，防止重放
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验投递请求的签名，供接收方和测试使用
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func newRoleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "role",
+		Short: "管理 role",
+	}
+	cmd.AddCommand(newRoleApplyCommand())
+	return cmd
+}
+
+// newRoleApplyCommand 文件的格式和批量导出的 roles 相同（GET /api/v1/export/roles），按名称创建或修改 role
+func newRoleApplyCommand() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "apply -f <file>",
+		Short: "按名称创建或修改文件中的 role，文件为 YAML 或 JSON，- 表示标准输入",
+		Args:  cobra.NoArgs,
+		RunE: withAdmin(func(cmd *cobra.Command, a *admin, args []string) error {
+			roles, err := readRoles(cmd, file)
+			if err != nil {
+				return err
+			}
+			// 先校验全部 role，有错误时不修改任何 role
+			for i := range roles {
+				if err := checkStruct(&roles[i]); err != nil {
+					return fmt.Errorf("role %d %s: %w", i+1, roles[i].Name, err)
+				}
+			}
+			for _, role := range roles {
+				action, err := applyRole(a, role)
+				if err != nil {
+					return fmt.Errorf("role %s: %w", role.Name, err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "role/%s %s\n", role.Name, action)
+			}
+			return nil
+		}),
+	}
+	cmd.Flags().StringVarP(&file, "filename", "f", "", "role 文件")
+	cmd.MarkFlagRequired("filename")
+	return cmd
+}
+
+// readRoles 读取文件中的 roles，文件中不能有 groups 和 users
+func readRoles(cmd *cobra.Command, file string) ([]model.Role, error) {
+	var reader io.Reader = cmd.InOrStdin()
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		reader = f
+	}
+	doc := new(model.BulkDocument)
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(doc); err != nil {
+		return nil, errors.Wrapf(err, "无法解析 %s", file)
+	}
+	if len(doc.Groups) > 0 || len(doc.Users) > 0 {
+		return nil, fmt.Errorf("%s 中只能有 roles，导入 groups 和 users 使用 POST /api/v1/import", file)
+	}
+	if len(doc.Roles) == 0 {
+		return nil, fmt.Errorf("%s 中没有 role", file)
+	}
+
+	roles := make([]model.Role, 0, len(doc.Roles))
+	for _, r := range doc.Roles {
+		roles = append(roles, model.Role{Name: r.Name, Scope: r.Scope, Namespace: r.Namespace, Rules: r.Rules})
+	}
+	return roles, nil
+}
+
+// applyRole 名称不存在时创建 role，存在时修改；RBAC 配置文件管理的 role 只能通过配置文件修改
+func applyRole(a *admin, role model.Role) (string, error) {
+	existing, err := a.repository.RBAC().GetRoleByName(role.Name)
+	if apierrors.IsNotFound(err) {
+		if _, err := a.rbacService.Create(&role); err != nil {
+			return "", err
+		}
+		return "created", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if existing.ManagedBy != "" {
+		return "", fmt.Errorf("role is managed by %s", existing.ManagedBy)
+	}
+	role.ResourceVersion = existing.ResourceVersion
+	if _, err := a.rbacService.Update(idString(existing.ID), &role); err != nil {
+		return "", err
+	}
+	return "configured", nil
+}
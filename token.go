@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"chitchat4.0/pkg/model"
+	"github.com/spf13/cobra"
+)
+
+func newTokenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "管理个人访问 token",
+	}
+	cmd.AddCommand(newTokenIssueCommand())
+	return cmd
+}
+
+// newTokenIssueCommand 给 user 签发访问 token，token 的 role 必须是 user 拥有的 role，明文只打印一次
+func newTokenIssueCommand() *cobra.Command {
+	var (
+		name    string
+		roles   []string
+		expires time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "issue <user>",
+		Short: "给 user 签发个人访问 token",
+		Args:  cobra.ExactArgs(1),
+		RunE: withAdmin(func(cmd *cobra.Command, a *admin, args []string) error {
+			user, err := a.user(args[0])
+			if err != nil {
+				return err
+			}
+			created := &model.CreatedAccessToken{Name: name}
+			for _, name := range roles {
+				role, err := a.role(name)
+				if err != nil {
+					return err
+				}
+				created.RoleIds = append(created.RoleIds, role.ID)
+			}
+			if expires > 0 {
+				expiresAt := time.Now().Add(expires)
+				created.ExpiresAt = &expiresAt
+			}
+			if err := checkStruct(created); err != nil {
+				return err
+			}
+
+			// 管理命令直接以 user 自己的身份创建，只能绑定 user 拥有的角色
+			issued, err := a.tokenService.Create(user, idString(user.ID), created)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), issued.Token)
+			return nil
+		}),
+	}
+	cmd.Flags().StringVar(&name, "name", "", "token 的名称")
+	cmd.Flags().StringSliceVar(&roles, "role", nil, "token 的 role，可以指定多次，必须是 user 拥有的 role")
+	cmd.Flags().DurationVar(&expires, "expires", 0, "有效期，例如 720h，0 表示永不过期")
+	cmd.MarkFlagRequired("name")
+	cmd.MarkFlagRequired("role")
+	return cmd
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+
+	"chitchat4.0/pkg/apierrors"
+	"chitchat4.0/pkg/model"
+	"github.com/spf13/cobra"
+)
+
+func newUserCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "管理 user",
+	}
+	cmd.AddCommand(newUserCreateCommand(), newUserResetPasswordCommand(), newUserAddRoleCommand())
+	return cmd
+}
+
+// newUserCreateCommand 和创建 user 的接口使用相同的校验和默认值
+func newUserCreateCommand() *cobra.Command {
+	created := new(model.CreatedUser)
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "创建 user，没有指定 --password 时从标准输入读取密码",
+		Args:  cobra.ExactArgs(1),
+		RunE: withAdmin(func(cmd *cobra.Command, a *admin, args []string) error {
+			created.Name = args[0]
+			if !created.ServiceAccount {
+				password, err := readPassword(cmd, created.Password)
+				if err != nil {
+					return err
+				}
+				created.Password = password
+			}
+			if err := checkStruct(created); err != nil {
+				return err
+			}
+
+			user := created.GetUser()
+			if err := a.userService.Validate(user); err != nil {
+				return err
+			}
+			a.userService.Default(user)
+			user, err := a.userService.Create(user)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "user/%s created, id %d\n", user.Name, user.ID)
+			return nil
+		}),
+	}
+	cmd.Flags().StringVar(&created.Password, "password", "", "密码")
+	cmd.Flags().StringVar(&created.Email, "email", "", "邮箱，为空时使用默认邮箱")
+	cmd.Flags().BoolVar(&created.ServiceAccount, "service-account", false, "创建没有密码的服务账号，只能使用访问 token")
+	return cmd
+}
+
+// newUserResetPasswordCommand 重置密码后默认撤销 user 的全部登录会话，已经签发的 token 立即失效
+func newUserResetPasswordCommand() *cobra.Command {
+	var (
+		password       string
+		revokeSessions bool
+	)
+	cmd := &cobra.Command{
+		Use:   "reset-password <name>",
+		Short: "重置 user 的密码，没有指定 --password 时从标准输入读取密码",
+		Args:  cobra.ExactArgs(1),
+		RunE: withAdmin(func(cmd *cobra.Command, a *admin, args []string) error {
+			user, err := a.user(args[0])
+			if err != nil {
+				return err
+			}
+			password, err := readPassword(cmd, password)
+			if err != nil {
+				return err
+			}
+			if err := checkStruct(&model.UpdatedUser{Name: user.Name, Password: password}); err != nil {
+				return err
+			}
+
+			user.Password = password
+			if _, err := a.userService.Update(idString(user.ID), user); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "user/%s password reset\n", user.Name)
+			if !revokeSessions {
+				return nil
+			}
+			// redis 禁用时不保存会话，密码已经重置，只提示 token 在过期前仍然有效
+			if err := a.sessionService.DeleteAll(idString(user.ID)); err != nil {
+				if apierrors.IsNotImplemented(err) {
+					fmt.Fprintf(cmd.ErrOrStderr(), "user/%s sessions not revoked: %v\n", user.Name, err)
+					return nil
+				}
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "user/%s sessions revoked\n", user.Name)
+			return nil
+		}),
+	}
+	cmd.Flags().StringVar(&password, "password", "", "新密码")
+	cmd.Flags().BoolVar(&revokeSessions, "revoke-sessions", true, "撤销 user 的全部登录会话")
+	return cmd
+}
+
+func newUserAddRoleCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add-role <user> <role>",
+		Short: "给 user 添加 role",
+		Args:  cobra.ExactArgs(2),
+		RunE: withAdmin(func(cmd *cobra.Command, a *admin, args []string) error {
+			user, err := a.user(args[0])
+			if err != nil {
+				return err
+			}
+			role, err := a.role(args[1])
+			if err != nil {
+				return err
+			}
+			if err := a.userService.AddRole(idString(user.ID), idString(role.ID)); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "user/%s role %s added\n", user.Name, role.Name)
+			return nil
+		}),
+	}
+}